@@ -2,10 +2,8 @@ package cli
 
 import (
 	"bufio"
-	"bytes"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"os"
 	"strings"
@@ -13,6 +11,7 @@ import (
 	"time"
 
 	"github.com/binhbb2204/Manga-Hub-Group13/cli/config"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/models"
 	"github.com/spf13/cobra"
 	"golang.org/x/term"
 )
@@ -61,8 +60,7 @@ var authRegisterCmd = &cobra.Command{
 			return fmt.Errorf("passwords do not match")
 		}
 
-		serverURL, err := config.GetServerURL()
-		if err != nil {
+		if _, err := config.GetServerURL(); err != nil {
 			printError("Configuration not initialized")
 			fmt.Println("Run: mangahub init")
 			return err
@@ -73,17 +71,13 @@ var authRegisterCmd = &cobra.Command{
 			"email":    email,
 			"password": password,
 		}
-		jsonData, _ := json.Marshal(reqBody)
 
-		res, err := http.Post(serverURL+"/auth/register", "application/json", bytes.NewBuffer(jsonData))
+		res, body, err := sendWithToken(http.MethodPost, "/auth/register", "", reqBody)
 		if err != nil {
 			printError("Registration failed: Server connection error")
 			fmt.Println("Check server status: mangahub server status")
 			return err
 		}
-		defer res.Body.Close()
-
-		body, _ := io.ReadAll(res.Body)
 
 		if res.StatusCode != http.StatusCreated {
 			var errRes map[string]string
@@ -105,15 +99,16 @@ var authRegisterCmd = &cobra.Command{
 		}
 
 		var authRes struct {
-			Token     string    `json:"token"`
-			UserID    string    `json:"user_id"`
-			Username  string    `json:"username"`
-			Email     string    `json:"email"`
-			CreatedAt time.Time `json:"created_at"`
+			Token        string    `json:"token"`
+			RefreshToken string    `json:"refresh_token"`
+			UserID       string    `json:"user_id"`
+			Username     string    `json:"username"`
+			Email        string    `json:"email"`
+			CreatedAt    time.Time `json:"created_at"`
 		}
 		json.Unmarshal(body, &authRes)
 
-		if err := config.UpdateUserToken(authRes.Username, authRes.Token); err != nil {
+		if err := config.UpdateUserToken(authRes.Username, authRes.Token, authRes.RefreshToken); err != nil {
 			fmt.Println("Warning: Failed to save token to config")
 		}
 
@@ -122,7 +117,7 @@ var authRegisterCmd = &cobra.Command{
 		fmt.Printf("Username: %s\n", authRes.Username)
 		fmt.Printf("Email: %s\n", authRes.Email)
 		fmt.Printf("Created: %s\n", authRes.CreatedAt.Format("2006-01-02 15:04:05 MST"))
-		fmt.Println("\nYou are now logged in!")
+		fmt.Println("\nYou are now logged in! Check your inbox to verify your email.")
 		fmt.Println("Try: mangahub manga search \"your favorite manga\"")
 
 		return nil
@@ -132,10 +127,16 @@ var authRegisterCmd = &cobra.Command{
 var authLoginCmd = &cobra.Command{
 	Use:   "login",
 	Short: "Login to your account",
-	Long:  `Login to your MangaHub account with username or email.`,
+	Long: `Login to your MangaHub account with username or email.
+
+With --sso you sign in through your organisation's identity provider in a
+browser instead of typing a password.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if sso {
+			return ssoLogin()
+		}
 		if username == "" && email == "" {
-			return fmt.Errorf("username or email is required (--username or --email)")
+			return fmt.Errorf("username or email is required (--username or --email, or --sso)")
 		}
 
 		//Get password securely
@@ -148,8 +149,7 @@ var authLoginCmd = &cobra.Command{
 		password := string(passwordBytes)
 
 		//Call login API
-		serverURL, err := config.GetServerURL()
-		if err != nil {
+		if _, err := config.GetServerURL(); err != nil {
 			printError("Configuration not initialized")
 			fmt.Println("Run: mangahub init")
 			return err
@@ -164,17 +164,21 @@ var authLoginCmd = &cobra.Command{
 		if email != "" {
 			reqBody["email"] = email
 		}
-		jsonData, _ := json.Marshal(reqBody)
-
-		resp, err := http.Post(serverURL+"/auth/login", "application/json", bytes.NewBuffer(jsonData))
+		// The session list on the server shows the device name sent with it
+		resp, body, err := sendWithToken(http.MethodPost, "/auth/login", "", reqBody)
 		if err != nil {
 			printError("Login failed: Server connection error")
 			fmt.Println("Check server status: mangahub server status")
 			return err
 		}
-		defer resp.Body.Close()
 
-		body, _ := io.ReadAll(resp.Body)
+		//Accounts with two-factor authentication need a code as well
+		if resp.StatusCode == http.StatusAccepted {
+			resp, body, err = completeTwoFactorLogin(body)
+			if err != nil {
+				return err
+			}
+		}
 
 		if resp.StatusCode != http.StatusOK {
 			var errResp map[string]string
@@ -183,54 +187,63 @@ var authLoginCmd = &cobra.Command{
 			if strings.Contains(errResp["error"], "Invalid credentials") {
 				printError("Login failed: Invalid credentials")
 				fmt.Println("Check your username and password")
-			} else if strings.Contains(errResp["error"], "not found") {
-				printError("Login failed: Account not found")
-				identifier := username
-				if identifier == "" {
-					identifier = email
+			} else if strings.Contains(errResp["error"], "Invalid code") {
+				printError("Login failed: Invalid two-factor code")
+			} else if resp.StatusCode == http.StatusTooManyRequests {
+				printError("Login failed: Too many failed attempts")
+				if wait := resp.Header.Get("Retry-After"); wait != "" {
+					fmt.Printf("Try again in %s seconds\n", wait)
 				}
-				fmt.Printf("Try: mangahub auth register --username %s --email %s\n", identifier, email)
 			} else {
 				printError(fmt.Sprintf("Login failed: %s", errResp["error"]))
 			}
 			return fmt.Errorf("login failed")
 		}
 
-		var authResp struct {
-			Token     string    `json:"token"`
-			UserID    string    `json:"user_id"`
-			Username  string    `json:"username"`
-			Email     string    `json:"email"`
-			ExpiresAt time.Time `json:"expires_at"`
-		}
-		json.Unmarshal(body, &authResp)
+		return saveLogin(body)
+	},
+}
 
-		//Save token to config
-		if err := config.UpdateUserToken(authResp.Username, authResp.Token); err != nil {
-			fmt.Println("Warning: Failed to save token to config")
-		}
+// saveLogin stores the session from a successful login response and prints
+// a welcome
+func saveLogin(body []byte) error {
+	var authResp struct {
+		Token            string    `json:"token"`
+		RefreshToken     string    `json:"refresh_token"`
+		UserID           string    `json:"user_id"`
+		Username         string    `json:"username"`
+		Email            string    `json:"email"`
+		ExpiresAt        time.Time `json:"expires_at"`
+		RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+	}
+	json.Unmarshal(body, &authResp)
 
-		printSuccess("Login successful!")
-		fmt.Printf("Welcome back, %s!\n", authResp.Username)
-		fmt.Println("\nSession Details:")
-		fmt.Printf("  Token expires: %s (24 hours)\n", authResp.ExpiresAt.Format("2006-01-02 15:04:05 MST"))
-		fmt.Println("  Permissions: read, write, sync")
+	//Save token to config
+	if err := config.UpdateUserToken(authResp.Username, authResp.Token, authResp.RefreshToken); err != nil {
+		fmt.Println("Warning: Failed to save token to config")
+	}
 
-		cfg, _ := config.Load()
-		fmt.Printf("  Auto-sync: %v\n", cfg.Sync.AutoSync)
-		fmt.Printf("  Notifications: %v\n", cfg.Notifications.Enabled)
+	printSuccess("Login successful!")
+	fmt.Printf("Welcome back, %s!\n", authResp.Username)
+	fmt.Println("\nSession Details:")
+	fmt.Printf("  Token expires: %s (renewed automatically)\n", authResp.ExpiresAt.Format("2006-01-02 15:04:05 MST"))
+	fmt.Printf("  Session expires: %s\n", authResp.RefreshExpiresAt.Format("2006-01-02 15:04:05 MST"))
+	fmt.Println("  Permissions: read, write, sync")
 
-		fmt.Println("\nReady to use MangaHub! Try:")
-		fmt.Println("  mangahub manga search \"your favorite manga\"")
+	cfg, _ := config.Load()
+	fmt.Printf("  Auto-sync: %v\n", cfg.Sync.AutoSync)
+	fmt.Printf("  Notifications: %v\n", cfg.Notifications.Enabled)
 
-		return nil
-	},
+	fmt.Println("\nReady to use MangaHub! Try:")
+	fmt.Println("  mangahub manga search \"your favorite manga\"")
+
+	return nil
 }
 
 var authLogoutCmd = &cobra.Command{
 	Use:   "logout",
 	Short: "Logout from your account",
-	Long:  `Logout from your MangaHub account and remove stored token.`,
+	Long:  `Logout from your MangaHub account, revoke the session on the server and remove stored tokens.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		cfg, err := config.Load()
 		if err != nil {
@@ -246,6 +259,16 @@ var authLogoutCmd = &cobra.Command{
 
 		currentUser := cfg.User.Username
 
+		// Revoke the tokens on the server too, so a copy of them stops
+		// working. The local logout goes ahead even if the server is down.
+		res, body, err := sendWithToken(http.MethodPost, "/auth/logout", cfg.User.Token,
+			map[string]string{"refresh_token": cfg.User.RefreshToken})
+		if err != nil {
+			fmt.Println("Warning: Could not reach the server to revoke your session")
+		} else if res.StatusCode != http.StatusOK {
+			fmt.Printf("Warning: Server did not revoke your session: %s\n", errorMessage(body))
+		}
+
 		if err := config.ClearUserToken(); err != nil {
 			return fmt.Errorf("failed to logout: %w", err)
 		}
@@ -259,6 +282,231 @@ var authLogoutCmd = &cobra.Command{
 	},
 }
 
+var authLinkMALCmd = &cobra.Command{
+	Use:   "link-mal",
+	Short: "Link your MyAnimeList account",
+	Long: `Link a MyAnimeList account so your library and MAL list stay in sync.
+
+Open the printed URL, approve access on MyAnimeList, and this command picks up
+the link and runs the first sync. After that the server syncs on a schedule;
+an entry changed on both sides since the last sync keeps the most recent change.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		resp, body, err := authRequest("POST", "/users/me/mal/link", nil)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode != http.StatusOK {
+			printError(fmt.Sprintf("Failed to start linking: %s", errorMessage(body)))
+			return fmt.Errorf("failed to start linking")
+		}
+		var link models.MALLinkResponse
+		json.Unmarshal(body, &link)
+
+		started := time.Now()
+		fmt.Println("Open this URL in your browser and approve access:")
+		fmt.Printf("\n  %s\n\n", link.AuthorizationURL)
+		fmt.Println("Waiting for MyAnimeList...")
+
+		for time.Now().Before(link.ExpiresAt) {
+			time.Sleep(2 * time.Second)
+			resp, body, err := authRequest("GET", "/users/me/mal", nil)
+			if err != nil {
+				return err
+			}
+			if resp.StatusCode != http.StatusOK {
+				continue
+			}
+			var status models.MALAccountStatus
+			json.Unmarshal(body, &status)
+			if !status.Linked || status.LinkedAt == nil || status.LinkedAt.Before(started) {
+				continue
+			}
+
+			printSuccess("MyAnimeList account linked!")
+			if status.Username != "" {
+				fmt.Printf("MAL user: %s\n", status.Username)
+			}
+			return runMALSync()
+		}
+
+		printError("Linking timed out")
+		fmt.Println("Run: mangahub auth link-mal")
+		return fmt.Errorf("linking timed out")
+	},
+}
+
+var authMALSyncCmd = &cobra.Command{
+	Use:   "mal-sync",
+	Short: "Sync your library with MyAnimeList now",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runMALSync()
+	},
+}
+
+var authUnlinkMALCmd = &cobra.Command{
+	Use:   "unlink-mal",
+	Short: "Unlink your MyAnimeList account",
+	Long:  `Stop syncing with MyAnimeList and delete the stored MAL tokens. Your MAL list is not changed.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		resp, body, err := authRequest("DELETE", "/users/me/mal", nil)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode != http.StatusOK {
+			printError(fmt.Sprintf("Failed to unlink: %s", errorMessage(body)))
+			return fmt.Errorf("failed to unlink")
+		}
+		printSuccess("MyAnimeList account unlinked")
+		return nil
+	},
+}
+
+var resetToken string
+
+var authForgotPasswordCmd = &cobra.Command{
+	Use:   "forgot-password",
+	Short: "Email yourself a password reset token",
+	Long:  `Send a password reset token to the email address on your account. Use it with: mangahub auth reset-password --token <token>`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if email == "" {
+			return fmt.Errorf("email is required (--email)")
+		}
+		resp, body, err := sendWithToken("POST", "/auth/forgot-password", "", models.ForgotPasswordRequest{Email: email})
+		if err != nil {
+			printError("Server connection error")
+			fmt.Println("Check server status: mangahub server status")
+			return err
+		}
+		if resp.StatusCode != http.StatusOK {
+			printError(fmt.Sprintf("Request failed: %s", errorMessage(body)))
+			return fmt.Errorf("forgot password failed")
+		}
+		printSuccess("Check your inbox")
+		fmt.Printf("If an account uses %s, a reset token has been sent to it.\n", email)
+		fmt.Println("Then run: mangahub auth reset-password --token <token>")
+		return nil
+	},
+}
+
+var authResetPasswordCmd = &cobra.Command{
+	Use:   "reset-password",
+	Short: "Set a new password with an emailed reset token",
+	Long:  `Set a new password using the token from 'mangahub auth forgot-password'. Every device is logged out afterwards.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if resetToken == "" {
+			return fmt.Errorf("reset token is required (--token)")
+		}
+
+		fmt.Print("New password: ")
+		passwordBytes, err := term.ReadPassword(int(syscall.Stdin))
+		fmt.Println()
+		if err != nil {
+			return fmt.Errorf("failed to read password: %w", err)
+		}
+		fmt.Print("Confirm new password: ")
+		confirmBytes, err := term.ReadPassword(int(syscall.Stdin))
+		fmt.Println()
+		if err != nil {
+			return fmt.Errorf("failed to read password confirmation: %w", err)
+		}
+		if string(passwordBytes) != string(confirmBytes) {
+			printError("Passwords do not match")
+			return fmt.Errorf("passwords do not match")
+		}
+
+		resp, body, err := sendWithToken("POST", "/auth/reset-password", "", models.ResetPasswordRequest{
+			Token:       resetToken,
+			NewPassword: string(passwordBytes),
+		})
+		if err != nil {
+			printError("Server connection error")
+			fmt.Println("Check server status: mangahub server status")
+			return err
+		}
+		if resp.StatusCode != http.StatusOK {
+			printError(fmt.Sprintf("Password reset failed: %s", errorMessage(body)))
+			return fmt.Errorf("password reset failed")
+		}
+
+		// The server ended every session, including this one
+		config.ClearUserToken()
+		printSuccess("Password reset successfully!")
+		fmt.Println("Log in with your new password: mangahub auth login --username <username>")
+		return nil
+	},
+}
+
+var authChangeEmailCmd = &cobra.Command{
+	Use:   "change-email",
+	Short: "Move your account to a new email address",
+	Long:  `Send a confirmation link to a new email address. Your account switches to it once the link is opened.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if email == "" {
+			return fmt.Errorf("email is required (--email)")
+		}
+
+		fmt.Print("Current password: ")
+		passwordBytes, err := term.ReadPassword(int(syscall.Stdin))
+		fmt.Println()
+		if err != nil {
+			return fmt.Errorf("failed to read password: %w", err)
+		}
+
+		resp, body, err := authRequest("PUT", "/users/me/email", models.ChangeEmailRequest{
+			Email:    email,
+			Password: string(passwordBytes),
+		})
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode != http.StatusAccepted {
+			printError(fmt.Sprintf("Email change failed: %s", errorMessage(body)))
+			return fmt.Errorf("email change failed")
+		}
+		printSuccess("Confirmation sent")
+		fmt.Printf("Open the link sent to %s to finish the change.\n", email)
+		return nil
+	},
+}
+
+var authResendVerificationCmd = &cobra.Command{
+	Use:   "resend-verification",
+	Short: "Send a new email verification link",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		resp, body, err := authRequest("POST", "/auth/verify/resend", nil)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode != http.StatusAccepted {
+			printError(fmt.Sprintf("Request failed: %s", errorMessage(body)))
+			return fmt.Errorf("resend verification failed")
+		}
+		printSuccess("Verification email sent")
+		return nil
+	},
+}
+
+func runMALSync() error {
+	fmt.Println("Syncing with MyAnimeList...")
+	resp, body, err := authRequest("POST", "/users/me/mal/sync", nil)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		printError(fmt.Sprintf("Sync failed: %s", errorMessage(body)))
+		return fmt.Errorf("sync failed")
+	}
+
+	var result models.MALSyncResult
+	json.Unmarshal(body, &result)
+	printSuccess("Sync complete!")
+	fmt.Printf("Sent to MAL: %d  Received from MAL: %d  Conflicts resolved: %d\n", result.Pushed, result.Pulled, result.Conflicts)
+	for _, e := range result.Errors {
+		fmt.Printf("  failed: %s\n", e)
+	}
+	return nil
+}
+
 func init() {
 	authRegisterCmd.Flags().StringVar(&username, "username", "", "Username for registration")
 	authRegisterCmd.Flags().StringVar(&email, "email", "", "Email for registration")
@@ -267,10 +515,22 @@ func init() {
 
 	authLoginCmd.Flags().StringVar(&username, "username", "", "Username for login")
 	authLoginCmd.Flags().StringVar(&email, "email", "", "Email for login")
+	authLoginCmd.Flags().BoolVar(&sso, "sso", false, "Sign in through your identity provider")
+
+	authForgotPasswordCmd.Flags().StringVar(&email, "email", "", "Email address of your account")
+	authResetPasswordCmd.Flags().StringVar(&resetToken, "token", "", "Reset token from the email")
+	authChangeEmailCmd.Flags().StringVar(&email, "email", "", "New email address")
 
 	authCmd.AddCommand(authRegisterCmd)
 	authCmd.AddCommand(authLoginCmd)
 	authCmd.AddCommand(authLogoutCmd)
+	authCmd.AddCommand(authLinkMALCmd)
+	authCmd.AddCommand(authMALSyncCmd)
+	authCmd.AddCommand(authUnlinkMALCmd)
+	authCmd.AddCommand(authForgotPasswordCmd)
+	authCmd.AddCommand(authResetPasswordCmd)
+	authCmd.AddCommand(authChangeEmailCmd)
+	authCmd.AddCommand(authResendVerificationCmd)
 }
 
 func readPasswordFallback() (string, error) {
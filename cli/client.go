@@ -0,0 +1,170 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/binhbb2204/Manga-Hub-Group13/cli/config"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// authRequest sends an authenticated request to the API server and returns
+// the response with its body already read. It prints the usual setup hints
+// when the CLI is not initialized or the user is not logged in. An expired
+// access token is refreshed and the request retried once.
+func authRequest(method, path string, payload interface{}) (*http.Response, []byte, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		printError("Configuration not initialized")
+		fmt.Println("Run: mangahub init")
+		return nil, nil, err
+	}
+
+	if cfg.User.Token == "" {
+		printError("Not logged in")
+		fmt.Println("Run: mangahub auth login --username <username>")
+		return nil, nil, fmt.Errorf("authentication required")
+	}
+
+	if tokenExpiring(cfg.User.Token) {
+		refreshSession(cfg)
+	}
+
+	resp, respBody, err := sendWithToken(method, path, cfg.User.Token, payload)
+	if err != nil {
+		printError("Server connection error")
+		fmt.Println("Check server status: mangahub server status")
+		return nil, nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized && cfg.User.RefreshToken != "" {
+		if refreshSession(cfg) == nil {
+			resp, respBody, err = sendWithToken(method, path, cfg.User.Token, payload)
+			if err != nil {
+				printError("Server connection error")
+				return nil, nil, err
+			}
+		}
+	}
+	return resp, respBody, nil
+}
+
+// sendWithToken sends one request to the API server with token as the
+// bearer credential and reads the whole response body.
+func sendWithToken(method, path, token string, payload interface{}) (*http.Response, []byte, error) {
+	serverURL, err := config.GetServerURL()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var body io.Reader
+	if payload != nil {
+		jsonData, err := json.Marshal(payload)
+		if err != nil {
+			return nil, nil, err
+		}
+		body = bytes.NewBuffer(jsonData)
+	}
+
+	req, err := http.NewRequest(method, serverURL+path, body)
+	if err != nil {
+		return nil, nil, err
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if name := deviceName(); name != "" {
+		req.Header.Set(deviceNameHeader, name)
+	}
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(resp.Body)
+	return resp, respBody, nil
+}
+
+// deviceNameHeader names the machine a login comes from, so it can be told
+// apart in `mangahub auth sessions`
+const deviceNameHeader = "X-Device-Name"
+
+func deviceName() string {
+	hostname, _ := os.Hostname()
+	return hostname
+}
+
+// tokenExpiring reports whether the access token expires within a minute.
+// The CLI can't verify the signature; it only reads the expiry to decide
+// whether to refresh ahead of time.
+func tokenExpiring(token string) bool {
+	var claims jwt.RegisteredClaims
+	if _, _, err := jwt.NewParser().ParseUnverified(token, &claims); err != nil || claims.ExpiresAt == nil {
+		return false
+	}
+	return time.Until(claims.ExpiresAt.Time) < time.Minute
+}
+
+// refreshSession trades the stored refresh token for a new token pair and
+// saves both. A rejected refresh token means the session is over, so the
+// stored tokens are cleared and the user is asked to log in again.
+func refreshSession(cfg *config.Config) error {
+	if cfg.User.RefreshToken == "" {
+		return fmt.Errorf("no refresh token stored")
+	}
+
+	resp, body, err := sendWithToken(http.MethodPost, "/auth/refresh", "",
+		map[string]string{"refresh_token": cfg.User.RefreshToken})
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		config.ClearUserToken()
+		cfg.User.Token, cfg.User.RefreshToken = "", ""
+		printError("Session expired")
+		fmt.Println("Run: mangahub auth login --username <username>")
+		return fmt.Errorf("session expired")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("refresh failed: %s", errorMessage(body))
+	}
+
+	var tokens struct {
+		Token        string `json:"token"`
+		RefreshToken string `json:"refresh_token"`
+		Username     string `json:"username"`
+	}
+	if err := json.Unmarshal(body, &tokens); err != nil {
+		return err
+	}
+	cfg.User.Token, cfg.User.RefreshToken = tokens.Token, tokens.RefreshToken
+	return config.UpdateUserToken(tokens.Username, tokens.Token, tokens.RefreshToken)
+}
+
+// refreshIfExpiring runs before every command so the commands that read the
+// stored token directly, like the TCP and UDP clients, get a live one.
+func refreshIfExpiring() {
+	cfg, err := config.Load()
+	if err != nil || cfg.User.Token == "" || !tokenExpiring(cfg.User.Token) {
+		return
+	}
+	refreshSession(cfg)
+}
+
+// errorMessage extracts the "error" field from an API error response.
+func errorMessage(body []byte) string {
+	var errResp map[string]interface{}
+	json.Unmarshal(body, &errResp)
+	msg, _ := errResp["error"].(string)
+	return msg
+}
@@ -41,8 +41,9 @@ type Config struct {
 		Path string `yaml:"path"`
 	} `yaml:"database"`
 	User struct {
-		Username string `yaml:"username"`
-		Token    string `yaml:"token"`
+		Username     string `yaml:"username"`
+		Token        string `yaml:"token"`
+		RefreshToken string `yaml:"refresh_token,omitempty"`
 	} `yaml:"user"`
 	Sync struct {
 		AutoSync           bool   `yaml:"auto_sync"`
@@ -157,7 +158,7 @@ func Init() error {
 	return Save(config)
 }
 
-func UpdateUserToken(username, token string) error {
+func UpdateUserToken(username, token, refreshToken string) error {
 	config, err := Load()
 	if err != nil {
 		return err
@@ -165,6 +166,7 @@ func UpdateUserToken(username, token string) error {
 
 	config.User.Username = username
 	config.User.Token = token
+	config.User.RefreshToken = refreshToken
 
 	return Save(config)
 }
@@ -177,6 +179,7 @@ func ClearUserToken() error {
 
 	config.User.Username = ""
 	config.User.Token = ""
+	config.User.RefreshToken = ""
 
 	return Save(config)
 }
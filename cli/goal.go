@@ -0,0 +1,133 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/models"
+	"github.com/spf13/cobra"
+)
+
+var (
+	goalType   string
+	goalTarget int
+	goalGenre  string
+	goalYear   int
+	goalFrom   string
+	goalTo     string
+)
+
+var goalCmd = &cobra.Command{
+	Use:   "goal",
+	Short: "Set and track reading goals",
+	Long: `Set goals like "read 500 chapters this year" or "finish 12 series in 2026"
+and see whether you're on pace to hit them.`,
+}
+
+var goalSetCmd = &cobra.Command{
+	Use:   "set",
+	Short: "Set a reading goal",
+	Long: `Set a reading goal for a calendar year (this year by default) or a date range.
+
+Examples:
+  mangahub goal set --type chapters --target 500
+  mangahub goal set --type series_completed --target 12 --year 2026
+  mangahub goal set --type chapters --target 100 --genre Horror --from 2026-10-01 --to 2026-10-31`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if goalType != models.GoalChapters && goalType != models.GoalSeriesCompleted {
+			return fmt.Errorf("invalid goal type: %s (use: %s, %s)", goalType, models.GoalChapters, models.GoalSeriesCompleted)
+		}
+		if goalTarget < 1 {
+			return fmt.Errorf("target must be at least 1 (--target)")
+		}
+
+		req := models.CreateGoalRequest{
+			Type:      goalType,
+			Target:    goalTarget,
+			Genre:     goalGenre,
+			Year:      goalYear,
+			StartDate: goalFrom,
+			EndDate:   goalTo,
+		}
+		resp, body, err := authRequest("POST", "/users/goals", req)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode != http.StatusCreated {
+			printError(fmt.Sprintf("Failed to set goal: %s", errorMessage(body)))
+			return fmt.Errorf("failed to set goal")
+		}
+
+		var g models.Goal
+		json.Unmarshal(body, &g)
+
+		printSuccess("Goal set!")
+		printGoal(g)
+		fmt.Println("\nTrack it:")
+		fmt.Println("  mangahub goal status")
+		return nil
+	},
+}
+
+var goalStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show progress on your reading goals",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		resp, body, err := authRequest("GET", "/users/goals", nil)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode != http.StatusOK {
+			printError(fmt.Sprintf("Failed to get goals: %s", errorMessage(body)))
+			return fmt.Errorf("failed to get goals")
+		}
+
+		var result struct {
+			Goals []models.Goal `json:"goals"`
+		}
+		json.Unmarshal(body, &result)
+
+		if len(result.Goals) == 0 {
+			fmt.Println("You have no reading goals yet")
+			fmt.Println("\nSet one:")
+			fmt.Println("  mangahub goal set --type chapters --target 500")
+			return nil
+		}
+
+		fmt.Printf("Your Goals (%d):\n\n", len(result.Goals))
+		for _, g := range result.Goals {
+			printGoal(g)
+			fmt.Println()
+		}
+		return nil
+	},
+}
+
+func printGoal(g models.Goal) {
+	what := "chapters"
+	if g.Type == models.GoalSeriesCompleted {
+		what = "series completed"
+	}
+	if g.Genre != "" {
+		what = g.Genre + " " + what
+	}
+	fmt.Printf("%d %s (%s to %s)\n", g.Target, what, g.StartDate, g.EndDate)
+	fmt.Printf("   ID: %s\n", g.ID)
+	fmt.Printf("   Progress: %d/%d (%d expected by now)\n", g.Progress, g.Target, g.Expected)
+	fmt.Printf("   Pace: %s\n", strings.ReplaceAll(g.Pace, "_", " "))
+}
+
+func init() {
+	goalSetCmd.Flags().StringVar(&goalType, "type", models.GoalChapters, "Goal type (chapters, series_completed)")
+	goalSetCmd.Flags().IntVar(&goalTarget, "target", 0, "Number of chapters or series to reach")
+	goalSetCmd.Flags().StringVar(&goalGenre, "genre", "", "Only count manga in this genre")
+	goalSetCmd.Flags().IntVar(&goalYear, "year", 0, "Calendar year of the goal (default: this year)")
+	goalSetCmd.Flags().StringVar(&goalFrom, "from", "", "Start date, YYYY-MM-DD (use with --to instead of --year)")
+	goalSetCmd.Flags().StringVar(&goalTo, "to", "", "End date, YYYY-MM-DD")
+	goalSetCmd.MarkFlagRequired("target")
+
+	goalCmd.AddCommand(goalSetCmd)
+	goalCmd.AddCommand(goalStatusCmd)
+}
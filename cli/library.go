@@ -6,15 +6,27 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"os"
+	"strings"
 
 	"github.com/binhbb2204/Manga-Hub-Group13/cli/config"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/models"
 	"github.com/spf13/cobra"
 )
 
 var (
-	mangaID      string
-	mangaStatus  string
-	favoriteFlag bool
+	mangaID        string
+	mangaStatus    string
+	favoriteFlag   bool
+	favoriteRemove bool
+	libraryTag     string
+	importFormat   string
+	importFile     string
+	importConflict string
+	importDryRun   bool
+	exportFormat   string
+	exportOutput   string
 )
 
 var libraryCmd = &cobra.Command{
@@ -36,15 +48,9 @@ var libraryAddCmd = &cobra.Command{
 		}
 
 		// Validate status
-		validStatuses := map[string]bool{
-			"reading":      true,
-			"completed":    true,
-			"on_hold":      true,
-			"dropped":      true,
-			"plan_to_read": true,
-		}
-		if !validStatuses[mangaStatus] {
-			return fmt.Errorf("invalid status: %s (use: reading, completed, on_hold, dropped, plan_to_read)", mangaStatus)
+		status, ok := models.ParseReadingStatus(mangaStatus)
+		if !ok {
+			return fmt.Errorf("invalid status: %s (use: %s)", mangaStatus, models.StatusList())
 		}
 
 		cfg, err := config.Load()
@@ -67,7 +73,7 @@ var libraryAddCmd = &cobra.Command{
 
 		reqBody := map[string]interface{}{
 			"manga_id":    mangaID,
-			"status":      mangaStatus,
+			"status":      status,
 			"is_favorite": favoriteFlag,
 		}
 		jsonData, _ := json.Marshal(reqBody)
@@ -130,7 +136,12 @@ var libraryListCmd = &cobra.Command{
 			return err
 		}
 
-		req, _ := http.NewRequest("GET", serverURL+"/users/library", nil)
+		libraryURL := serverURL + "/users/library"
+		if libraryTag != "" {
+			libraryURL += "?tag=" + url.QueryEscape(libraryTag)
+		}
+
+		req, _ := http.NewRequest("GET", libraryURL, nil)
 		req.Header.Set("Authorization", "Bearer "+cfg.User.Token)
 
 		client := &http.Client{}
@@ -150,15 +161,33 @@ var libraryListCmd = &cobra.Command{
 			return fmt.Errorf("failed to get library")
 		}
 
-		var library []struct {
-			MangaID    string `json:"manga_id"`
-			Title      string `json:"title"`
-			Status     string `json:"status"`
-			IsFavorite bool   `json:"is_favorite"`
+		var library map[string][]struct {
+			Manga struct {
+				ID           string `json:"id"`
+				Title        string `json:"title"`
+				DisplayTitle string `json:"display_title"`
+			} `json:"manga"`
+			CurrentChapter int      `json:"current_chapter"`
+			CurrentVolume  int      `json:"current_volume"`
+			Status         string   `json:"status"`
+			IsFavorite     bool     `json:"is_favorite"`
+			UserScore      int      `json:"user_score"`
+			Tags           []string `json:"tags"`
 		}
 		json.Unmarshal(body, &library)
 
-		if len(library) == 0 {
+		// The favorites group repeats entries from the status groups
+		total := 0
+		for _, status := range models.ReadingStatuses {
+			total += len(library[string(status)])
+		}
+
+		if total == 0 && libraryTag != "" {
+			fmt.Printf("No manga in your library tagged \"%s\"\n", libraryTag)
+			return nil
+		}
+
+		if total == 0 {
 			fmt.Println("Your library is empty")
 			fmt.Println("\nAdd manga to library:")
 			fmt.Println("  mangahub manga search \"one piece\"")
@@ -166,17 +195,239 @@ var libraryListCmd = &cobra.Command{
 			return nil
 		}
 
-		fmt.Printf("Your Library (%d manga):\n\n", len(library))
-		for i, item := range library {
-			fmt.Printf("%d. %s\n", i+1, item.Title)
-			fmt.Printf("   ID: %s\n", item.MangaID)
-			fmt.Printf("   Status: %s\n", item.Status)
-			if item.IsFavorite {
-				fmt.Println("   ⭐ Favorite")
+		fmt.Printf("Your Library (%d manga):\n\n", total)
+		i := 0
+		for _, status := range models.ReadingStatuses {
+			for _, item := range library[string(status)] {
+				i++
+				fmt.Printf("%d. %s\n", i, displayTitle(item.Manga.Title, item.Manga.DisplayTitle))
+				fmt.Printf("   ID: %s\n", item.Manga.ID)
+				fmt.Printf("   Status: %s\n", item.Status)
+				fmt.Printf("   Progress: %s\n", formatProgress(item.CurrentVolume, item.CurrentChapter))
+				if item.IsFavorite {
+					fmt.Println("   ⭐ Favorite")
+				}
+				if item.UserScore > 0 {
+					fmt.Printf("   Your score: %d/10\n", item.UserScore)
+				}
+				if len(item.Tags) > 0 {
+					fmt.Printf("   Tags: %s\n", strings.Join(item.Tags, ", "))
+				}
+				fmt.Println()
+			}
+		}
+
+		return nil
+	},
+}
+
+var libraryFavoriteCmd = &cobra.Command{
+	Use:   "favorite",
+	Short: "Mark a library entry as a favorite",
+	Long:  `Mark a manga in your library as a favorite, or unmark it with --remove.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		isFavorite := !favoriteRemove
+		path := fmt.Sprintf("/users/library/%s/favorite", url.PathEscape(mangaID))
+		resp, body, err := authRequest("PUT", path, map[string]interface{}{"is_favorite": isFavorite})
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode != http.StatusOK {
+			printError(fmt.Sprintf("Failed to update favorite: %s", errorMessage(body)))
+			return fmt.Errorf("failed to update favorite")
+		}
+
+		if isFavorite {
+			printSuccess("Marked as favorite!")
+		} else {
+			printSuccess("Removed from favorites")
+		}
+		fmt.Printf("Manga ID: %s\n", mangaID)
+		return nil
+	},
+}
+
+var libraryTagCmd = &cobra.Command{
+	Use:   "tag",
+	Short: "Tag manga in your library",
+	Long:  `Add or remove your own tags (e.g. "re-read", "webtoon") on library entries.`,
+}
+
+var libraryTagAddCmd = &cobra.Command{
+	Use:   "add [tags...]",
+	Short: "Add tags to a library entry",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return changeLibraryTags("POST", args)
+	},
+}
+
+var libraryTagRemoveCmd = &cobra.Command{
+	Use:   "remove [tags...]",
+	Short: "Remove tags from a library entry",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return changeLibraryTags("DELETE", args)
+	},
+}
+
+func changeLibraryTags(method string, tags []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		printError("Configuration not initialized")
+		fmt.Println("Run: mangahub init")
+		return err
+	}
+
+	if cfg.User.Token == "" {
+		printError("Not logged in")
+		fmt.Println("Run: mangahub auth login --username <username>")
+		return fmt.Errorf("authentication required")
+	}
+
+	serverURL, err := config.GetServerURL()
+	if err != nil {
+		return err
+	}
+
+	jsonData, _ := json.Marshal(map[string]interface{}{"tags": tags})
+
+	tagsURL := fmt.Sprintf("%s/users/library/%s/tags", serverURL, url.PathEscape(mangaID))
+	req, _ := http.NewRequest(method, tagsURL, bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+cfg.User.Token)
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		printError("Failed to update tags: Server connection error")
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		var errResp map[string]string
+		json.Unmarshal(body, &errResp)
+		printError(fmt.Sprintf("Failed to update tags: %s", errResp["error"]))
+		return fmt.Errorf("failed to update tags")
+	}
+
+	var result struct {
+		Tags []string `json:"tags"`
+	}
+	json.Unmarshal(body, &result)
+
+	printSuccess("Tags updated!")
+	fmt.Printf("Manga ID: %s\n", mangaID)
+	if len(result.Tags) == 0 {
+		fmt.Println("Tags: -")
+	} else {
+		fmt.Printf("Tags: %s\n", strings.Join(result.Tags, ", "))
+	}
+
+	return nil
+}
+
+var libraryImportCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Import your library from MyAnimeList, AniList or CSV",
+	Long: `Import reading statuses, chapters, volumes, scores and dates from an export file.
+
+Formats:
+  mal-xml       MyAnimeList manga list export (unzipped .xml)
+  anilist-json  AniList MediaListCollection query result
+  csv           CSV with a header row: mal_id or title, status, chapter, volume, score, started_at, finished_at
+
+Titles missing from the catalog are looked up on MyAnimeList. Entries already
+in your library follow --conflict: keep_higher (default) only updates when the
+import is further along, overwrite always replaces, skip leaves them alone.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		data, err := os.ReadFile(importFile)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", importFile, err)
+		}
+
+		payload := map[string]interface{}{
+			"format":   importFormat,
+			"data":     string(data),
+			"conflict": importConflict,
+			"dry_run":  importDryRun,
+		}
+		resp, body, err := authRequest("POST", "/users/library/import", payload)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode != http.StatusOK {
+			printError(fmt.Sprintf("Import failed: %s", errorMessage(body)))
+			return fmt.Errorf("import failed")
+		}
+
+		var result models.ImportResult
+		if err := json.Unmarshal(body, &result); err != nil {
+			return fmt.Errorf("failed to parse response: %w", err)
+		}
+
+		if result.DryRun {
+			fmt.Println("Dry run - nothing was changed")
+		} else {
+			printSuccess("Library imported!")
+		}
+		fmt.Printf("Entries: %d  Created: %d  Updated: %d  Skipped: %d  Failed: %d\n",
+			result.Total, result.Created, result.Updated, result.Skipped, result.Failed)
+
+		for _, e := range result.Entries {
+			switch e.Action {
+			case models.ImportSkipped, models.ImportFailed:
+				fmt.Printf("  %-7s %s: %s\n", e.Action, e.Title, e.Reason)
+			default:
+				if result.DryRun || e.AddedToCatalog {
+					fmt.Printf("  %-7s %s (%s, %s)\n", e.Action, e.Title, e.Status, formatProgress(e.CurrentVolume, e.CurrentChapter))
+				}
+			}
+		}
+		return nil
+	},
+}
+
+var libraryExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export your library to MAL XML, CSV or JSON",
+	Long: `Download your library to a file.
+
+Formats:
+  mal-xml  MyAnimeList list format, importable at myanimelist.net/import.php
+  csv      One row per entry; columns are listed in the file's header row
+  json     Library entries with tags and favorites`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path := "/users/library/export?format=" + url.QueryEscape(exportFormat)
+		resp, body, err := authRequest("GET", path, nil)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode != http.StatusOK {
+			printError(fmt.Sprintf("Export failed: %s", errorMessage(body)))
+			return fmt.Errorf("export failed")
+		}
+
+		output := exportOutput
+		if output == "" {
+			ext := exportFormat
+			if ext == "mal-xml" {
+				ext = "xml"
 			}
-			fmt.Println()
+			output = "mangahub-library." + ext
+		}
+		if err := os.WriteFile(output, body, 0o644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", output, err)
 		}
 
+		printSuccess("Library exported!")
+		fmt.Printf("File: %s (%d bytes)\n", output, len(body))
+		if exportFormat == "mal-xml" {
+			fmt.Println("Upload it at https://myanimelist.net/import.php")
+		}
 		return nil
 	},
 }
@@ -187,6 +438,32 @@ func init() {
 	libraryAddCmd.Flags().BoolVar(&favoriteFlag, "favorite", false, "Mark as favorite")
 	libraryAddCmd.MarkFlagRequired("manga-id")
 
+	libraryListCmd.Flags().StringVar(&libraryTag, "tag", "", "Only show manga with this tag")
+
+	libraryFavoriteCmd.Flags().StringVar(&mangaID, "manga-id", "", "Manga ID to mark")
+	libraryFavoriteCmd.Flags().BoolVar(&favoriteRemove, "remove", false, "Remove from favorites instead")
+	libraryFavoriteCmd.MarkFlagRequired("manga-id")
+
+	libraryImportCmd.Flags().StringVar(&importFormat, "format", "", "Export format (mal-xml, anilist-json, csv)")
+	libraryImportCmd.Flags().StringVar(&importFile, "file", "", "Path to the export file")
+	libraryImportCmd.Flags().StringVar(&importConflict, "conflict", "keep_higher", "Policy for entries already in your library (keep_higher, overwrite, skip)")
+	libraryImportCmd.Flags().BoolVar(&importDryRun, "dry-run", false, "Show what would change without importing")
+	libraryImportCmd.MarkFlagRequired("format")
+	libraryImportCmd.MarkFlagRequired("file")
+
+	libraryExportCmd.Flags().StringVar(&exportFormat, "format", "json", "Export format (mal-xml, csv, json)")
+	libraryExportCmd.Flags().StringVarP(&exportOutput, "output", "o", "", "File to write (default mangahub-library.<ext>)")
+
+	for _, c := range []*cobra.Command{libraryTagAddCmd, libraryTagRemoveCmd} {
+		c.Flags().StringVar(&mangaID, "manga-id", "", "Manga ID to tag")
+		c.MarkFlagRequired("manga-id")
+		libraryTagCmd.AddCommand(c)
+	}
+
 	libraryCmd.AddCommand(libraryAddCmd)
 	libraryCmd.AddCommand(libraryListCmd)
+	libraryCmd.AddCommand(libraryTagCmd)
+	libraryCmd.AddCommand(libraryFavoriteCmd)
+	libraryCmd.AddCommand(libraryImportCmd)
+	libraryCmd.AddCommand(libraryExportCmd)
 }
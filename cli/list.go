@@ -0,0 +1,279 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/binhbb2204/Manga-Hub-Group13/cli/config"
+	"github.com/spf13/cobra"
+)
+
+var (
+	listDescription string
+	listPublic      bool
+	listPosition    int
+	listRevoke      bool
+)
+
+type cliReadingList struct {
+	ID          string    `json:"id"`
+	Owner       string    `json:"owner"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	IsPublic    bool      `json:"is_public"`
+	ShareID     string    `json:"share_id"`
+	ItemCount   int       `json:"item_count"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	Items       []struct {
+		Position int `json:"position"`
+		Manga    struct {
+			ID           string `json:"id"`
+			Title        string `json:"title"`
+			DisplayTitle string `json:"display_title"`
+			Author       string `json:"author"`
+		} `json:"manga"`
+	} `json:"items"`
+}
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Manage custom reading lists",
+	Long: `Create ordered, named reading lists and share them with others.
+Run without a subcommand to see your lists.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		resp, body, err := authRequest("GET", "/users/lists", nil)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode != http.StatusOK {
+			printError(fmt.Sprintf("Failed to get lists: %s", errorMessage(body)))
+			return fmt.Errorf("failed to get lists")
+		}
+
+		var result struct {
+			Lists []cliReadingList `json:"lists"`
+		}
+		json.Unmarshal(body, &result)
+
+		if len(result.Lists) == 0 {
+			fmt.Println("You have no lists yet")
+			fmt.Println("\nCreate one:")
+			fmt.Println("  mangahub list create \"Best isekai\"")
+			return nil
+		}
+
+		fmt.Printf("Your Lists (%d):\n\n", len(result.Lists))
+		for _, l := range result.Lists {
+			visibility := "private"
+			if l.IsPublic {
+				visibility = "public"
+			}
+			fmt.Printf("%s (%d manga, %s)\n", l.Name, l.ItemCount, visibility)
+			fmt.Printf("   ID: %s\n", l.ID)
+			if l.Description != "" {
+				fmt.Printf("   %s\n", l.Description)
+			}
+			fmt.Println()
+		}
+		fmt.Println("View a list: mangahub list show <list-id>")
+
+		return nil
+	},
+}
+
+var listCreateCmd = &cobra.Command{
+	Use:   "create [name]",
+	Short: "Create a new list",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		reqBody := map[string]interface{}{
+			"name":        args[0],
+			"description": listDescription,
+			"is_public":   listPublic,
+		}
+		resp, body, err := authRequest("POST", "/users/lists", reqBody)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode != http.StatusCreated {
+			printError(fmt.Sprintf("Failed to create list: %s", errorMessage(body)))
+			return fmt.Errorf("failed to create list")
+		}
+
+		var created cliReadingList
+		json.Unmarshal(body, &created)
+
+		printSuccess("List created!")
+		fmt.Printf("Name: %s\n", created.Name)
+		fmt.Printf("List ID: %s\n", created.ID)
+		if created.IsPublic {
+			printShareLink(created.ShareID)
+		}
+		fmt.Println("\nAdd manga: mangahub list add", created.ID, "<manga-id>")
+
+		return nil
+	},
+}
+
+var listShowCmd = &cobra.Command{
+	Use:   "show [list-id]",
+	Short: "Show the manga in a list",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		resp, body, err := authRequest("GET", "/users/lists/"+url.PathEscape(args[0]), nil)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode != http.StatusOK {
+			printError(fmt.Sprintf("Failed to get list: %s", errorMessage(body)))
+			return fmt.Errorf("failed to get list")
+		}
+
+		var l cliReadingList
+		json.Unmarshal(body, &l)
+		printReadingList(l)
+		if l.IsPublic {
+			fmt.Println()
+			printShareLink(l.ShareID)
+		}
+
+		return nil
+	},
+}
+
+var listAddCmd = &cobra.Command{
+	Use:   "add [list-id] [manga-id]",
+	Short: "Add a manga to a list",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		reqBody := map[string]interface{}{
+			"manga_id": args[1],
+			"position": listPosition,
+		}
+		resp, body, err := authRequest("POST", "/users/lists/"+url.PathEscape(args[0])+"/items", reqBody)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode != http.StatusOK {
+			printError(fmt.Sprintf("Failed to add to list: %s", errorMessage(body)))
+			return fmt.Errorf("failed to add to list")
+		}
+
+		printSuccess("Manga added to list!")
+		return nil
+	},
+}
+
+var listReorderCmd = &cobra.Command{
+	Use:   "reorder [list-id] [manga-id]",
+	Short: "Move a manga to a new position in a list",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if listPosition < 1 {
+			return fmt.Errorf("position must be 1 or greater (--position)")
+		}
+
+		path := fmt.Sprintf("/users/lists/%s/items/%s", url.PathEscape(args[0]), url.PathEscape(args[1]))
+		resp, body, err := authRequest("PUT", path, map[string]interface{}{"position": listPosition})
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode != http.StatusOK {
+			printError(fmt.Sprintf("Failed to reorder list: %s", errorMessage(body)))
+			return fmt.Errorf("failed to reorder list")
+		}
+
+		printSuccess(fmt.Sprintf("Moved %s to position %d", args[1], listPosition))
+		return nil
+	},
+}
+
+var listShareCmd = &cobra.Command{
+	Use:   "share [list-id]",
+	Short: "Share a list via a public link",
+	Long: `Make a list public and print its share link. Anyone with the link can view it.
+Use --revoke to make the list private again.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		method := "POST"
+		if listRevoke {
+			method = "DELETE"
+		}
+		resp, body, err := authRequest(method, "/users/lists/"+url.PathEscape(args[0])+"/share", nil)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode != http.StatusOK {
+			printError(fmt.Sprintf("Failed to update sharing: %s", errorMessage(body)))
+			return fmt.Errorf("failed to update sharing")
+		}
+
+		if listRevoke {
+			printSuccess("List is now private")
+			return nil
+		}
+
+		var result struct {
+			ShareID string `json:"share_id"`
+		}
+		json.Unmarshal(body, &result)
+
+		printSuccess("List shared!")
+		printShareLink(result.ShareID)
+		return nil
+	},
+}
+
+func printReadingList(l cliReadingList) {
+	fmt.Printf("%s", l.Name)
+	if l.Owner != "" {
+		fmt.Printf(" by %s", l.Owner)
+	}
+	fmt.Println()
+	if l.Description != "" {
+		fmt.Println(wrapText(l.Description, 76))
+	}
+	fmt.Println()
+
+	if len(l.Items) == 0 {
+		fmt.Println("This list is empty")
+		return
+	}
+	for _, item := range l.Items {
+		fmt.Printf("%3d. %s", item.Position, displayTitle(item.Manga.Title, item.Manga.DisplayTitle))
+		if item.Manga.Author != "" {
+			fmt.Printf(" - %s", item.Manga.Author)
+		}
+		fmt.Printf("  [%s]\n", item.Manga.ID)
+	}
+}
+
+func printShareLink(shareID string) {
+	serverURL, err := config.GetServerURL()
+	if err != nil {
+		fmt.Printf("Share ID: %s\n", shareID)
+		return
+	}
+	fmt.Printf("Share link: %s/lists/%s\n", serverURL, shareID)
+}
+
+func init() {
+	listCreateCmd.Flags().StringVar(&listDescription, "description", "", "List description")
+	listCreateCmd.Flags().BoolVar(&listPublic, "public", false, "Share the list publicly right away")
+
+	listAddCmd.Flags().IntVar(&listPosition, "position", 0, "Position to insert at (default: end of list)")
+
+	listReorderCmd.Flags().IntVar(&listPosition, "position", 0, "New position, starting at 1")
+	listReorderCmd.MarkFlagRequired("position")
+
+	listShareCmd.Flags().BoolVar(&listRevoke, "revoke", false, "Make the list private again")
+
+	listCmd.AddCommand(listCreateCmd)
+	listCmd.AddCommand(listShowCmd)
+	listCmd.AddCommand(listAddCmd)
+	listCmd.AddCommand(listReorderCmd)
+	listCmd.AddCommand(listShareCmd)
+}
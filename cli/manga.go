@@ -9,6 +9,7 @@ import (
 	"strings"
 
 	"github.com/binhbb2204/Manga-Hub-Group13/cli/config"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/query"
 	"github.com/spf13/cobra"
 )
 
@@ -16,6 +17,7 @@ var (
 	searchGenre  string
 	searchStatus string
 	searchLimit  int
+	searchLocal  bool
 	rankingLimit int
 )
 
@@ -28,13 +30,41 @@ var mangaCmd = &cobra.Command{
 var mangaSearchCmd = &cobra.Command{
 	Use:   "search [query]",
 	Short: "Search for manga",
-	Long:  `Search for manga by title using MyAnimeList API with optional genre and status filters.`,
-	Args:  cobra.MinimumNArgs(1),
+	Long: `Search for manga by title using MyAnimeList API with optional filters.
+
+The query accepts field clauses alongside free text:
+  genre:action -genre:horror status:completed chapters:>100
+  volumes:<=20 year:2010..2020 score:>=8 author:oda "title:with colon"
+
+Queries with only field clauses search the local catalog.`,
+	Args: cobra.MinimumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		query := args[0]
+		rawQuery := strings.Join(args, " ")
+		if searchGenre != "" {
+			rawQuery += " genre:" + quoteClauseValue(searchGenre)
+		}
+		if searchStatus != "" {
+			rawQuery += " status:" + quoteClauseValue(searchStatus)
+		}
+
+		parsed, err := query.Parse(rawQuery)
+		if err != nil {
+			if unsupported, ok := err.(*query.UnsupportedClauseError); ok {
+				printError("Unsupported search clauses")
+				for i := range unsupported.Clauses {
+					fmt.Printf("  - %s: %s\n", unsupported.Clauses[i], unsupported.Reasons[i])
+				}
+				fmt.Println("\nSupported fields: title, author, genre, status, chapters, volumes, year, score")
+				return fmt.Errorf("invalid search query")
+			}
+			return err
+		}
+
+		text := parsed.Text()
+		local := searchLocal || (text == "" && parsed.HasFilters())
 
-		if len(strings.TrimSpace(query)) < 3 {
-			fmt.Printf("\nSearching for \"%s\"...\n", query)
+		if !local && len(strings.TrimSpace(text)) < 3 {
+			fmt.Printf("\nSearching for \"%s\"...\n", rawQuery)
 			fmt.Println("\n✗ Search query too short")
 			fmt.Println("\nRequirements:")
 			fmt.Println("  - Search query must be at least 3 characters")
@@ -55,9 +85,12 @@ var mangaSearchCmd = &cobra.Command{
 		if requestLimit > 100 || requestLimit <= 0 {
 			requestLimit = 100
 		}
-		searchURL := fmt.Sprintf("%s/manga/search?q=%s&limit=%d", serverURL, url.QueryEscape(query), requestLimit)
+		searchURL := fmt.Sprintf("%s/manga/search?q=%s&limit=%d", serverURL, url.QueryEscape(rawQuery), requestLimit)
+		if local {
+			searchURL = fmt.Sprintf("%s/manga?q=%s&limit=%d", serverURL, url.QueryEscape(rawQuery), requestLimit)
+		}
 
-		res, err := http.Get(searchURL)
+		res, err := getWithOptionalAuth(searchURL)
 		if err != nil {
 			printError("Search failed: Server connection error")
 			fmt.Println("Check server status: mangahub server status")
@@ -68,11 +101,12 @@ var mangaSearchCmd = &cobra.Command{
 		body, _ := io.ReadAll(res.Body)
 
 		if res.StatusCode != http.StatusOK {
-			var errRes map[string]string
+			var errRes map[string]interface{}
 			json.Unmarshal(body, &errRes)
+			errMsg, _ := errRes["error"].(string)
 
-			if strings.Contains(errRes["error"], "at least 3 characters") {
-				fmt.Printf("\nSearching for \"%s\"...\n", query)
+			if strings.Contains(errMsg, "at least 3 characters") {
+				fmt.Printf("\nSearching for \"%s\"...\n", rawQuery)
 				fmt.Println("\n✗ Search query too short")
 				fmt.Println("\nRequirements:")
 				fmt.Println("  - Search query must be at least 3 characters")
@@ -82,7 +116,7 @@ var mangaSearchCmd = &cobra.Command{
 				return nil
 			}
 
-			printError(fmt.Sprintf("Search failed: %s", errRes["error"]))
+			printError(fmt.Sprintf("Search failed: %s", errMsg))
 			return fmt.Errorf("search failed")
 		}
 
@@ -90,6 +124,7 @@ var mangaSearchCmd = &cobra.Command{
 			Mangas []struct {
 				ID            string   `json:"id"`
 				Title         string   `json:"title"`
+				DisplayTitle  string   `json:"display_title"`
 				Author        string   `json:"author"`
 				Genres        []string `json:"genres"`
 				Status        string   `json:"status"`
@@ -100,69 +135,23 @@ var mangaSearchCmd = &cobra.Command{
 		}
 		json.Unmarshal(body, &result)
 
-		// Apply client-side filters
-		filteredMangas := result.Mangas
-		if searchGenre != "" || searchStatus != "" {
-			filteredMangas = []struct {
-				ID            string   `json:"id"`
-				Title         string   `json:"title"`
-				Author        string   `json:"author"`
-				Genres        []string `json:"genres"`
-				Status        string   `json:"status"`
-				TotalChapters int      `json:"total_chapters"`
-				Description   string   `json:"description"`
-			}{}
-
-			for _, manga := range result.Mangas {
-				//Check genre filter
-				genreMatch := searchGenre == ""
-				if searchGenre != "" {
-					for _, g := range manga.Genres {
-						if strings.EqualFold(g, searchGenre) {
-							genreMatch = true
-							break
-						}
-					}
-				}
-
-				//Check status filter
-				statusMatch := searchStatus == ""
-				if searchStatus != "" {
-					if strings.EqualFold(manga.Status, searchStatus) ||
-						(strings.EqualFold(searchStatus, "completed") && strings.EqualFold(manga.Status, "finished")) {
-						statusMatch = true
-					}
-				}
+		mangas := result.Mangas
+		if len(mangas) > requestLimit {
+			mangas = mangas[:requestLimit]
+		}
 
-				if genreMatch && statusMatch {
-					filteredMangas = append(filteredMangas, manga)
-					if len(filteredMangas) >= searchLimit {
-						break
-					}
-				}
-			}
-		} else if len(filteredMangas) > searchLimit {
-			filteredMangas = filteredMangas[:searchLimit]
+		source := "MyAnimeList"
+		if local {
+			source = "local catalog"
 		}
 
-		if len(filteredMangas) == 0 {
-			fmt.Printf("\nSearching for \"%s\"", query)
-			if searchGenre != "" || searchStatus != "" {
-				fmt.Printf(" (filters:")
-				if searchGenre != "" {
-					fmt.Printf(" genre=%s", searchGenre)
-				}
-				if searchStatus != "" {
-					fmt.Printf(" status=%s", searchStatus)
-				}
-				fmt.Printf(")")
-			}
-			fmt.Println("...")
+		if len(mangas) == 0 {
+			fmt.Printf("\nSearching %s for \"%s\"...\n", source, rawQuery)
 			fmt.Println("\nNo manga found matching your search criteria.")
 			fmt.Println("\nSuggestions:")
 			fmt.Println("  - Check spelling and try again")
 			fmt.Println("  - Use broader search terms")
-			if searchGenre != "" || searchStatus != "" {
+			if parsed.HasFilters() {
 				fmt.Println("  - Try removing filters")
 			}
 			fmt.Println("  - Try different keywords")
@@ -171,19 +160,8 @@ var mangaSearchCmd = &cobra.Command{
 		}
 
 		//Print formatted table output
-		fmt.Printf("\nSearching for \"%s\"", query)
-		if searchGenre != "" || searchStatus != "" {
-			fmt.Printf(" (filters:")
-			if searchGenre != "" {
-				fmt.Printf(" genre=%s", searchGenre)
-			}
-			if searchStatus != "" {
-				fmt.Printf(" status=%s", searchStatus)
-			}
-			fmt.Printf(")")
-		}
-		fmt.Println("...")
-		fmt.Printf("\nFound %d results:\n\n", len(filteredMangas))
+		fmt.Printf("\nSearching %s for \"%s\"...\n", source, rawQuery)
+		fmt.Printf("\nFound %d results:\n\n", len(mangas))
 
 		//Print table header (match ranking table width)
 		fmt.Println("┌─────────────────────┬──────────────────────┬──────────────────────┬──────────┬─────────────┐")
@@ -191,7 +169,7 @@ var mangaSearchCmd = &cobra.Command{
 		fmt.Println("├─────────────────────┼──────────────────────┼──────────────────────┼──────────┼─────────────┤")
 
 		//Print manga rows
-		for _, manga := range filteredMangas {
+		for _, manga := range mangas {
 			chaptersStr := fmt.Sprintf("%d", manga.TotalChapters)
 			if manga.TotalChapters == 0 {
 				chaptersStr = "Ongoing"
@@ -199,7 +177,7 @@ var mangaSearchCmd = &cobra.Command{
 
 			fmt.Printf("│ %-19s │ %-20s │ %-20s │ %-8s │ %-11s │\n",
 				truncateString(manga.ID, 19),
-				truncateString(manga.Title, 20),
+				truncateString(displayTitle(manga.Title, manga.DisplayTitle), 20),
 				truncateString(manga.Author, 20),
 				truncateString(manga.Status, 8),
 				chaptersStr)
@@ -243,7 +221,7 @@ var mangaInfoCmd = &cobra.Command{
 			return fmt.Errorf("invalid manga ID")
 		}
 
-		res, err := http.Get(fmt.Sprintf("%s/manga/info/%s", serverURL, mangaID))
+		res, err := getWithOptionalAuth(fmt.Sprintf("%s/manga/info/%s", serverURL, mangaID))
 		if err != nil {
 			printError("Failed to get manga info: Server connection error")
 			fmt.Println("Check server status: mangahub server status")
@@ -268,34 +246,37 @@ var mangaInfoCmd = &cobra.Command{
 		}
 
 		var manga struct {
-			ID                string                   `json:"id"`
-			Title             string                   `json:"title"`
-			Author            string                   `json:"author"`
-			Genres            []string                 `json:"genres"`
-			Status            string                   `json:"status"`
-			TotalChapters     int                      `json:"total_chapters"`
-			Description       string                   `json:"description"`
-			CoverURL          string                   `json:"cover_url"`
-			AlternativeTitles map[string]interface{}   `json:"alternative_titles"`
-			StartDate         string                   `json:"start_date"`
-			EndDate           string                   `json:"end_date"`
-			Mean              float64                  `json:"mean"`
-			Rank              int                      `json:"rank"`
-			Popularity        int                      `json:"popularity"`
-			NumListUsers      int                      `json:"num_list_users"`
-			NumScoringUsers   int                      `json:"num_scoring_users"`
-			MediaType         string                   `json:"media_type"`
-			NumVolumes        int                      `json:"num_volumes"`
-			Authors           []map[string]interface{} `json:"authors"`
-			Serialization     []map[string]interface{} `json:"serialization"`
-			Background        string                   `json:"background"`
+			ID                  string                   `json:"id"`
+			Title               string                   `json:"title"`
+			Author              string                   `json:"author"`
+			Genres              []string                 `json:"genres"`
+			Status              string                   `json:"status"`
+			TotalChapters       int                      `json:"total_chapters"`
+			Description         string                   `json:"description"`
+			CoverURL            string                   `json:"cover_url"`
+			AlternativeTitles   map[string]interface{}   `json:"alternative_titles"`
+			StartDate           string                   `json:"start_date"`
+			EndDate             string                   `json:"end_date"`
+			Mean                float64                  `json:"mean"`
+			CommunityScore      float64                  `json:"community_score"`
+			CommunityScoreCount int                      `json:"community_score_count"`
+			Rank                int                      `json:"rank"`
+			Popularity          int                      `json:"popularity"`
+			NumListUsers        int                      `json:"num_list_users"`
+			NumScoringUsers     int                      `json:"num_scoring_users"`
+			MediaType           string                   `json:"media_type"`
+			NumVolumes          int                      `json:"num_volumes"`
+			Authors             []map[string]interface{} `json:"authors"`
+			Serialization       []map[string]interface{} `json:"serialization"`
+			Background          string                   `json:"background"`
+			DisplayTitle        string                   `json:"display_title"`
 		}
 		json.Unmarshal(body, &manga)
 
 		// Title box
 		fmt.Println()
 		fmt.Println("┌─────────────────────────────────────────────────────────────────────┐")
-		title := strings.ToUpper(manga.Title)
+		title := strings.ToUpper(displayTitle(manga.Title, manga.DisplayTitle))
 		boxWidth := 69
 		lp := (boxWidth - len(title)) / 2
 		rp := boxWidth - len(title) - lp
@@ -356,6 +337,9 @@ var mangaInfoCmd = &cobra.Command{
 		if manga.Mean > 0 {
 			fmt.Printf("Score: %.2f\n", manga.Mean)
 		}
+		if manga.CommunityScoreCount > 0 {
+			fmt.Printf("Community Score: %.2f (%d ratings)\n", manga.CommunityScore, manga.CommunityScoreCount)
+		}
 		if manga.Rank > 0 {
 			fmt.Printf("Ranked: #%d\n", manga.Rank)
 		}
@@ -477,6 +461,34 @@ var mangaListCmd = &cobra.Command{
 	},
 }
 
+// getWithOptionalAuth sends the stored token when logged in so the server can
+// apply the user's preferred title language.
+func getWithOptionalAuth(url string) (*http.Response, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if cfg, err := config.Load(); err == nil && cfg.User.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+cfg.User.Token)
+	}
+	return http.DefaultClient.Do(req)
+}
+
+func displayTitle(title, display string) string {
+	if display != "" {
+		return display
+	}
+	return title
+}
+
+// quoteClauseValue wraps flag values containing spaces so they stay a single clause.
+func quoteClauseValue(value string) string {
+	if strings.ContainsAny(value, " \t") {
+		return `"` + value + `"`
+	}
+	return value
+}
+
 func truncateString(s string, maxLen int) string {
 	if len(s) <= maxLen {
 		return s
@@ -765,6 +777,7 @@ func init() {
 	mangaSearchCmd.Flags().StringVar(&searchGenre, "genre", "", "Filter by genre (e.g., Action, Romance, Comedy)")
 	mangaSearchCmd.Flags().StringVar(&searchStatus, "status", "", "Filter by status (ongoing, completed, finished)")
 	mangaSearchCmd.Flags().IntVar(&searchLimit, "limit", 100, "Maximum number of results (max 100)")
+	mangaSearchCmd.Flags().BoolVar(&searchLocal, "local", false, "Search the local catalog instead of MyAnimeList")
 
 	mangaCmd.AddCommand(mangaSearchCmd)
 	mangaCmd.AddCommand(mangaInfoCmd)
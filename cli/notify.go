@@ -290,5 +290,5 @@ func init() {
 	notifyCmd.AddCommand(notifyPreferencesCmd)
 	notifyCmd.AddCommand(notifyTestCmd)
 
-	notifySubscribeCmd.Flags().StringSliceVar(&eventTypes, "events", []string{}, "event types to subscribe to (progress_update, library_update)")
+	notifySubscribeCmd.Flags().StringSliceVar(&eventTypes, "events", []string{}, "event types to subscribe to (progress_update, library_update, goal_update, comment_reply, achievement_unlocked)")
 }
@@ -0,0 +1,182 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/binhbb2204/Manga-Hub-Group13/cli/config"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/models"
+	"github.com/spf13/cobra"
+)
+
+var titleLanguage string
+
+var profileCmd = &cobra.Command{
+	Use:   "profile",
+	Short: "View and update your profile",
+	Long:  `View your account profile and update display preferences.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load()
+		if err != nil {
+			printError("Configuration not initialized")
+			fmt.Println("Run: mangahub init")
+			return err
+		}
+
+		if cfg.User.Token == "" {
+			printError("Not logged in")
+			fmt.Println("Run: mangahub auth login --username <username>")
+			return fmt.Errorf("authentication required")
+		}
+
+		serverURL, err := config.GetServerURL()
+		if err != nil {
+			return err
+		}
+
+		req, _ := http.NewRequest("GET", serverURL+"/users/me", nil)
+		req.Header.Set("Authorization", "Bearer "+cfg.User.Token)
+
+		client := &http.Client{}
+		resp, err := client.Do(req)
+		if err != nil {
+			printError("Failed to get profile: Server connection error")
+			return err
+		}
+		defer resp.Body.Close()
+
+		body, _ := io.ReadAll(resp.Body)
+
+		if resp.StatusCode != http.StatusOK {
+			var errResp map[string]string
+			json.Unmarshal(body, &errResp)
+			printError(fmt.Sprintf("Failed to get profile: %s", errResp["error"]))
+			return fmt.Errorf("failed to get profile")
+		}
+
+		var profile struct {
+			ID                     string    `json:"id"`
+			Username               string    `json:"username"`
+			Email                  string    `json:"email"`
+			CreatedAt              time.Time `json:"created_at"`
+			PreferredTitleLanguage string    `json:"preferred_title_language"`
+		}
+		json.Unmarshal(body, &profile)
+
+		lang := profile.PreferredTitleLanguage
+		if lang == "" {
+			lang = "default"
+		}
+
+		fmt.Println("Profile:")
+		fmt.Printf("  Username: %s\n", profile.Username)
+		fmt.Printf("  Email: %s\n", profile.Email)
+		fmt.Printf("  User ID: %s\n", profile.ID)
+		fmt.Printf("  Member since: %s\n", profile.CreatedAt.Format("2006-01-02"))
+		fmt.Printf("  Title language: %s\n", lang)
+
+		printAchievements()
+		return nil
+	},
+}
+
+// printAchievements lists the user's unlocked badges under their profile.
+// The profile is still useful without them, so failures are ignored.
+func printAchievements() {
+	resp, body, err := authRequest("GET", "/users/me/achievements", nil)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return
+	}
+
+	var result struct {
+		Achievements []models.Achievement `json:"achievements"`
+		Unlocked     int                  `json:"unlocked"`
+		Total        int                  `json:"total"`
+	}
+	json.Unmarshal(body, &result)
+
+	fmt.Printf("\nAchievements (%d/%d):\n", result.Unlocked, result.Total)
+	if result.Unlocked == 0 {
+		fmt.Println("  None yet. Keep reading!")
+		return
+	}
+	for _, a := range result.Achievements {
+		if !a.Unlocked {
+			continue
+		}
+		fmt.Printf("  🏆 %s: %s (%s)\n", a.Name, a.Description, a.UnlockedAt.Format("2006-01-02"))
+	}
+}
+
+var profileSetCmd = &cobra.Command{
+	Use:   "set",
+	Short: "Update profile preferences",
+	Long:  `Update display preferences such as the language used for manga titles.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		validLanguages := map[string]bool{
+			"default": true,
+			"en":      true,
+			"ja":      true,
+		}
+		if !validLanguages[titleLanguage] {
+			return fmt.Errorf("invalid title language: %s (use: default, en, ja)", titleLanguage)
+		}
+
+		cfg, err := config.Load()
+		if err != nil {
+			printError("Configuration not initialized")
+			fmt.Println("Run: mangahub init")
+			return err
+		}
+
+		if cfg.User.Token == "" {
+			printError("Not logged in")
+			fmt.Println("Run: mangahub auth login --username <username>")
+			return fmt.Errorf("authentication required")
+		}
+
+		serverURL, err := config.GetServerURL()
+		if err != nil {
+			return err
+		}
+
+		jsonData, _ := json.Marshal(map[string]string{"title_language": titleLanguage})
+
+		req, _ := http.NewRequest("PUT", serverURL+"/users/me/preferences", bytes.NewBuffer(jsonData))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+cfg.User.Token)
+
+		client := &http.Client{}
+		resp, err := client.Do(req)
+		if err != nil {
+			printError("Failed to update profile: Server connection error")
+			return err
+		}
+		defer resp.Body.Close()
+
+		body, _ := io.ReadAll(resp.Body)
+
+		if resp.StatusCode != http.StatusOK {
+			var errResp map[string]string
+			json.Unmarshal(body, &errResp)
+			printError(fmt.Sprintf("Failed to update profile: %s", errResp["error"]))
+			return fmt.Errorf("failed to update profile")
+		}
+
+		printSuccess("Preferences updated!")
+		fmt.Printf("Title language: %s\n", titleLanguage)
+
+		return nil
+	},
+}
+
+func init() {
+	profileSetCmd.Flags().StringVar(&titleLanguage, "title-language", "", "Preferred title language (default, en, ja)")
+	profileSetCmd.MarkFlagRequired("title-language")
+
+	profileCmd.AddCommand(profileSetCmd)
+}
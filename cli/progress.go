@@ -59,7 +59,7 @@ var progressUpdateCmd = &cobra.Command{
 		}
 		jsonData, _ := json.Marshal(reqBody)
 
-		req, _ := http.NewRequest("POST", serverURL+"/users/progress", bytes.NewBuffer(jsonData))
+		req, _ := http.NewRequest("PUT", serverURL+"/users/progress", bytes.NewBuffer(jsonData))
 		req.Header.Set("Content-Type", "application/json")
 		req.Header.Set("Authorization", "Bearer "+cfg.User.Token)
 
@@ -82,11 +82,7 @@ var progressUpdateCmd = &cobra.Command{
 
 		printSuccess("Reading progress updated!")
 		fmt.Printf("Manga ID: %s\n", progressMangaID)
-		if volume > 0 {
-			fmt.Printf("Progress: Volume %d, Chapter %d\n", volume, chapter)
-		} else {
-			fmt.Printf("Progress: Chapter %d\n", chapter)
-		}
+		fmt.Printf("Progress: %s\n", formatProgress(volume, chapter))
 		fmt.Println("\nView your library:")
 		fmt.Println("  mangahub library list")
 
@@ -146,23 +142,33 @@ var progressViewCmd = &cobra.Command{
 			MangaID        string `json:"manga_id"`
 			CurrentChapter int    `json:"current_chapter"`
 			CurrentVolume  int    `json:"current_volume"`
-			LastReadAt     string `json:"last_read_at"`
+			Status         string `json:"status"`
+			UpdatedAt      string `json:"updated_at"`
 		}
 		json.Unmarshal(body, &progress)
 
 		fmt.Printf("Reading Progress for %s:\n", progressMangaID)
-		if progress.CurrentVolume > 0 {
-			fmt.Printf("  Volume: %d\n", progress.CurrentVolume)
+		fmt.Printf("  Progress: %s\n", formatProgress(progress.CurrentVolume, progress.CurrentChapter))
+		if progress.Status != "" {
+			fmt.Printf("  Status: %s\n", progress.Status)
 		}
-		fmt.Printf("  Chapter: %d\n", progress.CurrentChapter)
-		if progress.LastReadAt != "" {
-			fmt.Printf("  Last read: %s\n", progress.LastReadAt)
+		if progress.UpdatedAt != "" {
+			fmt.Printf("  Last read: %s\n", progress.UpdatedAt)
 		}
 
 		return nil
 	},
 }
 
+// formatProgress renders progress as "Vol. X, Ch. Y", leaving out the volume
+// when it isn't tracked.
+func formatProgress(volume, chapter int) string {
+	if volume > 0 {
+		return fmt.Sprintf("Vol. %d, Ch. %d", volume, chapter)
+	}
+	return fmt.Sprintf("Ch. %d", chapter)
+}
+
 func init() {
 	progressUpdateCmd.Flags().StringVar(&progressMangaID, "manga-id", "", "Manga ID")
 	progressUpdateCmd.Flags().IntVar(&chapter, "chapter", 0, "Current chapter number")
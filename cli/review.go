@@ -0,0 +1,211 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/binhbb2204/Manga-Hub-Group13/cli/config"
+	"github.com/spf13/cobra"
+)
+
+var (
+	reviewScore        int
+	reviewText         string
+	reviewSpoiler      bool
+	reviewPage         int
+	reviewLimit        int
+	reviewShowSpoilers bool
+)
+
+var reviewCmd = &cobra.Command{
+	Use:   "review",
+	Short: "Rate and review manga",
+	Long:  `Score manga from 1 to 10, write reviews, and read what other users think.`,
+}
+
+var reviewAddCmd = &cobra.Command{
+	Use:   "add [manga-id]",
+	Short: "Rate or review a manga",
+	Long: `Rate a manga from 1 to 10 with an optional written review.
+If you have already reviewed the manga, your existing review is updated.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		mangaID := args[0]
+
+		if reviewScore < 1 || reviewScore > 10 {
+			return fmt.Errorf("invalid score: %d (use a value from 1 to 10)", reviewScore)
+		}
+
+		cfg, err := config.Load()
+		if err != nil {
+			printError("Configuration not initialized")
+			fmt.Println("Run: mangahub init")
+			return err
+		}
+
+		if cfg.User.Token == "" {
+			printError("Not logged in")
+			fmt.Println("Run: mangahub auth login --username <username>")
+			return fmt.Errorf("authentication required")
+		}
+
+		serverURL, err := config.GetServerURL()
+		if err != nil {
+			return err
+		}
+
+		reqBody := map[string]interface{}{
+			"score":   reviewScore,
+			"text":    reviewText,
+			"spoiler": reviewSpoiler,
+		}
+		jsonData, _ := json.Marshal(reqBody)
+		reviewURL := fmt.Sprintf("%s/manga/%s/reviews", serverURL, mangaID)
+
+		resp, body, err := sendReview("POST", reviewURL, cfg.User.Token, jsonData)
+		if err != nil {
+			printError("Failed to save review: Server connection error")
+			return err
+		}
+		if resp.StatusCode == http.StatusConflict {
+			resp, body, err = sendReview("PUT", reviewURL, cfg.User.Token, jsonData)
+			if err != nil {
+				printError("Failed to save review: Server connection error")
+				return err
+			}
+		}
+
+		if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+			var errResp map[string]string
+			json.Unmarshal(body, &errResp)
+			printError(fmt.Sprintf("Failed to save review: %s", errResp["error"]))
+			if resp.StatusCode == http.StatusNotFound {
+				fmt.Printf("Load the manga first: mangahub manga info %s\n", mangaID)
+			}
+			return fmt.Errorf("failed to save review")
+		}
+
+		if resp.StatusCode == http.StatusCreated {
+			printSuccess("Review added!")
+		} else {
+			printSuccess("Review updated!")
+		}
+		fmt.Printf("Manga ID: %s\n", mangaID)
+		fmt.Printf("Score: %d/10\n", reviewScore)
+		if reviewSpoiler {
+			fmt.Println("Marked as spoiler: Yes")
+		}
+
+		return nil
+	},
+}
+
+var reviewListCmd = &cobra.Command{
+	Use:   "list [manga-id]",
+	Short: "List reviews for a manga",
+	Long:  `List community ratings and reviews for a manga, newest first.`,
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		mangaID := args[0]
+
+		serverURL, err := config.GetServerURL()
+		if err != nil {
+			printError("Configuration not initialized")
+			fmt.Println("Run: mangahub init")
+			return err
+		}
+
+		listURL := fmt.Sprintf("%s/manga/%s/reviews?page=%d&limit=%d", serverURL, mangaID, reviewPage, reviewLimit)
+		res, err := getWithOptionalAuth(listURL)
+		if err != nil {
+			printError("Failed to get reviews: Server connection error")
+			return err
+		}
+		defer res.Body.Close()
+
+		body, _ := io.ReadAll(res.Body)
+
+		if res.StatusCode != http.StatusOK {
+			var errResp map[string]string
+			json.Unmarshal(body, &errResp)
+			printError(fmt.Sprintf("Failed to get reviews: %s", errResp["error"]))
+			return fmt.Errorf("failed to get reviews")
+		}
+
+		var result struct {
+			Reviews []struct {
+				Username  string    `json:"username"`
+				Score     int       `json:"score"`
+				Text      string    `json:"text"`
+				Spoiler   bool      `json:"spoiler"`
+				UpdatedAt time.Time `json:"updated_at"`
+			} `json:"reviews"`
+			Page                int     `json:"page"`
+			Total               int     `json:"total"`
+			CommunityScore      float64 `json:"community_score"`
+			CommunityScoreCount int     `json:"community_score_count"`
+		}
+		json.Unmarshal(body, &result)
+
+		if result.CommunityScoreCount == 0 {
+			fmt.Println("No reviews yet")
+			fmt.Printf("\nBe the first: mangahub review add %s --score <1-10>\n", mangaID)
+			return nil
+		}
+
+		fmt.Printf("Community Score: %.2f (%d ratings)\n", result.CommunityScore, result.CommunityScoreCount)
+		fmt.Printf("Page %d\n\n", result.Page)
+
+		for _, r := range result.Reviews {
+			fmt.Printf("%s rated %d/10 on %s\n", r.Username, r.Score, r.UpdatedAt.Format("2006-01-02"))
+			switch {
+			case r.Text == "":
+			case r.Spoiler && !reviewShowSpoilers:
+				fmt.Println("  [Spoiler hidden - use --spoilers to show]")
+			default:
+				fmt.Println(wrapText(r.Text, 76))
+			}
+			fmt.Println()
+		}
+
+		if result.Page*reviewLimit < result.Total {
+			fmt.Printf("Next page: mangahub review list %s --page %d\n", mangaID, result.Page+1)
+		}
+
+		return nil
+	},
+}
+
+func sendReview(method, url, token string, payload []byte) (*http.Response, []byte, error) {
+	req, _ := http.NewRequest(method, url, bytes.NewBuffer(payload))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	return resp, body, nil
+}
+
+func init() {
+	reviewAddCmd.Flags().IntVar(&reviewScore, "score", 0, "Score from 1 to 10")
+	reviewAddCmd.Flags().StringVar(&reviewText, "text", "", "Written review (optional)")
+	reviewAddCmd.Flags().BoolVar(&reviewSpoiler, "spoiler", false, "Mark the review as containing spoilers")
+	reviewAddCmd.MarkFlagRequired("score")
+
+	reviewListCmd.Flags().IntVar(&reviewPage, "page", 1, "Page number")
+	reviewListCmd.Flags().IntVar(&reviewLimit, "limit", 20, "Reviews per page (max 100)")
+	reviewListCmd.Flags().BoolVar(&reviewShowSpoilers, "spoilers", false, "Show reviews marked as spoilers")
+
+	reviewCmd.AddCommand(reviewAddCmd)
+	reviewCmd.AddCommand(reviewListCmd)
+}
@@ -19,6 +19,9 @@ var rootCmd = &cobra.Command{
 It provides commands for authentication, manga search, library management,
 and reading progress tracking.`,
 	Version: "1.0.0",
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		refreshIfExpiring()
+	},
 }
 
 func init() {
@@ -34,6 +37,11 @@ func init() {
 	rootCmd.AddCommand(progressCmd)
 	rootCmd.AddCommand(syncCmd)
 	rootCmd.AddCommand(notifyCmd)
+	rootCmd.AddCommand(profileCmd)
+	rootCmd.AddCommand(reviewCmd)
+	rootCmd.AddCommand(listCmd)
+	rootCmd.AddCommand(statsCmd)
+	rootCmd.AddCommand(goalCmd)
 
 }
 
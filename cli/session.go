@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/models"
+	"github.com/spf13/cobra"
+)
+
+var authSessionsCmd = &cobra.Command{
+	Use:   "sessions",
+	Short: "List where you are logged in",
+	Long: `List every device logged in to your account, with the TCP sync and UDP
+notification connections each one holds open.
+
+Sign out a lost or unknown device with:
+  mangahub auth sessions revoke <session-id>`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		resp, body, err := authRequest("GET", "/users/me/sessions", nil)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode != http.StatusOK {
+			printError(fmt.Sprintf("Failed to list sessions: %s", errorMessage(body)))
+			return fmt.Errorf("failed to list sessions")
+		}
+
+		var result struct {
+			Sessions []models.LoginSession `json:"sessions"`
+		}
+		json.Unmarshal(body, &result)
+
+		fmt.Printf("Active Sessions (%d):\n\n", len(result.Sessions))
+		for _, s := range result.Sessions {
+			name := s.DeviceName
+			if name == "" {
+				name = "Unnamed device"
+			}
+			if s.Current {
+				name += " (this device)"
+			}
+			fmt.Printf("%s\n", name)
+			fmt.Printf("   ID: %s\n", s.ID)
+			if s.UserAgent != "" {
+				fmt.Printf("   Client: %s\n", s.UserAgent)
+			}
+			if s.IP != "" {
+				fmt.Printf("   IP: %s\n", s.IP)
+			}
+			fmt.Printf("   Logged in: %s\n", s.CreatedAt.Format("2006-01-02 15:04"))
+			fmt.Printf("   Last active: %s\n", s.LastSeenAt.Format("2006-01-02 15:04"))
+			if len(s.Connections) == 0 {
+				fmt.Println("   Connections: none")
+			} else {
+				fmt.Println("   Connections:")
+				for _, c := range s.Connections {
+					fmt.Printf("     %s %s", c.Transport, c.RemoteAddr)
+					if c.DeviceName != "" {
+						fmt.Printf(" %s (%s)", c.DeviceName, c.DeviceType)
+					}
+					fmt.Printf(", since %s\n", c.ConnectedAt.Format("2006-01-02 15:04"))
+				}
+			}
+			fmt.Println()
+		}
+		return nil
+	},
+}
+
+var authSessionsRevokeCmd = &cobra.Command{
+	Use:   "revoke <session-id>",
+	Short: "Sign a device out",
+	Long: `Sign a session out. Its tokens stop working straight away and its TCP and
+UDP connections are closed. Revoking this device's own session logs the CLI out.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		resp, body, err := authRequest("DELETE", "/users/me/sessions/"+args[0], nil)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode != http.StatusOK {
+			printError(fmt.Sprintf("Failed to revoke session: %s", errorMessage(body)))
+			return fmt.Errorf("failed to revoke session")
+		}
+		printSuccess("Session revoked")
+		return nil
+	},
+}
+
+func init() {
+	authSessionsCmd.AddCommand(authSessionsRevokeCmd)
+	authCmd.AddCommand(authSessionsCmd)
+}
@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/models"
+)
+
+var sso bool
+
+// ssoLogin signs in with the device flow: the server hands out a short code,
+// the user signs in at the identity provider in any browser, and we poll
+// until the server has a session for us.
+func ssoLogin() error {
+	resp, body, err := sendWithToken("POST", "/auth/oidc/device", "", nil)
+	if err != nil {
+		printError("Login failed: Server connection error")
+		fmt.Println("Check server status: mangahub server status")
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		printError(fmt.Sprintf("Login failed: %s", errorMessage(body)))
+		return fmt.Errorf("login failed")
+	}
+	var device models.DeviceAuthorization
+	json.Unmarshal(body, &device)
+
+	fmt.Println("Open this URL in your browser and sign in:")
+	fmt.Printf("\n  %s\n\n", device.VerificationURIComplete)
+	fmt.Printf("Confirm on that page only if it shows your code: %s\n", device.UserCode)
+	fmt.Println("Waiting for sign-in...")
+
+	interval := time.Duration(device.Interval) * time.Second
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	deadline := time.Now().Add(time.Duration(device.ExpiresIn) * time.Second)
+	for time.Now().Before(deadline) {
+		time.Sleep(interval)
+		resp, body, err := sendWithToken("POST", "/auth/oidc/device/token", "", models.DeviceTokenRequest{DeviceCode: device.DeviceCode})
+		if err != nil {
+			printError("Login failed: Server connection error")
+			return err
+		}
+		if resp.StatusCode == http.StatusAccepted {
+			var challenge models.TwoFactorChallenge
+			json.Unmarshal(body, &challenge)
+			if !challenge.TwoFactorRequired {
+				continue
+			}
+			//Accounts with two-factor authentication need a code as well
+			if resp, body, err = completeTwoFactorLogin(body); err != nil {
+				return err
+			}
+		}
+		switch resp.StatusCode {
+		case http.StatusOK:
+			return saveLogin(body)
+		default:
+			printError(fmt.Sprintf("Login failed: %s", errorMessage(body)))
+			return fmt.Errorf("login failed")
+		}
+	}
+
+	printError("Login timed out")
+	fmt.Println("Run: mangahub auth login --sso")
+	return fmt.Errorf("login timed out")
+}
@@ -0,0 +1,74 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/models"
+	"github.com/spf13/cobra"
+)
+
+var statsRange string
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show your reading statistics",
+	Long: `Show how much you read: chapters per day, week and month, reading streaks,
+your top genres and authors, and how long series take you to finish.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		resp, body, err := authRequest("GET", "/users/stats?range="+url.QueryEscape(statsRange), nil)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode != http.StatusOK {
+			printError(fmt.Sprintf("Failed to get stats: %s", errorMessage(body)))
+			return fmt.Errorf("failed to get stats")
+		}
+
+		var stats models.ReadingStats
+		json.Unmarshal(body, &stats)
+
+		if stats.From != "" {
+			fmt.Printf("Reading Stats (%s to %s):\n\n", stats.From, stats.To)
+		} else {
+			fmt.Println("Reading Stats:")
+			fmt.Println()
+		}
+
+		read := stats.ChaptersRead
+		fmt.Printf("Chapters read: %d\n", read.Total)
+		fmt.Printf("   %.1f per day, %.1f per week, %.1f per month\n", read.PerDay, read.PerWeek, read.PerMonth)
+		fmt.Printf("Current streak: %d days (longest: %d)\n", stats.CurrentStreak, stats.LongestStreak)
+		if stats.CompletedSeries > 0 {
+			fmt.Printf("Completed: %d series, %.1f days on average\n", stats.CompletedSeries, stats.AverageCompletionDays)
+		}
+
+		fmt.Println("\nLibrary:")
+		for _, status := range models.ReadingStatuses {
+			fmt.Printf("   %-13s %d\n", status, stats.StatusDistribution[string(status)])
+		}
+
+		printBreakdown("Top genres", stats.Genres)
+		printBreakdown("Top authors", stats.Authors)
+		return nil
+	},
+}
+
+func printBreakdown(heading string, groups []models.StatsBreakdown) {
+	if len(groups) == 0 {
+		return
+	}
+	fmt.Printf("\n%s:\n", heading)
+	for i, g := range groups {
+		if i == 5 {
+			break
+		}
+		fmt.Printf("   %s: %d chapters across %d series\n", g.Name, g.Chapters, g.Series)
+	}
+}
+
+func init() {
+	statsCmd.Flags().StringVar(&statsRange, "range", models.StatsRangeAll, "Time range (7d, 30d, 365d, all)")
+}
@@ -4,10 +4,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"net"
-	"os"
 	"testing"
 	"time"
 
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/auth"
 	"github.com/binhbb2204/Manga-Hub-Group13/internal/udp"
 	"github.com/binhbb2204/Manga-Hub-Group13/pkg/utils"
 )
@@ -26,12 +26,22 @@ func startTestUDPServer(t *testing.T, port int) (*udp.Server, func()) {
 	return server, cleanup
 }
 
-func generateTestToken(t *testing.T) string {
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		jwtSecret = "your-secret-key-change-this-in-production"
+// testKeys sign the tokens used in these tests
+var testKeys = auth.KeysFromSecret("test-secret")
+
+// signToken issues an access token and makes testKeys the keys the servers
+// under test verify with
+func signToken(userID, username, role string) (string, error) {
+	auth.SetDefaultKeys(testKeys)
+	claims, err := utils.NewAccessClaims(userID, username, role)
+	if err != nil {
+		return "", err
 	}
-	token, err := utils.GenerateJWT("test-user-123", "testuser", jwtSecret)
+	return testKeys.Sign(claims)
+}
+
+func generateTestToken(t *testing.T) string {
+	token, err := signToken("test-user-123", "testuser", "")
 	if err != nil {
 		t.Fatalf("Failed to generate JWT: %v", err)
 	}
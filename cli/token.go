@@ -0,0 +1,146 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/models"
+	"github.com/spf13/cobra"
+)
+
+var (
+	tokenScopes      []string
+	tokenExpiresDays int
+)
+
+var authTokenCmd = &cobra.Command{
+	Use:   "token",
+	Short: "Manage personal access tokens",
+	Long: `Personal access tokens let scripts use the API without your password.
+Each token only reaches what its scopes allow and can be revoked at any time.
+Send one as "Authorization: Bearer <token>" or in the TCP/UDP auth message.`,
+}
+
+var authTokenCreateCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "Create a personal access token",
+	Long: fmt.Sprintf(`Create a named personal access token with the given scopes.
+
+Available scopes: %s
+A write scope also allows reading the same data.`, joinScopes(models.Scopes)),
+	Example: `  mangahub auth token create backup-script --scope library:read
+  mangahub auth token create tracker --scope progress:write --scope library:write --expires-days 90`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(tokenScopes) == 0 {
+			return fmt.Errorf("at least one scope is required (--scope)")
+		}
+		req := models.CreateTokenRequest{Name: args[0], ExpiresInDays: tokenExpiresDays}
+		for _, s := range tokenScopes {
+			req.Scopes = append(req.Scopes, models.Scope(s))
+		}
+
+		resp, body, err := authRequest("POST", "/users/me/tokens", req)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode != http.StatusCreated {
+			printError(fmt.Sprintf("Failed to create token: %s", errorMessage(body)))
+			return fmt.Errorf("failed to create token")
+		}
+
+		var created models.CreateTokenResponse
+		json.Unmarshal(body, &created)
+		printSuccess(fmt.Sprintf("Token %q created", created.Name))
+		fmt.Printf("\n  %s\n\n", created.Token)
+		fmt.Println("Copy it now, it won't be shown again.")
+		fmt.Printf("Scopes: %s\n", joinScopes(created.Scopes))
+		if created.ExpiresAt != nil {
+			fmt.Printf("Expires: %s\n", created.ExpiresAt.Format("2006-01-02"))
+		}
+		return nil
+	},
+}
+
+var authTokenListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List your personal access tokens",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		resp, body, err := authRequest("GET", "/users/me/tokens", nil)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode != http.StatusOK {
+			printError(fmt.Sprintf("Failed to list tokens: %s", errorMessage(body)))
+			return fmt.Errorf("failed to list tokens")
+		}
+
+		var result struct {
+			Tokens []models.PersonalAccessToken `json:"tokens"`
+		}
+		json.Unmarshal(body, &result)
+
+		if len(result.Tokens) == 0 {
+			fmt.Println("You have no personal access tokens")
+			fmt.Println("\nCreate one:")
+			fmt.Println("  mangahub auth token create my-script --scope library:read")
+			return nil
+		}
+
+		fmt.Printf("Personal Access Tokens (%d):\n\n", len(result.Tokens))
+		for _, t := range result.Tokens {
+			fmt.Printf("%s\n", t.Name)
+			fmt.Printf("   ID: %s\n", t.ID)
+			fmt.Printf("   Scopes: %s\n", joinScopes(t.Scopes))
+			fmt.Printf("   Created: %s\n", t.CreatedAt.Format("2006-01-02"))
+			if t.ExpiresAt != nil {
+				fmt.Printf("   Expires: %s\n", t.ExpiresAt.Format("2006-01-02"))
+			}
+			if t.LastUsedAt != nil {
+				fmt.Printf("   Last used: %s\n", t.LastUsedAt.Format("2006-01-02 15:04"))
+			} else {
+				fmt.Println("   Last used: never")
+			}
+			fmt.Println()
+		}
+		return nil
+	},
+}
+
+var authTokenRevokeCmd = &cobra.Command{
+	Use:   "revoke <token-id>",
+	Short: "Revoke a personal access token",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		resp, body, err := authRequest("DELETE", "/users/me/tokens/"+args[0], nil)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode != http.StatusOK {
+			printError(fmt.Sprintf("Failed to revoke token: %s", errorMessage(body)))
+			return fmt.Errorf("failed to revoke token")
+		}
+		printSuccess("Token revoked")
+		return nil
+	},
+}
+
+func joinScopes(scopes []models.Scope) string {
+	names := make([]string, len(scopes))
+	for i, s := range scopes {
+		names[i] = string(s)
+	}
+	return strings.Join(names, ", ")
+}
+
+func init() {
+	authTokenCreateCmd.Flags().StringSliceVar(&tokenScopes, "scope", nil, "Scope to grant (repeatable)")
+	authTokenCreateCmd.Flags().IntVar(&tokenExpiresDays, "expires-days", 0, "Expire the token after this many days (default never)")
+
+	authTokenCmd.AddCommand(authTokenCreateCmd)
+	authTokenCmd.AddCommand(authTokenListCmd)
+	authTokenCmd.AddCommand(authTokenRevokeCmd)
+	authCmd.AddCommand(authTokenCmd)
+}
@@ -0,0 +1,146 @@
+package cli
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"syscall"
+
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/models"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var authTwoFactorCmd = &cobra.Command{
+	Use:   "2fa",
+	Short: "Manage two-factor authentication",
+	Long: `Two-factor authentication asks for a code from an authenticator app
+(Google Authenticator, Aegis, 1Password, ...) every time you log in.`,
+}
+
+var authTwoFactorEnableCmd = &cobra.Command{
+	Use:   "enable",
+	Short: "Turn on two-factor authentication",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fmt.Print("Password: ")
+		passwordBytes, err := term.ReadPassword(int(syscall.Stdin))
+		fmt.Println()
+		if err != nil {
+			return fmt.Errorf("failed to read password: %w", err)
+		}
+
+		resp, body, err := authRequest("POST", "/auth/2fa/setup", models.TwoFactorSetupRequest{Password: string(passwordBytes)})
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode != http.StatusOK {
+			printError(fmt.Sprintf("Two-factor setup failed: %s", errorMessage(body)))
+			return fmt.Errorf("two-factor setup failed")
+		}
+		var setup models.TwoFactorSetupResponse
+		json.Unmarshal(body, &setup)
+
+		fmt.Println("\nAdd this account to your authenticator app, either by opening the link")
+		fmt.Println("or by entering the secret by hand:")
+		fmt.Printf("\n  %s\n\n", setup.OTPAuthURI)
+		fmt.Printf("  Secret: %s\n\n", setup.Secret)
+
+		code, err := promptLine("Code from the app: ")
+		if err != nil {
+			return err
+		}
+		resp, body, err = authRequest("POST", "/auth/2fa/enable", models.TwoFactorCodeRequest{Code: code})
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode != http.StatusOK {
+			printError(fmt.Sprintf("Could not enable two-factor authentication: %s", errorMessage(body)))
+			return fmt.Errorf("enable two-factor failed")
+		}
+		var enabled models.TwoFactorEnableResponse
+		json.Unmarshal(body, &enabled)
+
+		printSuccess("Two-factor authentication enabled!")
+		fmt.Println("\nRecovery codes (each works once if you lose your phone). Store them")
+		fmt.Println("somewhere safe, they won't be shown again:")
+		fmt.Println()
+		for _, c := range enabled.RecoveryCodes {
+			fmt.Printf("  %s\n", c)
+		}
+		return nil
+	},
+}
+
+var authTwoFactorDisableCmd = &cobra.Command{
+	Use:   "disable",
+	Short: "Turn off two-factor authentication",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fmt.Print("Password: ")
+		passwordBytes, err := term.ReadPassword(int(syscall.Stdin))
+		fmt.Println()
+		if err != nil {
+			return fmt.Errorf("failed to read password: %w", err)
+		}
+		code, err := promptLine("Code from the app (or a recovery code): ")
+		if err != nil {
+			return err
+		}
+
+		resp, body, err := authRequest("POST", "/auth/2fa/disable", models.TwoFactorDisableRequest{
+			Password: string(passwordBytes),
+			Code:     code,
+		})
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode != http.StatusOK {
+			printError(fmt.Sprintf("Could not disable two-factor authentication: %s", errorMessage(body)))
+			return fmt.Errorf("disable two-factor failed")
+		}
+		printSuccess("Two-factor authentication disabled")
+		return nil
+	},
+}
+
+// completeTwoFactorLogin asks for a code to answer the challenge Login sent
+// back and returns the server's reply to it
+func completeTwoFactorLogin(body []byte) (*http.Response, []byte, error) {
+	var challenge models.TwoFactorChallenge
+	if err := json.Unmarshal(body, &challenge); err != nil || challenge.MFAToken == "" {
+		printError("Login failed: unexpected response from server")
+		return nil, nil, fmt.Errorf("login failed")
+	}
+
+	code, err := promptLine("Two-factor code (or a recovery code): ")
+	if err != nil {
+		return nil, nil, err
+	}
+	resp, body, err := sendWithToken("POST", "/auth/login/2fa", "", models.TwoFactorLoginRequest{
+		MFAToken: challenge.MFAToken,
+		Code:     code,
+	})
+	if err != nil {
+		printError("Login failed: Server connection error")
+		fmt.Println("Check server status: mangahub server status")
+		return nil, nil, err
+	}
+	return resp, body, nil
+}
+
+func promptLine(prompt string) (string, error) {
+	fmt.Print(prompt)
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("failed to read input: %w", err)
+	}
+	return strings.TrimSpace(line), nil
+}
+
+func init() {
+	authTwoFactorCmd.AddCommand(authTwoFactorEnableCmd)
+	authTwoFactorCmd.AddCommand(authTwoFactorDisableCmd)
+	authCmd.AddCommand(authTwoFactorCmd)
+}
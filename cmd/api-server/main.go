@@ -3,10 +3,18 @@ package main
 import (
 	"os"
 
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/achievement"
 	"github.com/binhbb2204/Manga-Hub-Group13/internal/auth"
 	"github.com/binhbb2204/Manga-Hub-Group13/internal/bridge"
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/comment"
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/goal"
 	"github.com/binhbb2204/Manga-Hub-Group13/internal/health"
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/list"
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/malsync"
 	"github.com/binhbb2204/Manga-Hub-Group13/internal/manga"
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/oidc"
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/review"
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/stats"
 	"github.com/binhbb2204/Manga-Hub-Group13/internal/user"
 	"github.com/binhbb2204/Manga-Hub-Group13/pkg/database"
 	"github.com/binhbb2204/Manga-Hub-Group13/pkg/logger"
@@ -40,28 +48,57 @@ func main() {
 	}
 	defer database.Close()
 
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		jwtSecret = "your-secret-key-change-this-in-production"
-		log.Warn("using_default_jwt_secret", "message", "Set JWT_SECRET environment variable in production!")
+	keys, err := auth.KeysFromEnv()
+	if err != nil {
+		log.Error("failed_to_load_signing_keys", "error", err.Error())
+		os.Exit(1)
 	}
+	auth.SetDefaultKeys(keys)
+	log.Info("signing_keys_loaded", "active_kid", keys.ActiveKeyID())
 
 	frontendURL := os.Getenv("FRONTEND_URL")
 	if frontendURL == "" {
 		frontendURL = "http://localhost:3000"
 		log.Info("using_default_frontend_url", "url", frontendURL)
 	}
+	if os.Getenv("PUBLIC_API_URL") == "" {
+		log.Warn("public_api_url_not_set", "effect", "verification emails and SSO device login are disabled")
+	}
 
 	apiBridge := bridge.NewBridge(logger.GetLogger())
 	apiBridge.Start()
 	defer apiBridge.Stop()
 	log.Info("tcp_http_bridge_started")
+	achievement.NewEngine(apiBridge)
+
+	var malSyncer *malsync.Syncer
+	if malConfig, ok := malsync.ConfigFromEnv(); ok {
+		malSyncer = malsync.NewSyncer(malsync.NewClient(malConfig), apiBridge)
+		malWorker := malsync.NewWorker(malSyncer, malsync.SyncIntervalFromEnv())
+		malWorker.Start()
+		defer malWorker.Stop()
+	} else {
+		log.Info("mal_sync_disabled", "message", "Set MAL_CLIENT_ID and MAL_CLIENT_SECRET to enable MyAnimeList linking")
+	}
 
-	authHandler := auth.NewHandler(jwtSecret)
+	authHandler := auth.NewHandler(keys)
+	if oidcConfig, ok := oidc.ConfigFromEnv(); ok {
+		authHandler.SetOIDC(oidc.NewProvider(oidcConfig))
+		log.Info("sso_enabled", "issuer", oidcConfig.Issuer)
+	} else {
+		log.Info("sso_disabled", "message", "Set OIDC_ISSUER and OIDC_CLIENT_ID to enable single sign-on")
+	}
 	mangaHandler := manga.NewHandler()
+	reviewHandler := review.NewHandler()
+	commentHandler := comment.NewHandler(apiBridge)
+	listHandler := list.NewHandler()
+	statsHandler := stats.NewHandler()
+	goalHandler := goal.NewHandler(apiBridge)
+	achievementHandler := achievement.NewHandler()
 	userHandler := user.NewHandler(apiBridge)
 	healthHandler := health.NewHandler(apiBridge)
 	metricsHandler := metrics.NewHandler()
+	malHandler := malsync.NewHandler(malSyncer)
 
 	router := gin.Default()
 
@@ -79,20 +116,38 @@ func main() {
 	router.GET("/healthz", healthHandler.Healthz)
 	router.GET("/readyz", healthHandler.Readyz)
 	router.GET("/metrics", metricsHandler.Metrics)
+	router.GET("/.well-known/jwks.json", authHandler.JWKS)
 
 	authGroup := router.Group("/auth")
 	{
 		authGroup.POST("/register", authHandler.Register)
 		authGroup.POST("/login", authHandler.Login)
+		authGroup.POST("/login/2fa", authHandler.LoginTwoFactor)
+		authGroup.POST("/refresh", authHandler.Refresh)
+		authGroup.POST("/forgot-password", authHandler.ForgotPassword)
+		authGroup.POST("/reset-password", authHandler.ResetPassword)
+		authGroup.GET("/verify", authHandler.VerifyEmail)
+		authGroup.GET("/mal/callback", malHandler.Callback)
+		authGroup.GET("/oidc/login", authHandler.OIDCLogin)
+		authGroup.POST("/oidc/login", authHandler.OIDCConfirmDevice)
+		authGroup.GET("/oidc/callback", authHandler.OIDCCallback)
+		authGroup.POST("/oidc/device", authHandler.OIDCDeviceStart)
+		authGroup.POST("/oidc/device/token", authHandler.OIDCDeviceToken)
 	}
 
 	protectedAuth := router.Group("/auth")
-	protectedAuth.Use(auth.AuthMiddleware(jwtSecret))
+	protectedAuth.Use(auth.AuthMiddleware(keys))
 	{
 		protectedAuth.POST("/change-password", authHandler.ChangePassword)
+		protectedAuth.POST("/logout", authHandler.Logout)
+		protectedAuth.POST("/verify/resend", authHandler.ResendVerification)
+		protectedAuth.POST("/2fa/setup", authHandler.SetupTwoFactor)
+		protectedAuth.POST("/2fa/enable", authHandler.EnableTwoFactor)
+		protectedAuth.POST("/2fa/disable", authHandler.DisableTwoFactor)
 	}
 
 	mangaGroup := router.Group("/manga")
+	mangaGroup.Use(auth.OptionalAuthMiddleware(keys))
 	{
 		mangaGroup.GET("", mangaHandler.SearchManga)
 		mangaGroup.GET("/all", mangaHandler.GetAllManga)
@@ -101,25 +156,76 @@ func main() {
 		mangaGroup.GET("/:id", mangaHandler.GetMangaByID)
 		mangaGroup.GET("/featured", mangaHandler.GetFeaturedManga)
 		mangaGroup.GET("/ranking", mangaHandler.GetRanking)
+		mangaGroup.GET("/:id/reviews", reviewHandler.ListReviews)
+		mangaGroup.GET("/:id/chapters/:n/comments", commentHandler.ListComments)
 		// Protected routes
 		protected := mangaGroup.Group("")
-		protected.Use(auth.AuthMiddleware(jwtSecret))
+		protected.Use(auth.AuthMiddleware(keys))
 		{
 			protected.POST("", mangaHandler.CreateManga)
+			protected.POST("/:id/reviews", reviewHandler.CreateReview)
+			protected.PUT("/:id/reviews", reviewHandler.UpdateReview)
+			protected.DELETE("/:id/reviews", reviewHandler.DeleteReview)
+			protected.POST("/:id/chapters/:n/comments", commentHandler.CreateComment)
+			protected.DELETE("/:id/chapters/:n/comments/:comment_id", commentHandler.DeleteComment)
 		}
 	}
 
 	// User routes (all protected)
 	userGroup := router.Group("/users")
-	userGroup.Use(auth.AuthMiddleware(jwtSecret))
+	userGroup.Use(auth.AuthMiddleware(keys))
 	{
-		userGroup.GET("/me", userHandler.GetProfile)                          // Get current user profile
-		userGroup.POST("/library", userHandler.AddToLibrary)                  // Add manga to library
-		userGroup.GET("/library", userHandler.GetLibrary)                     // Get user's library
-		userGroup.PUT("/progress", userHandler.UpdateProgress)                // Update reading progress
-		userGroup.DELETE("/library/:manga_id", userHandler.RemoveFromLibrary) // Remove from library
+		userGroup.GET("/me", userHandler.GetProfile)                              // Get current user profile
+		userGroup.PUT("/me/preferences", userHandler.UpdatePreferences)           // Update display preferences
+		userGroup.PUT("/me/email", authHandler.ChangeEmail)                       // Change email once the new address is confirmed
+		userGroup.GET("/me/tokens", authHandler.ListTokens)                       // List personal access tokens
+		userGroup.POST("/me/tokens", authHandler.CreateToken)                     // Create a scoped personal access token
+		userGroup.DELETE("/me/tokens/:token_id", authHandler.RevokePersonalToken) // Revoke a personal access token
+		userGroup.GET("/me/sessions", authHandler.ListSessions)                   // List devices logged in and their live connections
+		userGroup.DELETE("/me/sessions/:session_id", authHandler.RevokeSession)   // Sign a session out and drop its connections
+		userGroup.GET("/me/achievements", achievementHandler.GetAchievements)     // List badges and what's left to unlock
+		userGroup.GET("/me/mal", malHandler.Status)                               // MyAnimeList link and sync status
+		userGroup.POST("/me/mal/link", malHandler.Link)                           // Start linking a MyAnimeList account
+		userGroup.POST("/me/mal/sync", malHandler.SyncNow)                        // Sync with MyAnimeList now
+		userGroup.DELETE("/me/mal", malHandler.Unlink)                            // Unlink MyAnimeList
+		userGroup.POST("/library", userHandler.AddToLibrary)                      // Add manga to library
+		userGroup.GET("/library", userHandler.GetLibrary)                         // Get user's library
+		userGroup.POST("/library/import", userHandler.ImportLibrary)              // Import a MAL, AniList or CSV export
+		userGroup.GET("/library/export", userHandler.ExportLibrary)               // Download the library as MAL XML, CSV or JSON
+		userGroup.PUT("/progress", userHandler.UpdateProgress)                    // Update reading progress
+		userGroup.GET("/progress/:manga_id", userHandler.GetProgress)             // Get progress on one manga
+		userGroup.DELETE("/library/:manga_id", userHandler.RemoveFromLibrary)     // Remove from library
+		userGroup.POST("/library/:manga_id/tags", userHandler.AddTags)            // Tag a library entry
+		userGroup.DELETE("/library/:manga_id/tags", userHandler.RemoveTags)       // Untag a library entry
+		userGroup.PUT("/library/:manga_id/favorite", userHandler.SetFavorite)     // Mark or unmark as favorite
+		userGroup.GET("/stats", statsHandler.GetStats)                            // Reading statistics
+		userGroup.GET("/goals", goalHandler.GetGoals)                             // Get reading goals and pace
+		userGroup.POST("/goals", goalHandler.CreateGoal)                          // Set a reading goal
+
+		userGroup.GET("/lists", listHandler.GetLists)                               // Get user's custom lists
+		userGroup.POST("/lists", listHandler.CreateList)                            // Create a list
+		userGroup.GET("/lists/:list_id", listHandler.GetList)                       // Get a list with its items
+		userGroup.PUT("/lists/:list_id", listHandler.UpdateList)                    // Rename or change visibility
+		userGroup.DELETE("/lists/:list_id", listHandler.DeleteList)                 // Delete a list
+		userGroup.POST("/lists/:list_id/items", listHandler.AddItem)                // Add manga to a list
+		userGroup.PUT("/lists/:list_id/items/:manga_id", listHandler.MoveItem)      // Move manga to a new position
+		userGroup.DELETE("/lists/:list_id/items/:manga_id", listHandler.RemoveItem) // Remove manga from a list
+		userGroup.POST("/lists/:list_id/share", listHandler.ShareList)              // Make a list public
+		userGroup.DELETE("/lists/:list_id/share", listHandler.UnshareList)          // Make a list private
 	}
 
+	// Admin routes
+	adminGroup := router.Group("/admin")
+	adminGroup.Use(auth.AuthMiddleware(keys), auth.RequirePermission(auth.PermManageRoles))
+	{
+		adminGroup.PUT("/users/:username/role", authHandler.SetRole)
+		adminGroup.DELETE("/users/:username/lockout", authHandler.UnlockAccount)
+		adminGroup.GET("/auth-events", authHandler.GetAuthEvents)
+	}
+
+	// Public shared lists
+	router.GET("/lists/:share_id", listHandler.GetSharedList)
+
 	//Get port from environment or use default
 	port := os.Getenv("API_PORT")
 	if port == "" {
@@ -0,0 +1,58 @@
+// Command bootstrap-admin makes the first admin account. Later role changes
+// go through PUT /admin/users/:username/role.
+//
+//	go run ./cmd/bootstrap-admin --username alice
+//	go run ./cmd/bootstrap-admin --username alice --email alice@example.com --password 'S3cret...'
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/auth"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/database"
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	_ = godotenv.Load()
+
+	username := flag.String("username", "", "account to make admin (created if it doesn't exist)")
+	email := flag.String("email", "", "email for a new account")
+	password := flag.String("password", "", "password for a new account")
+	flag.Parse()
+
+	if *username == "" {
+		fmt.Fprintln(os.Stderr, "Error: --username is required")
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	dbPath := os.Getenv("DB_PATH")
+	if dbPath == "" {
+		dbPath = "./data/mangahub.db"
+	}
+	if err := database.InitDatabase(dbPath); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	defer database.Close()
+
+	created, err := auth.BootstrapAdmin(*username, *email, *password)
+	if errors.Is(err, auth.ErrAdminExists) {
+		fmt.Fprintln(os.Stderr, "Error: an admin already exists; ask them to grant roles with PUT /admin/users/:username/role")
+		os.Exit(1)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if created {
+		fmt.Printf("Created admin account %s\n", *username)
+	} else {
+		fmt.Printf("Promoted %s to admin\n", *username)
+	}
+}
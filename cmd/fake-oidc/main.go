@@ -0,0 +1,58 @@
+// Command fake-oidc runs a fake OpenID provider for trying SSO locally. It
+// signs everyone in as the user given by the flags without asking.
+//
+//	go run ./cmd/fake-oidc --email alice@example.com
+//
+// then start the API server with
+//
+//	OIDC_ISSUER=http://localhost:9400 OIDC_CLIENT_ID=mangahub-test OIDC_CLIENT_SECRET=mangahub-test-secret
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/oidc/oidctest"
+)
+
+func main() {
+	addr := flag.String("addr", "localhost:9400", "address to listen on")
+	email := flag.String("email", "dev@example.com", "email of the signed-in user")
+	username := flag.String("username", "", "preferred_username claim (defaults to the email's local part)")
+	subject := flag.String("subject", "", "sub claim (defaults to the email)")
+	unverified := flag.Bool("unverified", false, "send email_verified=false")
+	flag.Parse()
+
+	user := oidctest.User{
+		Subject:           *subject,
+		Email:             *email,
+		EmailVerified:     !*unverified,
+		PreferredUsername: *username,
+	}
+	if user.Subject == "" {
+		user.Subject = *email
+	}
+	if user.PreferredUsername == "" {
+		user.PreferredUsername = strings.SplitN(*email, "@", 2)[0]
+	}
+
+	p, err := oidctest.NewServerAt(*addr, user)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	defer p.Close()
+
+	fmt.Printf("Fake OIDC provider at %s\n", p.Issuer())
+	fmt.Printf("  client id:     %s\n", oidctest.ClientID)
+	fmt.Printf("  client secret: %s\n", oidctest.ClientSecret)
+	fmt.Printf("  signs in as:   %s (%s)\n", user.Email, user.Subject)
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+}
@@ -0,0 +1,65 @@
+// Command jwt-keygen writes a new token signing key into JWT_KEYS_DIR. Keys
+// are named by date, so the new one becomes the active key when the servers
+// restart while the old ones keep verifying tokens they already signed.
+//
+//	go run ./cmd/jwt-keygen --dir ./data/jwt-keys
+//	go run ./cmd/jwt-keygen --dir ./data/jwt-keys --alg RS256
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/auth"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/utils"
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	_ = godotenv.Load()
+
+	dir := flag.String("dir", os.Getenv("JWT_KEYS_DIR"), "directory to write the key to (default $JWT_KEYS_DIR)")
+	alg := flag.String("alg", "EdDSA", "signing algorithm, EdDSA or RS256")
+	flag.Parse()
+
+	if *dir == "" {
+		fmt.Fprintln(os.Stderr, "Error: --dir or JWT_KEYS_DIR is required")
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	pemData, err := auth.GenerateKeyPEM(*alg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if err := os.MkdirAll(*dir, 0700); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	kid := time.Now().UTC().Format("20060102T150405Z") + "-" + strings.ToLower(*alg)
+	path := filepath.Join(*dir, kid+".pem")
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if _, err := f.Write(pemData); err != nil {
+		f.Close()
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if err := f.Close(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Wrote %s (kid %s)\n", path, kid)
+	fmt.Println("Restart the TCP and UDP servers, then the API server, to start signing with")
+	fmt.Printf("it. Delete an old key once %s has passed since the restart.\n", utils.AccessTokenTTL)
+}
@@ -5,6 +5,8 @@ import (
 	"os/signal"
 	"syscall"
 
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/achievement"
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/auth"
 	"github.com/binhbb2204/Manga-Hub-Group13/internal/bridge"
 	"github.com/binhbb2204/Manga-Hub-Group13/internal/tcp"
 	"github.com/binhbb2204/Manga-Hub-Group13/pkg/database"
@@ -36,6 +38,13 @@ func main() {
 	}
 	defer database.Close()
 
+	keys, err := auth.KeysFromEnv()
+	if err != nil {
+		log.Error("failed_to_load_signing_keys", "error", err.Error())
+		os.Exit(1)
+	}
+	auth.SetDefaultKeys(keys)
+
 	port := os.Getenv("TCP_PORT")
 	if port == "" {
 		port = "9090"
@@ -44,6 +53,7 @@ func main() {
 	tcpBridge := bridge.NewBridge(logger.WithContext("component", "bridge"))
 	tcpBridge.Start()
 	defer tcpBridge.Stop()
+	achievement.NewEngine(tcpBridge)
 
 	server := tcp.NewServer(port, tcpBridge)
 	if err := server.Start(); err != nil {
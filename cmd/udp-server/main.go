@@ -5,8 +5,10 @@ import (
 	"os/signal"
 	"syscall"
 
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/auth"
 	"github.com/binhbb2204/Manga-Hub-Group13/internal/bridge"
 	"github.com/binhbb2204/Manga-Hub-Group13/internal/udp"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/database"
 	"github.com/binhbb2204/Manga-Hub-Group13/pkg/logger"
 	"github.com/joho/godotenv"
 )
@@ -24,6 +26,25 @@ func main() {
 	log := logger.GetLogger().WithContext("component", "udp_main")
 	log.Info("starting_udp_server", "version", "1.0.0")
 
+	// The database is only read to check the token denylist on register
+	dbPath := os.Getenv("DB_PATH")
+	if dbPath == "" {
+		dbPath = "./data/mangahub.db"
+	}
+
+	if err := database.InitDatabase(dbPath); err != nil {
+		log.Error("failed_to_initialize_database", "error", err.Error(), "path", dbPath)
+		os.Exit(1)
+	}
+	defer database.Close()
+
+	keys, err := auth.KeysFromEnv()
+	if err != nil {
+		log.Error("failed_to_load_signing_keys", "error", err.Error())
+		os.Exit(1)
+	}
+	auth.SetDefaultKeys(keys)
+
 	port := os.Getenv("UDP_PORT")
 	if port == "" {
 		port = "9091"
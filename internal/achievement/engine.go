@@ -0,0 +1,100 @@
+package achievement
+
+import (
+	"time"
+
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/bridge"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/database"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/logger"
+)
+
+// Engine checks achievement rules whenever a progress or library event for a
+// user passes through the bridge, and announces new unlocks on the same bridge.
+type Engine struct {
+	bridge *bridge.Bridge
+	log    *logger.Logger
+}
+
+// NewEngine creates an engine and starts listening to the bridge's events
+func NewEngine(br *bridge.Bridge) *Engine {
+	e := &Engine{
+		bridge: br,
+		log:    logger.WithContext("component", "achievements"),
+	}
+	br.OnEvent(e.handleEvent)
+	return e
+}
+
+func (e *Engine) handleEvent(event bridge.Event) {
+	if event.Type != bridge.EventTypeProgressUpdate && event.Type != bridge.EventTypeLibraryUpdate {
+		return
+	}
+	if _, err := e.Evaluate(event.UserID); err != nil {
+		e.log.Warn("achievement_evaluation_failed", "user_id", event.UserID, "error", err.Error())
+	}
+}
+
+// Evaluate unlocks every achievement whose rule the user now meets and
+// returns the IDs of the ones unlocked by this call.
+func (e *Engine) Evaluate(userID string) ([]string, error) {
+	unlocked, err := unlockedAt(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var newlyUnlocked []string
+	for _, rule := range Rules {
+		if _, ok := unlocked[rule.ID]; ok {
+			continue
+		}
+
+		value, err := rule.Metric(userID, now)
+		if err != nil {
+			return newlyUnlocked, err
+		}
+		if value < rule.Threshold {
+			continue
+		}
+
+		// Events can be evaluated concurrently; only the insert that wins announces it
+		result, err := database.DB.Exec(`INSERT OR IGNORE INTO achievements (user_id, achievement_id, unlocked_at) VALUES (?, ?, ?)`,
+			userID, rule.ID, now)
+		if err != nil {
+			return newlyUnlocked, err
+		}
+		if n, _ := result.RowsAffected(); n == 0 {
+			continue
+		}
+
+		newlyUnlocked = append(newlyUnlocked, rule.ID)
+		e.log.Info("achievement_unlocked", "user_id", userID, "achievement_id", rule.ID)
+		e.bridge.NotifyAchievementUnlocked(bridge.AchievementUnlockedEvent{
+			UserID:        userID,
+			AchievementID: rule.ID,
+			Name:          rule.Name,
+			Description:   rule.Description,
+			UnlockedAt:    now,
+		})
+	}
+	return newlyUnlocked, nil
+}
+
+func unlockedAt(userID string) (map[string]time.Time, error) {
+	rows, err := database.DB.Query(`SELECT achievement_id, unlocked_at FROM achievements WHERE user_id = ?`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	unlocked := map[string]time.Time{}
+	for rows.Next() {
+		var id string
+		var at time.Time
+		if err := rows.Scan(&id, &at); err != nil {
+			return nil, err
+		}
+		unlocked[id] = at
+	}
+	return unlocked, rows.Err()
+}
@@ -0,0 +1,52 @@
+package achievement
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+// Handler handles achievement listing
+type Handler struct{}
+
+// NewHandler creates a new achievement handler
+func NewHandler() *Handler {
+	return &Handler{}
+}
+
+// GetAchievements lists every achievement, unlocked ones first in the order
+// they were earned, with the rest in rule order
+func (h *Handler) GetAchievements(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	unlocked, err := unlockedAt(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	var earned, locked []models.Achievement
+	for _, rule := range Rules {
+		a := models.Achievement{ID: rule.ID, Name: rule.Name, Description: rule.Description}
+		if at, ok := unlocked[rule.ID]; ok {
+			a.Unlocked = true
+			a.UnlockedAt = &at
+			earned = append(earned, a)
+		} else {
+			locked = append(locked, a)
+		}
+	}
+	sort.SliceStable(earned, func(i, j int) bool { return earned[i].UnlockedAt.Before(*earned[j].UnlockedAt) })
+
+	c.JSON(http.StatusOK, gin.H{
+		"achievements": append(earned, locked...),
+		"unlocked":     len(earned),
+		"total":        len(Rules),
+	})
+}
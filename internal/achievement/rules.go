@@ -0,0 +1,88 @@
+package achievement
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/database"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/models"
+)
+
+// Rule unlocks an achievement once Metric reaches Threshold
+type Rule struct {
+	ID          string
+	Name        string
+	Description string
+	Metric      Metric
+	Threshold   int
+}
+
+// Metric measures one thing about a user's reading as of now
+type Metric func(userID string, now time.Time) (int, error)
+
+// Rules are checked in order. IDs are stored with unlocked achievements, so
+// they must never change once released.
+var Rules = []Rule{
+	{ID: "first_completed", Name: "Finisher", Description: "Complete your first series", Metric: completedSeries, Threshold: 1},
+	{ID: "ten_completed", Name: "Completionist", Description: "Complete 10 series", Metric: completedSeries, Threshold: 10},
+	{ID: "library_25", Name: "Collector", Description: "Have 25 manga in your library", Metric: librarySize, Threshold: 25},
+	{ID: "chapters_1000", Name: "Bookworm", Description: "Read 1,000 chapters", Metric: chaptersRead, Threshold: 1000},
+	{ID: "week_100", Name: "Binge Reader", Description: "Read 100 chapters in a week", Metric: chaptersThisWeek, Threshold: 100},
+	{ID: "genres_10", Name: "Explorer", Description: "Read across 10 genres", Metric: genresRead, Threshold: 10},
+}
+
+func completedSeries(userID string, now time.Time) (int, error) {
+	var n int
+	err := database.DB.QueryRow(`SELECT COUNT(*) FROM user_progress WHERE user_id = ? AND status = ?`,
+		userID, models.StatusCompleted).Scan(&n)
+	return n, err
+}
+
+func librarySize(userID string, now time.Time) (int, error) {
+	var n int
+	err := database.DB.QueryRow(`SELECT COUNT(*) FROM user_progress WHERE user_id = ?`, userID).Scan(&n)
+	return n, err
+}
+
+// chaptersRead uses the library's current chapters rather than the progress
+// history so reading done before history was kept still counts.
+func chaptersRead(userID string, now time.Time) (int, error) {
+	var n int
+	err := database.DB.QueryRow(`SELECT COALESCE(SUM(current_chapter), 0) FROM user_progress WHERE user_id = ?`, userID).Scan(&n)
+	return n, err
+}
+
+// chaptersThisWeek sums the last seven UTC days, today included
+func chaptersThisWeek(userID string, now time.Time) (int, error) {
+	since := now.UTC().AddDate(0, 0, -6).Format("2006-01-02")
+	var n int
+	err := database.DB.QueryRow(`SELECT COALESCE(SUM(chapters), 0) FROM reading_days WHERE user_id = ? AND day >= ?`,
+		userID, since).Scan(&n)
+	return n, err
+}
+
+// genresRead counts distinct genres among library manga the user has started
+func genresRead(userID string, now time.Time) (int, error) {
+	rows, err := database.DB.Query(`SELECT COALESCE(m.genres, '') FROM user_progress up
+                                    JOIN manga m ON m.id = up.manga_id
+                                    WHERE up.user_id = ? AND up.current_chapter > 0`, userID)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	seen := map[string]bool{}
+	for rows.Next() {
+		var genresJSON string
+		if err := rows.Scan(&genresJSON); err != nil {
+			return 0, err
+		}
+		var genres []string
+		json.Unmarshal([]byte(genresJSON), &genres)
+		for _, g := range genres {
+			seen[strings.ToLower(g)] = true
+		}
+	}
+	return len(seen), rows.Err()
+}
@@ -0,0 +1,145 @@
+package achievement_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/achievement"
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/bridge"
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/manga"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/database"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/logger"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+type recordingBroadcaster struct {
+	mu       sync.Mutex
+	unlocked []string
+}
+
+func (r *recordingBroadcaster) BroadcastToUser(userID string, event bridge.BroadcastEvent) {
+	if event.EventType != "achievement_unlocked" {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	data, _ := event.Data.(map[string]interface{})
+	r.unlocked = append(r.unlocked, data["achievement_id"].(string))
+}
+
+func (r *recordingBroadcaster) waitFor(t *testing.T, n int) []string {
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		r.mu.Lock()
+		got := append([]string{}, r.unlocked...)
+		r.mu.Unlock()
+		if len(got) >= n {
+			return got
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %d unlocks", n)
+	return nil
+}
+
+func setupAchievementTest(t *testing.T) (*bridge.Bridge, *recordingBroadcaster) {
+	if err := database.InitDatabase(t.TempDir() + "/test.db"); err != nil {
+		t.Fatalf("init db: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	if _, err := database.DB.Exec(`INSERT INTO users (id, username, email, password_hash) VALUES ('u1', 'reader', 'reader@example.com', 'x')`); err != nil {
+		t.Fatalf("insert user: %v", err)
+	}
+
+	logger.Init(logger.INFO, false, nil)
+	br := bridge.NewBridge(logger.GetLogger())
+	br.Start()
+	t.Cleanup(br.Stop)
+	recorder := &recordingBroadcaster{}
+	br.SetUDPBroadcaster(recorder)
+	return br, recorder
+}
+
+func addManga(t *testing.T, id string, genres []string, chapter int, status models.ReadingStatus) {
+	m := models.Manga{ID: id, Title: "Manga " + id, Genres: genres}
+	if err := manga.InsertManga(&m); err != nil {
+		t.Fatalf("insert manga: %v", err)
+	}
+	_, err := database.DB.Exec(`INSERT INTO user_progress (user_id, manga_id, current_chapter, status) VALUES ('u1', ?, ?, ?)`,
+		id, chapter, status)
+	if err != nil {
+		t.Fatalf("insert progress: %v", err)
+	}
+}
+
+func TestEngine_UnlocksFromBridgeEvents(t *testing.T) {
+	br, recorder := setupAchievementTest(t)
+	achievement.NewEngine(br)
+
+	addManga(t, "1", []string{"Action"}, 50, models.StatusCompleted)
+	br.NotifyLibraryUpdate(bridge.LibraryUpdateEvent{UserID: "u1", MangaID: "1", Action: "added"})
+
+	if got := recorder.waitFor(t, 1); got[0] != "first_completed" {
+		t.Fatalf("expected first_completed, got %v", got)
+	}
+
+	// Unrelated events don't trigger evaluation, and an unlocked badge is never announced twice
+	for i := 2; i <= 10; i++ {
+		addManga(t, fmt.Sprint(i), []string{fmt.Sprintf("Genre %d", i)}, 1, models.StatusReading)
+	}
+	br.NotifyGoalUpdate(bridge.GoalUpdateEvent{UserID: "u1", GoalID: "g1", Pace: models.GoalBehind})
+	time.Sleep(100 * time.Millisecond)
+	if got := recorder.waitFor(t, 1); len(got) != 1 {
+		t.Fatalf("expected goal events to be ignored, got %v", got)
+	}
+
+	br.NotifyProgressUpdate(bridge.ProgressUpdateEvent{UserID: "u1", MangaID: "2", ChapterID: 1, LastReadDate: time.Now()})
+	br.NotifyProgressUpdate(bridge.ProgressUpdateEvent{UserID: "u1", MangaID: "3", ChapterID: 1, LastReadDate: time.Now()})
+	recorder.waitFor(t, 2)
+	time.Sleep(100 * time.Millisecond)
+	if got := recorder.waitFor(t, 2); len(got) != 2 || got[1] != "genres_10" {
+		t.Errorf("expected genres_10 to be announced once, got %v", got)
+	}
+}
+
+func TestGetAchievements(t *testing.T) {
+	br, _ := setupAchievementTest(t)
+	engine := achievement.NewEngine(br)
+
+	addManga(t, "1", nil, 1200, models.StatusCompleted)
+	unlocked, err := engine.Evaluate("u1")
+	if err != nil {
+		t.Fatalf("evaluate: %v", err)
+	}
+	if len(unlocked) != 2 {
+		t.Fatalf("expected first_completed and chapters_1000, got %v", unlocked)
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	// Stand-in for the auth middleware
+	router.Use(func(c *gin.Context) { c.Set("user_id", "u1") })
+	router.GET("/users/me/achievements", achievement.NewHandler().GetAchievements)
+
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, httptest.NewRequest("GET", "/users/me/achievements", nil))
+	var result struct {
+		Achievements []models.Achievement `json:"achievements"`
+		Unlocked     int                  `json:"unlocked"`
+		Total        int                  `json:"total"`
+	}
+	json.Unmarshal(resp.Body.Bytes(), &result)
+
+	if result.Unlocked != 2 || result.Total != len(achievement.Rules) || len(result.Achievements) != result.Total {
+		t.Fatalf("unexpected response %+v", result)
+	}
+	if !result.Achievements[0].Unlocked || !result.Achievements[1].Unlocked || result.Achievements[2].Unlocked {
+		t.Errorf("expected unlocked achievements first, got %+v", result.Achievements)
+	}
+}
@@ -0,0 +1,99 @@
+package auth
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/mail"
+
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/database"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/models"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+var ErrAdminExists = errors.New("an admin account already exists")
+
+// SetRole changes a user's role. The new role reaches the user's tokens the
+// next time they refresh, so within utils.AccessTokenTTL.
+func (h *Handler) SetRole(c *gin.Context) {
+	var req models.SetRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	username := c.Param("username")
+	var userID string
+	var current models.Role
+	err := database.DB.QueryRow(`SELECT id, role FROM users WHERE username = ?`, username).Scan(&userID, &current)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	if current == models.RoleAdmin && req.Role != models.RoleAdmin {
+		var admins int
+		if err := database.DB.QueryRow(`SELECT COUNT(*) FROM users WHERE role = ?`, models.RoleAdmin).Scan(&admins); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+		if admins <= 1 {
+			c.JSON(http.StatusConflict, gin.H{"error": "Cannot demote the last admin"})
+			return
+		}
+	}
+
+	if _, err := database.DB.Exec(`UPDATE users SET role = ? WHERE id = ?`, req.Role, userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update role"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"user_id": userID, "username": username, "role": req.Role})
+}
+
+// BootstrapAdmin makes username the first admin. An existing account is
+// promoted; otherwise one is created from email and password. It refuses
+// once any admin exists, after which roles are managed through the API.
+func BootstrapAdmin(username, email, password string) (created bool, err error) {
+	var admins int
+	if err := database.DB.QueryRow(`SELECT COUNT(*) FROM users WHERE role = ?`, models.RoleAdmin).Scan(&admins); err != nil {
+		return false, err
+	}
+	if admins > 0 {
+		return false, ErrAdminExists
+	}
+
+	result, err := database.DB.Exec(`UPDATE users SET role = ? WHERE username = ?`, models.RoleAdmin, username)
+	if err != nil {
+		return false, err
+	}
+	if n, _ := result.RowsAffected(); n > 0 {
+		return false, nil
+	}
+
+	if email == "" || password == "" {
+		return false, fmt.Errorf("user %q not found; pass an email and password to create it", username)
+	}
+	if _, err := mail.ParseAddress(email); err != nil {
+		return false, fmt.Errorf("Invalid email format")
+	}
+	if err := validatePasswordStrength(password); err != nil {
+		return false, err
+	}
+	userID, err := utils.GenerateID(16)
+	if err != nil {
+		return false, err
+	}
+	hash, err := utils.HashPassword(password)
+	if err != nil {
+		return false, err
+	}
+	_, err = database.DB.Exec(`INSERT INTO users (id, username, email, password_hash, role) VALUES (?, ?, ?, ?, ?)`,
+		userID, username, email, hash, models.RoleAdmin)
+	return err == nil, err
+}
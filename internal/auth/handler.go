@@ -2,13 +2,17 @@ package auth
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"net/mail"
 	"strings"
 	"time"
 
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/mailer"
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/oidc"
 	"github.com/binhbb2204/Manga-Hub-Group13/pkg/database"
 	"github.com/binhbb2204/Manga-Hub-Group13/pkg/models"
 	"github.com/binhbb2204/Manga-Hub-Group13/pkg/utils"
@@ -16,15 +20,23 @@ import (
 )
 
 type Handler struct {
-	JWTSecret string
+	keys   *KeyManager
+	mailer mailer.Mailer
+	oidc   *oidc.Provider
 }
 
-func NewHandler(jwtSecret string) *Handler {
+func NewHandler(keys *KeyManager) *Handler {
 	return &Handler{
-		JWTSecret: jwtSecret,
+		keys:   keys,
+		mailer: mailer.NewFromEnv(),
 	}
 }
 
+// SetMailer replaces the mailer chosen from the environment
+func (h *Handler) SetMailer(m mailer.Mailer) {
+	h.mailer = m
+}
+
 func (h *Handler) Register(c *gin.Context) {
 	var req models.RegisterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -70,23 +82,23 @@ func (h *Handler) Register(c *gin.Context) {
 		return
 	}
 
-	token, err := utils.GenerateJWT(userID, req.Username, h.JWTSecret)
+	user := models.User{ID: userID, Username: req.Username, Email: req.Email, Role: models.RoleUser}
+	_ = database.DB.QueryRow(`SELECT created_at FROM users WHERE id = ?`, userID).Scan(&user.CreatedAt)
+
+	if base, ok := publicBaseURL(); !ok {
+		log.Printf("Warning: PUBLIC_API_URL is not set, no verification email sent to user %s", userID)
+	} else if token, err := newVerification(userID, req.Email, time.Now()); err != nil {
+		log.Printf("Warning: failed to start email verification for user %s: %v", userID, err)
+	} else {
+		go h.sendVerificationEmail(user.Username, user.Email, verifyLink(base, token), false)
+	}
+
+	resp, err := h.newSession(c, &user)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
 		return
 	}
-
-	var createdAt time.Time
-	_ = database.DB.QueryRow(`SELECT created_at FROM users WHERE id = ?`, userID).Scan(&createdAt)
-
-	c.JSON(http.StatusCreated, models.AuthResponse{
-		Token:     token,
-		UserID:    userID,
-		Username:  req.Username,
-		Email:     req.Email,
-		ExpiresAt: time.Now().Add(24 * time.Hour),
-		CreatedAt: createdAt,
-	})
+	c.JSON(http.StatusCreated, resp)
 }
 
 func (h *Handler) Login(c *gin.Context) {
@@ -101,46 +113,181 @@ func (h *Handler) Login(c *gin.Context) {
 		return
 	}
 
+	now := time.Now()
+	ip := c.ClientIP()
+
 	//Query user from database
 	var user models.User
 	var err error
 	if req.Username != "" {
-		err = database.DB.QueryRow(`SELECT id, username, email, password_hash, created_at FROM users WHERE username = ?`, req.Username).
-			Scan(&user.ID, &user.Username, &user.Email, &user.PasswordHash, &user.CreatedAt)
+		err = database.DB.QueryRow(`SELECT id, username, email, email_verified, totp_enabled, password_hash, role, created_at FROM users WHERE username = ?`, req.Username).
+			Scan(&user.ID, &user.Username, &user.Email, &user.EmailVerified, &user.TwoFactor, &user.PasswordHash, &user.Role, &user.CreatedAt)
 	} else {
-		err = database.DB.QueryRow(`SELECT id, username, email, password_hash, created_at FROM users WHERE email = ?`, req.Email).
-			Scan(&user.ID, &user.Username, &user.Email, &user.PasswordHash, &user.CreatedAt)
+		err = database.DB.QueryRow(`SELECT id, username, email, email_verified, totp_enabled, password_hash, role, created_at FROM users WHERE email = ?`, req.Email).
+			Scan(&user.ID, &user.Username, &user.Email, &user.EmailVerified, &user.TwoFactor, &user.PasswordHash, &user.Role, &user.CreatedAt)
 	}
+	if err != nil && err != sql.ErrNoRows {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	found := err == nil
+
+	accountKey := unknownAttemptKey(req.Username + req.Email)
+	if found {
+		accountKey = accountAttemptKey(user.ID)
+	}
+	wait, err := retryAfter(now, accountKey, ipAttemptKey(ip))
 	if err != nil {
-		if err == sql.ErrNoRows {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Account not found"})
-			return
-		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
 		return
 	}
+	if wait > 0 {
+		tooManyAttempts(c, wait)
+		return
+	}
 
-	//Verify password
+	//Verify password. Unknown accounts get the same answer as a wrong
+	//password, so the response doesn't reveal who has signed up.
+	if !found {
+		checkDummyPassword(req.Password)
+		loginFailed(accountKey, "", ip, now)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+		return
+	}
 	if err := utils.CheckPassword(user.PasswordHash, req.Password); err != nil {
+		loginFailed(accountKey, user.ID, ip, now)
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
 		return
 	}
 
-	//Generate JWT token
-	token, err := utils.GenerateJWT(user.ID, user.Username, h.JWTSecret)
+	//With two-factor authentication on, the password only opens the second
+	//step. Failures are kept until that step succeeds too.
+	if user.TwoFactor {
+		challenge, err := newMFAChallenge(user.ID, now)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start two-factor login"})
+			return
+		}
+		c.JSON(http.StatusAccepted, challenge)
+		return
+	}
+	if err := clearFailures(accountKey); err != nil {
+		log.Printf("Warning: failed to clear login failures for user %s: %v", user.ID, err)
+	}
+
+	//Generate access and refresh tokens
+	resp, err := h.newSession(c, &user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// newSession issues an access token and the first refresh token of a new
+// session for user, recording the client that logged in
+func (h *Handler) newSession(c *gin.Context, user *models.User) (*models.AuthResponse, error) {
+	now := time.Now()
+	sessionID, refreshToken, refreshExpiresAt, err := startSession(user.ID, clientOf(c), now)
+	if err != nil {
+		return nil, err
+	}
+	return h.authResponse(user, sessionID, refreshToken, refreshExpiresAt, now)
+}
+
+func (h *Handler) authResponse(user *models.User, sessionID, refreshToken string, refreshExpiresAt, now time.Time) (*models.AuthResponse, error) {
+	claims, err := utils.NewAccessClaims(user.ID, user.Username, string(user.Role))
+	if err != nil {
+		return nil, err
+	}
+	claims.SessionID = sessionID
+	token, err := h.keys.Sign(claims)
+	if err != nil {
+		return nil, err
+	}
+	return &models.AuthResponse{
+		Token:            token,
+		RefreshToken:     refreshToken,
+		SessionID:        sessionID,
+		UserID:           user.ID,
+		Username:         user.Username,
+		Email:            user.Email,
+		EmailVerified:    user.EmailVerified,
+		Role:             user.Role,
+		ExpiresAt:        now.Add(utils.AccessTokenTTL),
+		RefreshExpiresAt: refreshExpiresAt,
+		CreatedAt:        user.CreatedAt,
+	}, nil
+}
+
+// Refresh exchanges a refresh token for a new access token. The refresh
+// token is rotated: the one presented stops working and a new one is
+// returned.
+func (h *Handler) Refresh(c *gin.Context) {
+	var req models.RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	now := time.Now()
+	userID, sessionID, refreshToken, refreshExpiresAt, err := rotateRefreshToken(req.RefreshToken, clientOf(c), now)
+	if errors.Is(err, ErrRefreshTokenInvalid) || errors.Is(err, ErrRefreshTokenReused) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	var user models.User
+	err = database.DB.QueryRow(`SELECT id, username, email, email_verified, role, created_at FROM users WHERE id = ?`, userID).
+		Scan(&user.ID, &user.Username, &user.Email, &user.EmailVerified, &user.Role, &user.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Account not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	resp, err := h.authResponse(&user, sessionID, refreshToken, refreshExpiresAt, now)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
 		return
 	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// Logout revokes the access token used to call it and, when one is given,
+// the session of the refresh token in the body
+func (h *Handler) Logout(c *gin.Context) {
+	claims, ok := c.Get("claims")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	jwtClaims := claims.(*utils.JWTClaims)
+
+	var req models.LogoutRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	c.JSON(http.StatusOK, models.AuthResponse{
-		Token:     token,
-		UserID:    user.ID,
-		Username:  user.Username,
-		Email:     user.Email,
-		ExpiresAt: time.Now().Add(24 * time.Hour),
-		CreatedAt: user.CreatedAt,
-	})
+	if err := RevokeToken(jwtClaims); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke token"})
+		return
+	}
+	if req.RefreshToken != "" {
+		if err := revokeRefreshToken(jwtClaims.UserID, req.RefreshToken, time.Now()); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke refresh token"})
+			return
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
 }
 
 type ChangePasswordRequest struct {
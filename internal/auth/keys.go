@@ -0,0 +1,358 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/models"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const (
+	// devJWTSecret signs tokens when nothing is configured and
+	// JWT_ALLOW_DEV_SECRET=true, so a local checkout runs. It is public, so
+	// it is never used without that opt-in.
+	devJWTSecret = "your-secret-key-change-this-in-production"
+	// SecretKeyID is the key ID JWT_SECRET is loaded under
+	SecretKeyID = "secret"
+	// minRSABits is the smallest RSA key accepted for signing
+	minRSABits = 2048
+)
+
+var ErrUnknownKey = errors.New("unknown signing key")
+
+type signingKey struct {
+	id     string
+	method jwt.SigningMethod
+	// private is []byte, *rsa.PrivateKey or ed25519.PrivateKey, and public
+	// the matching []byte, *rsa.PublicKey or ed25519.PublicKey
+	private interface{}
+	public  interface{}
+}
+
+// KeyManager holds the keys access tokens are signed and verified with.
+// Tokens are signed with the active key and name it in their kid header;
+// any key still held verifies them. Rotating is adding a key, making it
+// active and removing the old one once its tokens have expired, which
+// takes AccessTokenTTL. Nobody is logged out, since refresh tokens don't
+// depend on the keys.
+type KeyManager struct {
+	mu     sync.RWMutex
+	keys   map[string]*signingKey
+	active string
+	// legacy verifies tokens with no kid, issued before keys had IDs
+	legacy string
+}
+
+func NewKeyManager() *KeyManager {
+	return &KeyManager{keys: map[string]*signingKey{}}
+}
+
+// KeysFromSecret returns a manager that signs with HS256 using secret and
+// also accepts tokens without a kid, issued before keys had IDs
+func KeysFromSecret(secret string) *KeyManager {
+	m := NewKeyManager()
+	m.AddKey(SecretKeyID, []byte(secret))
+	m.SetActive(SecretKeyID)
+	m.SetLegacy(SecretKeyID)
+	return m
+}
+
+// KeysFromEnv loads the signing keys. Every *.pem file in JWT_KEYS_DIR is
+// a private key (RSA for RS256, Ed25519 for EdDSA) whose file name is its
+// key ID. JWT_SECRET adds an HS256 key that also verifies tokens without a
+// kid. JWT_ACTIVE_KEY picks the signing key; by default it is the last
+// file name in sort order, so naming keys by date makes the newest one
+// active, or JWT_SECRET if there are no files. With neither set it fails
+// unless JWT_ALLOW_DEV_SECRET=true.
+func KeysFromEnv() (*KeyManager, error) {
+	m := NewKeyManager()
+	var fileKeys []string
+	if dir := strings.TrimSpace(os.Getenv("JWT_KEYS_DIR")); dir != "" {
+		var err error
+		if fileKeys, err = m.loadDir(dir); err != nil {
+			return nil, err
+		}
+	}
+
+	secret := os.Getenv("JWT_SECRET")
+	if secret == "" && len(fileKeys) == 0 {
+		if os.Getenv("JWT_ALLOW_DEV_SECRET") != "true" {
+			return nil, errors.New("set JWT_KEYS_DIR or JWT_SECRET to sign tokens, or JWT_ALLOW_DEV_SECRET=true for local development")
+		}
+		log.Printf("Warning: JWT_SECRET and JWT_KEYS_DIR are not set, signing tokens with the development secret")
+		secret = devJWTSecret
+	}
+	if secret != "" {
+		if err := m.AddKey(SecretKeyID, []byte(secret)); err != nil {
+			return nil, err
+		}
+		m.SetLegacy(SecretKeyID)
+	}
+
+	active := strings.TrimSpace(os.Getenv("JWT_ACTIVE_KEY"))
+	if active == "" {
+		active = SecretKeyID
+		if len(fileKeys) > 0 {
+			active = fileKeys[len(fileKeys)-1]
+		}
+	}
+	if err := m.SetActive(active); err != nil {
+		return nil, fmt.Errorf("JWT_ACTIVE_KEY: %w", err)
+	}
+	return m, nil
+}
+
+// loadDir adds every *.pem key in dir and returns their IDs in order
+func (m *KeyManager) loadDir(dir string) ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no *.pem keys in JWT_KEYS_DIR %s", dir)
+	}
+	sort.Strings(paths)
+
+	var ids []string
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		kid := strings.TrimSuffix(filepath.Base(path), ".pem")
+		if err := m.AddPEM(kid, data); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		ids = append(ids, kid)
+	}
+	return ids, nil
+}
+
+// AddKey adds a key for verifying, and signing once it is made active. key
+// is an HMAC secret ([]byte), *rsa.PrivateKey or ed25519.PrivateKey.
+func (m *KeyManager) AddKey(kid string, key interface{}) error {
+	if kid == "" {
+		return errors.New("key ID is required")
+	}
+	k := &signingKey{id: kid, private: key}
+	switch key := key.(type) {
+	case []byte:
+		if len(key) == 0 {
+			return errors.New("empty HMAC secret")
+		}
+		k.method, k.public = jwt.SigningMethodHS256, key
+	case *rsa.PrivateKey:
+		if key.N.BitLen() < minRSABits {
+			return fmt.Errorf("RSA key is %d bits, need at least %d", key.N.BitLen(), minRSABits)
+		}
+		k.method, k.public = jwt.SigningMethodRS256, &key.PublicKey
+	case ed25519.PrivateKey:
+		k.method, k.public = jwt.SigningMethodEdDSA, key.Public()
+	default:
+		return fmt.Errorf("unsupported key type %T", key)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.keys[kid] = k
+	return nil
+}
+
+// AddPEM adds a PKCS#8 or PKCS#1 private key
+func (m *KeyManager) AddPEM(kid string, data []byte) error {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return errors.New("no PEM data")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		if key, err = x509.ParsePKCS1PrivateKey(block.Bytes); err != nil {
+			return errors.New("not a PKCS#8 or PKCS#1 private key")
+		}
+	}
+	return m.AddKey(kid, key)
+}
+
+// SetActive makes kid the key new tokens are signed with
+func (m *KeyManager) SetActive(kid string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.keys[kid] == nil {
+		return fmt.Errorf("%w %q", ErrUnknownKey, kid)
+	}
+	m.active = kid
+	return nil
+}
+
+// SetLegacy makes kid verify tokens that carry no kid
+func (m *KeyManager) SetLegacy(kid string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.keys[kid] == nil {
+		return fmt.Errorf("%w %q", ErrUnknownKey, kid)
+	}
+	m.legacy = kid
+	return nil
+}
+
+// Remove drops a retired key. Tokens it signed stop working.
+func (m *KeyManager) Remove(kid string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if kid == m.active {
+		return errors.New("cannot remove the active key")
+	}
+	delete(m.keys, kid)
+	if kid == m.legacy {
+		m.legacy = ""
+	}
+	return nil
+}
+
+// ActiveKeyID is the kid of the key new tokens are signed with
+func (m *KeyManager) ActiveKeyID() string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.active
+}
+
+// Sign signs claims with the active key
+func (m *KeyManager) Sign(claims *utils.JWTClaims) (string, error) {
+	m.mu.RLock()
+	key := m.keys[m.active]
+	m.mu.RUnlock()
+	if key == nil {
+		return "", errors.New("no active signing key")
+	}
+	token := jwt.NewWithClaims(key.method, claims)
+	token.Header["kid"] = key.id
+	return token.SignedString(key.private)
+}
+
+// Parse checks a token's signature against the key its kid names and its
+// expiry. The algorithm must be the key's own, so an RSA public key can't
+// be passed off as an HMAC secret.
+func (m *KeyManager) Parse(tokenString string) (*utils.JWTClaims, error) {
+	claims := &utils.JWTClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		m.mu.RLock()
+		if kid == "" {
+			kid = m.legacy
+		}
+		key := m.keys[kid]
+		m.mu.RUnlock()
+		if key == nil {
+			return nil, ErrUnknownKey
+		}
+		if token.Method.Alg() != key.method.Alg() {
+			return nil, errors.New("invalid signing method")
+		}
+		return key.public, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// JWKS lists the public keys so other services can verify tokens. HMAC
+// secrets are never published.
+func (m *KeyManager) JWKS() models.JSONWebKeySet {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	set := models.JSONWebKeySet{Keys: []models.JSONWebKey{}}
+	for _, k := range m.keys {
+		jwk := models.JSONWebKey{Kid: k.id, Use: "sig", Alg: k.method.Alg()}
+		switch pub := k.public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		default:
+			continue
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	sort.Slice(set.Keys, func(i, j int) bool { return set.Keys[i].Kid < set.Keys[j].Kid })
+	return set
+}
+
+// GenerateKeyPEM makes a new PKCS#8 private key for alg, RS256 or EdDSA
+func GenerateKeyPEM(alg string) ([]byte, error) {
+	var key interface{}
+	var err error
+	switch strings.ToUpper(alg) {
+	case "RS256":
+		key, err = rsa.GenerateKey(rand.Reader, minRSABits)
+	case "EDDSA":
+		_, key, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %q, use RS256 or EdDSA", alg)
+	}
+	if err != nil {
+		return nil, err
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+var (
+	defaultKeysMu sync.Mutex
+	defaultKeys   *KeyManager
+)
+
+// DefaultKeys is the key manager the TCP and UDP servers verify tokens
+// with. Unless SetDefaultKeys was called it is loaded from the environment
+// on first use; if that fails every token is rejected.
+func DefaultKeys() *KeyManager {
+	defaultKeysMu.Lock()
+	defer defaultKeysMu.Unlock()
+	if defaultKeys == nil {
+		keys, err := KeysFromEnv()
+		if err != nil {
+			log.Printf("Warning: failed to load token signing keys, rejecting all tokens: %v", err)
+			keys = NewKeyManager()
+		}
+		defaultKeys = keys
+	}
+	return defaultKeys
+}
+
+// SetDefaultKeys makes every server in the process share keys
+func SetDefaultKeys(keys *KeyManager) {
+	defaultKeysMu.Lock()
+	defer defaultKeysMu.Unlock()
+	defaultKeys = keys
+}
+
+// JWKS publishes the public signing keys
+func (h *Handler) JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.keys.JWKS())
+}
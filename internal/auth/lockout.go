@@ -0,0 +1,250 @@
+package auth
+
+import (
+	"database/sql"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/database"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// LockoutDuration is how long an account or address stays locked once
+	// it reaches its failure limit, and the longest backoff before that
+	LockoutDuration = 15 * time.Minute
+	// loginAttemptWindow is how long failures are remembered. A quiet
+	// spell this long starts the count again.
+	loginAttemptWindow = time.Hour
+)
+
+// Auth event types recorded in auth_events
+const (
+	EventAccountLocked = "account_locked"
+	EventIPLocked      = "ip_locked"
+	EventUnlocked      = "account_unlocked"
+)
+
+// attemptPolicy says how many failures are allowed before each further one
+// doubles the wait, and at how many the key is locked outright
+type attemptPolicy struct {
+	free   int
+	lockAt int
+}
+
+var (
+	accountPolicy = attemptPolicy{free: 3, lockAt: 10}
+	// Many people can share one address, so it gets more room
+	ipPolicy = attemptPolicy{free: 20, lockAt: 100}
+)
+
+// delay is the wait imposed after the given number of failures
+func (p attemptPolicy) delay(failures int) time.Duration {
+	if failures >= p.lockAt {
+		return LockoutDuration
+	}
+	if failures <= p.free {
+		return 0
+	}
+	d := time.Second << uint(failures-p.free-1)
+	if d > LockoutDuration || d <= 0 {
+		return LockoutDuration
+	}
+	return d
+}
+
+// AuthEvent is one entry of the authentication audit log
+type AuthEvent struct {
+	ID        int64     `json:"id"`
+	Event     string    `json:"event"`
+	UserID    string    `json:"user_id,omitempty"`
+	IP        string    `json:"ip,omitempty"`
+	Detail    string    `json:"detail,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func accountAttemptKey(userID string) string { return "user:" + userID }
+
+// unknownAttemptKey tracks guesses at names with no account behind them, so
+// they slow down and lock exactly like real accounts do
+func unknownAttemptKey(identifier string) string {
+	return "name:" + strings.ToLower(strings.TrimSpace(identifier))
+}
+
+func ipAttemptKey(ip string) string { return "ip:" + ip }
+
+// retryAfter returns how long until the next login attempt is allowed for
+// any of keys
+func retryAfter(now time.Time, keys ...string) (time.Duration, error) {
+	var wait time.Duration
+	for _, key := range keys {
+		var lockedUntil sql.NullTime
+		err := database.DB.QueryRow(`SELECT locked_until FROM login_attempts WHERE attempt_key = ?`, key).Scan(&lockedUntil)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+		if lockedUntil.Valid && lockedUntil.Time.After(now) {
+			if d := lockedUntil.Time.Sub(now); d > wait {
+				wait = d
+			}
+		}
+	}
+	return wait, nil
+}
+
+// recordFailure counts a failed attempt against key and reports whether it
+// just pushed the key into lockout
+func recordFailure(key string, policy attemptPolicy, now time.Time) (locked bool, err error) {
+	var failures int
+	var lastFailure time.Time
+	err = database.DB.QueryRow(`SELECT failures, last_failure_at FROM login_attempts WHERE attempt_key = ?`, key).
+		Scan(&failures, &lastFailure)
+	if err != nil && err != sql.ErrNoRows {
+		return false, err
+	}
+	if now.Sub(lastFailure) > loginAttemptWindow {
+		failures = 0
+	}
+	failures++
+
+	var lockedUntil interface{}
+	if d := policy.delay(failures); d > 0 {
+		lockedUntil = now.Add(d)
+	}
+	_, err = database.DB.Exec(`INSERT INTO login_attempts (attempt_key, failures, locked_until, last_failure_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT(attempt_key) DO UPDATE SET failures = excluded.failures, locked_until = excluded.locked_until, last_failure_at = excluded.last_failure_at`,
+		key, failures, lockedUntil, now)
+	return failures == policy.lockAt, err
+}
+
+func clearFailures(key string) error {
+	_, err := database.DB.Exec(`DELETE FROM login_attempts WHERE attempt_key = ?`, key)
+	return err
+}
+
+func recordAuthEvent(event, userID, ip, detail string) {
+	_, err := database.DB.Exec(`INSERT INTO auth_events (event, user_id, ip, detail) VALUES (?, ?, ?, ?)`,
+		event, nullIfEmpty(userID), nullIfEmpty(ip), detail)
+	if err != nil {
+		log.Printf("Warning: failed to record auth event %s: %v", event, err)
+	}
+	log.Printf("Auth event %s user=%s ip=%s %s", event, userID, ip, detail)
+}
+
+func nullIfEmpty(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+var (
+	dummyHashOnce sync.Once
+	dummyHash     string
+)
+
+// checkDummyPassword spends as long as a real password check, so a missing
+// account can't be told apart by how quickly the login fails
+func checkDummyPassword(password string) {
+	dummyHashOnce.Do(func() {
+		dummyHash, _ = utils.HashPassword("not-a-real-password")
+	})
+	utils.CheckPassword(dummyHash, password)
+}
+
+// loginFailed records a failed attempt for the account (or the unknown
+// name) and the client address, locking either once it hits its limit
+func loginFailed(accountKey, userID, ip string, now time.Time) {
+	locked, err := recordFailure(accountKey, accountPolicy, now)
+	if err != nil {
+		log.Printf("Warning: failed to record login failure: %v", err)
+	} else if locked {
+		recordAuthEvent(EventAccountLocked, userID, ip, strings.TrimPrefix(accountKey, "name:"))
+	}
+	locked, err = recordFailure(ipAttemptKey(ip), ipPolicy, now)
+	if err != nil {
+		log.Printf("Warning: failed to record login failure: %v", err)
+	} else if locked {
+		recordAuthEvent(EventIPLocked, "", ip, "")
+	}
+}
+
+func tooManyAttempts(c *gin.Context, wait time.Duration) {
+	c.Header("Retry-After", strconv.Itoa(int(wait.Round(time.Second)/time.Second)))
+	c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many failed login attempts, try again later"})
+}
+
+// UnlockAccount clears failed login attempts for a user. A lock on the
+// address they sign in from is separate; pass it as ?ip= to lift that too
+func (h *Handler) UnlockAccount(c *gin.Context) {
+	username := c.Param("username")
+	ip := strings.TrimSpace(c.Query("ip"))
+	if ip != "" && net.ParseIP(ip) == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid IP address"})
+		return
+	}
+	var userID string
+	err := database.DB.QueryRow(`SELECT id FROM users WHERE username = ?`, username).Scan(&userID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	if err := clearFailures(accountAttemptKey(userID)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unlock account"})
+		return
+	}
+	if ip == "" {
+		recordAuthEvent(EventUnlocked, userID, c.ClientIP(), "by "+c.GetString("username"))
+		c.JSON(http.StatusOK, gin.H{"user_id": userID, "username": username,
+			"message": "Account unlocked; any lock on the user's address is still in place"})
+		return
+	}
+
+	if err := clearFailures(ipAttemptKey(ip)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unlock address"})
+		return
+	}
+	recordAuthEvent(EventUnlocked, userID, c.ClientIP(), "by "+c.GetString("username")+", address "+ip)
+	c.JSON(http.StatusOK, gin.H{"user_id": userID, "username": username, "ip": ip, "message": "Account and address unlocked"})
+}
+
+// GetAuthEvents lists recent lockouts and unlocks, newest first
+func (h *Handler) GetAuthEvents(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if limit <= 0 || limit > 500 {
+		limit = 50
+	}
+
+	rows, err := database.DB.Query(`SELECT id, event, COALESCE(user_id, ''), COALESCE(ip, ''), COALESCE(detail, ''), created_at
+		FROM auth_events ORDER BY id DESC LIMIT ?`, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	defer rows.Close()
+
+	events := []AuthEvent{}
+	for rows.Next() {
+		var e AuthEvent
+		if err := rows.Scan(&e.ID, &e.Event, &e.UserID, &e.IP, &e.Detail, &e.CreatedAt); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+		events = append(events, e)
+	}
+	c.JSON(http.StatusOK, gin.H{"events": events})
+}
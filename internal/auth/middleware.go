@@ -4,12 +4,12 @@ import (
 	"net/http"
 	"strings"
 
-	"github.com/binhbb2204/Manga-Hub-Group13/pkg/utils"
 	"github.com/gin-gonic/gin"
 )
 
-// AuthMiddleware validates JWT tokens and adds user info to context
-func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
+// AuthMiddleware validates JWT tokens and personal access tokens and adds
+// user info to context
+func AuthMiddleware(keys *KeyManager) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Get Authorization header
 		authHeader := c.GetHeader("Authorization")
@@ -29,18 +29,43 @@ func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
 
 		token := parts[1]
 
-		// Validate token
-		claims, err := utils.ValidateJWT(token, jwtSecret)
+		// Validate token and check it hasn't been revoked
+		claims, err := ValidateToken(token, keys)
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
 			c.Abort()
 			return
 		}
 
+		// Personal access tokens only reach the routes their scopes cover
+		if !allowedRequest(c, claims) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Token scope does not allow this request"})
+			c.Abort()
+			return
+		}
+
 		// Add user info to context
 		c.Set("user_id", claims.UserID)
 		c.Set("username", claims.Username)
+		c.Set("role", claims.Role)
+		c.Set("claims", claims)
+
+		c.Next()
+	}
+}
 
+// OptionalAuthMiddleware adds user info to context when a valid token is
+// present, but lets anonymous requests through
+func OptionalAuthMiddleware(keys *KeyManager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		parts := strings.Split(c.GetHeader("Authorization"), " ")
+		if len(parts) == 2 && parts[0] == "Bearer" {
+			if claims, err := ValidateToken(parts[1], keys); err == nil && allowedRequest(c, claims) {
+				c.Set("user_id", claims.UserID)
+				c.Set("username", claims.Username)
+				c.Set("role", claims.Role)
+			}
+		}
 		c.Next()
 	}
 }
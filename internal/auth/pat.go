@@ -0,0 +1,254 @@
+package auth
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/database"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/models"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// PersonalTokenPrefix starts every personal access token, which tells them
+// apart from JWTs and makes leaked ones easy to search for
+const PersonalTokenPrefix = "mhp_"
+
+// personalTokenTouchInterval limits how often last_used_at is written for a
+// busy token
+const personalTokenTouchInterval = time.Minute
+
+// IsPersonalToken reports whether claims came from a personal access token
+// rather than a login session
+func IsPersonalToken(claims *utils.JWTClaims) bool {
+	return claims != nil && claims.Scopes != nil
+}
+
+// HasScope reports whether a token's scopes allow need. Nil scopes are a
+// login session and allow everything; a write scope also allows reading the
+// same data.
+func HasScope(scopes []string, need models.Scope) bool {
+	if scopes == nil {
+		return true
+	}
+	for _, s := range scopes {
+		if models.Scope(s) == need {
+			return true
+		}
+		if strings.HasSuffix(string(need), ":read") &&
+			models.Scope(s) == models.Scope(strings.TrimSuffix(string(need), ":read")+":write") {
+			return true
+		}
+	}
+	return false
+}
+
+// routeScopes maps API paths to the data they touch. Personal access tokens
+// can only reach these; everything else, including token management and
+// account settings, needs a login session.
+var routeScopes = []struct {
+	path     string
+	prefix   bool
+	resource string
+}{
+	{"/users/me", false, "profile"},
+	{"/users/library", true, "library"},
+	{"/users/lists", true, "library"},
+	{"/users/progress", true, "progress"},
+	{"/users/goals", true, "progress"},
+	{"/users/stats", true, "progress"},
+}
+
+// requiredScope returns the scope a personal access token needs for a
+// request, or false if no scope reaches it
+func requiredScope(method, path string) (models.Scope, bool) {
+	for _, r := range routeScopes {
+		if path == r.path || (r.prefix && strings.HasPrefix(path, r.path+"/")) {
+			if method == http.MethodGet || method == http.MethodHead {
+				return models.Scope(r.resource + ":read"), true
+			}
+			if r.resource == "profile" {
+				return "", false
+			}
+			return models.Scope(r.resource + ":write"), true
+		}
+	}
+	return "", false
+}
+
+// allowedRequest reports whether claims may make the request in c
+func allowedRequest(c *gin.Context, claims *utils.JWTClaims) bool {
+	if !IsPersonalToken(claims) {
+		return true
+	}
+	need, ok := requiredScope(c.Request.Method, c.FullPath())
+	return ok && HasScope(claims.Scopes, need)
+}
+
+// validatePersonalToken looks up a personal access token and returns claims
+// for its owner carrying the token's scopes
+func validatePersonalToken(token string, now time.Time) (*utils.JWTClaims, error) {
+	if database.DB == nil {
+		return nil, errors.New("personal access tokens need the database")
+	}
+
+	var id, scopes string
+	var expiresAt, lastUsedAt sql.NullTime
+	claims := &utils.JWTClaims{}
+	err := database.DB.QueryRow(`SELECT t.id, t.scopes, t.expires_at, t.last_used_at, u.id, u.username, u.role
+		FROM personal_access_tokens t JOIN users u ON u.id = t.user_id
+		WHERE t.token_hash = ? AND t.revoked_at IS NULL`, hashToken(token)).
+		Scan(&id, &scopes, &expiresAt, &lastUsedAt, &claims.UserID, &claims.Username, &claims.Role)
+	if err == sql.ErrNoRows || (err == nil && expiresAt.Valid && now.After(expiresAt.Time)) {
+		return nil, errors.New("invalid or expired personal access token")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	claims.Scopes = splitScopes(scopes)
+	if !lastUsedAt.Valid || now.Sub(lastUsedAt.Time) > personalTokenTouchInterval {
+		database.DB.Exec(`UPDATE personal_access_tokens SET last_used_at = ? WHERE id = ?`, now, id)
+	}
+	return claims, nil
+}
+
+// splitScopes never returns nil, since nil scopes mean a login session
+func splitScopes(s string) []string {
+	scopes := []string{}
+	for _, scope := range strings.Split(s, ",") {
+		if scope != "" {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes
+}
+
+// CreateToken issues a personal access token for the current user. The
+// token is returned once and only its hash is kept.
+func (h *Handler) CreateToken(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	var req models.CreateTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
+		return
+	}
+	seen := map[models.Scope]bool{}
+	var scopes []models.Scope
+	var names []string
+	for _, s := range req.Scopes {
+		if !s.Valid() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown scope: " + string(s)})
+			return
+		}
+		if !seen[s] {
+			seen[s] = true
+			scopes = append(scopes, s)
+			names = append(names, string(s))
+		}
+	}
+
+	var taken int
+	if err := database.DB.QueryRow(`SELECT COUNT(*) FROM personal_access_tokens WHERE user_id = ? AND name = ? AND revoked_at IS NULL`,
+		userID, name).Scan(&taken); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if taken > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "A token with that name already exists"})
+		return
+	}
+
+	id, err := utils.GenerateID(8)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create token"})
+		return
+	}
+	secret, err := utils.GenerateID(20)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create token"})
+		return
+	}
+	token := PersonalTokenPrefix + secret
+
+	now := time.Now()
+	resp := models.CreateTokenResponse{
+		PersonalAccessToken: models.PersonalAccessToken{ID: id, Name: name, Scopes: scopes, CreatedAt: now},
+		Token:               token,
+	}
+	var expiresAt interface{}
+	if req.ExpiresInDays > 0 {
+		exp := now.Add(time.Duration(req.ExpiresInDays) * 24 * time.Hour)
+		resp.ExpiresAt = &exp
+		expiresAt = exp
+	}
+
+	_, err = database.DB.Exec(`INSERT INTO personal_access_tokens (id, user_id, name, token_hash, scopes, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`, id, userID, name, hashToken(token), strings.Join(names, ","), now, expiresAt)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create token"})
+		return
+	}
+	c.JSON(http.StatusCreated, resp)
+}
+
+// ListTokens lists the current user's active personal access tokens
+func (h *Handler) ListTokens(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	rows, err := database.DB.Query(`SELECT id, name, scopes, created_at, expires_at, last_used_at
+		FROM personal_access_tokens WHERE user_id = ? AND revoked_at IS NULL ORDER BY created_at`, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	defer rows.Close()
+
+	tokens := []models.PersonalAccessToken{}
+	for rows.Next() {
+		var t models.PersonalAccessToken
+		var scopes string
+		var expiresAt, lastUsedAt sql.NullTime
+		if err := rows.Scan(&t.ID, &t.Name, &scopes, &t.CreatedAt, &expiresAt, &lastUsedAt); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+		for _, s := range splitScopes(scopes) {
+			t.Scopes = append(t.Scopes, models.Scope(s))
+		}
+		if expiresAt.Valid {
+			t.ExpiresAt = &expiresAt.Time
+		}
+		if lastUsedAt.Valid {
+			t.LastUsedAt = &lastUsedAt.Time
+		}
+		tokens = append(tokens, t)
+	}
+	c.JSON(http.StatusOK, gin.H{"tokens": tokens})
+}
+
+// RevokePersonalToken stops a personal access token from working straight away
+func (h *Handler) RevokePersonalToken(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	result, err := database.DB.Exec(`UPDATE personal_access_tokens SET revoked_at = ? WHERE id = ? AND user_id = ? AND revoked_at IS NULL`,
+		time.Now(), c.Param("token_id"), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke token"})
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Token not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Token revoked"})
+}
@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"net/http"
+
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+// Permission is an action that some roles may perform. Everything a user
+// does to their own data needs no permission.
+type Permission string
+
+const (
+	// PermModerateContent allows removing other users' comments
+	PermModerateContent Permission = "content:moderate"
+	// PermViewSessions allows listing every live TCP sync session
+	PermViewSessions Permission = "sessions:view"
+	// PermBroadcast allows sending announcements to every UDP subscriber
+	PermBroadcast Permission = "notifications:broadcast"
+	// PermManageRoles allows changing other users' roles
+	PermManageRoles Permission = "users:manage_roles"
+)
+
+// rolePermissions lists what each role may do. Roles are cumulative: each
+// one has every permission of the roles before it in models.Roles.
+var rolePermissions = map[models.Role][]Permission{
+	models.RoleModerator: {PermModerateContent, PermViewSessions},
+	models.RoleAdmin:     {PermBroadcast, PermManageRoles},
+}
+
+// HasPermission reports whether role grants perm. An empty or unknown role
+// is treated as an ordinary user.
+func HasPermission(role models.Role, perm Permission) bool {
+	if !role.Valid() {
+		role = models.RoleUser
+	}
+	for _, r := range models.Roles {
+		for _, p := range rolePermissions[r] {
+			if p == perm {
+				return true
+			}
+		}
+		if r == role {
+			break
+		}
+	}
+	return false
+}
+
+// ContextRole returns the role AuthMiddleware stored for the request
+func ContextRole(c *gin.Context) models.Role {
+	return models.Role(c.GetString("role"))
+}
+
+// RequirePermission rejects requests whose token doesn't grant perm. It must
+// run after AuthMiddleware.
+func RequirePermission(perm Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString("user_id") == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+			c.Abort()
+			return
+		}
+		if !HasPermission(ContextRole(c), perm) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Permission denied"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
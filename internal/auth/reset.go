@@ -0,0 +1,152 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/mailer"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/database"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/models"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// ResetTokenTTL is how long a password reset email stays usable
+const ResetTokenTTL = time.Hour
+
+// ForgotPassword emails a reset token to the account with the given address.
+// Only verified addresses get one, since an unconfirmed address may not
+// belong to the account holder. The response is the same either way, so it
+// can't be used to find out who has signed up.
+func (h *Handler) ForgotPassword(c *gin.Context) {
+	var req models.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var user models.User
+	err := database.DB.QueryRow(`SELECT id, username, email FROM users WHERE email = ? AND email_verified = 1`, strings.TrimSpace(req.Email)).
+		Scan(&user.ID, &user.Username, &user.Email)
+	if err != nil && err != sql.ErrNoRows {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if err == nil {
+		token, err := newResetToken(user.ID, time.Now())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start password reset"})
+			return
+		}
+		// Sending in the background keeps the response time the same for
+		// unknown addresses
+		go h.sendResetEmail(user, token)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "If an account uses that email, a reset token is on its way"})
+}
+
+func (h *Handler) sendResetEmail(user models.User, token string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	err := h.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your MangaHub password",
+		Body: fmt.Sprintf(`Hi %s,
+
+Someone asked to reset the password for your MangaHub account. If it was
+you, run:
+
+    mangahub auth reset-password --token %s
+
+The token works once and expires in %d minutes. If you didn't ask for a
+reset, you can ignore this email.
+`, user.Username, token, int(ResetTokenTTL.Minutes())),
+	})
+	if err != nil {
+		log.Printf("Warning: failed to send password reset email to user %s: %v", user.ID, err)
+	}
+}
+
+// ResetPassword sets a new password using a token from ForgotPassword. It
+// also ends every session, so whoever knew the old password is logged out.
+func (h *Handler) ResetPassword(c *gin.Context) {
+	var req models.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validatePasswordStrength(req.NewPassword); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	newHash, err := utils.HashPassword(req.NewPassword)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+		return
+	}
+
+	now := time.Now()
+	tx, err := database.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	defer tx.Rollback()
+
+	var userID string
+	var expiresAt time.Time
+	var usedAt sql.NullTime
+	hash := hashToken(strings.TrimSpace(req.Token))
+	err = tx.QueryRow(`SELECT user_id, expires_at, used_at FROM password_resets WHERE token_hash = ?`, hash).
+		Scan(&userID, &expiresAt, &usedAt)
+	if err == sql.ErrNoRows || (err == nil && (usedAt.Valid || now.After(expiresAt))) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired reset token"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	if _, err := tx.Exec(`UPDATE password_resets SET used_at = ? WHERE token_hash = ?`, now, hash); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset password"})
+		return
+	}
+	if _, err := tx.Exec(`UPDATE users SET password_hash = ? WHERE id = ?`, newHash, userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset password"})
+		return
+	}
+	if _, err := tx.Exec(`UPDATE refresh_tokens SET revoked_at = ? WHERE user_id = ? AND revoked_at IS NULL`, now, userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset password"})
+		return
+	}
+	if _, err := tx.Exec(`UPDATE login_sessions SET revoked_at = ? WHERE user_id = ? AND revoked_at IS NULL`, now, userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset password"})
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset password"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password reset successfully, please log in again"})
+}
+
+// newResetToken stores a reset token for the user. Asking again replaces any
+// token that hasn't been used yet.
+func newResetToken(userID string, now time.Time) (string, error) {
+	token, err := utils.GenerateID(32)
+	if err != nil {
+		return "", err
+	}
+	database.DB.Exec(`DELETE FROM password_resets WHERE user_id = ? OR expires_at < ?`, userID, now)
+	_, err = database.DB.Exec(`INSERT INTO password_resets (token_hash, user_id, expires_at, created_at) VALUES (?, ?, ?, ?)`,
+		hashToken(token), userID, now.Add(ResetTokenTTL), now)
+	return token, err
+}
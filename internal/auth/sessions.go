@@ -0,0 +1,283 @@
+package auth
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/database"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/models"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// EventSessionRevoked is recorded when a user signs one of their sessions out
+const EventSessionRevoked = "session_revoked"
+
+// DeviceNameHeader lets a client name the device it logs in from
+const DeviceNameHeader = "X-Device-Name"
+
+// liveConnectionTTL is how long a live connection is listed after its
+// server last reported it, so connections of a crashed server drop out
+const liveConnectionTTL = time.Minute
+
+// SessionCheckInterval is how often the TCP and UDP servers look for
+// sessions signed out through the API, which runs in another process
+var SessionCheckInterval = 5 * time.Second
+
+// sessionClient is what a login session records about where it came from
+type sessionClient struct {
+	ip         string
+	userAgent  string
+	deviceName string
+}
+
+func clientOf(c *gin.Context) sessionClient {
+	return sessionClient{
+		ip:         c.ClientIP(),
+		userAgent:  c.Request.UserAgent(),
+		deviceName: strings.TrimSpace(c.GetHeader(DeviceNameHeader)),
+	}
+}
+
+// touchSession records that the session familyID was just used. Families
+// started before sessions were recorded get their row on the next refresh.
+func touchSession(exec execer, familyID, userID string, client sessionClient, now time.Time) error {
+	_, err := exec.Exec(`INSERT INTO login_sessions (id, user_id, ip, user_agent, device_name, created_at, last_seen_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET ip = excluded.ip, user_agent = excluded.user_agent,
+			device_name = COALESCE(excluded.device_name, login_sessions.device_name), last_seen_at = excluded.last_seen_at`,
+		familyID, userID, nullIfEmpty(client.ip), nullIfEmpty(client.userAgent), nullIfEmpty(client.deviceName), now, now)
+	return err
+}
+
+// endSession revokes a session's refresh tokens and marks it signed out, so
+// its access tokens stop working too. It reports whether there was anything
+// left to revoke.
+func endSession(exec execer, userID, familyID string, now time.Time) (bool, error) {
+	tokens, err := exec.Exec(`UPDATE refresh_tokens SET revoked_at = ? WHERE family_id = ? AND user_id = ? AND revoked_at IS NULL`,
+		now, familyID, userID)
+	if err != nil {
+		return false, err
+	}
+	session, err := exec.Exec(`UPDATE login_sessions SET revoked_at = ? WHERE id = ? AND user_id = ? AND revoked_at IS NULL`,
+		now, familyID, userID)
+	if err != nil {
+		return false, err
+	}
+	n, _ := tokens.RowsAffected()
+	m, _ := session.RowsAffected()
+	return n+m > 0, nil
+}
+
+// ListSessions lists where the current user is logged in, with the TCP and
+// UDP connections each session holds open
+func (h *Handler) ListSessions(c *gin.Context) {
+	userID := c.GetString("user_id")
+	var currentID string
+	if claims, ok := c.Get("claims"); ok {
+		currentID = claims.(*utils.JWTClaims).SessionID
+	}
+	now := time.Now()
+
+	rows, err := database.DB.Query(`SELECT id, ip, user_agent, device_name, created_at, last_seen_at FROM login_sessions s
+		WHERE user_id = ? AND revoked_at IS NULL
+		  AND EXISTS (SELECT 1 FROM refresh_tokens t WHERE t.family_id = s.id AND t.revoked_at IS NULL AND t.expires_at > ?)
+		ORDER BY last_seen_at DESC`, userID, now)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	defer rows.Close()
+
+	sessions := []models.LoginSession{}
+	index := map[string]int{}
+	for rows.Next() {
+		var s models.LoginSession
+		var ip, userAgent, deviceName sql.NullString
+		if err := rows.Scan(&s.ID, &ip, &userAgent, &deviceName, &s.CreatedAt, &s.LastSeenAt); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+		s.IP, s.UserAgent, s.DeviceName = ip.String, userAgent.String, deviceName.String
+		s.Current = s.ID == currentID
+		s.Connections = []models.LiveConnection{}
+		index[s.ID] = len(sessions)
+		sessions = append(sessions, s)
+	}
+	if err := rows.Err(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	conns, err := liveConnections(userID, now)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	for _, conn := range conns {
+		if i, ok := index[conn.SessionID]; ok {
+			sessions[i].Connections = append(sessions[i].Connections, conn)
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"sessions": sessions})
+}
+
+// RevokeSession signs one of the current user's sessions out. Its refresh
+// and access tokens stop working at once, and the TCP and UDP servers drop
+// its connections within SessionCheckInterval.
+func (h *Handler) RevokeSession(c *gin.Context) {
+	userID := c.GetString("user_id")
+	sessionID := c.Param("session_id")
+
+	ended, err := endSession(database.DB, userID, sessionID, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke session"})
+		return
+	}
+	if !ended {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		return
+	}
+	recordAuthEvent(EventSessionRevoked, userID, c.ClientIP(), sessionID)
+	c.JSON(http.StatusOK, gin.H{"message": "Session revoked"})
+}
+
+func liveConnections(userID string, now time.Time) ([]models.LiveConnection, error) {
+	rows, err := database.DB.Query(`SELECT id, session_id, transport, remote_addr, device_type, device_name, connected_at, last_seen_at
+		FROM live_connections WHERE user_id = ? AND last_seen_at > ? ORDER BY connected_at`, userID, now.Add(-liveConnectionTTL))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var conns []models.LiveConnection
+	for rows.Next() {
+		var conn models.LiveConnection
+		var deviceType, deviceName sql.NullString
+		if err := rows.Scan(&conn.ID, &conn.SessionID, &conn.Transport, &conn.RemoteAddr, &deviceType, &deviceName,
+			&conn.ConnectedAt, &conn.LastSeenAt); err != nil {
+			return nil, err
+		}
+		conn.DeviceType, conn.DeviceName = deviceType.String, deviceName.String
+		conns = append(conns, conn)
+	}
+	return conns, rows.Err()
+}
+
+// TrackConnection records a TCP or UDP connection made with a login
+// session's token so the session list can show it. It fills in conn.ID,
+// which the server reports back through WatchSessions and
+// UntrackConnection.
+func TrackConnection(conn *models.LiveConnection) error {
+	if database.DB == nil {
+		return nil
+	}
+	id, err := utils.GenerateID(16)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	database.DB.Exec(`DELETE FROM live_connections WHERE last_seen_at < ?`, now.Add(-liveConnectionTTL))
+	_, err = database.DB.Exec(`INSERT INTO live_connections (id, session_id, user_id, transport, remote_addr, device_type, device_name, connected_at, last_seen_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		id, conn.SessionID, conn.UserID, conn.Transport, conn.RemoteAddr,
+		nullIfEmpty(conn.DeviceType), nullIfEmpty(conn.DeviceName), now, now)
+	if err != nil {
+		return err
+	}
+	conn.ID, conn.ConnectedAt, conn.LastSeenAt = id, now, now
+	return nil
+}
+
+// SetConnectionDevice records the device a TCP client announced
+func SetConnectionDevice(id, deviceType, deviceName string) error {
+	if id == "" || database.DB == nil {
+		return nil
+	}
+	_, err := database.DB.Exec(`UPDATE live_connections SET device_type = ?, device_name = ? WHERE id = ?`,
+		nullIfEmpty(deviceType), nullIfEmpty(deviceName), id)
+	return err
+}
+
+// UntrackConnection forgets a connection that has closed
+func UntrackConnection(id string) error {
+	if id == "" || database.DB == nil {
+		return nil
+	}
+	_, err := database.DB.Exec(`DELETE FROM live_connections WHERE id = ?`, id)
+	return err
+}
+
+// WatchSessions runs until stop is closed. Every SessionCheckInterval it
+// asks connections for the IDs of the live connections a server tracks and
+// the login sessions its clients belong to, keeps the tracked connections
+// listed, and calls signedOut once for each session that has been revoked
+// so the server can close its connections.
+func WatchSessions(stop <-chan struct{}, connections func() (ids, sessionIDs []string), signedOut func(sessionID string)) {
+	ticker := time.NewTicker(SessionCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			ids, sessionIDs := connections()
+			revoked, err := checkSessions(ids, sessionIDs, time.Now())
+			if err != nil {
+				log.Printf("Warning: failed to check login sessions: %v", err)
+				continue
+			}
+			for _, sessionID := range revoked {
+				signedOut(sessionID)
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+// checkSessions marks ids as seen and returns which of sessionIDs have been
+// revoked
+func checkSessions(ids, sessionIDs []string, now time.Time) ([]string, error) {
+	if database.DB == nil {
+		return nil, nil
+	}
+	if len(ids) > 0 {
+		args := []interface{}{now}
+		for _, id := range ids {
+			args = append(args, id)
+		}
+		if _, err := database.DB.Exec(`UPDATE live_connections SET last_seen_at = ? WHERE id IN (`+placeholders(len(ids))+`)`, args...); err != nil {
+			return nil, err
+		}
+	}
+	if len(sessionIDs) == 0 {
+		return nil, nil
+	}
+
+	args := make([]interface{}, 0, len(sessionIDs))
+	for _, id := range sessionIDs {
+		args = append(args, id)
+	}
+	rows, err := database.DB.Query(`SELECT id FROM login_sessions WHERE revoked_at IS NOT NULL AND id IN (`+placeholders(len(sessionIDs))+`)`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var revoked []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		revoked = append(revoked, id)
+	}
+	return revoked, rows.Err()
+}
+
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
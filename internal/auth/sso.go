@@ -0,0 +1,607 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/oidc"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/database"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/models"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// oidcLoginTTL is how long the user has to sign in at the provider
+	oidcLoginTTL = 10 * time.Minute
+	// DeviceCodeTTL is how long `mangahub auth login --sso` waits for the
+	// browser sign-in
+	DeviceCodeTTL = 10 * time.Minute
+	// devicePollInterval is how often, in seconds, the CLI should poll
+	devicePollInterval = 5
+	// userCodeAlphabet leaves out vowels and look-alike characters so codes
+	// are easy to read out and never spell words
+	userCodeAlphabet = "BCDFGHJKLMNPQRSTVWXZ"
+	// deviceConfirmCookie ties the device confirmation form to the browser
+	// that was shown the page
+	deviceConfirmCookie = "mangahub_device_confirm"
+)
+
+var deviceConfirmPage = template.Must(template.New("confirm").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head><meta charset="utf-8"><title>Sign in to MangaHub</title></head>
+<body>
+<h1>Sign in a device to MangaHub</h1>
+<p>A device is asking to sign in to MangaHub as you. Only continue if you
+started this sign-in yourself and your terminal shows the same code.</p>
+<p>Code: <strong>{{.UserCode}}</strong></p>
+<p>Device: {{.Device}}<br>Address: {{.IP}}<br>Requested: {{.RequestedAt}}</p>
+<form method="post" action="login">
+<input type="hidden" name="user_code" value="{{.UserCode}}">
+<input type="hidden" name="confirm" value="{{.Confirm}}">
+<button type="submit">Continue sign-in</button>
+</form>
+<p>If you didn't start this, close this page.</p>
+</body>
+</html>
+`))
+
+// Auth event types for single sign-on
+const (
+	EventSSOLinked      = "sso_linked"
+	EventSSOProvisioned = "sso_provisioned"
+)
+
+var (
+	errSSONoEmail    = errors.New("the identity provider did not share an email address")
+	errSSOEmailInUse = errors.New("an account with this email already exists; log in with your password and verify the email first")
+	errSSONoAccount  = errors.New("no MangaHub account is linked to this identity")
+)
+
+// SetOIDC enables single sign-on through p. Without it the SSO endpoints
+// answer 503.
+func (h *Handler) SetOIDC(p *oidc.Provider) {
+	h.oidc = p
+}
+
+func (h *Handler) ssoConfigured(c *gin.Context) bool {
+	if h.oidc == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Single sign-on is not configured"})
+		return false
+	}
+	return true
+}
+
+// OIDCLogin sends the browser to the identity provider. With ?user_code=
+// it shows which device asked to sign in instead; the user has to confirm
+// on that page before the provider is involved, so a link sent by someone
+// else can't sign a victim into the sender's terminal.
+func (h *Handler) OIDCLogin(c *gin.Context) {
+	if !h.ssoConfigured(c) {
+		return
+	}
+
+	now := time.Now()
+	raw := c.Query("user_code")
+	if raw == "" {
+		h.startOIDCLogin(c, "", now, http.StatusFound)
+		return
+	}
+
+	userCode := normalizeUserCode(raw)
+	device, createdAt, err := pendingDeviceCode(userCode, now)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown or expired code, run `mangahub auth login --sso` again"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	confirm, err := utils.GenerateID(16)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start sign-in"})
+		return
+	}
+
+	var page bytes.Buffer
+	err = deviceConfirmPage.Execute(&page, map[string]string{
+		"UserCode":    userCode,
+		"Device":      describeDevice(device),
+		"IP":          device.ip,
+		"RequestedAt": createdAt.UTC().Format("2006-01-02 15:04 MST"),
+		"Confirm":     confirm,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start sign-in"})
+		return
+	}
+	c.SetSameSite(http.SameSiteStrictMode)
+	c.SetCookie(deviceConfirmCookie, confirm, int(oidcLoginTTL.Seconds()), "/auth/oidc", "", c.Request.TLS != nil, true)
+	c.Header("X-Frame-Options", "DENY")
+	c.Header("Content-Security-Policy", "frame-ancestors 'none'")
+	c.Data(http.StatusOK, "text/html; charset=utf-8", page.Bytes())
+}
+
+// OIDCConfirmDevice is the form on OIDCLogin's confirmation page. It starts
+// the provider sign-in for the pending device login.
+func (h *Handler) OIDCConfirmDevice(c *gin.Context) {
+	if !h.ssoConfigured(c) {
+		return
+	}
+	// The cookie is only sent from MangaHub's own page, so another site
+	// can't submit this form for the user
+	cookie, err := c.Cookie(deviceConfirmCookie)
+	if err != nil || cookie == "" || subtle.ConstantTimeCompare([]byte(cookie), []byte(c.PostForm("confirm"))) != 1 {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Open the sign-in link again and confirm on that page"})
+		return
+	}
+	c.SetCookie(deviceConfirmCookie, "", -1, "/auth/oidc", "", c.Request.TLS != nil, true)
+
+	now := time.Now()
+	userCode := normalizeUserCode(c.PostForm("user_code"))
+	_, _, err = pendingDeviceCode(userCode, now)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown or expired code, run `mangahub auth login --sso` again"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	h.startOIDCLogin(c, userCode, now, http.StatusSeeOther)
+}
+
+// startOIDCLogin records a sign-in and redirects to the identity provider
+func (h *Handler) startOIDCLogin(c *gin.Context, userCode string, now time.Time, status int) {
+	state, nonce, verifier, err := newOIDCLogin(userCode, now)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start sign-in"})
+		return
+	}
+	authURL, err := h.oidc.AuthorizationURL(c.Request.Context(), state, nonce, verifier)
+	if err != nil {
+		log.Printf("Warning: OIDC login failed: %v", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Identity provider is unavailable"})
+		return
+	}
+	c.Redirect(status, authURL)
+}
+
+// OIDCCallback is the provider's redirect target. It validates the ID token,
+// finds or creates the account and either returns a session or, for a
+// device login, hands the account to the waiting CLI. Accounts with
+// two-factor authentication get the same challenge as Login instead of a
+// session.
+func (h *Handler) OIDCCallback(c *gin.Context) {
+	if !h.ssoConfigured(c) {
+		return
+	}
+	if denied := c.Query("error"); denied != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Sign-in was not completed: " + denied})
+		return
+	}
+	code, state := c.Query("code"), c.Query("state")
+	if code == "" || state == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "code and state are required"})
+		return
+	}
+
+	now := time.Now()
+	nonce, verifier, userCode, err := takeOIDCLogin(state, now)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Sign-in expired, please start again"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	claims, err := h.oidc.Exchange(c.Request.Context(), code, verifier, nonce)
+	if err != nil {
+		log.Printf("Warning: OIDC callback rejected: %v", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Sign-in could not be verified"})
+		return
+	}
+
+	user, err := h.ssoUser(claims, c.ClientIP(), now)
+	switch {
+	case errors.Is(err, errSSONoEmail):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, errSSOEmailInUse):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, errSSONoAccount):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to sign in"})
+		return
+	}
+
+	if userCode != "" {
+		res, err := database.DB.Exec(`UPDATE oidc_device_codes SET user_id = ? WHERE user_code = ? AND user_id IS NULL AND expires_at > ?`,
+			user.ID, userCode, now)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown or expired code, run `mangahub auth login --sso` again"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"message":  fmt.Sprintf("Signed in as %s. Return to your terminal to finish.", user.Username),
+			"username": user.Username,
+		})
+		return
+	}
+
+	if user.TwoFactor {
+		h.ssoTwoFactor(c, user, now)
+		return
+	}
+	resp, err := h.newSession(c, user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// ssoTwoFactor answers a sign-in by an account with two-factor
+// authentication. The identity provider stands in for the password, so the
+// second step is still needed.
+func (h *Handler) ssoTwoFactor(c *gin.Context, user *models.User, now time.Time) {
+	challenge, err := newMFAChallenge(user.ID, now)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start two-factor login"})
+		return
+	}
+	c.JSON(http.StatusAccepted, challenge)
+}
+
+// OIDCDeviceStart begins a device login for the CLI
+func (h *Handler) OIDCDeviceStart(c *gin.Context) {
+	if !h.ssoConfigured(c) {
+		return
+	}
+	base, ok := linksConfigured(c)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	deviceCode, err := utils.GenerateID(32)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start device login"})
+		return
+	}
+	database.DB.Exec(`DELETE FROM oidc_device_codes WHERE expires_at < ?`, now)
+
+	client := clientOf(c)
+	// A clash with a pending code is unlikely but possible, so try again
+	var userCode string
+	for i := 0; i < 5; i++ {
+		if userCode, err = newUserCode(); err != nil {
+			break
+		}
+		_, err = database.DB.Exec(`INSERT INTO oidc_device_codes (device_code_hash, user_code, ip, user_agent, device_name, expires_at, created_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			hashToken(deviceCode), userCode, nullIfEmpty(client.ip), nullIfEmpty(client.userAgent), nullIfEmpty(client.deviceName),
+			now.Add(DeviceCodeTTL), now)
+		if err == nil || !strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			break
+		}
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start device login"})
+		return
+	}
+
+	verifyURI := base + "/auth/oidc/login"
+	c.JSON(http.StatusOK, models.DeviceAuthorization{
+		DeviceCode:              deviceCode,
+		UserCode:                userCode,
+		VerificationURI:         verifyURI,
+		VerificationURIComplete: verifyURI + "?user_code=" + url.QueryEscape(userCode),
+		ExpiresIn:               int(DeviceCodeTTL.Seconds()),
+		Interval:                devicePollInterval,
+	})
+}
+
+// OIDCDeviceToken is polled by the CLI. It answers 202 until the browser
+// sign-in has finished and then returns a session, once. Accounts with
+// two-factor authentication get a 202 with the two-factor challenge instead.
+func (h *Handler) OIDCDeviceToken(c *gin.Context) {
+	if !h.ssoConfigured(c) {
+		return
+	}
+	var req models.DeviceTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	now := time.Now()
+	hash := hashToken(strings.TrimSpace(req.DeviceCode))
+	var userID sql.NullString
+	var expiresAt time.Time
+	err := database.DB.QueryRow(`SELECT user_id, expires_at FROM oidc_device_codes WHERE device_code_hash = ?`, hash).
+		Scan(&userID, &expiresAt)
+	if err == sql.ErrNoRows || (err == nil && now.After(expiresAt)) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Device login expired, please start again"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if !userID.Valid {
+		c.JSON(http.StatusAccepted, gin.H{"status": "authorization_pending"})
+		return
+	}
+
+	// Deleting first means two pollers can't both get a session
+	res, err := database.DB.Exec(`DELETE FROM oidc_device_codes WHERE device_code_hash = ?`, hash)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Device login expired, please start again"})
+		return
+	}
+
+	user, err := loadUser(userID.String)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if user.TwoFactor {
+		h.ssoTwoFactor(c, user, now)
+		return
+	}
+	resp, err := h.newSession(c, user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// ssoUser finds the account for a verified ID token. Identities already
+// seen map straight to their account. A new identity is linked to the
+// account with the same email only when both the provider and MangaHub
+// have verified that address, so nobody can pre-register a victim's email
+// and wait for them to sign in. Otherwise a new account is created if the
+// provider allows sign-ups.
+func (h *Handler) ssoUser(claims *oidc.Claims, ip string, now time.Time) (*models.User, error) {
+	issuer := h.oidc.Config().Issuer
+
+	var userID string
+	err := database.DB.QueryRow(`SELECT user_id FROM user_identities WHERE issuer = ? AND subject = ?`, issuer, claims.Subject).
+		Scan(&userID)
+	if err == nil {
+		database.DB.Exec(`UPDATE user_identities SET email = ?, last_login_at = ? WHERE issuer = ? AND subject = ?`,
+			claims.Email, now, issuer, claims.Subject)
+		return loadUser(userID)
+	}
+	if err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	email := strings.TrimSpace(claims.Email)
+	if email == "" {
+		return nil, errSSONoEmail
+	}
+
+	var verified bool
+	err = database.DB.QueryRow(`SELECT id, email_verified FROM users WHERE email = ?`, email).Scan(&userID, &verified)
+	switch {
+	case err == nil && verified && claims.Verified():
+		if err := linkIdentity(issuer, claims.Subject, userID, email, now); err != nil {
+			return nil, err
+		}
+		recordAuthEvent(EventSSOLinked, userID, ip, issuer)
+		return loadUser(userID)
+	case err == nil:
+		return nil, errSSOEmailInUse
+	case err != sql.ErrNoRows:
+		return nil, err
+	}
+
+	if !h.oidc.Config().AllowSignup {
+		return nil, errSSONoAccount
+	}
+	user, err := provisionUser(claims, email)
+	if err != nil {
+		return nil, err
+	}
+	if err := linkIdentity(issuer, claims.Subject, user.ID, email, now); err != nil {
+		return nil, err
+	}
+	recordAuthEvent(EventSSOProvisioned, user.ID, ip, issuer)
+	return user, nil
+}
+
+// provisionUser creates an account for someone signing in for the first
+// time. It gets a random password nobody knows; the owner can set one
+// through the password reset flow.
+func provisionUser(claims *oidc.Claims, email string) (*models.User, error) {
+	userID, err := utils.GenerateID(16)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := utils.GenerateID(32)
+	if err != nil {
+		return nil, err
+	}
+	hashedPassword, err := utils.HashPassword(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	base := ssoUsername(claims.PreferredUsername)
+	if base == "" {
+		base = ssoUsername(strings.SplitN(email, "@", 2)[0])
+	}
+	if base == "" {
+		base = "reader"
+	}
+
+	// Taken usernames get a number on the end
+	for i := 1; i <= 50; i++ {
+		username := base
+		if i > 1 {
+			username = fmt.Sprintf("%s%d", base, i)
+		}
+		_, err = database.DB.Exec(`INSERT INTO users (id, username, email, password_hash, email_verified) VALUES (?, ?, ?, ?, ?)`,
+			userID, username, email, hashedPassword, claims.Verified())
+		if err == nil {
+			return loadUser(userID)
+		}
+		if strings.Contains(err.Error(), "UNIQUE constraint failed: users.email") {
+			return nil, errSSOEmailInUse
+		}
+		if !strings.Contains(err.Error(), "UNIQUE constraint failed: users.username") {
+			return nil, err
+		}
+	}
+	return nil, fmt.Errorf("no free username for %q", base)
+}
+
+// ssoUsername keeps the characters of a provider's username that are safe
+// to show and type
+func ssoUsername(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '_' || r == '-' || r == '.' {
+			b.WriteRune(r)
+		}
+		if b.Len() == 30 {
+			break
+		}
+	}
+	return strings.Trim(b.String(), ".-")
+}
+
+func linkIdentity(issuer, subject, userID, email string, now time.Time) error {
+	_, err := database.DB.Exec(`INSERT INTO user_identities (issuer, subject, user_id, email, created_at, last_login_at) VALUES (?, ?, ?, ?, ?, ?)`,
+		issuer, subject, userID, email, now, now)
+	return err
+}
+
+func loadUser(userID string) (*models.User, error) {
+	var user models.User
+	err := database.DB.QueryRow(`SELECT id, username, email, email_verified, totp_enabled, role, created_at FROM users WHERE id = ?`, userID).
+		Scan(&user.ID, &user.Username, &user.Email, &user.EmailVerified, &user.TwoFactor, &user.Role, &user.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// pendingDeviceCode returns who asked for userCode and when. A code that is
+// unknown, expired or already signed in is sql.ErrNoRows.
+func pendingDeviceCode(userCode string, now time.Time) (sessionClient, time.Time, error) {
+	var ip, userAgent, deviceName, userID sql.NullString
+	var expiresAt, createdAt time.Time
+	err := database.DB.QueryRow(`SELECT ip, user_agent, device_name, user_id, expires_at, created_at FROM oidc_device_codes WHERE user_code = ?`, userCode).
+		Scan(&ip, &userAgent, &deviceName, &userID, &expiresAt, &createdAt)
+	if err != nil {
+		return sessionClient{}, time.Time{}, err
+	}
+	if now.After(expiresAt) || userID.Valid {
+		return sessionClient{}, time.Time{}, sql.ErrNoRows
+	}
+	return sessionClient{ip: ip.String, userAgent: userAgent.String, deviceName: deviceName.String}, createdAt, nil
+}
+
+// describeDevice names a device for the confirmation page
+func describeDevice(client sessionClient) string {
+	switch {
+	case client.deviceName != "" && client.userAgent != "":
+		return client.deviceName + " (" + client.userAgent + ")"
+	case client.deviceName != "":
+		return client.deviceName
+	case client.userAgent != "":
+		return client.userAgent
+	}
+	return "unknown"
+}
+
+// newOIDCLogin stores the state, nonce and PKCE verifier for one sign-in
+func newOIDCLogin(userCode string, now time.Time) (state, nonce, verifier string, err error) {
+	if state, err = utils.GenerateID(16); err != nil {
+		return "", "", "", err
+	}
+	if nonce, err = utils.GenerateID(16); err != nil {
+		return "", "", "", err
+	}
+	// 96 hex characters, within PKCE's 43-128 character limit
+	if verifier, err = utils.GenerateID(48); err != nil {
+		return "", "", "", err
+	}
+
+	database.DB.Exec(`DELETE FROM oidc_logins WHERE expires_at < ?`, now)
+	_, err = database.DB.Exec(`INSERT INTO oidc_logins (state, nonce, code_verifier, user_code, expires_at, created_at) VALUES (?, ?, ?, ?, ?, ?)`,
+		state, nonce, verifier, nullIfEmpty(userCode), now.Add(oidcLoginTTL), now)
+	return state, nonce, verifier, err
+}
+
+// takeOIDCLogin returns and deletes the sign-in for state, so a callback
+// can't be replayed. An unknown or expired state is sql.ErrNoRows.
+func takeOIDCLogin(state string, now time.Time) (nonce, verifier, userCode string, err error) {
+	var code sql.NullString
+	var expiresAt time.Time
+	err = database.DB.QueryRow(`SELECT nonce, code_verifier, user_code, expires_at FROM oidc_logins WHERE state = ?`, state).
+		Scan(&nonce, &verifier, &code, &expiresAt)
+	if err != nil {
+		return "", "", "", err
+	}
+	database.DB.Exec(`DELETE FROM oidc_logins WHERE state = ?`, state)
+	if now.After(expiresAt) {
+		return "", "", "", sql.ErrNoRows
+	}
+	return nonce, verifier, code.String, nil
+}
+
+// newUserCode makes a code like BDFG-HJKL for the user to check in the
+// browser
+func newUserCode() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	code := make([]byte, 0, 9)
+	for i, v := range b {
+		if i == 4 {
+			code = append(code, '-')
+		}
+		// 256 is not a multiple of 20, but the bias is too small to matter
+		code = append(code, userCodeAlphabet[int(v)%len(userCodeAlphabet)])
+	}
+	return string(code), nil
+}
+
+// normalizeUserCode accepts codes typed in lower case or without the dash
+func normalizeUserCode(code string) string {
+	code = strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(code))
+	if len(code) == 8 {
+		code = code[:4] + "-" + code[4:]
+	}
+	return code
+}
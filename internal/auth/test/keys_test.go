@@ -0,0 +1,189 @@
+package auth_test
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/auth"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/database"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/models"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+func addGeneratedKey(t *testing.T, keys *auth.KeyManager, kid, alg string) {
+	pemData, err := auth.GenerateKeyPEM(alg)
+	if err != nil {
+		t.Fatalf("GenerateKeyPEM(%s): %v", alg, err)
+	}
+	if err := keys.AddPEM(kid, pemData); err != nil {
+		t.Fatalf("AddPEM(%s): %v", kid, err)
+	}
+}
+
+func signFor(t *testing.T, keys *auth.KeyManager) string {
+	claims, err := utils.NewAccessClaims("user-1", "reader", "")
+	if err != nil {
+		t.Fatalf("NewAccessClaims: %v", err)
+	}
+	token, err := keys.Sign(claims)
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	return token
+}
+
+// legacyToken signs an HS256 token without a kid, as tokens were issued
+// before keys had IDs
+func legacyToken(t *testing.T, secret string) string {
+	claims, err := utils.NewAccessClaims("user-1", "reader", "")
+	if err != nil {
+		t.Fatalf("NewAccessClaims: %v", err)
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+	if err != nil {
+		t.Fatalf("SignedString: %v", err)
+	}
+	return token
+}
+
+func TestKeyRotation(t *testing.T) {
+	if err := database.InitDatabase(t.TempDir() + "/test.db"); err != nil {
+		t.Fatalf("init db: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	keys := auth.NewKeyManager()
+	addGeneratedKey(t, keys, "2026-01-rsa", "RS256")
+	addGeneratedKey(t, keys, "2026-02-ed", "EdDSA")
+	keys.SetActive("2026-01-rsa")
+	old := signFor(t, keys)
+
+	keys.SetActive("2026-02-ed")
+	current := signFor(t, keys)
+	parsed, _, _ := jwt.NewParser().ParseUnverified(current, &utils.JWTClaims{})
+	if parsed.Header["kid"] != "2026-02-ed" || parsed.Method.Alg() != "EdDSA" {
+		t.Fatalf("new token header = %v", parsed.Header)
+	}
+
+	// Tokens from the previous key keep working until it is removed
+	for _, token := range []string{old, current} {
+		if claims, err := auth.ValidateToken(token, keys); err != nil || claims.UserID != "user-1" {
+			t.Fatalf("ValidateToken after rotation: %v", err)
+		}
+	}
+	if err := keys.Remove("2026-02-ed"); err == nil {
+		t.Fatalf("removing the active key should fail")
+	}
+	keys.Remove("2026-01-rsa")
+	if _, err := auth.ValidateToken(old, keys); err == nil {
+		t.Fatalf("token from a removed key was accepted")
+	}
+	if _, err := auth.ValidateToken(current, keys); err != nil {
+		t.Fatalf("current token rejected: %v", err)
+	}
+}
+
+func TestKeyManagerRejectsForgedTokens(t *testing.T) {
+	keys := auth.NewKeyManager()
+	addGeneratedKey(t, keys, "rsa", "RS256")
+	keys.SetActive("rsa")
+
+	// Tokens without a kid only verify against a legacy key
+	legacy := legacyToken(t, "test-secret")
+	if _, err := keys.Parse(legacy); err == nil {
+		t.Fatalf("kid-less token accepted without a legacy key")
+	}
+	if _, err := testKeys.Parse(legacy); err != nil {
+		t.Fatalf("kid-less token rejected by the legacy key: %v", err)
+	}
+
+	// An HS256 token keyed with the published RSA key must not pass as RS256
+	jwk := keys.JWKS().Keys[0]
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, &utils.JWTClaims{UserID: "admin"})
+	forged.Header["kid"] = "rsa"
+	signed, _ := forged.SignedString([]byte(jwk.N))
+	if _, err := keys.Parse(signed); err == nil {
+		t.Fatalf("algorithm confusion token accepted")
+	}
+}
+
+func TestJWKSVerifiesTokens(t *testing.T) {
+	keys := auth.KeysFromSecret("test-secret")
+	addGeneratedKey(t, keys, "rsa", "RS256")
+	addGeneratedKey(t, keys, "ed", "EdDSA")
+	keys.SetActive("rsa")
+	token := signFor(t, keys)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/.well-known/jwks.json", auth.NewHandler(keys).JWKS)
+	w := do(r, http.MethodGet, "/.well-known/jwks.json", "", nil)
+	var set models.JSONWebKeySet
+	decode(t, w, &set)
+	if len(set.Keys) != 2 || set.Keys[0].Kid != "ed" || set.Keys[0].Kty != "OKP" || set.Keys[1].Kty != "RSA" {
+		t.Fatalf("JWKS = %+v, want the ed and rsa public keys only", set)
+	}
+
+	// Another service can check the token with nothing but the JWKS
+	n, _ := base64.RawURLEncoding.DecodeString(set.Keys[1].N)
+	e, _ := base64.RawURLEncoding.DecodeString(set.Keys[1].E)
+	pub := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	if _, err := jwt.Parse(token, func(*jwt.Token) (interface{}, error) { return pub, nil }); err != nil {
+		t.Fatalf("token does not verify with the published key: %v", err)
+	}
+}
+
+func TestKeysFromEnv(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"2026-01", "2026-03"} {
+		pemData, _ := auth.GenerateKeyPEM("EdDSA")
+		os.WriteFile(filepath.Join(dir, name+".pem"), pemData, 0600)
+	}
+	t.Setenv("JWT_KEYS_DIR", dir)
+	t.Setenv("JWT_SECRET", "old-secret")
+	t.Setenv("JWT_ACTIVE_KEY", "")
+
+	keys, err := auth.KeysFromEnv()
+	if err != nil {
+		t.Fatalf("KeysFromEnv: %v", err)
+	}
+	if keys.ActiveKeyID() != "2026-03" {
+		t.Fatalf("active key = %q, want the newest file", keys.ActiveKeyID())
+	}
+	// Sessions signed with the old secret survive the move to key files
+	legacy := legacyToken(t, "old-secret")
+	if _, err := keys.Parse(legacy); err != nil {
+		t.Fatalf("old HS256 token rejected: %v", err)
+	}
+
+	t.Setenv("JWT_ACTIVE_KEY", "missing")
+	if _, err := auth.KeysFromEnv(); err == nil {
+		t.Fatalf("unknown JWT_ACTIVE_KEY accepted")
+	}
+}
+
+func TestKeysFromEnvNeedsOptInForDevSecret(t *testing.T) {
+	t.Setenv("JWT_KEYS_DIR", "")
+	t.Setenv("JWT_SECRET", "")
+	t.Setenv("JWT_ACTIVE_KEY", "")
+
+	t.Setenv("JWT_ALLOW_DEV_SECRET", "")
+	if _, err := auth.KeysFromEnv(); err == nil {
+		t.Fatalf("fell back to the development secret without the opt-in")
+	}
+	t.Setenv("JWT_ALLOW_DEV_SECRET", "true")
+	keys, err := auth.KeysFromEnv()
+	if err != nil {
+		t.Fatalf("KeysFromEnv with the opt-in: %v", err)
+	}
+	if keys.ActiveKeyID() != auth.SecretKeyID {
+		t.Fatalf("active key = %q, want the development secret", keys.ActiveKeyID())
+	}
+}
@@ -0,0 +1,153 @@
+package auth_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/auth"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/database"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+func login(r *gin.Engine, username, password string) (int, string) {
+	w := do(r, http.MethodPost, "/auth/login", "", gin.H{"username": username, "password": password})
+	var body struct {
+		Error string `json:"error"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &body)
+	return w.Code, body.Error
+}
+
+// skipBackoff lets the next attempt through without waiting out the delay
+func skipBackoff(t *testing.T) {
+	if _, err := database.DB.Exec(`UPDATE login_attempts SET locked_until = NULL`); err != nil {
+		t.Fatalf("clear backoff: %v", err)
+	}
+}
+
+func TestLoginFailuresLookTheSame(t *testing.T) {
+	r := setupAuthTest(t)
+	register(t, r)
+
+	for i := 1; i <= 4; i++ {
+		known, knownErr := login(r, "reader", "Wrong123")
+		unknown, unknownErr := login(r, "nobody", "Wrong123")
+		if known != http.StatusUnauthorized || known != unknown || knownErr != unknownErr {
+			t.Fatalf("attempt %d: known %d %q, unknown %d %q", i, known, knownErr, unknown, unknownErr)
+		}
+	}
+
+	// Past the free attempts each failure imposes a wait, for real and
+	// made-up accounts alike, and even the right password is refused
+	w := do(r, http.MethodPost, "/auth/login", "", gin.H{"username": "reader", "password": "Secret123"})
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" {
+		t.Fatalf("during backoff: got %d, want 429 with Retry-After", w.Code)
+	}
+	if code, _ := login(r, "nobody", "Wrong123"); code != http.StatusTooManyRequests {
+		t.Fatalf("unknown account during backoff: got %d, want 429", code)
+	}
+
+	skipBackoff(t)
+	if code, _ := login(r, "reader", "Secret123"); code != http.StatusOK {
+		t.Fatalf("login after backoff: got %d, want 200", code)
+	}
+	var rows int
+	database.DB.QueryRow(`SELECT COUNT(*) FROM login_attempts WHERE attempt_key LIKE 'user:%'`).Scan(&rows)
+	if rows != 0 {
+		t.Fatalf("a successful login should clear the account's failures")
+	}
+}
+
+func TestAccountLockoutAndUnlock(t *testing.T) {
+	r := setupAuthTest(t)
+	h := auth.NewHandler(testKeys)
+	admin := r.Group("/admin", auth.AuthMiddleware(testKeys), auth.RequirePermission(auth.PermManageRoles))
+	admin.DELETE("/users/:username/lockout", h.UnlockAccount)
+	admin.GET("/auth-events", h.GetAuthEvents)
+
+	session := register(t, r)
+	if _, err := auth.BootstrapAdmin("boss", "boss@example.com", "Secret123"); err != nil {
+		t.Fatalf("BootstrapAdmin: %v", err)
+	}
+	w := do(r, http.MethodPost, "/auth/login", "", gin.H{"username": "boss", "password": "Secret123"})
+	var boss models.AuthResponse
+	decode(t, w, &boss)
+
+	for i := 0; i < 10; i++ {
+		skipBackoff(t)
+		login(r, "reader", "Wrong123")
+	}
+	var lockedUntil string
+	database.DB.QueryRow(`SELECT locked_until FROM login_attempts WHERE attempt_key = ?`, "user:"+session.UserID).Scan(&lockedUntil)
+	if lockedUntil == "" {
+		t.Fatalf("account should be locked after 10 failures")
+	}
+	if code, _ := login(r, "reader", "Secret123"); code != http.StatusTooManyRequests {
+		t.Fatalf("locked account: got %d, want 429", code)
+	}
+
+	if w := do(r, http.MethodDelete, "/admin/users/reader/lockout", session.Token, nil); w.Code != http.StatusForbidden {
+		t.Fatalf("user unlocking: got %d, want 403", w.Code)
+	}
+	if w := do(r, http.MethodDelete, "/admin/users/reader/lockout", boss.Token, nil); w.Code != http.StatusOK {
+		t.Fatalf("unlock: %d %s", w.Code, w.Body.String())
+	}
+	if code, _ := login(r, "reader", "Secret123"); code != http.StatusOK {
+		t.Fatalf("login after unlock: got %d, want 200", code)
+	}
+
+	w = do(r, http.MethodGet, "/admin/auth-events", boss.Token, nil)
+	var resp struct {
+		Events []auth.AuthEvent `json:"events"`
+	}
+	decode(t, w, &resp)
+	if len(resp.Events) != 2 || resp.Events[0].Event != auth.EventUnlocked || resp.Events[1].Event != auth.EventAccountLocked {
+		t.Fatalf("audit events = %+v, want unlock then lock", resp.Events)
+	}
+	if resp.Events[1].UserID != session.UserID {
+		t.Fatalf("lock event user = %q, want %q", resp.Events[1].UserID, session.UserID)
+	}
+}
+
+func TestUnlockAccountAndAddress(t *testing.T) {
+	r := setupAuthTest(t)
+	h := auth.NewHandler(testKeys)
+	admin := r.Group("/admin", auth.AuthMiddleware(testKeys), auth.RequirePermission(auth.PermManageRoles))
+	admin.DELETE("/users/:username/lockout", h.UnlockAccount)
+
+	register(t, r)
+	if _, err := auth.BootstrapAdmin("boss", "boss@example.com", "Secret123"); err != nil {
+		t.Fatalf("BootstrapAdmin: %v", err)
+	}
+	w := do(r, http.MethodPost, "/auth/login", "", gin.H{"username": "boss", "password": "Secret123"})
+	var boss models.AuthResponse
+	decode(t, w, &boss)
+
+	for i := 0; i < 10; i++ {
+		skipBackoff(t)
+		login(r, "reader", "Wrong123")
+	}
+	// Locking the address for real takes far more failures than the account
+	if _, err := database.DB.Exec(`UPDATE login_attempts SET locked_until = datetime('now', '+15 minutes') WHERE attempt_key = 'ip:192.0.2.1'`); err != nil {
+		t.Fatalf("lock address: %v", err)
+	}
+
+	if w := do(r, http.MethodDelete, "/admin/users/reader/lockout?ip=not-an-ip", boss.Token, nil); w.Code != http.StatusBadRequest {
+		t.Fatalf("bad ip: got %d, want 400", w.Code)
+	}
+	if w := do(r, http.MethodDelete, "/admin/users/reader/lockout", boss.Token, nil); w.Code != http.StatusOK {
+		t.Fatalf("unlock account: %d %s", w.Code, w.Body.String())
+	}
+	if code, _ := login(r, "reader", "Secret123"); code != http.StatusTooManyRequests {
+		t.Fatalf("address still locked: got %d, want 429", code)
+	}
+
+	if w := do(r, http.MethodDelete, "/admin/users/reader/lockout?ip=192.0.2.1", boss.Token, nil); w.Code != http.StatusOK {
+		t.Fatalf("unlock address: %d %s", w.Code, w.Body.String())
+	}
+	if code, _ := login(r, "reader", "Secret123"); code != http.StatusOK {
+		t.Fatalf("login after unlocking both: got %d, want 200", code)
+	}
+}
@@ -0,0 +1,120 @@
+package auth_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/auth"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+func setupTokenTest(t *testing.T) *gin.Engine {
+	r := setupAuthTest(t)
+	h := auth.NewHandler(testKeys)
+	ok := func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"user_id": c.GetString("user_id")}) }
+
+	users := r.Group("/users", auth.AuthMiddleware(testKeys))
+	users.GET("/me/tokens", h.ListTokens)
+	users.POST("/me/tokens", h.CreateToken)
+	users.DELETE("/me/tokens/:token_id", h.RevokePersonalToken)
+	users.GET("/library", ok)
+	users.POST("/library", ok)
+	users.PUT("/progress", ok)
+	users.PUT("/me/preferences", ok)
+	r.GET("/manga/:id", auth.OptionalAuthMiddleware(testKeys), ok)
+	return r
+}
+
+func createToken(t *testing.T, r *gin.Engine, session string, name string, scopes ...string) models.CreateTokenResponse {
+	w := do(r, http.MethodPost, "/users/me/tokens", session, gin.H{"name": name, "scopes": scopes})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create token: %d %s", w.Code, w.Body.String())
+	}
+	var created models.CreateTokenResponse
+	decode(t, w, &created)
+	if !strings.HasPrefix(created.Token, auth.PersonalTokenPrefix) {
+		t.Fatalf("token %q lacks the %s prefix", created.Token, auth.PersonalTokenPrefix)
+	}
+	return created
+}
+
+func TestPersonalTokenScopes(t *testing.T) {
+	r := setupTokenTest(t)
+	session := register(t, r)
+	pat := createToken(t, r, session.Token, "backup", "library:write")
+
+	cases := []struct {
+		method, path string
+		want         int
+	}{
+		{http.MethodGet, "/users/library", http.StatusOK},  // write includes read
+		{http.MethodPost, "/users/library", http.StatusOK}, // library:write
+		{http.MethodPut, "/users/progress", http.StatusForbidden},
+		{http.MethodPut, "/users/me/preferences", http.StatusForbidden},
+		{http.MethodGet, "/users/me/tokens", http.StatusForbidden}, // tokens can't manage tokens
+		{http.MethodPost, "/auth/logout", http.StatusForbidden},
+	}
+	for _, tc := range cases {
+		if w := do(r, tc.method, tc.path, pat.Token, nil); w.Code != tc.want {
+			t.Errorf("%s %s: got %d, want %d", tc.method, tc.path, w.Code, tc.want)
+		}
+	}
+
+	// Optional auth treats an out-of-scope token as anonymous
+	w := do(r, http.MethodGet, "/manga/1", pat.Token, nil)
+	var anon struct {
+		UserID string `json:"user_id"`
+	}
+	decode(t, w, &anon)
+	if w.Code != http.StatusOK || anon.UserID != "" {
+		t.Fatalf("optional auth with out-of-scope token: %d %+v", w.Code, anon)
+	}
+
+	claims, err := auth.ValidateToken(pat.Token, testKeys)
+	if err != nil || claims.UserID != session.UserID || !auth.IsPersonalToken(claims) {
+		t.Fatalf("ValidateToken = %+v, %v", claims, err)
+	}
+}
+
+func TestPersonalTokenLifecycle(t *testing.T) {
+	r := setupTokenTest(t)
+	session := register(t, r)
+
+	w := do(r, http.MethodPost, "/users/me/tokens", session.Token, gin.H{"name": "bad", "scopes": []string{"admin:all"}})
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("unknown scope: got %d, want 400", w.Code)
+	}
+	pat := createToken(t, r, session.Token, "reader-script", "library:read")
+	w = do(r, http.MethodPost, "/users/me/tokens", session.Token, gin.H{"name": "reader-script", "scopes": []string{"library:read"}})
+	if w.Code != http.StatusConflict {
+		t.Fatalf("duplicate name: got %d, want 409", w.Code)
+	}
+
+	if w := do(r, http.MethodGet, "/users/library", pat.Token, nil); w.Code != http.StatusOK {
+		t.Fatalf("use token: %d", w.Code)
+	}
+
+	w = do(r, http.MethodGet, "/users/me/tokens", session.Token, nil)
+	var list struct {
+		Tokens []models.PersonalAccessToken `json:"tokens"`
+	}
+	decode(t, w, &list)
+	if len(list.Tokens) != 1 || list.Tokens[0].ID != pat.ID || list.Tokens[0].LastUsedAt == nil {
+		t.Fatalf("token list = %+v, want one used token", list.Tokens)
+	}
+	if strings.Contains(w.Body.String(), pat.Token) {
+		t.Fatalf("token list must not include the secret")
+	}
+
+	if w := do(r, http.MethodDelete, "/users/me/tokens/"+pat.ID, session.Token, nil); w.Code != http.StatusOK {
+		t.Fatalf("revoke: %d %s", w.Code, w.Body.String())
+	}
+	if w := do(r, http.MethodGet, "/users/library", pat.Token, nil); w.Code != http.StatusUnauthorized {
+		t.Fatalf("revoked token: got %d, want 401", w.Code)
+	}
+	if w := do(r, http.MethodDelete, "/users/me/tokens/"+pat.ID, session.Token, nil); w.Code != http.StatusNotFound {
+		t.Fatalf("revoking twice: got %d, want 404", w.Code)
+	}
+}
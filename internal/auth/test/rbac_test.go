@@ -0,0 +1,112 @@
+package auth_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/auth"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/database"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+func TestHasPermission(t *testing.T) {
+	cases := []struct {
+		role models.Role
+		perm auth.Permission
+		want bool
+	}{
+		{models.RoleUser, auth.PermModerateContent, false},
+		{models.RoleModerator, auth.PermModerateContent, true},
+		{models.RoleModerator, auth.PermViewSessions, true},
+		{models.RoleModerator, auth.PermManageRoles, false},
+		{models.RoleAdmin, auth.PermModerateContent, true},
+		{models.RoleAdmin, auth.PermBroadcast, true},
+		{models.RoleAdmin, auth.PermManageRoles, true},
+		{"", auth.PermModerateContent, false},
+		{"superuser", auth.PermManageRoles, false},
+	}
+	for _, tc := range cases {
+		if got := auth.HasPermission(tc.role, tc.perm); got != tc.want {
+			t.Errorf("HasPermission(%q, %q) = %v, want %v", tc.role, tc.perm, got, tc.want)
+		}
+	}
+}
+
+func setupAdminTest(t *testing.T) *gin.Engine {
+	r := setupAuthTest(t)
+	h := auth.NewHandler(testKeys)
+	admin := r.Group("/admin", auth.AuthMiddleware(testKeys), auth.RequirePermission(auth.PermManageRoles))
+	admin.PUT("/users/:username/role", h.SetRole)
+	return r
+}
+
+func TestSetRoleRequiresAdmin(t *testing.T) {
+	r := setupAdminTest(t)
+	session := register(t, r)
+	if session.Role != models.RoleUser {
+		t.Fatalf("new accounts should be users, got %q", session.Role)
+	}
+
+	w := do(r, http.MethodPut, "/admin/users/reader/role", session.Token, gin.H{"role": "admin"})
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("user setting a role: got %d, want 403", w.Code)
+	}
+
+	created, err := auth.BootstrapAdmin("boss", "boss@example.com", "Secret123")
+	if err != nil || !created {
+		t.Fatalf("BootstrapAdmin = %v, %v", created, err)
+	}
+	if _, err := auth.BootstrapAdmin("reader", "", ""); err != auth.ErrAdminExists {
+		t.Fatalf("second bootstrap error = %v, want ErrAdminExists", err)
+	}
+
+	w = do(r, http.MethodPost, "/auth/login", "", gin.H{"username": "boss", "password": "Secret123"})
+	var boss models.AuthResponse
+	decode(t, w, &boss)
+	if boss.Role != models.RoleAdmin {
+		t.Fatalf("bootstrapped account role = %q, want admin", boss.Role)
+	}
+
+	w = do(r, http.MethodPut, "/admin/users/reader/role", boss.Token, gin.H{"role": "moderator"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("admin setting a role: %d %s", w.Code, w.Body.String())
+	}
+	w = do(r, http.MethodPut, "/admin/users/reader/role", boss.Token, gin.H{"role": "owner"})
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("unknown role: got %d, want 400", w.Code)
+	}
+	w = do(r, http.MethodPut, "/admin/users/boss/role", boss.Token, gin.H{"role": "user"})
+	if w.Code != http.StatusConflict {
+		t.Fatalf("demoting the last admin: got %d, want 409", w.Code)
+	}
+
+	// The new role reaches the user's tokens on their next refresh
+	_, refreshed := refresh(r, session.RefreshToken)
+	if refreshed.Role != models.RoleModerator {
+		t.Fatalf("refreshed role = %q, want moderator", refreshed.Role)
+	}
+	claims, err := auth.ValidateToken(refreshed.Token, testKeys)
+	if err != nil || claims.Role != string(models.RoleModerator) {
+		t.Fatalf("refreshed token role = %+v, %v", claims, err)
+	}
+}
+
+func TestBootstrapAdminPromotesExistingUser(t *testing.T) {
+	r := setupAuthTest(t)
+	register(t, r)
+
+	created, err := auth.BootstrapAdmin("reader", "", "")
+	if err != nil || created {
+		t.Fatalf("BootstrapAdmin = %v, %v", created, err)
+	}
+	var role string
+	database.DB.QueryRow(`SELECT role FROM users WHERE username = 'reader'`).Scan(&role)
+	if role != string(models.RoleAdmin) {
+		t.Fatalf("role = %q, want admin", role)
+	}
+
+	if _, err := auth.BootstrapAdmin("nobody", "", ""); err == nil {
+		t.Fatalf("bootstrapping a missing user without credentials should fail")
+	}
+}
@@ -0,0 +1,146 @@
+package auth_test
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/auth"
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/mailer"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/database"
+	"github.com/gin-gonic/gin"
+)
+
+type recordingMailer struct {
+	sent chan mailer.Message
+}
+
+func (m *recordingMailer) Send(ctx context.Context, msg mailer.Message) error {
+	m.sent <- msg
+	return nil
+}
+
+var resetTokenPattern = regexp.MustCompile(`--token ([0-9a-f]+)`)
+
+func setupResetTest(t *testing.T) (*gin.Engine, *recordingMailer) {
+	r := setupAuthTest(t)
+	m := &recordingMailer{sent: make(chan mailer.Message, 4)}
+	h := auth.NewHandler(testKeys)
+	h.SetMailer(m)
+	r.POST("/auth/forgot-password", h.ForgotPassword)
+	r.POST("/auth/reset-password", h.ResetPassword)
+	return r, m
+}
+
+// markVerified confirms the test account's email without going through the
+// verification link
+func markVerified(t *testing.T) {
+	if _, err := database.DB.Exec(`UPDATE users SET email_verified = 1 WHERE username = 'reader'`); err != nil {
+		t.Fatalf("mark verified: %v", err)
+	}
+}
+
+func requestReset(t *testing.T, r *gin.Engine, m *recordingMailer, email string) string {
+	w := do(r, http.MethodPost, "/auth/forgot-password", "", gin.H{"email": email})
+	if w.Code != http.StatusOK {
+		t.Fatalf("forgot-password: %d %s", w.Code, w.Body.String())
+	}
+	select {
+	case msg := <-m.sent:
+		if msg.To != email {
+			t.Fatalf("reset email sent to %q, want %q", msg.To, email)
+		}
+		match := resetTokenPattern.FindStringSubmatch(msg.Body)
+		if match == nil {
+			t.Fatalf("no token in reset email: %s", msg.Body)
+		}
+		return match[1]
+	case <-time.After(2 * time.Second):
+		t.Fatalf("no reset email sent")
+		return ""
+	}
+}
+
+func TestPasswordReset(t *testing.T) {
+	r, m := setupResetTest(t)
+	session := register(t, r)
+	markVerified(t)
+
+	token := requestReset(t, r, m, "reader@example.com")
+
+	w := do(r, http.MethodPost, "/auth/reset-password", "", gin.H{"token": token, "new_password": "weak"})
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("weak password: got %d, want 400", w.Code)
+	}
+
+	w = do(r, http.MethodPost, "/auth/reset-password", "", gin.H{"token": token, "new_password": "NewSecret456"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("reset-password: %d %s", w.Code, w.Body.String())
+	}
+
+	if w := do(r, http.MethodPost, "/auth/login", "", gin.H{"username": "reader", "password": "Secret123"}); w.Code != http.StatusUnauthorized {
+		t.Fatalf("old password: got %d, want 401", w.Code)
+	}
+	if w := do(r, http.MethodPost, "/auth/login", "", gin.H{"username": "reader", "password": "NewSecret456"}); w.Code != http.StatusOK {
+		t.Fatalf("new password: got %d, want 200", w.Code)
+	}
+
+	// Resetting ends existing sessions, and the token only works once
+	if w, _ := refresh(r, session.RefreshToken); w.Code != http.StatusUnauthorized {
+		t.Fatalf("refresh after reset: got %d, want 401", w.Code)
+	}
+	w = do(r, http.MethodPost, "/auth/reset-password", "", gin.H{"token": token, "new_password": "Another789"})
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("reused reset token: got %d, want 400", w.Code)
+	}
+}
+
+func TestForgotPasswordUnknownEmail(t *testing.T) {
+	r, m := setupResetTest(t)
+	register(t, r)
+
+	w := do(r, http.MethodPost, "/auth/forgot-password", "", gin.H{"email": "nobody@example.com"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("unknown email should look like success, got %d", w.Code)
+	}
+	select {
+	case msg := <-m.sent:
+		t.Fatalf("no email should be sent for an unknown address, got one to %s", msg.To)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestForgotPasswordReplacesEarlierToken(t *testing.T) {
+	r, m := setupResetTest(t)
+	register(t, r)
+	markVerified(t)
+
+	first := requestReset(t, r, m, "reader@example.com")
+	second := requestReset(t, r, m, "reader@example.com")
+
+	w := do(r, http.MethodPost, "/auth/reset-password", "", gin.H{"token": first, "new_password": "NewSecret456"})
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("superseded token: got %d, want 400", w.Code)
+	}
+	w = do(r, http.MethodPost, "/auth/reset-password", "", gin.H{"token": second, "new_password": "NewSecret456"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("latest token: got %d, want 200", w.Code)
+	}
+}
+
+func TestForgotPasswordUnverifiedEmail(t *testing.T) {
+	r, m := setupResetTest(t)
+	register(t, r)
+
+	w := do(r, http.MethodPost, "/auth/forgot-password", "", gin.H{"email": "reader@example.com"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("forgot-password: got %d, want 200", w.Code)
+	}
+	select {
+	case msg := <-m.sent:
+		t.Fatalf("no reset email should go to an unverified address, got one to %s", msg.To)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -0,0 +1,165 @@
+package auth_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/auth"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+func setupSessionsTest(t *testing.T) *gin.Engine {
+	r := setupAuthTest(t)
+	h := auth.NewHandler(testKeys)
+	users := r.Group("/users", auth.AuthMiddleware(testKeys))
+	users.GET("/me/sessions", h.ListSessions)
+	users.DELETE("/me/sessions/:session_id", h.RevokeSession)
+	return r
+}
+
+// loginFrom logs the registered reader in from a named device
+func loginFrom(t *testing.T, r *gin.Engine, device string) models.AuthResponse {
+	req := httptest.NewRequest(http.MethodPost, "/auth/login",
+		strings.NewReader(`{"username":"reader","password":"Secret123"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "mangahub-cli")
+	req.Header.Set(auth.DeviceNameHeader, device)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("login from %s: %d %s", device, w.Code, w.Body.String())
+	}
+	var resp models.AuthResponse
+	decode(t, w, &resp)
+	return resp
+}
+
+func listSessions(t *testing.T, r *gin.Engine, token string) []models.LoginSession {
+	w := do(r, http.MethodGet, "/users/me/sessions", token, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("list sessions: %d %s", w.Code, w.Body.String())
+	}
+	var result struct {
+		Sessions []models.LoginSession `json:"sessions"`
+	}
+	decode(t, w, &result)
+	return result.Sessions
+}
+
+func TestListSessions(t *testing.T) {
+	r := setupSessionsTest(t)
+	first := register(t, r)
+	laptop := loginFrom(t, r, "laptop")
+
+	sessions := listSessions(t, r, laptop.Token)
+	if len(sessions) != 2 {
+		t.Fatalf("got %d sessions, want 2: %+v", len(sessions), sessions)
+	}
+	var current models.LoginSession
+	for _, s := range sessions {
+		if s.Current {
+			current = s
+		}
+	}
+	if current.ID != laptop.SessionID || current.DeviceName != "laptop" || current.UserAgent != "mangahub-cli" || current.IP == "" {
+		t.Fatalf("current session = %+v, want the laptop login", current)
+	}
+
+	// A refresh keeps the same session, and a logout ends it
+	w, rotated := refresh(r, first.RefreshToken)
+	if w.Code != http.StatusOK || rotated.SessionID != first.SessionID {
+		t.Fatalf("refresh: %d, session %q want %q", w.Code, rotated.SessionID, first.SessionID)
+	}
+	do(r, http.MethodPost, "/auth/logout", rotated.Token, gin.H{"refresh_token": rotated.RefreshToken})
+	if sessions := listSessions(t, r, laptop.Token); len(sessions) != 1 || sessions[0].ID != laptop.SessionID {
+		t.Fatalf("sessions after logout = %+v, want only the laptop", sessions)
+	}
+}
+
+func TestRevokeSession(t *testing.T) {
+	r := setupSessionsTest(t)
+	lost := register(t, r)
+	laptop := loginFrom(t, r, "laptop")
+
+	if w := do(r, http.MethodDelete, "/users/me/sessions/"+lost.SessionID, laptop.Token, nil); w.Code != http.StatusOK {
+		t.Fatalf("revoke: %d %s", w.Code, w.Body.String())
+	}
+	// The lost device's access token stops working before it expires, and
+	// it can't get a new one
+	if w := do(r, http.MethodGet, "/me", lost.Token, nil); w.Code != http.StatusUnauthorized {
+		t.Fatalf("revoked session's access token: got %d, want 401", w.Code)
+	}
+	if w, _ := refresh(r, lost.RefreshToken); w.Code != http.StatusUnauthorized {
+		t.Fatalf("revoked session's refresh token: got %d, want 401", w.Code)
+	}
+	if w := do(r, http.MethodGet, "/me", laptop.Token, nil); w.Code != http.StatusOK {
+		t.Fatalf("other session signed out too: %d", w.Code)
+	}
+
+	if w := do(r, http.MethodDelete, "/users/me/sessions/"+lost.SessionID, laptop.Token, nil); w.Code != http.StatusNotFound {
+		t.Fatalf("revoking twice: got %d, want 404", w.Code)
+	}
+
+	// Other users' sessions can't be revoked
+	w := do(r, http.MethodPost, "/auth/register", "", gin.H{
+		"username": "other", "email": "other@example.com", "password": "Secret123",
+	})
+	var other models.AuthResponse
+	decode(t, w, &other)
+	if w := do(r, http.MethodDelete, "/users/me/sessions/"+laptop.SessionID, other.Token, nil); w.Code != http.StatusNotFound {
+		t.Fatalf("revoking another user's session: got %d, want 404", w.Code)
+	}
+}
+
+func TestWatchSessionsReportsRevokedSessions(t *testing.T) {
+	r := setupSessionsTest(t)
+	phone := register(t, r)
+	laptop := loginFrom(t, r, "laptop")
+
+	conn := models.LiveConnection{
+		SessionID:  phone.SessionID,
+		UserID:     phone.UserID,
+		Transport:  models.TransportTCP,
+		RemoteAddr: "10.0.0.7:51000",
+	}
+	if err := auth.TrackConnection(&conn); err != nil {
+		t.Fatalf("TrackConnection: %v", err)
+	}
+	auth.SetConnectionDevice(conn.ID, "mobile", "phone")
+
+	for _, s := range listSessions(t, r, laptop.Token) {
+		if s.ID == phone.SessionID && (len(s.Connections) != 1 || s.Connections[0].DeviceName != "phone") {
+			t.Fatalf("phone session connections = %+v, want the tracked TCP connection", s.Connections)
+		}
+	}
+
+	interval := auth.SessionCheckInterval
+	auth.SessionCheckInterval = 20 * time.Millisecond
+	t.Cleanup(func() { auth.SessionCheckInterval = interval })
+
+	signedOut := make(chan string, 1)
+	stop := make(chan struct{})
+	defer close(stop)
+	go auth.WatchSessions(stop, func() ([]string, []string) {
+		return []string{conn.ID}, []string{phone.SessionID, laptop.SessionID}
+	}, func(sessionID string) {
+		select {
+		case signedOut <- sessionID:
+		default:
+		}
+	})
+
+	do(r, http.MethodDelete, "/users/me/sessions/"+phone.SessionID, laptop.Token, nil)
+	select {
+	case id := <-signedOut:
+		if id != phone.SessionID {
+			t.Fatalf("signed out %q, want the phone session", id)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("revoked session was not reported")
+	}
+}
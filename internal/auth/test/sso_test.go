@@ -0,0 +1,304 @@
+package auth_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/auth"
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/oidc"
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/oidc/oidctest"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/database"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+var alice = oidctest.User{
+	Subject:           "alice-subject",
+	Email:             "alice@corp.example",
+	EmailVerified:     true,
+	PreferredUsername: "Alice",
+}
+
+func setupSSOTest(t *testing.T, allowSignup bool) (*gin.Engine, *oidctest.Provider) {
+	r := setupAuthTest(t)
+	idp := oidctest.NewServer(alice)
+	t.Cleanup(idp.Close)
+
+	h := auth.NewHandler(testKeys)
+	h.SetOIDC(oidc.NewProvider(oidc.Config{
+		Issuer:       idp.Issuer(),
+		ClientID:     oidctest.ClientID,
+		ClientSecret: oidctest.ClientSecret,
+		RedirectURI:  "http://example.com/auth/oidc/callback",
+		AllowSignup:  allowSignup,
+	}))
+	r.GET("/auth/oidc/login", h.OIDCLogin)
+	r.POST("/auth/oidc/login", h.OIDCConfirmDevice)
+	r.GET("/auth/oidc/callback", h.OIDCCallback)
+	r.POST("/auth/oidc/device", h.OIDCDeviceStart)
+	r.POST("/auth/oidc/device/token", h.OIDCDeviceToken)
+	return r, idp
+}
+
+// ssoLogin follows the redirects a browser would: MangaHub to the
+// provider, which approves at once and sends it back to the callback
+func ssoLogin(t *testing.T, r *gin.Engine, loginPath string) *httptest.ResponseRecorder {
+	w := do(r, http.MethodGet, loginPath, "", nil)
+	if w.Code != http.StatusFound {
+		t.Fatalf("login: got %d %s, want 302", w.Code, w.Body.String())
+	}
+	return signInAtProvider(t, r, w.Header().Get("Location"))
+}
+
+// ssoDeviceLogin opens the link the CLI prints, confirms the code on the
+// page as the user would and then signs in at the provider
+func ssoDeviceLogin(t *testing.T, r *gin.Engine, userCode string) *httptest.ResponseRecorder {
+	page := do(r, http.MethodGet, "/auth/oidc/login?user_code="+url.QueryEscape(userCode), "", nil)
+	if page.Code != http.StatusOK {
+		t.Fatalf("confirmation page: got %d %s, want 200", page.Code, page.Body.String())
+	}
+	confirm := regexp.MustCompile(`name="confirm" value="([^"]+)"`).FindStringSubmatch(page.Body.String())
+	if confirm == nil {
+		t.Fatalf("confirmation page has no form: %s", page.Body.String())
+	}
+
+	form := url.Values{"user_code": {userCode}, "confirm": {confirm[1]}}
+	req := httptest.NewRequest(http.MethodPost, "/auth/oidc/login", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	for _, cookie := range page.Result().Cookies() {
+		req.AddCookie(cookie)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusSeeOther {
+		t.Fatalf("confirm: got %d %s, want 303", w.Code, w.Body.String())
+	}
+	return signInAtProvider(t, r, w.Header().Get("Location"))
+}
+
+func signInAtProvider(t *testing.T, r *gin.Engine, authURL string) *httptest.ResponseRecorder {
+	noFollow := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	res, err := noFollow.Get(authURL)
+	if err != nil {
+		t.Fatalf("authorize: %v", err)
+	}
+	res.Body.Close()
+	back, err := url.Parse(res.Header.Get("Location"))
+	if err != nil || res.StatusCode != http.StatusFound {
+		t.Fatalf("authorize: %d %q", res.StatusCode, res.Header.Get("Location"))
+	}
+	return do(r, http.MethodGet, back.RequestURI(), "", nil)
+}
+
+func TestSSOProvisionsAndReusesAccount(t *testing.T) {
+	r, _ := setupSSOTest(t, true)
+
+	w := ssoLogin(t, r, "/auth/oidc/login")
+	var first models.AuthResponse
+	decode(t, w, &first)
+	if w.Code != http.StatusOK || first.Username != "alice" || first.Email != alice.Email || !first.EmailVerified {
+		t.Fatalf("first SSO login: %d %s", w.Code, w.Body.String())
+	}
+	if w := do(r, http.MethodGet, "/me", first.Token, nil); w.Code != http.StatusOK {
+		t.Fatalf("SSO access token rejected: %d", w.Code)
+	}
+
+	w = ssoLogin(t, r, "/auth/oidc/login")
+	var second models.AuthResponse
+	decode(t, w, &second)
+	if second.UserID != first.UserID {
+		t.Fatalf("second SSO login made another account: %s != %s", second.UserID, first.UserID)
+	}
+}
+
+func TestSSOLinksVerifiedEmail(t *testing.T) {
+	r, idp := setupSSOTest(t, true)
+	session := register(t, r)
+	idp.SetUser(oidctest.User{Subject: "reader-subject", Email: "reader@example.com", EmailVerified: true})
+
+	// Someone could have registered with this address without owning it,
+	// so an unverified account is not linked
+	if w := ssoLogin(t, r, "/auth/oidc/login"); w.Code != http.StatusConflict {
+		t.Fatalf("SSO to unverified account: got %d %s, want 409", w.Code, w.Body.String())
+	}
+
+	markVerified(t)
+	w := ssoLogin(t, r, "/auth/oidc/login")
+	var resp models.AuthResponse
+	decode(t, w, &resp)
+	if w.Code != http.StatusOK || resp.UserID != session.UserID {
+		t.Fatalf("SSO to verified account: %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestSSOWithoutSignup(t *testing.T) {
+	r, _ := setupSSOTest(t, false)
+	if w := ssoLogin(t, r, "/auth/oidc/login"); w.Code != http.StatusForbidden {
+		t.Fatalf("SSO for unknown identity: got %d %s, want 403", w.Code, w.Body.String())
+	}
+}
+
+func TestSSORejectsBadIDToken(t *testing.T) {
+	r, idp := setupSSOTest(t, true)
+
+	idp.SetNonce("someone-elses-nonce")
+	if w := ssoLogin(t, r, "/auth/oidc/login"); w.Code != http.StatusUnauthorized {
+		t.Fatalf("wrong nonce: got %d, want 401", w.Code)
+	}
+	idp.SetNonce("")
+
+	idp.SetAudience("another-client")
+	if w := ssoLogin(t, r, "/auth/oidc/login"); w.Code != http.StatusUnauthorized {
+		t.Fatalf("wrong audience: got %d, want 401", w.Code)
+	}
+
+	var users int
+	database.DB.QueryRow(`SELECT COUNT(*) FROM users`).Scan(&users)
+	if users != 0 {
+		t.Fatalf("rejected logins created %d accounts", users)
+	}
+}
+
+func TestSSODeviceFlow(t *testing.T) {
+	r, _ := setupSSOTest(t, true)
+
+	t.Setenv("PUBLIC_API_URL", "")
+	if w := do(r, http.MethodPost, "/auth/oidc/device", "", nil); w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("device start without PUBLIC_API_URL: got %d, want 503", w.Code)
+	}
+	t.Setenv("PUBLIC_API_URL", "https://api.example.com")
+
+	w := do(r, http.MethodPost, "/auth/oidc/device", "", nil)
+	var device models.DeviceAuthorization
+	decode(t, w, &device)
+	if w.Code != http.StatusOK || device.DeviceCode == "" || len(device.UserCode) != 9 {
+		t.Fatalf("device start: %d %s", w.Code, w.Body.String())
+	}
+	if device.VerificationURI != "https://api.example.com/auth/oidc/login" {
+		t.Fatalf("verification URI = %q, want one under PUBLIC_API_URL", device.VerificationURI)
+	}
+
+	poll := func() *httptest.ResponseRecorder {
+		return do(r, http.MethodPost, "/auth/oidc/device/token", "", gin.H{"device_code": device.DeviceCode})
+	}
+	if w := poll(); w.Code != http.StatusAccepted {
+		t.Fatalf("poll before sign-in: got %d, want 202", w.Code)
+	}
+
+	// The code is accepted as typed by a person
+	typed := strings.ToLower(strings.ReplaceAll(device.UserCode, "-", ""))
+	if w := ssoDeviceLogin(t, r, typed); w.Code != http.StatusOK {
+		t.Fatalf("browser sign-in: %d %s", w.Code, w.Body.String())
+	}
+
+	w = poll()
+	var resp models.AuthResponse
+	decode(t, w, &resp)
+	if w.Code != http.StatusOK || resp.Username != "alice" || resp.RefreshToken == "" {
+		t.Fatalf("poll after sign-in: %d %s", w.Code, w.Body.String())
+	}
+	if w := poll(); w.Code != http.StatusBadRequest {
+		t.Fatalf("second poll: got %d, want 400", w.Code)
+	}
+	if w := do(r, http.MethodGet, "/auth/oidc/login?user_code="+typed, "", nil); w.Code != http.StatusBadRequest {
+		t.Fatalf("reusing a user code: got %d, want 400", w.Code)
+	}
+}
+
+func TestSSODeviceLinkNeedsConfirmation(t *testing.T) {
+	r, _ := setupSSOTest(t, true)
+	t.Setenv("PUBLIC_API_URL", "https://api.example.com")
+
+	req := httptest.NewRequest(http.MethodPost, "/auth/oidc/device", nil)
+	req.Header.Set(auth.DeviceNameHeader, "someone-elses-laptop")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	var device models.DeviceAuthorization
+	decode(t, w, &device)
+
+	// Opening the link shows the code and the device but doesn't go to the
+	// provider, where a signed-in victim would be approved without a prompt
+	w = do(r, http.MethodGet, "/auth/oidc/login?user_code="+device.UserCode, "", nil)
+	body := w.Body.String()
+	if w.Code != http.StatusOK || w.Header().Get("Location") != "" || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/html") {
+		t.Fatalf("GET with user_code: got %d %q, want a 200 HTML page", w.Code, w.Header().Get("Location"))
+	}
+	if !strings.Contains(body, device.UserCode) || !strings.Contains(body, "someone-elses-laptop") {
+		t.Fatalf("confirmation page should show the code and device: %s", body)
+	}
+
+	// Another site posting the form lacks the page's cookie
+	form := url.Values{"user_code": {device.UserCode}, "confirm": {"guess"}}
+	req = httptest.NewRequest(http.MethodPost, "/auth/oidc/login", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("cross-site confirm: got %d, want 403", w.Code)
+	}
+
+	var logins int
+	database.DB.QueryRow(`SELECT COUNT(*) FROM oidc_logins`).Scan(&logins)
+	if logins != 0 {
+		t.Fatalf("sign-in started without confirmation: %d pending logins", logins)
+	}
+	if w := do(r, http.MethodPost, "/auth/oidc/device/token", "", gin.H{"device_code": device.DeviceCode}); w.Code != http.StatusAccepted {
+		t.Fatalf("poll: got %d, want 202", w.Code)
+	}
+}
+
+func TestSSOAsksForTwoFactorCode(t *testing.T) {
+	r, idp := setupSSOTest(t, true)
+	t.Setenv("PUBLIC_API_URL", "https://api.example.com")
+	h := auth.NewHandler(testKeys)
+	r.POST("/auth/login/2fa", h.LoginTwoFactor)
+	r.POST("/auth/2fa/setup", auth.AuthMiddleware(testKeys), h.SetupTwoFactor)
+	r.POST("/auth/2fa/enable", auth.AuthMiddleware(testKeys), h.EnableTwoFactor)
+
+	session := register(t, r)
+	markVerified(t)
+	w := do(r, http.MethodPost, "/auth/2fa/setup", session.Token, gin.H{"password": "Secret123"})
+	var setup models.TwoFactorSetupResponse
+	decode(t, w, &setup)
+	w = do(r, http.MethodPost, "/auth/2fa/enable", session.Token, gin.H{"code": totpAt(t, setup.Secret, 0)})
+	var enabled models.TwoFactorEnableResponse
+	decode(t, w, &enabled)
+	if w.Code != http.StatusOK {
+		t.Fatalf("enable: %d %s", w.Code, w.Body.String())
+	}
+	idp.SetUser(oidctest.User{Subject: "reader-subject", Email: "reader@example.com", EmailVerified: true})
+
+	// The identity provider stands in for the password, not the second step
+	challenge := func(w *httptest.ResponseRecorder) string {
+		var c models.TwoFactorChallenge
+		decode(t, w, &c)
+		if w.Code != http.StatusAccepted || !c.TwoFactorRequired || c.MFAToken == "" {
+			t.Fatalf("SSO with 2FA on: got %d %s, want a two-factor challenge", w.Code, w.Body.String())
+		}
+		return c.MFAToken
+	}
+	mfaToken := challenge(ssoLogin(t, r, "/auth/oidc/login"))
+	w = do(r, http.MethodPost, "/auth/login/2fa", "", gin.H{"mfa_token": mfaToken, "code": totpAt(t, setup.Secret, 1)})
+	var resp models.AuthResponse
+	decode(t, w, &resp)
+	if w.Code != http.StatusOK || resp.UserID != session.UserID {
+		t.Fatalf("second step: %d %s", w.Code, w.Body.String())
+	}
+
+	// The CLI's device login gets the challenge from its poll
+	w = do(r, http.MethodPost, "/auth/oidc/device", "", nil)
+	var device models.DeviceAuthorization
+	decode(t, w, &device)
+	if w := ssoDeviceLogin(t, r, device.UserCode); w.Code != http.StatusOK {
+		t.Fatalf("browser sign-in: %d %s", w.Code, w.Body.String())
+	}
+	mfaToken = challenge(do(r, http.MethodPost, "/auth/oidc/device/token", "", gin.H{"device_code": device.DeviceCode}))
+	w = do(r, http.MethodPost, "/auth/login/2fa", "", gin.H{"mfa_token": mfaToken, "code": enabled.RecoveryCodes[0]})
+	if w.Code != http.StatusOK {
+		t.Fatalf("device second step: %d %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,169 @@
+package auth_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/auth"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/database"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+var testKeys = auth.KeysFromSecret("test-secret")
+
+func setupAuthTest(t *testing.T) *gin.Engine {
+	if err := database.InitDatabase(t.TempDir() + "/test.db"); err != nil {
+		t.Fatalf("init db: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	gin.SetMode(gin.TestMode)
+	h := auth.NewHandler(testKeys)
+	r := gin.New()
+	r.POST("/auth/register", h.Register)
+	r.POST("/auth/login", h.Login)
+	r.POST("/auth/refresh", h.Refresh)
+	protected := r.Group("/", auth.AuthMiddleware(testKeys))
+	protected.POST("/auth/logout", h.Logout)
+	protected.GET("/me", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"user_id": c.GetString("user_id")})
+	})
+	return r
+}
+
+func do(r *gin.Engine, method, path, token string, body interface{}) *httptest.ResponseRecorder {
+	var buf bytes.Buffer
+	if body != nil {
+		json.NewEncoder(&buf).Encode(body)
+	}
+	req := httptest.NewRequest(method, path, &buf)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func decode(t *testing.T, w *httptest.ResponseRecorder, v interface{}) {
+	if err := json.Unmarshal(w.Body.Bytes(), v); err != nil {
+		t.Fatalf("decode %d %s: %v", w.Code, w.Body.String(), err)
+	}
+}
+
+func register(t *testing.T, r *gin.Engine) models.AuthResponse {
+	w := do(r, http.MethodPost, "/auth/register", "", gin.H{
+		"username": "reader",
+		"email":    "reader@example.com",
+		"password": "Secret123",
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("register: %d %s", w.Code, w.Body.String())
+	}
+	var resp models.AuthResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if resp.Token == "" || resp.RefreshToken == "" {
+		t.Fatalf("register returned no token pair: %s", w.Body.String())
+	}
+	return resp
+}
+
+func refresh(r *gin.Engine, refreshToken string) (*httptest.ResponseRecorder, models.AuthResponse) {
+	w := do(r, http.MethodPost, "/auth/refresh", "", gin.H{"refresh_token": refreshToken})
+	var resp models.AuthResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	return w, resp
+}
+
+func TestRefreshRotatesToken(t *testing.T) {
+	r := setupAuthTest(t)
+	session := register(t, r)
+
+	w, rotated := refresh(r, session.RefreshToken)
+	if w.Code != http.StatusOK {
+		t.Fatalf("refresh: %d %s", w.Code, w.Body.String())
+	}
+	if rotated.RefreshToken == session.RefreshToken || rotated.Token == session.Token {
+		t.Fatalf("refresh should issue a new token pair")
+	}
+	if rotated.UserID != session.UserID || rotated.Username != "reader" {
+		t.Fatalf("refresh returned the wrong user: %+v", rotated)
+	}
+	if w := do(r, http.MethodGet, "/me", rotated.Token, nil); w.Code != http.StatusOK {
+		t.Fatalf("refreshed access token rejected: %d", w.Code)
+	}
+
+	var stored int
+	database.DB.QueryRow(`SELECT COUNT(*) FROM refresh_tokens WHERE token_hash = ?`, rotated.RefreshToken).Scan(&stored)
+	if stored != 0 {
+		t.Fatalf("refresh tokens must not be stored in plain text")
+	}
+}
+
+func TestRefreshTokenReuseRevokesSession(t *testing.T) {
+	r := setupAuthTest(t)
+	session := register(t, r)
+
+	_, rotated := refresh(r, session.RefreshToken)
+
+	if w, _ := refresh(r, session.RefreshToken); w.Code != http.StatusUnauthorized {
+		t.Fatalf("reused refresh token: got %d, want 401", w.Code)
+	}
+	// The reuse ends the whole session, including the token rotated from it
+	if w, _ := refresh(r, rotated.RefreshToken); w.Code != http.StatusUnauthorized {
+		t.Fatalf("refresh after reuse: got %d, want 401", w.Code)
+	}
+
+	if w, _ := refresh(r, "not-a-token"); w.Code != http.StatusUnauthorized {
+		t.Fatalf("unknown refresh token: got %d, want 401", w.Code)
+	}
+}
+
+func TestLogoutRevokesTokens(t *testing.T) {
+	r := setupAuthTest(t)
+	session := register(t, r)
+
+	w := do(r, http.MethodPost, "/auth/logout", session.Token, gin.H{"refresh_token": session.RefreshToken})
+	if w.Code != http.StatusOK {
+		t.Fatalf("logout: %d %s", w.Code, w.Body.String())
+	}
+
+	if w := do(r, http.MethodGet, "/me", session.Token, nil); w.Code != http.StatusUnauthorized {
+		t.Fatalf("revoked access token: got %d, want 401", w.Code)
+	}
+	if _, err := auth.ValidateToken(session.Token, testKeys); err != auth.ErrTokenRevoked {
+		t.Fatalf("ValidateToken error = %v, want ErrTokenRevoked", err)
+	}
+	if w, _ := refresh(r, session.RefreshToken); w.Code != http.StatusUnauthorized {
+		t.Fatalf("refresh after logout: got %d, want 401", w.Code)
+	}
+
+	// Other sessions of the same user are unaffected
+	w = do(r, http.MethodPost, "/auth/login", "", gin.H{"username": "reader", "password": "Secret123"})
+	var other models.AuthResponse
+	json.Unmarshal(w.Body.Bytes(), &other)
+	if w := do(r, http.MethodGet, "/me", other.Token, nil); w.Code != http.StatusOK {
+		t.Fatalf("new session rejected after logout: %d", w.Code)
+	}
+}
+
+func TestLogoutWithoutBody(t *testing.T) {
+	r := setupAuthTest(t)
+	session := register(t, r)
+
+	req := httptest.NewRequest(http.MethodPost, "/auth/logout", nil)
+	req.Header.Set("Authorization", "Bearer "+session.Token)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("logout without body: %d %s", w.Code, w.Body.String())
+	}
+	if w, _ := refresh(r, session.RefreshToken); w.Code != http.StatusOK {
+		t.Fatalf("refresh token not named in logout should keep working: %d", w.Code)
+	}
+}
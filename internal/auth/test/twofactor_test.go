@@ -0,0 +1,147 @@
+package auth_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/auth"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/models"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+func setupTwoFactorTest(t *testing.T) *gin.Engine {
+	r := setupAuthTest(t)
+	h := auth.NewHandler(testKeys)
+	r.POST("/auth/login/2fa", h.LoginTwoFactor)
+	protected := r.Group("/auth/2fa", auth.AuthMiddleware(testKeys))
+	protected.POST("/setup", h.SetupTwoFactor)
+	protected.POST("/enable", h.EnableTwoFactor)
+	protected.POST("/disable", h.DisableTwoFactor)
+	return r
+}
+
+func totpAt(t *testing.T, secret string, offset int64) string {
+	code, err := utils.TOTPCode(secret, utils.TOTPStep(time.Now())+offset)
+	if err != nil {
+		t.Fatalf("TOTPCode: %v", err)
+	}
+	return code
+}
+
+// startLogin sends the password and returns the second-step token
+func startLogin(t *testing.T, r *gin.Engine) string {
+	w := do(r, http.MethodPost, "/auth/login", "", gin.H{"username": "reader", "password": "Secret123"})
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("login with 2FA on: got %d %s, want 202", w.Code, w.Body.String())
+	}
+	var challenge models.TwoFactorChallenge
+	decode(t, w, &challenge)
+	if !challenge.TwoFactorRequired || challenge.MFAToken == "" {
+		t.Fatalf("challenge = %+v", challenge)
+	}
+	return challenge.MFAToken
+}
+
+func TestTwoFactorLogin(t *testing.T) {
+	r := setupTwoFactorTest(t)
+	session := register(t, r)
+
+	if w := do(r, http.MethodPost, "/auth/2fa/setup", session.Token, gin.H{"password": "wrong"}); w.Code != http.StatusUnauthorized {
+		t.Fatalf("setup with wrong password: got %d, want 401", w.Code)
+	}
+	w := do(r, http.MethodPost, "/auth/2fa/setup", session.Token, gin.H{"password": "Secret123"})
+	var setup models.TwoFactorSetupResponse
+	decode(t, w, &setup)
+	if !strings.HasPrefix(setup.OTPAuthURI, "otpauth://totp/") || !strings.Contains(setup.OTPAuthURI, setup.Secret) {
+		t.Fatalf("setup = %+v", setup)
+	}
+
+	// Until enrollment is confirmed the password alone still logs in
+	if w := do(r, http.MethodPost, "/auth/login", "", gin.H{"username": "reader", "password": "Secret123"}); w.Code != http.StatusOK {
+		t.Fatalf("login before enabling: got %d, want 200", w.Code)
+	}
+
+	if w := do(r, http.MethodPost, "/auth/2fa/enable", session.Token, gin.H{"code": "000000"}); w.Code != http.StatusBadRequest {
+		t.Fatalf("enable with wrong code: got %d, want 400", w.Code)
+	}
+	enrollCode := totpAt(t, setup.Secret, 0)
+	w = do(r, http.MethodPost, "/auth/2fa/enable", session.Token, gin.H{"code": enrollCode})
+	var enabled models.TwoFactorEnableResponse
+	decode(t, w, &enabled)
+	if w.Code != http.StatusOK || len(enabled.RecoveryCodes) != 10 {
+		t.Fatalf("enable: %d %+v", w.Code, enabled)
+	}
+
+	mfaToken := startLogin(t, r)
+	// A code that already worked can't be replayed
+	w = do(r, http.MethodPost, "/auth/login/2fa", "", gin.H{"mfa_token": mfaToken, "code": enrollCode})
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("replayed code: got %d, want 401", w.Code)
+	}
+	w = do(r, http.MethodPost, "/auth/login/2fa", "", gin.H{"mfa_token": mfaToken, "code": totpAt(t, setup.Secret, 1)})
+	var resp models.AuthResponse
+	decode(t, w, &resp)
+	if w.Code != http.StatusOK || resp.Token == "" || resp.RefreshToken == "" {
+		t.Fatalf("second step: %d %s", w.Code, w.Body.String())
+	}
+	w = do(r, http.MethodPost, "/auth/login/2fa", "", gin.H{"mfa_token": mfaToken, "code": totpAt(t, setup.Secret, 1)})
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("reusing the second-step token: got %d, want 401", w.Code)
+	}
+}
+
+func TestTwoFactorRecoveryCodes(t *testing.T) {
+	r := setupTwoFactorTest(t)
+	session := register(t, r)
+
+	w := do(r, http.MethodPost, "/auth/2fa/setup", session.Token, gin.H{"password": "Secret123"})
+	var setup models.TwoFactorSetupResponse
+	decode(t, w, &setup)
+	w = do(r, http.MethodPost, "/auth/2fa/enable", session.Token, gin.H{"code": totpAt(t, setup.Secret, 0)})
+	var enabled models.TwoFactorEnableResponse
+	decode(t, w, &enabled)
+	recovery := enabled.RecoveryCodes[0]
+
+	// Recovery codes are accepted without the dash and in lower case
+	mfaToken := startLogin(t, r)
+	relaxed := strings.ToLower(strings.ReplaceAll(recovery, "-", ""))
+	if w := do(r, http.MethodPost, "/auth/login/2fa", "", gin.H{"mfa_token": mfaToken, "code": relaxed}); w.Code != http.StatusOK {
+		t.Fatalf("recovery code login: %d %s", w.Code, w.Body.String())
+	}
+	mfaToken = startLogin(t, r)
+	if w := do(r, http.MethodPost, "/auth/login/2fa", "", gin.H{"mfa_token": mfaToken, "code": recovery}); w.Code != http.StatusUnauthorized {
+		t.Fatalf("spent recovery code: got %d, want 401", w.Code)
+	}
+
+	w = do(r, http.MethodPost, "/auth/2fa/disable", session.Token, gin.H{"password": "Secret123", "code": enabled.RecoveryCodes[1]})
+	if w.Code != http.StatusOK {
+		t.Fatalf("disable: %d %s", w.Code, w.Body.String())
+	}
+	if w := do(r, http.MethodPost, "/auth/login", "", gin.H{"username": "reader", "password": "Secret123"}); w.Code != http.StatusOK {
+		t.Fatalf("login after disabling: got %d, want 200", w.Code)
+	}
+}
+
+func TestTwoFactorChallengeAttemptLimit(t *testing.T) {
+	r := setupTwoFactorTest(t)
+	session := register(t, r)
+
+	w := do(r, http.MethodPost, "/auth/2fa/setup", session.Token, gin.H{"password": "Secret123"})
+	var setup models.TwoFactorSetupResponse
+	decode(t, w, &setup)
+	do(r, http.MethodPost, "/auth/2fa/enable", session.Token, gin.H{"code": totpAt(t, setup.Secret, 0)})
+
+	mfaToken := startLogin(t, r)
+	for i := 0; i < 5; i++ {
+		skipBackoff(t)
+		do(r, http.MethodPost, "/auth/login/2fa", "", gin.H{"mfa_token": mfaToken, "code": "000000"})
+	}
+	skipBackoff(t)
+	w = do(r, http.MethodPost, "/auth/login/2fa", "", gin.H{"mfa_token": mfaToken, "code": totpAt(t, setup.Secret, 1)})
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("challenge after too many wrong codes: got %d, want 401", w.Code)
+	}
+}
@@ -0,0 +1,202 @@
+package auth_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/auth"
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/mailer"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/database"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+var verifyLinkPattern = regexp.MustCompile(`https?://\S+/auth/verify\?token=\S+`)
+
+func setupVerifyTest(t *testing.T) (*gin.Engine, *recordingMailer) {
+	t.Setenv("PUBLIC_API_URL", "https://api.example.com/")
+	r := setupAuthTest(t)
+	m := &recordingMailer{sent: make(chan mailer.Message, 4)}
+	h := auth.NewHandler(testKeys)
+	h.SetMailer(m)
+	r.GET("/auth/verify", h.VerifyEmail)
+	protected := r.Group("/", auth.AuthMiddleware(testKeys))
+	protected.POST("/auth/verify/resend", h.ResendVerification)
+	protected.PUT("/users/me/email", h.ChangeEmail)
+	return r, m
+}
+
+// verifyPath waits for a verification email to the address and returns the
+// path of its link
+func verifyPath(t *testing.T, m *recordingMailer, email string) string {
+	select {
+	case msg := <-m.sent:
+		if msg.To != email {
+			t.Fatalf("verification sent to %q, want %q", msg.To, email)
+		}
+		link := verifyLinkPattern.FindString(msg.Body)
+		if link == "" {
+			t.Fatalf("no link in verification email: %s", msg.Body)
+		}
+		u, err := url.Parse(link)
+		if err != nil {
+			t.Fatalf("bad link %q: %v", link, err)
+		}
+		return u.RequestURI()
+	case <-time.After(2 * time.Second):
+		t.Fatalf("no verification email sent")
+		return ""
+	}
+}
+
+// storedEmail returns the user's address and whether it has been confirmed
+func storedEmail(t *testing.T, userID string) (string, bool) {
+	var email string
+	var verified bool
+	if err := database.DB.QueryRow(`SELECT email, email_verified FROM users WHERE id = ?`, userID).Scan(&email, &verified); err != nil {
+		t.Fatalf("load email: %v", err)
+	}
+	return email, verified
+}
+
+func TestVerifyEmail(t *testing.T) {
+	r, m := setupVerifyTest(t)
+	session := register(t, r)
+	if session.EmailVerified {
+		t.Fatalf("new accounts should start unverified")
+	}
+
+	w := do(r, http.MethodPost, "/auth/verify/resend", session.Token, nil)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("resend: %d %s", w.Code, w.Body.String())
+	}
+	path := verifyPath(t, m, "reader@example.com")
+
+	if w := do(r, http.MethodGet, "/auth/verify?token=nope", "", nil); w.Code != http.StatusBadRequest {
+		t.Fatalf("unknown token: got %d, want 400", w.Code)
+	}
+	if w := do(r, http.MethodGet, path, "", nil); w.Code != http.StatusOK {
+		t.Fatalf("verify: %d %s", w.Code, w.Body.String())
+	}
+	if w := do(r, http.MethodGet, path, "", nil); w.Code != http.StatusBadRequest {
+		t.Fatalf("reused token: got %d, want 400", w.Code)
+	}
+
+	email, verified := storedEmail(t, session.UserID)
+	if !verified || email != "reader@example.com" {
+		t.Fatalf("stored email = %q, %v", email, verified)
+	}
+	w = do(r, http.MethodPost, "/auth/login", "", gin.H{"username": "reader", "password": "Secret123"})
+	var login models.AuthResponse
+	decode(t, w, &login)
+	if !login.EmailVerified {
+		t.Fatalf("login should report the verified email")
+	}
+	if w := do(r, http.MethodPost, "/auth/verify/resend", session.Token, nil); w.Code != http.StatusConflict {
+		t.Fatalf("resend when verified: got %d, want 409", w.Code)
+	}
+}
+
+func TestVerificationLinksIgnoreHost(t *testing.T) {
+	r, m := setupVerifyTest(t)
+	session := register(t, r)
+
+	req := httptest.NewRequest(http.MethodPost, "/auth/verify/resend", nil)
+	req.Host = "attacker.example"
+	req.Header.Set("Authorization", "Bearer "+session.Token)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("resend: %d %s", w.Code, w.Body.String())
+	}
+	select {
+	case msg := <-m.sent:
+		if link := verifyLinkPattern.FindString(msg.Body); !strings.HasPrefix(link, "https://api.example.com/auth/verify?") {
+			t.Fatalf("link %q doesn't use PUBLIC_API_URL", link)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("no verification email sent")
+	}
+
+	// Without a configured URL there is nothing safe to link to
+	t.Setenv("PUBLIC_API_URL", "")
+	if w := do(r, http.MethodPost, "/auth/verify/resend", session.Token, nil); w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("resend without PUBLIC_API_URL: got %d, want 503", w.Code)
+	}
+	if w := do(r, http.MethodPut, "/users/me/email", session.Token, gin.H{"email": "new@example.com", "password": "Secret123"}); w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("change email without PUBLIC_API_URL: got %d, want 503", w.Code)
+	}
+}
+
+func TestChangeEmail(t *testing.T) {
+	r, m := setupVerifyTest(t)
+	session := register(t, r)
+	markVerified(t)
+
+	w := do(r, http.MethodPut, "/users/me/email", session.Token, gin.H{"email": "new@example.com", "password": "wrong"})
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("wrong password: got %d, want 401", w.Code)
+	}
+	w = do(r, http.MethodPut, "/users/me/email", session.Token, gin.H{"email": "not-an-email", "password": "Secret123"})
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("bad address: got %d, want 400", w.Code)
+	}
+	w = do(r, http.MethodPut, "/users/me/email", session.Token, gin.H{"email": "new@example.com", "password": "Secret123"})
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("change email: %d %s", w.Code, w.Body.String())
+	}
+	path := verifyPath(t, m, "new@example.com")
+
+	// Nothing changes until the new address is confirmed
+	email, verified := storedEmail(t, session.UserID)
+	if email != "reader@example.com" || !verified {
+		t.Fatalf("email changed before confirmation: %q, %v", email, verified)
+	}
+
+	if w := do(r, http.MethodGet, path, "", nil); w.Code != http.StatusOK {
+		t.Fatalf("confirm change: %d %s", w.Code, w.Body.String())
+	}
+	email, verified = storedEmail(t, session.UserID)
+	if email != "new@example.com" || !verified {
+		t.Fatalf("after confirmation: %q, %v", email, verified)
+	}
+}
+
+func TestChangeEmailToTakenAddress(t *testing.T) {
+	r, m := setupVerifyTest(t)
+	session := register(t, r)
+
+	w := do(r, http.MethodPut, "/users/me/email", session.Token, gin.H{"email": "other@example.com", "password": "Secret123"})
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("change email: %d %s", w.Code, w.Body.String())
+	}
+	path := verifyPath(t, m, "other@example.com")
+
+	// Someone else signs up with the address before the link is opened
+	w = do(r, http.MethodPost, "/auth/register", "", gin.H{
+		"username": "other",
+		"email":    "other@example.com",
+		"password": "Secret123",
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("register other: %d %s", w.Code, w.Body.String())
+	}
+	if w := do(r, http.MethodGet, path, "", nil); w.Code != http.StatusConflict {
+		t.Fatalf("confirming a taken address: got %d, want 409", w.Code)
+	}
+	w = do(r, http.MethodPut, "/users/me/email", session.Token, gin.H{"email": "other@example.com", "password": "Secret123"})
+	if w.Code != http.StatusConflict {
+		t.Fatalf("changing to a taken address: got %d, want 409", w.Code)
+	}
+
+	var email string
+	database.DB.QueryRow(`SELECT email FROM users WHERE id = ?`, session.UserID).Scan(&email)
+	if email != "reader@example.com" {
+		t.Fatalf("email = %q, want unchanged", email)
+	}
+}
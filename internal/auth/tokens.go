@@ -0,0 +1,185 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/database"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/utils"
+)
+
+// RefreshTokenTTL is how long a session survives without being used
+const RefreshTokenTTL = 30 * 24 * time.Hour
+
+var (
+	ErrRefreshTokenInvalid = errors.New("invalid or expired refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token was already used")
+	ErrTokenRevoked        = errors.New("token has been revoked")
+)
+
+// hashToken is what the database stores in place of a refresh token, so a
+// leaked copy of the database can't be used to resume sessions.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// newRefreshToken stores a fresh refresh token in familyID. Every token
+// rotated from the same login shares a family.
+func newRefreshToken(exec execer, userID, familyID string, now time.Time) (string, time.Time, error) {
+	token, err := utils.GenerateID(32)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	expiresAt := now.Add(RefreshTokenTTL)
+	_, err = exec.Exec(`INSERT INTO refresh_tokens (token_hash, user_id, family_id, expires_at, created_at) VALUES (?, ?, ?, ?, ?)`,
+		hashToken(token), userID, familyID, expiresAt, now)
+	return token, expiresAt, err
+}
+
+// startSession issues the first refresh token of a new family and records
+// the login session it stands for
+func startSession(userID string, client sessionClient, now time.Time) (familyID, token string, expiresAt time.Time, err error) {
+	familyID, err = utils.GenerateID(16)
+	if err != nil {
+		return "", "", time.Time{}, err
+	}
+	database.DB.Exec(`DELETE FROM refresh_tokens WHERE user_id = ? AND expires_at < ?`, userID, now)
+	database.DB.Exec(`DELETE FROM login_sessions WHERE user_id = ? AND id NOT IN (SELECT family_id FROM refresh_tokens)`, userID)
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return "", "", time.Time{}, err
+	}
+	defer tx.Rollback()
+	if token, expiresAt, err = newRefreshToken(tx, userID, familyID, now); err != nil {
+		return "", "", time.Time{}, err
+	}
+	if err := touchSession(tx, familyID, userID, client, now); err != nil {
+		return "", "", time.Time{}, err
+	}
+	return familyID, token, expiresAt, tx.Commit()
+}
+
+// rotateRefreshToken spends token and returns its replacement. Presenting a
+// token that was already spent means it was copied, so the whole family is
+// revoked and both holders have to log in again.
+func rotateRefreshToken(token string, client sessionClient, now time.Time) (userID, familyID, next string, expiresAt time.Time, err error) {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return "", "", "", time.Time{}, err
+	}
+	defer tx.Rollback()
+
+	var revokedAt sql.NullTime
+	hash := hashToken(token)
+	err = tx.QueryRow(`SELECT user_id, family_id, expires_at, revoked_at FROM refresh_tokens WHERE token_hash = ?`, hash).
+		Scan(&userID, &familyID, &expiresAt, &revokedAt)
+	if err == sql.ErrNoRows {
+		return "", "", "", time.Time{}, ErrRefreshTokenInvalid
+	}
+	if err != nil {
+		return "", "", "", time.Time{}, err
+	}
+	if revokedAt.Valid {
+		if _, err := endSession(tx, userID, familyID, now); err != nil {
+			return "", "", "", time.Time{}, err
+		}
+		if err := tx.Commit(); err != nil {
+			return "", "", "", time.Time{}, err
+		}
+		log.Printf("Warning: refresh token reuse for user %s, session revoked", userID)
+		return "", "", "", time.Time{}, ErrRefreshTokenReused
+	}
+	if now.After(expiresAt) {
+		return "", "", "", time.Time{}, ErrRefreshTokenInvalid
+	}
+
+	if _, err := tx.Exec(`UPDATE refresh_tokens SET revoked_at = ? WHERE token_hash = ?`, now, hash); err != nil {
+		return "", "", "", time.Time{}, err
+	}
+	next, expiresAt, err = newRefreshToken(tx, userID, familyID, now)
+	if err != nil {
+		return "", "", "", time.Time{}, err
+	}
+	if err := touchSession(tx, familyID, userID, client, now); err != nil {
+		return "", "", "", time.Time{}, err
+	}
+	return userID, familyID, next, expiresAt, tx.Commit()
+}
+
+// revokeRefreshToken ends the session token belongs to. Tokens belonging to
+// another user are left alone.
+func revokeRefreshToken(userID, token string, now time.Time) error {
+	var familyID string
+	err := database.DB.QueryRow(`SELECT family_id FROM refresh_tokens WHERE token_hash = ? AND user_id = ?`, hashToken(token), userID).
+		Scan(&familyID)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	_, err = endSession(database.DB, userID, familyID, now)
+	return err
+}
+
+// RevokeToken adds an access token's jti to the denylist until the token
+// would have expired anyway.
+func RevokeToken(claims *utils.JWTClaims) error {
+	if claims.ID == "" {
+		return nil
+	}
+	expiresAt := time.Now().Add(utils.AccessTokenTTL)
+	if claims.ExpiresAt != nil {
+		expiresAt = claims.ExpiresAt.Time
+	}
+	database.DB.Exec(`DELETE FROM revoked_tokens WHERE expires_at < ?`, time.Now())
+	_, err := database.DB.Exec(`INSERT OR IGNORE INTO revoked_tokens (jti, user_id, expires_at) VALUES (?, ?, ?)`,
+		claims.ID, claims.UserID, expiresAt)
+	return err
+}
+
+// isRevoked checks the denylist and whether the token's login session was
+// signed out. It fails open when the database can't be read: access tokens
+// are short-lived, and a database hiccup shouldn't disconnect every client.
+func isRevoked(claims *utils.JWTClaims) bool {
+	if (claims.ID == "" && claims.SessionID == "") || database.DB == nil {
+		return false
+	}
+	var revoked bool
+	err := database.DB.QueryRow(`SELECT EXISTS(SELECT 1 FROM revoked_tokens WHERE jti = ?)
+		OR EXISTS(SELECT 1 FROM login_sessions WHERE id = ? AND revoked_at IS NOT NULL)`, claims.ID, claims.SessionID).Scan(&revoked)
+	if err != nil {
+		log.Printf("Warning: failed to check token denylist: %v", err)
+		return false
+	}
+	return revoked
+}
+
+// ValidateToken checks an access token's signature against keys, its
+// expiry and that it hasn't been revoked. Personal access tokens are looked
+// up instead and come back with their scopes set. The HTTP middleware and
+// the TCP and UDP servers all authenticate through it.
+func ValidateToken(token string, keys *KeyManager) (*utils.JWTClaims, error) {
+	if strings.HasPrefix(token, PersonalTokenPrefix) {
+		return validatePersonalToken(token, time.Now())
+	}
+	claims, err := keys.Parse(token)
+	if err != nil {
+		return nil, err
+	}
+	if isRevoked(claims) {
+		return nil, ErrTokenRevoked
+	}
+	return claims, nil
+}
@@ -0,0 +1,342 @@
+package auth
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/database"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/models"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// MFAChallengeTTL is how long the second login step stays open after
+	// the password was accepted
+	MFAChallengeTTL = 5 * time.Minute
+	// maxMFAAttempts is how many wrong codes one challenge takes before the
+	// password has to be entered again
+	maxMFAAttempts    = 5
+	recoveryCodeCount = 10
+	totpIssuer        = "MangaHub"
+)
+
+// SetupTwoFactor creates a new authenticator secret for the current user.
+// Nothing is enforced until EnableTwoFactor confirms the app works.
+func (h *Handler) SetupTwoFactor(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	var req models.TwoFactorSetupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var username, hash string
+	var enabled bool
+	err := database.DB.QueryRow(`SELECT username, password_hash, totp_enabled FROM users WHERE id = ?`, userID).
+		Scan(&username, &hash, &enabled)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Account not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if err := utils.CheckPassword(hash, req.Password); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+		return
+	}
+	if enabled {
+		c.JSON(http.StatusConflict, gin.H{"error": "Two-factor authentication is already enabled"})
+		return
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start two-factor setup"})
+		return
+	}
+	if _, err := database.DB.Exec(`UPDATE users SET totp_secret = ? WHERE id = ?`, secret, userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start two-factor setup"})
+		return
+	}
+	c.JSON(http.StatusOK, models.TwoFactorSetupResponse{
+		Secret:     secret,
+		OTPAuthURI: utils.TOTPURI(totpIssuer, username, secret),
+	})
+}
+
+// EnableTwoFactor turns two-factor authentication on once the user proves
+// their app produces the right codes, and hands out recovery codes
+func (h *Handler) EnableTwoFactor(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	var req models.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var secret sql.NullString
+	var enabled bool
+	err := database.DB.QueryRow(`SELECT totp_secret, totp_enabled FROM users WHERE id = ?`, userID).Scan(&secret, &enabled)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Account not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if enabled {
+		c.JSON(http.StatusConflict, gin.H{"error": "Two-factor authentication is already enabled"})
+		return
+	}
+	if !secret.Valid || secret.String == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Start two-factor setup first"})
+		return
+	}
+	step, ok := utils.ValidateTOTP(secret.String, req.Code, time.Now())
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid code"})
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(`UPDATE users SET totp_enabled = 1, totp_last_step = ? WHERE id = ?`, step, userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enable two-factor authentication"})
+		return
+	}
+	codes, err := newRecoveryCodes(tx, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enable two-factor authentication"})
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enable two-factor authentication"})
+		return
+	}
+	c.JSON(http.StatusOK, models.TwoFactorEnableResponse{RecoveryCodes: codes})
+}
+
+// DisableTwoFactor turns two-factor authentication off and throws away the
+// secret and any recovery codes left
+func (h *Handler) DisableTwoFactor(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	var req models.TwoFactorDisableRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var hash string
+	var enabled bool
+	err := database.DB.QueryRow(`SELECT password_hash, totp_enabled FROM users WHERE id = ?`, userID).Scan(&hash, &enabled)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Account not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if !enabled {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Two-factor authentication is not enabled"})
+		return
+	}
+	if err := utils.CheckPassword(hash, req.Password); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+		return
+	}
+	ok, err := verifySecondFactor(userID, req.Code, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid code"})
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(`UPDATE users SET totp_secret = NULL, totp_enabled = 0, totp_last_step = 0 WHERE id = ?`, userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to disable two-factor authentication"})
+		return
+	}
+	if _, err := tx.Exec(`DELETE FROM recovery_codes WHERE user_id = ?`, userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to disable two-factor authentication"})
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to disable two-factor authentication"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication disabled"})
+}
+
+// LoginTwoFactor is the second step of logging in to an account with
+// two-factor authentication. Wrong codes count towards the same lockout as
+// wrong passwords.
+func (h *Handler) LoginTwoFactor(c *gin.Context) {
+	var req models.TwoFactorLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	now := time.Now()
+	ip := c.ClientIP()
+	challenge := hashToken(strings.TrimSpace(req.MFAToken))
+
+	var userID string
+	var attempts int
+	var expiresAt time.Time
+	err := database.DB.QueryRow(`SELECT user_id, attempts, expires_at FROM mfa_challenges WHERE token_hash = ?`, challenge).
+		Scan(&userID, &attempts, &expiresAt)
+	if err == sql.ErrNoRows || (err == nil && (now.After(expiresAt) || attempts >= maxMFAAttempts)) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Login attempt expired, please log in again"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	accountKey := accountAttemptKey(userID)
+	wait, err := retryAfter(now, accountKey, ipAttemptKey(ip))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if wait > 0 {
+		tooManyAttempts(c, wait)
+		return
+	}
+
+	ok, err := verifySecondFactor(userID, req.Code, now)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if !ok {
+		database.DB.Exec(`UPDATE mfa_challenges SET attempts = attempts + 1 WHERE token_hash = ?`, challenge)
+		loginFailed(accountKey, userID, ip, now)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid code"})
+		return
+	}
+
+	database.DB.Exec(`DELETE FROM mfa_challenges WHERE token_hash = ? OR expires_at < ?`, challenge, now)
+	if err := clearFailures(accountKey); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	var user models.User
+	err = database.DB.QueryRow(`SELECT id, username, email, email_verified, role, created_at FROM users WHERE id = ?`, userID).
+		Scan(&user.ID, &user.Username, &user.Email, &user.EmailVerified, &user.Role, &user.CreatedAt)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	resp, err := h.newSession(c, &user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// newMFAChallenge opens the second login step for a user whose password
+// was just accepted
+func newMFAChallenge(userID string, now time.Time) (models.TwoFactorChallenge, error) {
+	token, err := utils.GenerateID(32)
+	if err != nil {
+		return models.TwoFactorChallenge{}, err
+	}
+	expiresAt := now.Add(MFAChallengeTTL)
+	database.DB.Exec(`DELETE FROM mfa_challenges WHERE expires_at < ?`, now)
+	_, err = database.DB.Exec(`INSERT INTO mfa_challenges (token_hash, user_id, expires_at, created_at) VALUES (?, ?, ?, ?)`,
+		hashToken(token), userID, expiresAt, now)
+	return models.TwoFactorChallenge{TwoFactorRequired: true, MFAToken: token, ExpiresAt: expiresAt}, err
+}
+
+// verifySecondFactor accepts the current authenticator code or an unused
+// recovery code. An authenticator code only works once, and a recovery
+// code is spent when it is used.
+func verifySecondFactor(userID, code string, now time.Time) (bool, error) {
+	var secret sql.NullString
+	var lastStep int64
+	if err := database.DB.QueryRow(`SELECT totp_secret, totp_last_step FROM users WHERE id = ?`, userID).
+		Scan(&secret, &lastStep); err != nil {
+		return false, err
+	}
+
+	if step, ok := utils.ValidateTOTP(secret.String, code, now); secret.Valid && ok {
+		if step <= lastStep {
+			return false, nil
+		}
+		result, err := database.DB.Exec(`UPDATE users SET totp_last_step = ? WHERE id = ? AND totp_last_step < ?`, step, userID, step)
+		if err != nil {
+			return false, err
+		}
+		n, _ := result.RowsAffected()
+		return n == 1, nil
+	}
+
+	result, err := database.DB.Exec(`UPDATE recovery_codes SET used_at = ? WHERE code_hash = ? AND user_id = ? AND used_at IS NULL`,
+		now, hashToken(normalizeRecoveryCode(code)), userID)
+	if err != nil {
+		return false, err
+	}
+	n, _ := result.RowsAffected()
+	return n == 1, nil
+}
+
+// recoveryAlphabet leaves out characters that are easy to misread
+const recoveryAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// newRecoveryCodes replaces the user's recovery codes and returns the new
+// ones, formatted as XXXXX-XXXXX. Only their hashes are stored.
+func newRecoveryCodes(exec execer, userID string) ([]string, error) {
+	if _, err := exec.Exec(`DELETE FROM recovery_codes WHERE user_id = ?`, userID); err != nil {
+		return nil, err
+	}
+	codes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		raw := make([]byte, 10)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, err
+		}
+		for j := range raw {
+			raw[j] = recoveryAlphabet[int(raw[j])%len(recoveryAlphabet)]
+		}
+		code := string(raw[:5]) + "-" + string(raw[5:])
+		if _, err := exec.Exec(`INSERT INTO recovery_codes (code_hash, user_id) VALUES (?, ?)`,
+			hashToken(normalizeRecoveryCode(code)), userID); err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+	}
+	return codes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
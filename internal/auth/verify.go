@@ -0,0 +1,240 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/mailer"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/database"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/models"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// VerificationTokenTTL is how long an email confirmation link stays usable
+const VerificationTokenTTL = 24 * time.Hour
+
+// VerifyEmail confirms an address using the token from a verification email.
+// For an email change this is also the moment the account switches address.
+func (h *Handler) VerifyEmail(c *gin.Context) {
+	token := strings.TrimSpace(c.Query("token"))
+	if token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "token is required"})
+		return
+	}
+
+	now := time.Now()
+	tx, err := database.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	defer tx.Rollback()
+
+	var userID, email string
+	var expiresAt time.Time
+	hash := hashToken(token)
+	err = tx.QueryRow(`SELECT user_id, email, expires_at FROM email_verifications WHERE token_hash = ?`, hash).
+		Scan(&userID, &email, &expiresAt)
+	if err == sql.ErrNoRows || (err == nil && now.After(expiresAt)) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired verification token"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	// The address may have been taken by another account since the change
+	// was requested
+	var taken int
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM users WHERE email = ? AND id != ?`, email, userID).Scan(&taken); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if taken > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Email already exists"})
+		return
+	}
+
+	if _, err := tx.Exec(`UPDATE users SET email = ?, email_verified = 1 WHERE id = ?`, email, userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify email"})
+		return
+	}
+	if _, err := tx.Exec(`DELETE FROM email_verifications WHERE user_id = ?`, userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify email"})
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify email"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Email verified", "email": email})
+}
+
+// ResendVerification sends a fresh confirmation link for the account's
+// current address
+func (h *Handler) ResendVerification(c *gin.Context) {
+	base, ok := linksConfigured(c)
+	if !ok {
+		return
+	}
+	userID := c.GetString("user_id")
+
+	var user models.User
+	err := database.DB.QueryRow(`SELECT id, username, email, email_verified FROM users WHERE id = ?`, userID).
+		Scan(&user.ID, &user.Username, &user.Email, &user.EmailVerified)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Account not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if user.EmailVerified {
+		c.JSON(http.StatusConflict, gin.H{"error": "Email is already verified"})
+		return
+	}
+
+	token, err := newVerification(user.ID, user.Email, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start verification"})
+		return
+	}
+	go h.sendVerificationEmail(user.Username, user.Email, verifyLink(base, token), false)
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "Verification email sent to " + user.Email})
+}
+
+// ChangeEmail starts moving the account to a new address. Nothing changes
+// until the link sent to the new address is opened, so a typo can't lock
+// anyone out.
+func (h *Handler) ChangeEmail(c *gin.Context) {
+	base, ok := linksConfigured(c)
+	if !ok {
+		return
+	}
+	userID := c.GetString("user_id")
+
+	var req models.ChangeEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	email := strings.TrimSpace(req.Email)
+	if _, err := mail.ParseAddress(email); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid email format"})
+		return
+	}
+
+	var user models.User
+	err := database.DB.QueryRow(`SELECT id, username, email, password_hash FROM users WHERE id = ?`, userID).
+		Scan(&user.ID, &user.Username, &user.Email, &user.PasswordHash)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Account not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if err := utils.CheckPassword(user.PasswordHash, req.Password); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+		return
+	}
+	if strings.EqualFold(email, user.Email) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "That is already your email"})
+		return
+	}
+
+	var taken int
+	if err := database.DB.QueryRow(`SELECT COUNT(*) FROM users WHERE email = ?`, email).Scan(&taken); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if taken > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Email already exists"})
+		return
+	}
+
+	token, err := newVerification(user.ID, email, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start email change"})
+		return
+	}
+	go h.sendVerificationEmail(user.Username, email, verifyLink(base, token), true)
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "Confirmation sent to " + email + ", your email changes once it is opened"})
+}
+
+// newVerification stores a confirmation token for email. A newer request
+// replaces any pending one, so only the latest link works.
+func newVerification(userID, email string, now time.Time) (string, error) {
+	token, err := utils.GenerateID(32)
+	if err != nil {
+		return "", err
+	}
+	database.DB.Exec(`DELETE FROM email_verifications WHERE user_id = ? OR expires_at < ?`, userID, now)
+	_, err = database.DB.Exec(`INSERT INTO email_verifications (token_hash, user_id, email, expires_at, created_at) VALUES (?, ?, ?, ?, ?)`,
+		hashToken(token), userID, email, now.Add(VerificationTokenTTL), now)
+	return token, err
+}
+
+// verifyLink builds the confirmation URL
+func verifyLink(base, token string) string {
+	return base + "/auth/verify?token=" + url.QueryEscape(token)
+}
+
+// publicBaseURL is where users reach the API, set by PUBLIC_API_URL. Links
+// are never built from the request's Host header, which the client chooses,
+// so nothing that needs a link is sent without it.
+func publicBaseURL() (string, bool) {
+	base := strings.TrimRight(strings.TrimSpace(os.Getenv("PUBLIC_API_URL")), "/")
+	return base, base != ""
+}
+
+// linksConfigured answers 503 when there is no public URL to link to
+func linksConfigured(c *gin.Context) (string, bool) {
+	base, ok := publicBaseURL()
+	if !ok {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "PUBLIC_API_URL is not configured"})
+	}
+	return base, ok
+}
+
+func (h *Handler) sendVerificationEmail(username, email, link string, change bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	subject := "Confirm your MangaHub email"
+	intro := "Thanks for signing up to MangaHub. Please confirm this is your address:"
+	if change {
+		subject = "Confirm your new MangaHub email"
+		intro = "You asked to move your MangaHub account to this address. Open this\nlink to confirm the change:"
+	}
+	err := h.mailer.Send(ctx, mailer.Message{
+		To:      email,
+		Subject: subject,
+		Body: fmt.Sprintf(`Hi %s,
+
+%s
+
+    %s
+
+The link expires in %d hours. If this wasn't you, you can ignore this email.
+`, username, intro, link, int(VerificationTokenTTL.Hours())),
+	})
+	if err != nil {
+		log.Printf("Warning: failed to send verification email to %s: %v", email, err)
+	}
+}
@@ -23,6 +23,8 @@ type DeviceConnection struct {
 	LastHeartbeat time.Time
 }
 
+// GetUserDevices lists the user's TCP clients. Clients that have sent a
+// connect message report their sync session; the rest are named by address.
 func (b *Bridge) GetUserDevices(userID string) []DeviceInfo {
 	b.clientsLock.RLock()
 	defer b.clientsLock.RUnlock()
@@ -35,14 +37,24 @@ func (b *Bridge) GetUserDevices(userID string) []DeviceInfo {
 	devices := make([]DeviceInfo, 0, len(clients))
 
 	for _, client := range clients {
+		addr := client.Conn.RemoteAddr().String()
 		deviceInfo := DeviceInfo{
-			DeviceType:    "unknown",
-			DeviceName:    client.Conn.RemoteAddr().String(),
-			SessionID:     "",
-			ConnectedAt:   time.Now(),
-			LastHeartbeat: time.Now(),
+			DeviceName:    addr,
+			ConnectedAt:   client.ConnectedAt,
+			LastHeartbeat: client.ConnectedAt,
 			IsOnline:      true,
 		}
+		if b.sessionManager != nil {
+			if sessionAny, ok := b.sessionManager.GetSessionByClientID(addr); ok {
+				if session, ok := sessionAny.(Session); ok {
+					deviceInfo.SessionID = session.GetSessionID()
+					deviceInfo.DeviceType = session.GetDeviceType()
+					deviceInfo.DeviceName = session.GetDeviceName()
+					deviceInfo.ConnectedAt = session.GetConnectedAt()
+					deviceInfo.LastHeartbeat = session.GetLastHeartbeat()
+				}
+			}
+		}
 		devices = append(devices, deviceInfo)
 	}
 
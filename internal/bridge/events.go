@@ -1,13 +1,20 @@
 package bridge
 
-import "time"
+import (
+	"time"
+
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/models"
+)
 
 type EventType string
 
 const (
-	EventTypeProgressUpdate EventType = "progress_update"
-	EventTypeLibraryUpdate  EventType = "library_update"
-	EventTypeUserMessage    EventType = "user_message"
+	EventTypeProgressUpdate      EventType = "progress_update"
+	EventTypeLibraryUpdate       EventType = "library_update"
+	EventTypeUserMessage         EventType = "user_message"
+	EventTypeCommentReply        EventType = "comment_reply"
+	EventTypeGoalUpdate          EventType = "goal_update"
+	EventTypeAchievementUnlocked EventType = "achievement_unlocked"
 )
 
 type Event struct {
@@ -18,16 +25,49 @@ type Event struct {
 }
 
 type ProgressUpdateEvent struct {
-	UserID       string    `json:"user_id"`
-	MangaID      string    `json:"manga_id"`
-	MangaTitle   string    `json:"manga_title"`
-	ChapterID    int       `json:"chapter_id"`
-	Status       string    `json:"status"`
-	LastReadDate time.Time `json:"last_read_date"`
+	UserID       string               `json:"user_id"`
+	MangaID      string               `json:"manga_id"`
+	MangaTitle   string               `json:"manga_title"`
+	ChapterID    int                  `json:"chapter_id"`
+	Volume       int                  `json:"volume,omitempty"`
+	Status       models.ReadingStatus `json:"status"`
+	LastReadDate time.Time            `json:"last_read_date"`
 }
 
 type LibraryUpdateEvent struct {
-	UserID  string `json:"user_id"`
-	MangaID string `json:"manga_id"`
-	Action  string `json:"action"`
+	UserID  string   `json:"user_id"`
+	MangaID string   `json:"manga_id"`
+	Action  string   `json:"action"`
+	Tags    []string `json:"tags,omitempty"`
+}
+
+// CommentReplyEvent announces a new comment in a chapter thread. The body is
+// left out so notifications never spoil chapters the recipient hasn't read.
+type CommentReplyEvent struct {
+	CommentID      string   `json:"comment_id"`
+	ParentID       string   `json:"parent_id,omitempty"`
+	MangaID        string   `json:"manga_id"`
+	Chapter        int      `json:"chapter"`
+	AuthorID       string   `json:"author_id"`
+	AuthorUsername string   `json:"author_username"`
+	Participants   []string `json:"-"`
+}
+
+type GoalUpdateEvent struct {
+	UserID   string `json:"user_id"`
+	GoalID   string `json:"goal_id"`
+	Type     string `json:"type"`
+	Genre    string `json:"genre,omitempty"`
+	Target   int    `json:"target"`
+	Progress int    `json:"progress"`
+	Expected int    `json:"expected"`
+	Pace     string `json:"pace"`
+}
+
+type AchievementUnlockedEvent struct {
+	UserID        string    `json:"user_id"`
+	AchievementID string    `json:"achievement_id"`
+	Name          string    `json:"name"`
+	Description   string    `json:"description"`
+	UnlockedAt    time.Time `json:"unlocked_at"`
 }
@@ -11,8 +11,9 @@ import (
 )
 
 type TCPClient struct {
-	Conn   net.Conn
-	UserID string
+	Conn        net.Conn
+	UserID      string
+	ConnectedAt time.Time
 }
 
 type UDPBroadcaster interface {
@@ -33,6 +34,7 @@ type Bridge struct {
 	clientsLock    sync.RWMutex
 	eventChan      chan Event
 	stopChan       chan struct{}
+	listeners      []func(Event)
 }
 
 type SessionManager interface {
@@ -42,9 +44,12 @@ type SessionManager interface {
 }
 
 type Session interface {
+	GetSessionID() string
 	GetUserID() string
 	GetDeviceType() string
 	GetDeviceName() string
+	GetConnectedAt() time.Time
+	GetLastHeartbeat() time.Time
 }
 
 func NewBridge(log *logger.Logger) *Bridge {
@@ -74,6 +79,14 @@ func (b *Bridge) SetUDPBroadcaster(broadcaster UDPBroadcaster) {
 	b.logger.Info("udp_broadcaster_set")
 }
 
+// OnEvent registers fn to be called for every event the bridge delivers.
+// Listeners run on their own goroutine so they may queue further events.
+func (b *Bridge) OnEvent(fn func(Event)) {
+	b.clientsLock.Lock()
+	defer b.clientsLock.Unlock()
+	b.listeners = append(b.listeners, fn)
+}
+
 func (b *Bridge) SetSessionManager(sm SessionManager) {
 	b.clientsLock.Lock()
 	defer b.clientsLock.Unlock()
@@ -86,8 +99,9 @@ func (b *Bridge) RegisterTCPClient(conn net.Conn, userID string) {
 	defer b.clientsLock.Unlock()
 
 	client := &TCPClient{
-		Conn:   conn,
-		UserID: userID,
+		Conn:        conn,
+		UserID:      userID,
+		ConnectedAt: time.Now(),
 	}
 
 	b.clients[userID] = append(b.clients[userID], client)
@@ -128,6 +142,9 @@ func (b *Bridge) NotifyProgressUpdate(event ProgressUpdateEvent) {
 		"status":         event.Status,
 		"last_read_date": event.LastReadDate,
 	}
+	if event.Volume > 0 {
+		data["volume"] = event.Volume
+	}
 
 	b.eventChan <- Event{
 		Type:      EventTypeProgressUpdate,
@@ -157,6 +174,9 @@ func (b *Bridge) NotifyLibraryUpdate(event LibraryUpdateEvent) {
 		"manga_id": event.MangaID,
 		"action":   event.Action,
 	}
+	if event.Tags != nil {
+		data["tags"] = event.Tags
+	}
 
 	b.eventChan <- Event{
 		Type:   EventTypeLibraryUpdate,
@@ -180,6 +200,108 @@ func (b *Bridge) NotifyLibraryUpdate(event LibraryUpdateEvent) {
 	}
 }
 
+// NotifyGoalUpdate tells the user a reading goal was hit or fell behind pace
+func (b *Bridge) NotifyGoalUpdate(event GoalUpdateEvent) {
+	data := map[string]interface{}{
+		"goal_id":  event.GoalID,
+		"type":     event.Type,
+		"target":   event.Target,
+		"progress": event.Progress,
+		"expected": event.Expected,
+		"pace":     event.Pace,
+	}
+	if event.Genre != "" {
+		data["genre"] = event.Genre
+	}
+
+	b.eventChan <- Event{
+		Type:      EventTypeGoalUpdate,
+		UserID:    event.UserID,
+		Data:      data,
+		Timestamp: time.Now(),
+	}
+
+	b.logger.Debug("goal_update_queued",
+		"user_id", event.UserID,
+		"goal_id", event.GoalID,
+		"pace", event.Pace,
+	)
+
+	if b.udpBroadcaster != nil {
+		b.udpBroadcaster.BroadcastToUser(event.UserID, BroadcastEvent{
+			EventType: "goal_update",
+			Data:      data,
+		})
+	}
+}
+
+// NotifyAchievementUnlocked announces a newly unlocked badge to the user's devices
+func (b *Bridge) NotifyAchievementUnlocked(event AchievementUnlockedEvent) {
+	data := map[string]interface{}{
+		"achievement_id": event.AchievementID,
+		"name":           event.Name,
+		"description":    event.Description,
+		"unlocked_at":    event.UnlockedAt,
+	}
+
+	b.eventChan <- Event{
+		Type:      EventTypeAchievementUnlocked,
+		UserID:    event.UserID,
+		Data:      data,
+		Timestamp: event.UnlockedAt,
+	}
+
+	b.logger.Debug("achievement_unlocked_queued",
+		"user_id", event.UserID,
+		"achievement_id", event.AchievementID,
+	)
+
+	if b.udpBroadcaster != nil {
+		b.udpBroadcaster.BroadcastToUser(event.UserID, BroadcastEvent{
+			EventType: "achievement_unlocked",
+			Data:      data,
+		})
+	}
+}
+
+// NotifyCommentReply delivers a new chapter comment to every other
+// participant of the thread.
+func (b *Bridge) NotifyCommentReply(event CommentReplyEvent) {
+	data := map[string]interface{}{
+		"comment_id":      event.CommentID,
+		"parent_id":       event.ParentID,
+		"manga_id":        event.MangaID,
+		"chapter":         event.Chapter,
+		"author_username": event.AuthorUsername,
+	}
+
+	for _, userID := range event.Participants {
+		if userID == event.AuthorID {
+			continue
+		}
+
+		b.eventChan <- Event{
+			Type:      EventTypeCommentReply,
+			UserID:    userID,
+			Data:      data,
+			Timestamp: time.Now(),
+		}
+
+		if b.udpBroadcaster != nil {
+			b.udpBroadcaster.BroadcastToUser(userID, BroadcastEvent{
+				EventType: "comment_reply",
+				Data:      data,
+			})
+		}
+	}
+
+	b.logger.Debug("comment_reply_queued",
+		"manga_id", event.MangaID,
+		"chapter", event.Chapter,
+		"participants", len(event.Participants),
+	)
+}
+
 func (b *Bridge) broadcastUpdateEvent(userID, action, mangaTitle string, chapter int, direction string) {
 	if b.sessionManager == nil {
 		return
@@ -315,6 +437,11 @@ func (b *Bridge) processEvents() {
 		select {
 		case event := <-b.eventChan:
 			b.BroadcastToUser(event.UserID, event)
+			b.clientsLock.RLock()
+			for _, fn := range b.listeners {
+				go fn(event)
+			}
+			b.clientsLock.RUnlock()
 		case <-b.stopChan:
 			b.logger.Info("bridge_stopped")
 			return
@@ -0,0 +1,293 @@
+package comment
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/auth"
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/bridge"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/database"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/models"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// Handler handles chapter discussion threads
+type Handler struct {
+	bridge *bridge.Bridge
+}
+
+// NewHandler creates a new comment handler
+func NewHandler(br *bridge.Bridge) *Handler {
+	return &Handler{
+		bridge: br,
+	}
+}
+
+// ListComments returns the threaded comments for a chapter. Comments on
+// chapters past the caller's reading progress are returned without their
+// body unless ?spoilers=true is passed.
+func (h *Handler) ListComments(c *gin.Context) {
+	mangaID := c.Param("id")
+	chapter, ok := chapterParam(c)
+	if !ok {
+		return
+	}
+
+	if !mangaExists(mangaID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Manga not found"})
+		return
+	}
+
+	userID := c.GetString("user_id")
+	hidden := chapter > readChapter(userID, mangaID) && c.Query("spoilers") != "true"
+
+	query := `SELECT cc.id, cc.manga_id, cc.chapter, cc.user_id, COALESCE(u.username, ''),
+                     COALESCE(cc.parent_id, ''), cc.body, cc.created_at
+              FROM chapter_comments cc
+              LEFT JOIN users u ON u.id = cc.user_id
+              WHERE cc.manga_id = ? AND cc.chapter = ?
+              ORDER BY cc.created_at, cc.id`
+	rows, err := database.DB.Query(query, mangaID, chapter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	defer rows.Close()
+
+	var comments []models.ChapterComment
+	for rows.Next() {
+		var cm models.ChapterComment
+		err := rows.Scan(&cm.ID, &cm.MangaID, &cm.Chapter, &cm.UserID, &cm.Username, &cm.ParentID, &cm.Body, &cm.CreatedAt)
+		if err != nil {
+			continue
+		}
+		// Your own comments are never a spoiler to you
+		if hidden && cm.UserID != userID {
+			cm.Body = ""
+			cm.Hidden = true
+		}
+		comments = append(comments, cm)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"manga_id":          mangaID,
+		"chapter":           chapter,
+		"comments":          buildThreads(comments),
+		"count":             len(comments),
+		"spoiler_protected": hidden,
+	})
+}
+
+// CreateComment posts a comment or a reply in a chapter thread
+func (h *Handler) CreateComment(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	mangaID := c.Param("id")
+	chapter, ok := chapterParam(c)
+	if !ok {
+		return
+	}
+
+	var req models.CreateCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	body := strings.TrimSpace(req.Body)
+	if body == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Comment body is required"})
+		return
+	}
+
+	if !mangaExists(mangaID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Manga not found"})
+		return
+	}
+
+	if req.ParentID != "" {
+		var parentManga string
+		var parentChapter int
+		err := database.DB.QueryRow(`SELECT manga_id, chapter FROM chapter_comments WHERE id = ?`, req.ParentID).
+			Scan(&parentManga, &parentChapter)
+		if err == sql.ErrNoRows || (err == nil && (parentManga != mangaID || parentChapter != chapter)) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Parent comment not found in this thread"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+	}
+
+	commentID, err := utils.GenerateID(16)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate comment ID"})
+		return
+	}
+
+	var parentID interface{}
+	if req.ParentID != "" {
+		parentID = req.ParentID
+	}
+
+	now := time.Now()
+	_, err = database.DB.Exec(`INSERT INTO chapter_comments (id, manga_id, chapter, user_id, parent_id, body, created_at)
+                               VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		commentID, mangaID, chapter, userID, parentID, body, now)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to post comment"})
+		return
+	}
+
+	comment := models.ChapterComment{
+		ID:        commentID,
+		MangaID:   mangaID,
+		Chapter:   chapter,
+		UserID:    userID,
+		Username:  c.GetString("username"),
+		ParentID:  req.ParentID,
+		Body:      body,
+		CreatedAt: now,
+		Replies:   []models.ChapterComment{},
+	}
+
+	if h.bridge != nil {
+		participants, err := threadParticipants(mangaID, chapter)
+		if err == nil && len(participants) > 0 {
+			h.bridge.NotifyCommentReply(bridge.CommentReplyEvent{
+				CommentID:      commentID,
+				ParentID:       req.ParentID,
+				MangaID:        mangaID,
+				Chapter:        chapter,
+				AuthorID:       userID,
+				AuthorUsername: comment.Username,
+				Participants:   participants,
+			})
+		}
+	}
+
+	c.JSON(http.StatusCreated, comment)
+}
+
+// DeleteComment removes a comment and its replies. Authors can delete their
+// own comments; moderators can delete anyone's.
+func (h *Handler) DeleteComment(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	mangaID := c.Param("id")
+	chapter, ok := chapterParam(c)
+	if !ok {
+		return
+	}
+	commentID := c.Param("comment_id")
+
+	var authorID string
+	err := database.DB.QueryRow(`SELECT user_id FROM chapter_comments WHERE id = ? AND manga_id = ? AND chapter = ?`,
+		commentID, mangaID, chapter).Scan(&authorID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Comment not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if authorID != userID && !auth.HasPermission(auth.ContextRole(c), auth.PermModerateContent) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can only delete your own comments"})
+		return
+	}
+
+	if _, err := database.DB.Exec(`DELETE FROM chapter_comments WHERE id = ?`, commentID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete comment"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Comment deleted successfully"})
+}
+
+func chapterParam(c *gin.Context) (int, bool) {
+	chapter, err := strconv.Atoi(c.Param("n"))
+	if err != nil || chapter < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Chapter must be a positive number"})
+		return 0, false
+	}
+	return chapter, true
+}
+
+func mangaExists(mangaID string) bool {
+	var exists bool
+	err := database.DB.QueryRow(`SELECT EXISTS(SELECT 1 FROM manga WHERE id = ?)`, mangaID).Scan(&exists)
+	return err == nil && exists
+}
+
+// readChapter returns how far the user has read, or 0 for anonymous callers
+// and manga outside their library.
+func readChapter(userID, mangaID string) int {
+	if userID == "" {
+		return 0
+	}
+	var current int
+	database.DB.QueryRow(`SELECT current_chapter FROM user_progress WHERE user_id = ? AND manga_id = ?`, userID, mangaID).
+		Scan(&current)
+	return current
+}
+
+// threadParticipants returns everyone who has commented on the chapter.
+func threadParticipants(mangaID string, chapter int) ([]string, error) {
+	rows, err := database.DB.Query(`SELECT DISTINCT user_id FROM chapter_comments WHERE manga_id = ? AND chapter = ?`, mangaID, chapter)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var participants []string
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		participants = append(participants, userID)
+	}
+	return participants, rows.Err()
+}
+
+// buildThreads nests replies under their parents. Comments must be ordered
+// oldest first so parents are seen before their replies.
+func buildThreads(comments []models.ChapterComment) []models.ChapterComment {
+	children := map[string][]int{}
+	var roots []int
+	for i, cm := range comments {
+		if cm.ParentID == "" {
+			roots = append(roots, i)
+		} else {
+			children[cm.ParentID] = append(children[cm.ParentID], i)
+		}
+	}
+
+	var build func(i int) models.ChapterComment
+	build = func(i int) models.ChapterComment {
+		cm := comments[i]
+		cm.Replies = []models.ChapterComment{}
+		for _, child := range children[cm.ID] {
+			cm.Replies = append(cm.Replies, build(child))
+		}
+		return cm
+	}
+
+	threads := []models.ChapterComment{}
+	for _, i := range roots {
+		threads = append(threads, build(i))
+	}
+	return threads
+}
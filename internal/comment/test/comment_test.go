@@ -0,0 +1,254 @@
+package comment_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/bridge"
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/comment"
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/manga"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/database"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/logger"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+type recordingBroadcaster struct {
+	mu     sync.Mutex
+	events map[string][]bridge.BroadcastEvent
+}
+
+func (r *recordingBroadcaster) BroadcastToUser(userID string, event bridge.BroadcastEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events[userID] = append(r.events[userID], event)
+}
+
+func (r *recordingBroadcaster) count(userID string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.events[userID])
+}
+
+func setupCommentTest(t *testing.T) (*gin.Engine, *recordingBroadcaster) {
+	tmpDir := t.TempDir()
+	if err := database.InitDatabase(tmpDir + "/test.db"); err != nil {
+		t.Fatalf("init db: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	for _, u := range []string{"alice", "bob", "carol"} {
+		_, err := database.DB.Exec(`INSERT INTO users (id, username, email, password_hash) VALUES (?, ?, ?, 'x')`, u, u, u+"@example.com")
+		if err != nil {
+			t.Fatalf("insert user: %v", err)
+		}
+	}
+	if err := manga.InsertManga(&models.Manga{ID: "1", Title: "Vinland Saga"}); err != nil {
+		t.Fatalf("insert manga: %v", err)
+	}
+	// bob has read up to chapter 10
+	if _, err := database.DB.Exec(`INSERT INTO user_progress (user_id, manga_id, current_chapter, status) VALUES ('bob', '1', 10, 'reading')`); err != nil {
+		t.Fatalf("insert progress: %v", err)
+	}
+
+	logger.Init(logger.INFO, false, nil)
+	br := bridge.NewBridge(logger.GetLogger())
+	br.Start()
+	t.Cleanup(br.Stop)
+	recorder := &recordingBroadcaster{events: map[string][]bridge.BroadcastEvent{}}
+	br.SetUDPBroadcaster(recorder)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	// Stand-in for the auth middleware
+	router.Use(func(c *gin.Context) {
+		if id := c.GetHeader("X-User-ID"); id != "" {
+			c.Set("user_id", id)
+			c.Set("username", id)
+		}
+		c.Set("role", c.GetHeader("X-Role"))
+	})
+	handler := comment.NewHandler(br)
+	router.GET("/manga/:id/chapters/:n/comments", handler.ListComments)
+	router.POST("/manga/:id/chapters/:n/comments", handler.CreateComment)
+	router.DELETE("/manga/:id/chapters/:n/comments/:comment_id", handler.DeleteComment)
+	return router, recorder
+}
+
+func postComment(t *testing.T, router *gin.Engine, chapter, userID string, body gin.H) models.ChapterComment {
+	payload, _ := json.Marshal(body)
+	req := httptest.NewRequest("POST", "/manga/1/chapters/"+chapter+"/comments", bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-User-ID", userID)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	if resp.Code != 201 {
+		t.Fatalf("post: expected 201, got %d: %s", resp.Code, resp.Body.String())
+	}
+	var created models.ChapterComment
+	json.Unmarshal(resp.Body.Bytes(), &created)
+	return created
+}
+
+type threadResponse struct {
+	Comments         []models.ChapterComment `json:"comments"`
+	Count            int                     `json:"count"`
+	SpoilerProtected bool                    `json:"spoiler_protected"`
+}
+
+func getThread(t *testing.T, router *gin.Engine, path, userID string) threadResponse {
+	req := httptest.NewRequest("GET", path, nil)
+	if userID != "" {
+		req.Header.Set("X-User-ID", userID)
+	}
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	if resp.Code != 200 {
+		t.Fatalf("get: expected 200, got %d: %s", resp.Code, resp.Body.String())
+	}
+	var result threadResponse
+	json.Unmarshal(resp.Body.Bytes(), &result)
+	return result
+}
+
+func TestComments_Threading(t *testing.T) {
+	router, _ := setupCommentTest(t)
+
+	root := postComment(t, router, "5", "alice", gin.H{"body": "That fight!"})
+	postComment(t, router, "5", "bob", gin.H{"body": "Agreed", "parent_id": root.ID})
+	postComment(t, router, "5", "carol", gin.H{"body": "Great chapter"})
+
+	thread := getThread(t, router, "/manga/1/chapters/5/comments", "bob")
+	if thread.Count != 3 || len(thread.Comments) != 2 {
+		t.Fatalf("expected 3 comments in 2 threads, got %d in %d", thread.Count, len(thread.Comments))
+	}
+	if len(thread.Comments[0].Replies) != 1 || thread.Comments[0].Replies[0].Body != "Agreed" {
+		t.Errorf("expected reply nested under first comment, got %+v", thread.Comments[0])
+	}
+}
+
+func TestComments_SpoilerProtection(t *testing.T) {
+	router, _ := setupCommentTest(t)
+
+	postComment(t, router, "12", "alice", gin.H{"body": "He dies"})
+
+	thread := getThread(t, router, "/manga/1/chapters/12/comments", "bob")
+	if !thread.SpoilerProtected || !thread.Comments[0].Hidden || thread.Comments[0].Body != "" {
+		t.Errorf("expected chapter 12 to be hidden for a reader at chapter 10, got %+v", thread)
+	}
+
+	thread = getThread(t, router, "/manga/1/chapters/12/comments?spoilers=true", "bob")
+	if thread.Comments[0].Body != "He dies" {
+		t.Errorf("expected body when opting in to spoilers, got %+v", thread.Comments[0])
+	}
+
+	thread = getThread(t, router, "/manga/1/chapters/12/comments", "alice")
+	if thread.Comments[0].Hidden {
+		t.Errorf("authors should always see their own comments")
+	}
+
+	thread = getThread(t, router, "/manga/1/chapters/12/comments", "")
+	if !thread.Comments[0].Hidden {
+		t.Errorf("expected anonymous callers to be spoiler protected")
+	}
+}
+
+func TestComments_ParentMustBeInThread(t *testing.T) {
+	router, _ := setupCommentTest(t)
+
+	root := postComment(t, router, "3", "alice", gin.H{"body": "Hello"})
+
+	payload, _ := json.Marshal(gin.H{"body": "Wrong thread", "parent_id": root.ID})
+	req := httptest.NewRequest("POST", "/manga/1/chapters/4/comments", bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-User-ID", "bob")
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	if resp.Code != 400 {
+		t.Errorf("expected 400, got %d", resp.Code)
+	}
+}
+
+func TestComments_NotifiesParticipants(t *testing.T) {
+	router, recorder := setupCommentTest(t)
+
+	root := postComment(t, router, "7", "alice", gin.H{"body": "First"})
+	postComment(t, router, "7", "bob", gin.H{"body": "Reply", "parent_id": root.ID})
+
+	if recorder.count("alice") != 1 {
+		t.Errorf("expected alice to be notified once, got %d", recorder.count("alice"))
+	}
+	if recorder.count("bob") != 0 {
+		t.Errorf("authors should not be notified of their own comments")
+	}
+	if recorder.count("carol") != 0 {
+		t.Errorf("non-participants should not be notified")
+	}
+}
+
+func TestComments_DeleteOwnOrModerate(t *testing.T) {
+	router, _ := setupCommentTest(t)
+
+	root := postComment(t, router, "5", "alice", gin.H{"body": "That fight!"})
+	postComment(t, router, "5", "bob", gin.H{"body": "Agreed", "parent_id": root.ID})
+	other := postComment(t, router, "5", "carol", gin.H{"body": "Great chapter"})
+
+	deleteComment := func(id, userID, role string) int {
+		req := httptest.NewRequest("DELETE", "/manga/1/chapters/5/comments/"+id, nil)
+		req.Header.Set("X-User-ID", userID)
+		req.Header.Set("X-Role", role)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp.Code
+	}
+
+	if code := deleteComment(root.ID, "bob", "user"); code != 403 {
+		t.Fatalf("deleting someone else's comment: expected 403, got %d", code)
+	}
+	if code := deleteComment(other.ID, "carol", "user"); code != 200 {
+		t.Fatalf("deleting own comment: expected 200, got %d", code)
+	}
+	if code := deleteComment(root.ID, "bob", "moderator"); code != 200 {
+		t.Fatalf("moderator deleting a comment: expected 200, got %d", code)
+	}
+	if code := deleteComment(root.ID, "bob", "moderator"); code != 404 {
+		t.Fatalf("deleting a deleted comment: expected 404, got %d", code)
+	}
+
+	thread := getThread(t, router, "/manga/1/chapters/5/comments", "alice")
+	if thread.Count != 0 {
+		t.Fatalf("expected the thread and its replies to be gone, got %d comments", thread.Count)
+	}
+}
+
+func TestComments_DeleteRemovesReplies(t *testing.T) {
+	router, _ := setupCommentTest(t)
+
+	root := postComment(t, router, "5", "alice", gin.H{"body": "That fight!"})
+	postComment(t, router, "5", "bob", gin.H{"body": "Agreed", "parent_id": root.ID})
+
+	// Holding a connection makes the delete run on another one from the pool
+	held, err := database.DB.Conn(context.Background())
+	if err != nil {
+		t.Fatalf("conn: %v", err)
+	}
+	defer held.Close()
+
+	req := httptest.NewRequest("DELETE", "/manga/1/chapters/5/comments/"+root.ID, nil)
+	req.Header.Set("X-User-ID", "alice")
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	if resp.Code != 200 {
+		t.Fatalf("delete: expected 200, got %d: %s", resp.Code, resp.Body.String())
+	}
+
+	var replies int
+	database.DB.QueryRow(`SELECT COUNT(*) FROM chapter_comments WHERE parent_id = ?`, root.ID).Scan(&replies)
+	if replies != 0 {
+		t.Fatalf("expected replies to be deleted with their parent, %d left", replies)
+	}
+}
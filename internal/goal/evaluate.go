@@ -0,0 +1,192 @@
+package goal
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/bridge"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/database"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/models"
+)
+
+const dayFormat = "2006-01-02"
+
+const goalSelect = `SELECT id, type, target, COALESCE(genre, ''), start_date, end_date, COALESCE(pace, ''), achieved_at, created_at
+    FROM goals`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanGoal(row rowScanner) (models.Goal, error) {
+	var g models.Goal
+	err := row.Scan(&g.ID, &g.Type, &g.Target, &g.Genre, &g.StartDate, &g.EndDate, &g.Pace, &g.AchievedAt, &g.CreatedAt)
+	return g, err
+}
+
+// Evaluate re-measures the user's open goals after their progress changed and
+// pushes a goal_update event when one is hit or falls behind pace. Goals that
+// were already achieved or missed are left alone.
+func Evaluate(br *bridge.Bridge, userID string) error {
+	rows, err := database.DB.Query(goalSelect+` WHERE user_id = ? AND COALESCE(pace, '') NOT IN (?, ?)`,
+		userID, models.GoalAchieved, models.GoalMissed)
+	if err != nil {
+		return err
+	}
+	var open []models.Goal
+	for rows.Next() {
+		g, err := scanGoal(rows)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		open = append(open, g)
+	}
+	rows.Close()
+
+	now := time.Now()
+	for _, g := range open {
+		previous := g.Pace
+		if err := measure(userID, &g, now); err != nil {
+			return err
+		}
+		if g.Pace == previous {
+			continue
+		}
+
+		_, err := database.DB.Exec(`UPDATE goals SET pace = ?, achieved_at = ? WHERE id = ?`, g.Pace, g.AchievedAt, g.ID)
+		if err != nil {
+			return err
+		}
+
+		if br != nil && (g.Pace == models.GoalAchieved || g.Pace == models.GoalBehind) {
+			br.NotifyGoalUpdate(bridge.GoalUpdateEvent{
+				UserID:   userID,
+				GoalID:   g.ID,
+				Type:     g.Type,
+				Genre:    g.Genre,
+				Target:   g.Target,
+				Progress: g.Progress,
+				Expected: g.Expected,
+				Pace:     g.Pace,
+			})
+		}
+	}
+	return nil
+}
+
+// measure fills in the goal's progress, expected progress and pace as of now
+func measure(userID string, g *models.Goal, now time.Time) error {
+	start, err := time.Parse(dayFormat, g.StartDate)
+	if err != nil {
+		return err
+	}
+	end, err := time.Parse(dayFormat, g.EndDate)
+	if err != nil {
+		return err
+	}
+	end = end.AddDate(0, 0, 1)
+
+	if g.Type == models.GoalSeriesCompleted {
+		g.Progress, err = seriesCompleted(userID, g.Genre, start, end)
+	} else {
+		g.Progress, err = chaptersRead(userID, g.Genre, start, end)
+	}
+	if err != nil {
+		return err
+	}
+
+	elapsed := float64(now.Sub(start)) / float64(end.Sub(start))
+	if elapsed < 0 {
+		elapsed = 0
+	} else if elapsed > 1 {
+		elapsed = 1
+	}
+	g.Expected = int(float64(g.Target) * elapsed)
+
+	switch {
+	case g.Progress >= g.Target:
+		g.Pace = models.GoalAchieved
+		if g.AchievedAt == nil {
+			at := now
+			g.AchievedAt = &at
+		}
+	case !now.Before(end):
+		g.Pace = models.GoalMissed
+	case now.Before(start):
+		g.Pace = models.GoalNotStarted
+	case g.Progress < g.Expected:
+		g.Pace = models.GoalBehind
+	default:
+		g.Pace = models.GoalOnTrack
+	}
+	return nil
+}
+
+func chaptersRead(userID, genre string, start, end time.Time) (int, error) {
+	rows, err := database.DB.Query(`SELECT COALESCE(m.genres, ''), SUM(ph.chapters_read)
+                                    FROM progress_history ph JOIN manga m ON m.id = ph.manga_id
+                                    WHERE ph.user_id = ? AND ph.recorded_at >= ? AND ph.recorded_at < ?
+                                    GROUP BY ph.manga_id`, userID, start, end)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	total := 0
+	for rows.Next() {
+		var genres string
+		var n int
+		if err := rows.Scan(&genres, &n); err != nil {
+			return 0, err
+		}
+		if hasGenre(genres, genre) {
+			total += n
+		}
+	}
+	return total, rows.Err()
+}
+
+// seriesCompleted counts series first marked completed during the period, so
+// re-saving an already completed series doesn't count it again.
+func seriesCompleted(userID, genre string, start, end time.Time) (int, error) {
+	rows, err := database.DB.Query(`SELECT COALESCE(m.genres, '')
+                                    FROM progress_history ph JOIN manga m ON m.id = ph.manga_id
+                                    WHERE ph.user_id = ? AND ph.status = ?
+                                    GROUP BY ph.manga_id
+                                    HAVING MIN(ph.recorded_at) >= ? AND MIN(ph.recorded_at) < ?`,
+		userID, models.StatusCompleted, start, end)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	total := 0
+	for rows.Next() {
+		var genres string
+		if err := rows.Scan(&genres); err != nil {
+			return 0, err
+		}
+		if hasGenre(genres, genre) {
+			total++
+		}
+	}
+	return total, rows.Err()
+}
+
+// hasGenre reports whether the manga's JSON genre list contains genre. An
+// empty genre matches every manga.
+func hasGenre(genresJSON, genre string) bool {
+	if genre == "" {
+		return true
+	}
+	var genres []string
+	json.Unmarshal([]byte(genresJSON), &genres)
+	for _, g := range genres {
+		if strings.EqualFold(g, genre) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,127 @@
+package goal
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/bridge"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/database"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/models"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// Handler handles reading goals
+type Handler struct {
+	bridge *bridge.Bridge
+}
+
+// NewHandler creates a new goal handler
+func NewHandler(br *bridge.Bridge) *Handler {
+	return &Handler{
+		bridge: br,
+	}
+}
+
+// GetGoals returns the current user's goals with their progress and pace
+func (h *Handler) GetGoals(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	rows, err := database.DB.Query(goalSelect+` WHERE user_id = ? ORDER BY end_date, created_at`, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	defer rows.Close()
+
+	goals := []models.Goal{}
+	for rows.Next() {
+		g, err := scanGoal(rows)
+		if err != nil {
+			continue
+		}
+		goals = append(goals, g)
+	}
+	rows.Close()
+
+	now := time.Now()
+	for i := range goals {
+		if err := measure(userID, &goals[i], now); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to measure goal progress"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"goals": goals,
+		"count": len(goals),
+	})
+}
+
+// CreateGoal sets a new reading goal
+func (h *Handler) CreateGoal(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req models.CreateGoalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	now := time.Now()
+	g := models.Goal{
+		Type:      req.Type,
+		Target:    req.Target,
+		Genre:     strings.TrimSpace(req.Genre),
+		StartDate: req.StartDate,
+		EndDate:   req.EndDate,
+		CreatedAt: now,
+	}
+	switch {
+	case req.StartDate != "" || req.EndDate != "":
+		if req.StartDate == "" || req.EndDate == "" || req.Year != 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Give either a year or both start_date and end_date"})
+			return
+		}
+		if req.EndDate < req.StartDate {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "end_date must not be before start_date"})
+			return
+		}
+	default:
+		year := req.Year
+		if year == 0 {
+			year = now.Year()
+		}
+		g.StartDate = time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC).Format(dayFormat)
+		g.EndDate = time.Date(year, time.December, 31, 0, 0, 0, 0, time.UTC).Format(dayFormat)
+	}
+
+	var err error
+	if g.ID, err = utils.GenerateID(16); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate goal ID"})
+		return
+	}
+	if err := measure(userID, &g, now); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to measure goal progress"})
+		return
+	}
+
+	_, err = database.DB.Exec(`INSERT INTO goals (id, user_id, type, target, genre, start_date, end_date, pace, achieved_at, created_at)
+                               VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		g.ID, userID, g.Type, g.Target, g.Genre, g.StartDate, g.EndDate, g.Pace, g.AchievedAt, g.CreatedAt)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create goal"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, g)
+}
@@ -0,0 +1,186 @@
+package goal_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/bridge"
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/goal"
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/manga"
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/stats"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/database"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/logger"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+type recordingBroadcaster struct {
+	mu     sync.Mutex
+	events []bridge.BroadcastEvent
+}
+
+func (r *recordingBroadcaster) BroadcastToUser(userID string, event bridge.BroadcastEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+func (r *recordingBroadcaster) paces() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var paces []string
+	for _, e := range r.events {
+		data, _ := e.Data.(map[string]interface{})
+		paces = append(paces, data["pace"].(string))
+	}
+	return paces
+}
+
+func setupGoalTest(t *testing.T) (*gin.Engine, *bridge.Bridge, *recordingBroadcaster) {
+	if err := database.InitDatabase(t.TempDir() + "/test.db"); err != nil {
+		t.Fatalf("init db: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	if _, err := database.DB.Exec(`INSERT INTO users (id, username, email, password_hash) VALUES ('u1', 'reader', 'reader@example.com', 'x')`); err != nil {
+		t.Fatalf("insert user: %v", err)
+	}
+	for _, m := range []models.Manga{
+		{ID: "1", Title: "Berserk", Genres: []string{"Action", "Horror"}},
+		{ID: "2", Title: "Yotsuba&!", Genres: []string{"Comedy"}},
+	} {
+		m := m
+		if err := manga.InsertManga(&m); err != nil {
+			t.Fatalf("insert manga: %v", err)
+		}
+	}
+
+	logger.Init(logger.INFO, false, nil)
+	br := bridge.NewBridge(logger.GetLogger())
+	br.Start()
+	t.Cleanup(br.Stop)
+	recorder := &recordingBroadcaster{}
+	br.SetUDPBroadcaster(recorder)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	// Stand-in for the auth middleware
+	router.Use(func(c *gin.Context) { c.Set("user_id", "u1") })
+	handler := goal.NewHandler(br)
+	router.GET("/users/goals", handler.GetGoals)
+	router.POST("/users/goals", handler.CreateGoal)
+	return router, br, recorder
+}
+
+func createGoal(t *testing.T, router *gin.Engine, body gin.H, want int) models.Goal {
+	payload, _ := json.Marshal(body)
+	req := httptest.NewRequest("POST", "/users/goals", bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	if resp.Code != want {
+		t.Fatalf("create goal: expected %d, got %d: %s", want, resp.Code, resp.Body.String())
+	}
+	var g models.Goal
+	json.Unmarshal(resp.Body.Bytes(), &g)
+	return g
+}
+
+// readTo moves the library entry to chapter and records it like a progress update
+func readTo(t *testing.T, mangaID string, chapter int, status models.ReadingStatus) {
+	var previous int
+	database.DB.QueryRow(`SELECT current_chapter FROM user_progress WHERE user_id = 'u1' AND manga_id = ?`, mangaID).Scan(&previous)
+	_, err := database.DB.Exec(`INSERT INTO user_progress (user_id, manga_id, current_chapter, status) VALUES ('u1', ?, ?, ?)
+                                ON CONFLICT(user_id, manga_id) DO UPDATE SET current_chapter = excluded.current_chapter, status = excluded.status`,
+		mangaID, chapter, status)
+	if err != nil {
+		t.Fatalf("write progress: %v", err)
+	}
+	if err := stats.RecordProgress("u1", mangaID, previous, time.Now()); err != nil {
+		t.Fatalf("record progress: %v", err)
+	}
+}
+
+func TestCreateGoal_Periods(t *testing.T) {
+	router, _, _ := setupGoalTest(t)
+
+	g := createGoal(t, router, gin.H{"type": "chapters", "target": 500}, 201)
+	year := time.Now().Format("2006")
+	if g.StartDate != year+"-01-01" || g.EndDate != year+"-12-31" {
+		t.Errorf("expected this calendar year, got %s to %s", g.StartDate, g.EndDate)
+	}
+
+	g = createGoal(t, router, gin.H{"type": "series_completed", "target": 12, "year": 2030}, 201)
+	if g.StartDate != "2030-01-01" || g.Pace != models.GoalNotStarted {
+		t.Errorf("expected a future goal that hasn't started, got %+v", g)
+	}
+
+	createGoal(t, router, gin.H{"type": "pages", "target": 5}, 400)
+	createGoal(t, router, gin.H{"type": "chapters", "target": 5, "start_date": "2026-02-01"}, 400)
+	createGoal(t, router, gin.H{"type": "chapters", "target": 5, "start_date": "2026-02-01", "end_date": "2026-01-01"}, 400)
+}
+
+func TestEvaluate_NotifiesBehindAndAchieved(t *testing.T) {
+	router, br, recorder := setupGoalTest(t)
+
+	today := time.Now().UTC()
+	g := createGoal(t, router, gin.H{
+		"type":       "chapters",
+		"target":     20,
+		"start_date": today.AddDate(0, 0, -9).Format("2006-01-02"),
+		"end_date":   today.AddDate(0, 0, 10).Format("2006-01-02"),
+	}, 201)
+	if g.Pace != models.GoalBehind || g.Expected < 9 {
+		t.Fatalf("expected a new goal halfway through its period to be behind, got %+v", g)
+	}
+
+	readTo(t, "2", 5, models.StatusReading)
+	if err := goal.Evaluate(br, "u1"); err != nil {
+		t.Fatalf("evaluate: %v", err)
+	}
+	if paces := recorder.paces(); len(paces) != 0 {
+		t.Errorf("expected no event while the pace is unchanged, got %v", paces)
+	}
+
+	readTo(t, "1", 15, models.StatusReading)
+	goal.Evaluate(br, "u1")
+	readTo(t, "1", 30, models.StatusReading)
+	goal.Evaluate(br, "u1")
+
+	if paces := recorder.paces(); len(paces) != 1 || paces[0] != models.GoalAchieved {
+		t.Fatalf("expected a single achieved event, got %v", paces)
+	}
+
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, httptest.NewRequest("GET", "/users/goals", nil))
+	var result struct {
+		Goals []models.Goal `json:"goals"`
+	}
+	json.Unmarshal(resp.Body.Bytes(), &result)
+	if len(result.Goals) != 1 || result.Goals[0].Progress != 35 || result.Goals[0].AchievedAt == nil {
+		t.Errorf("expected 35/20 achieved, got %+v", result.Goals)
+	}
+}
+
+func TestEvaluate_GenreSeriesGoal(t *testing.T) {
+	router, br, recorder := setupGoalTest(t)
+
+	createGoal(t, router, gin.H{"type": "series_completed", "target": 1, "genre": "horror"}, 201)
+
+	readTo(t, "2", 10, models.StatusCompleted)
+	goal.Evaluate(br, "u1")
+	if paces := recorder.paces(); len(paces) != 0 && paces[len(paces)-1] == models.GoalAchieved {
+		t.Fatalf("a comedy series should not count toward a horror goal")
+	}
+
+	readTo(t, "1", 40, models.StatusCompleted)
+	goal.Evaluate(br, "u1")
+	paces := recorder.paces()
+	if len(paces) == 0 || paces[len(paces)-1] != models.GoalAchieved {
+		t.Errorf("expected the horror goal to be achieved, got %v", paces)
+	}
+}
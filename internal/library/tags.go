@@ -0,0 +1,25 @@
+// Package library holds storage helpers for users' libraries shared by the
+// HTTP API and the TCP sync server
+package library
+
+import "github.com/binhbb2204/Manga-Hub-Group13/pkg/database"
+
+// LoadTags returns the user's tags keyed by manga ID, each list sorted
+// alphabetically.
+func LoadTags(userID string) (map[string][]string, error) {
+	rows, err := database.DB.Query(`SELECT manga_id, tag FROM library_tags WHERE user_id = ? ORDER BY manga_id, tag`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := map[string][]string{}
+	for rows.Next() {
+		var mangaID, tag string
+		if err := rows.Scan(&mangaID, &tag); err != nil {
+			return nil, err
+		}
+		tags[mangaID] = append(tags[mangaID], tag)
+	}
+	return tags, rows.Err()
+}
@@ -0,0 +1,328 @@
+package list
+
+import (
+	"database/sql"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/manga"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/database"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/models"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// Handler handles custom reading lists
+type Handler struct{}
+
+// NewHandler creates a new list handler
+func NewHandler() *Handler {
+	return &Handler{}
+}
+
+// GetLists returns the current user's lists without their items
+func (h *Handler) GetLists(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	rows, err := database.DB.Query(listSelect+` WHERE l.user_id = ? ORDER BY l.updated_at DESC`, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	defer rows.Close()
+
+	lists := []models.ReadingList{}
+	for rows.Next() {
+		l, err := scanList(rows)
+		if err != nil {
+			continue
+		}
+		lists = append(lists, l)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"lists": lists,
+		"count": len(lists),
+	})
+}
+
+// CreateList creates a new, empty list
+func (h *Handler) CreateList(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req models.CreateListRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "List name is required"})
+		return
+	}
+
+	listID, err := utils.GenerateID(16)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate list ID"})
+		return
+	}
+
+	var shareID interface{}
+	if req.IsPublic {
+		if shareID, err = utils.GenerateID(12); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate share ID"})
+			return
+		}
+	}
+
+	now := time.Now()
+	_, err = database.DB.Exec(`INSERT INTO lists (id, user_id, name, description, is_public, share_id, created_at, updated_at)
+                               VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		listID, userID, name, req.Description, req.IsPublic, shareID, now, now)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create list"})
+		return
+	}
+
+	l, _ := getList(`l.id = ?`, listID)
+	c.JSON(http.StatusCreated, l)
+}
+
+// GetList returns one of the current user's lists with its items
+func (h *Handler) GetList(c *gin.Context) {
+	l, ok := ownedList(c)
+	if !ok {
+		return
+	}
+
+	items, err := loadItems(l.ID, manga.UserTitleLanguage(l.UserID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	l.Items = items
+
+	c.JSON(http.StatusOK, l)
+}
+
+// UpdateList renames a list or changes its description or visibility
+func (h *Handler) UpdateList(c *gin.Context) {
+	l, ok := ownedList(c)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateListRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Name != nil {
+		l.Name = strings.TrimSpace(*req.Name)
+		if l.Name == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "List name is required"})
+			return
+		}
+	}
+	if req.Description != nil {
+		l.Description = *req.Description
+	}
+
+	if _, err := database.DB.Exec(`UPDATE lists SET name = ?, description = ?, updated_at = ? WHERE id = ?`,
+		l.Name, l.Description, time.Now(), l.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update list"})
+		return
+	}
+	if req.IsPublic != nil {
+		if err := setVisibility(l, *req.IsPublic); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update list"})
+			return
+		}
+	}
+
+	updated, _ := getList(`l.id = ?`, l.ID)
+	c.JSON(http.StatusOK, updated)
+}
+
+// DeleteList deletes a list and its items
+func (h *Handler) DeleteList(c *gin.Context) {
+	l, ok := ownedList(c)
+	if !ok {
+		return
+	}
+
+	if _, err := database.DB.Exec(`DELETE FROM lists WHERE id = ?`, l.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete list"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "List deleted successfully"})
+}
+
+// AddItem adds a manga to a list, at the end unless a position is given
+func (h *Handler) AddItem(c *gin.Context) {
+	l, ok := ownedList(c)
+	if !ok {
+		return
+	}
+
+	var req models.AddListItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var exists bool
+	err := database.DB.QueryRow(`SELECT EXISTS(SELECT 1 FROM manga WHERE id = ?)`, req.MangaID).Scan(&exists)
+	if err != nil || !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Manga not found"})
+		return
+	}
+
+	err = reorder(l.ID, func(ids []string) ([]string, error) {
+		for _, id := range ids {
+			if id == req.MangaID {
+				return nil, errAlreadyInList
+			}
+		}
+		return insertAt(ids, req.MangaID, req.Position), nil
+	})
+	if err == errAlreadyInList {
+		c.JSON(http.StatusConflict, gin.H{"error": "Manga already in list"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add manga to list"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Manga added to list successfully"})
+}
+
+// MoveItem moves a manga to a new position, shifting the items in between
+func (h *Handler) MoveItem(c *gin.Context) {
+	l, ok := ownedList(c)
+	if !ok {
+		return
+	}
+
+	mangaID := c.Param("manga_id")
+	var req models.MoveListItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := reorder(l.ID, func(ids []string) ([]string, error) {
+		rest, found := without(ids, mangaID)
+		if !found {
+			return nil, errNotInList
+		}
+		return insertAt(rest, mangaID, req.Position), nil
+	})
+	if err == errNotInList {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Manga not in list"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reorder list"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "List reordered successfully"})
+}
+
+// RemoveItem removes a manga from a list
+func (h *Handler) RemoveItem(c *gin.Context) {
+	l, ok := ownedList(c)
+	if !ok {
+		return
+	}
+
+	mangaID := c.Param("manga_id")
+	err := reorder(l.ID, func(ids []string) ([]string, error) {
+		rest, found := without(ids, mangaID)
+		if !found {
+			return nil, errNotInList
+		}
+		return rest, nil
+	})
+	if err == errNotInList {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Manga not in list"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove manga from list"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Manga removed from list successfully"})
+}
+
+// ShareList makes a list public and returns its share link. The share ID
+// stays the same if the list is later made private and shared again.
+func (h *Handler) ShareList(c *gin.Context) {
+	l, ok := ownedList(c)
+	if !ok {
+		return
+	}
+
+	if err := setVisibility(l, true); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to share list"})
+		return
+	}
+
+	shared, _ := getList(`l.id = ?`, l.ID)
+	c.JSON(http.StatusOK, gin.H{
+		"share_id":  shared.ShareID,
+		"share_url": "/lists/" + shared.ShareID,
+	})
+}
+
+// UnshareList makes a list private again
+func (h *Handler) UnshareList(c *gin.Context) {
+	l, ok := ownedList(c)
+	if !ok {
+		return
+	}
+
+	if err := setVisibility(l, false); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unshare list"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "List is now private"})
+}
+
+// GetSharedList returns a public list by its share ID. No authentication is required.
+func (h *Handler) GetSharedList(c *gin.Context) {
+	l, err := getList(`l.share_id = ? AND l.is_public = 1`, c.Param("share_id"))
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "List not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	items, err := loadItems(l.ID, c.Query("lang"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	l.Items = items
+	l.UserID = ""
+
+	c.JSON(http.StatusOK, l)
+}
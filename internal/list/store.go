@@ -0,0 +1,198 @@
+package list
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/manga"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/database"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/models"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	errAlreadyInList = errors.New("manga already in list")
+	errNotInList     = errors.New("manga not in list")
+)
+
+const listSelect = `SELECT l.id, l.user_id, COALESCE(u.username, ''), l.name, COALESCE(l.description, ''),
+        l.is_public, COALESCE(l.share_id, ''), l.created_at, l.updated_at,
+        (SELECT COUNT(*) FROM list_items li WHERE li.list_id = l.id)
+    FROM lists l
+    LEFT JOIN users u ON u.id = l.user_id`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanList(row rowScanner) (models.ReadingList, error) {
+	var l models.ReadingList
+	err := row.Scan(&l.ID, &l.UserID, &l.Owner, &l.Name, &l.Description,
+		&l.IsPublic, &l.ShareID, &l.CreatedAt, &l.UpdatedAt, &l.ItemCount)
+	return l, err
+}
+
+func getList(where string, args ...interface{}) (models.ReadingList, error) {
+	return scanList(database.DB.QueryRow(listSelect+` WHERE `+where, args...))
+}
+
+// ownedList loads the :list_id list and writes a 404 unless it belongs to the
+// caller, so other users' private lists are indistinguishable from missing ones.
+func ownedList(c *gin.Context) (models.ReadingList, bool) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return models.ReadingList{}, false
+	}
+
+	l, err := getList(`l.id = ? AND l.user_id = ?`, c.Param("list_id"), userID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "List not found"})
+		return l, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return l, false
+	}
+	return l, true
+}
+
+func loadItems(listID, lang string) ([]models.ListItem, error) {
+	query := `SELECT li.position, li.added_at, m.id, m.title, COALESCE(m.author, ''), COALESCE(m.status, ''),
+                     COALESCE(m.total_chapters, 0), COALESCE(m.cover_url, '')
+              FROM list_items li
+              JOIN manga m ON m.id = li.manga_id
+              WHERE li.list_id = ?
+              ORDER BY li.position`
+	rows, err := database.DB.Query(query, listID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []models.ListItem{}
+	for rows.Next() {
+		var item models.ListItem
+		err := rows.Scan(&item.Position, &item.AddedAt, &item.Manga.ID, &item.Manga.Title, &item.Manga.Author,
+			&item.Manga.Status, &item.Manga.TotalChapters, &item.Manga.CoverURL)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	titles := make([]*models.Manga, len(items))
+	for i := range items {
+		titles[i] = &items[i].Manga
+	}
+	if err := manga.AttachAlternativeTitles(titles, lang); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// setVisibility publishes or hides a list, assigning a share ID the first
+// time it is made public.
+func setVisibility(l models.ReadingList, public bool) error {
+	shareID := l.ShareID
+	if public && shareID == "" {
+		var err error
+		if shareID, err = utils.GenerateID(12); err != nil {
+			return err
+		}
+	}
+
+	var share interface{}
+	if shareID != "" {
+		share = shareID
+	}
+	_, err := database.DB.Exec(`UPDATE lists SET is_public = ?, share_id = ?, updated_at = ? WHERE id = ?`,
+		public, share, time.Now(), l.ID)
+	return err
+}
+
+// reorder loads the list's manga IDs in position order, lets change return
+// the new order and rewrites every position so they stay contiguous from 1.
+func reorder(listID string, change func(ids []string) ([]string, error)) error {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`SELECT manga_id FROM list_items WHERE list_id = ? ORDER BY position`, listID)
+	if err != nil {
+		return err
+	}
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+
+	newIDs, err := change(ids)
+	if err != nil {
+		return err
+	}
+
+	kept := make(map[string]bool, len(newIDs))
+	for _, id := range newIDs {
+		kept[id] = true
+	}
+	for _, id := range ids {
+		if kept[id] {
+			continue
+		}
+		if _, err := tx.Exec(`DELETE FROM list_items WHERE list_id = ? AND manga_id = ?`, listID, id); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	for i, id := range newIDs {
+		_, err := tx.Exec(`INSERT INTO list_items (list_id, manga_id, position, added_at) VALUES (?, ?, ?, ?)
+                           ON CONFLICT(list_id, manga_id) DO UPDATE SET position = excluded.position`,
+			listID, id, i+1, now)
+		if err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec(`UPDATE lists SET updated_at = ? WHERE id = ?`, now, listID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// insertAt places id at the 1-based position, appending when position is 0
+// or past the end.
+func insertAt(ids []string, id string, position int) []string {
+	if position <= 0 || position > len(ids) {
+		return append(ids, id)
+	}
+	out := make([]string, 0, len(ids)+1)
+	out = append(out, ids[:position-1]...)
+	out = append(out, id)
+	return append(out, ids[position-1:]...)
+}
+
+func without(ids []string, id string) ([]string, bool) {
+	for i, existing := range ids {
+		if existing == id {
+			out := append([]string{}, ids[:i]...)
+			return append(out, ids[i+1:]...), true
+		}
+	}
+	return ids, false
+}
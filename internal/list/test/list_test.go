@@ -0,0 +1,163 @@
+package list_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/list"
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/manga"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/database"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+func setupListTest(t *testing.T) *gin.Engine {
+	tmpDir := t.TempDir()
+	if err := database.InitDatabase(tmpDir + "/test.db"); err != nil {
+		t.Fatalf("init db: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	for _, u := range []string{"alice", "bob"} {
+		_, err := database.DB.Exec(`INSERT INTO users (id, username, email, password_hash) VALUES (?, ?, ?, 'x')`, u, u, u+"@example.com")
+		if err != nil {
+			t.Fatalf("insert user: %v", err)
+		}
+	}
+	for _, id := range []string{"1", "2", "3"} {
+		if err := manga.InsertManga(&models.Manga{ID: id, Title: "Manga " + id}); err != nil {
+			t.Fatalf("insert manga: %v", err)
+		}
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handler := list.NewHandler()
+	router.GET("/lists/:share_id", handler.GetSharedList)
+
+	users := router.Group("/users")
+	// Stand-in for the auth middleware
+	users.Use(func(c *gin.Context) { c.Set("user_id", c.GetHeader("X-User-ID")) })
+	users.POST("/lists", handler.CreateList)
+	users.GET("/lists/:list_id", handler.GetList)
+	users.POST("/lists/:list_id/items", handler.AddItem)
+	users.PUT("/lists/:list_id/items/:manga_id", handler.MoveItem)
+	users.DELETE("/lists/:list_id/items/:manga_id", handler.RemoveItem)
+	users.POST("/lists/:list_id/share", handler.ShareList)
+	users.DELETE("/lists/:list_id/share", handler.UnshareList)
+	return router
+}
+
+func do(t *testing.T, router *gin.Engine, method, path, userID string, body interface{}, want int) []byte {
+	var payload []byte
+	if body != nil {
+		payload, _ = json.Marshal(body)
+	}
+	req := httptest.NewRequest(method, path, bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-User-ID", userID)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	if resp.Code != want {
+		t.Fatalf("%s %s: expected %d, got %d: %s", method, path, want, resp.Code, resp.Body.String())
+	}
+	return resp.Body.Bytes()
+}
+
+func createList(t *testing.T, router *gin.Engine, public bool) models.ReadingList {
+	body := do(t, router, "POST", "/users/lists", "alice", gin.H{"name": "Best isekai", "is_public": public}, 201)
+	var l models.ReadingList
+	json.Unmarshal(body, &l)
+	return l
+}
+
+func itemOrder(t *testing.T, router *gin.Engine, listID string) []string {
+	body := do(t, router, "GET", "/users/lists/"+listID, "alice", nil, 200)
+	var l models.ReadingList
+	json.Unmarshal(body, &l)
+	ids := make([]string, len(l.Items))
+	for i, item := range l.Items {
+		if item.Position != i+1 {
+			t.Errorf("expected contiguous positions, item %d has position %d", i, item.Position)
+		}
+		ids[i] = item.Manga.ID
+	}
+	return ids
+}
+
+func assertOrder(t *testing.T, got []string, want ...string) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("expected order %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("expected order %v, got %v", want, got)
+		}
+	}
+}
+
+func TestLists_Ordering(t *testing.T) {
+	router := setupListTest(t)
+	l := createList(t, router, false)
+	items := "/users/lists/" + l.ID + "/items"
+
+	do(t, router, "POST", items, "alice", gin.H{"manga_id": "1"}, 200)
+	do(t, router, "POST", items, "alice", gin.H{"manga_id": "2"}, 200)
+	do(t, router, "POST", items, "alice", gin.H{"manga_id": "3", "position": 1}, 200)
+	assertOrder(t, itemOrder(t, router, l.ID), "3", "1", "2")
+
+	do(t, router, "PUT", items+"/3", "alice", gin.H{"position": 3}, 200)
+	assertOrder(t, itemOrder(t, router, l.ID), "1", "2", "3")
+
+	do(t, router, "DELETE", items+"/2", "alice", nil, 200)
+	assertOrder(t, itemOrder(t, router, l.ID), "1", "3")
+
+	do(t, router, "POST", items, "alice", gin.H{"manga_id": "1"}, 409)
+	do(t, router, "PUT", items+"/2", "alice", gin.H{"position": 1}, 404)
+}
+
+func TestLists_OwnerOnly(t *testing.T) {
+	router := setupListTest(t)
+	l := createList(t, router, false)
+
+	do(t, router, "GET", "/users/lists/"+l.ID, "bob", nil, 404)
+	do(t, router, "POST", "/users/lists/"+l.ID+"/items", "bob", gin.H{"manga_id": "1"}, 404)
+}
+
+func TestLists_Sharing(t *testing.T) {
+	router := setupListTest(t)
+	l := createList(t, router, false)
+	if l.ShareID != "" {
+		t.Fatalf("private list should not have a share ID")
+	}
+	do(t, router, "POST", "/users/lists/"+l.ID+"/items", "alice", gin.H{"manga_id": "2"}, 200)
+
+	body := do(t, router, "POST", "/users/lists/"+l.ID+"/share", "alice", nil, 200)
+	var shared struct {
+		ShareID string `json:"share_id"`
+	}
+	json.Unmarshal(body, &shared)
+
+	body = do(t, router, "GET", "/lists/"+shared.ShareID, "", nil, 200)
+	var public models.ReadingList
+	json.Unmarshal(body, &public)
+	if public.Name != "Best isekai" || public.Owner != "alice" || len(public.Items) != 1 {
+		t.Errorf("unexpected shared list: %+v", public)
+	}
+
+	do(t, router, "DELETE", "/users/lists/"+l.ID+"/share", "alice", nil, 200)
+	do(t, router, "GET", "/lists/"+shared.ShareID, "", nil, 404)
+
+	// Sharing again keeps the same link
+	body = do(t, router, "POST", "/users/lists/"+l.ID+"/share", "alice", nil, 200)
+	var again struct {
+		ShareID string `json:"share_id"`
+	}
+	json.Unmarshal(body, &again)
+	if again.ShareID != shared.ShareID {
+		t.Errorf("expected share ID to be stable, got %q then %q", shared.ShareID, again.ShareID)
+	}
+}
@@ -0,0 +1,54 @@
+package mailer_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/mailer"
+)
+
+func TestFileMailerWritesMessages(t *testing.T) {
+	dir := t.TempDir()
+	m := &mailer.FileMailer{Dir: filepath.Join(dir, "mail"), From: "MangaHub <no-reply@example.com>"}
+
+	for _, subject := range []string{"First", "Second"} {
+		err := m.Send(context.Background(), mailer.Message{To: "reader@example.com", Subject: subject, Body: "line one\nline two"})
+		if err != nil {
+			t.Fatalf("Send: %v", err)
+		}
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "mail", "*.eml"))
+	if len(files) != 2 {
+		t.Fatalf("expected 2 .eml files, got %d", len(files))
+	}
+	data, _ := os.ReadFile(files[0])
+	msg := string(data)
+	for _, want := range []string{"To: reader@example.com\r\n", "Subject: First\r\n", "\r\n\r\nline one\r\nline two"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("message missing %q:\n%s", want, msg)
+		}
+	}
+}
+
+func TestNewFromEnv(t *testing.T) {
+	t.Setenv("SMTP_HOST", "")
+	t.Setenv("MAIL_DIR", "")
+	if _, ok := mailer.NewFromEnv().(mailer.LogMailer); !ok {
+		t.Errorf("expected LogMailer with no mail settings")
+	}
+
+	t.Setenv("MAIL_DIR", t.TempDir())
+	if _, ok := mailer.NewFromEnv().(*mailer.FileMailer); !ok {
+		t.Errorf("expected FileMailer when MAIL_DIR is set")
+	}
+
+	t.Setenv("SMTP_HOST", "smtp.example.com")
+	m, ok := mailer.NewFromEnv().(*mailer.SMTPMailer)
+	if !ok || m.Addr != "smtp.example.com:587" {
+		t.Errorf("expected SMTPMailer on the default port, got %#v", m)
+	}
+}
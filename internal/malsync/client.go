@@ -0,0 +1,252 @@
+package malsync
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/models"
+)
+
+// Config holds the OAuth2 client registered at myanimelist.net/apiconfig.
+// AuthURL and APIURL only need changing to point at a stand-in server.
+type Config struct {
+	ClientID     string
+	ClientSecret string
+	RedirectURI  string
+	AuthURL      string
+	APIURL       string
+	HTTPClient   *http.Client
+}
+
+// ConfigFromEnv reads MAL_CLIENT_ID, MAL_CLIENT_SECRET and MAL_REDIRECT_URI.
+// The second result is false when account linking isn't configured.
+func ConfigFromEnv() (Config, bool) {
+	cfg := Config{
+		ClientID:     strings.TrimSpace(os.Getenv("MAL_CLIENT_ID")),
+		ClientSecret: strings.TrimSpace(os.Getenv("MAL_CLIENT_SECRET")),
+		RedirectURI:  strings.TrimSpace(os.Getenv("MAL_REDIRECT_URI")),
+	}
+	if cfg.RedirectURI == "" {
+		cfg.RedirectURI = "http://localhost:8080/auth/mal/callback"
+	}
+	return cfg, cfg.ClientID != "" && cfg.ClientSecret != ""
+}
+
+// Client talks to MyAnimeList's OAuth2 and user list endpoints
+type Client struct {
+	cfg Config
+}
+
+func NewClient(cfg Config) *Client {
+	if cfg.AuthURL == "" {
+		cfg.AuthURL = "https://myanimelist.net/v1/oauth2"
+	}
+	if cfg.APIURL == "" {
+		cfg.APIURL = "https://api.myanimelist.net/v2"
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = &http.Client{Timeout: 15 * time.Second}
+	}
+	return &Client{cfg: cfg}
+}
+
+// Token is MAL's token endpoint response
+type Token struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"`
+}
+
+// ListStatus is an entry's state on a MAL list, as returned by the list
+// endpoints. MAL's status values match models.ReadingStatus.
+type ListStatus struct {
+	Status       models.ReadingStatus `json:"status"`
+	ChaptersRead int                  `json:"num_chapters_read"`
+	VolumesRead  int                  `json:"num_volumes_read"`
+	Score        int                  `json:"score"`
+	StartDate    string               `json:"start_date,omitempty"`
+	FinishDate   string               `json:"finish_date,omitempty"`
+	UpdatedAt    time.Time            `json:"updated_at"`
+}
+
+// RemoteEntry is one manga on the user's MAL list
+type RemoteEntry struct {
+	MangaID  string
+	Title    string
+	CoverURL string
+	Status   ListStatus
+}
+
+// AuthorizationURL is where the user approves access. MAL only supports the
+// "plain" PKCE method, so the challenge is the verifier itself.
+func (c *Client) AuthorizationURL(state, verifier string) string {
+	q := url.Values{}
+	q.Set("response_type", "code")
+	q.Set("client_id", c.cfg.ClientID)
+	q.Set("redirect_uri", c.cfg.RedirectURI)
+	q.Set("state", state)
+	q.Set("code_challenge", verifier)
+	q.Set("code_challenge_method", "plain")
+	return c.cfg.AuthURL + "/authorize?" + q.Encode()
+}
+
+// ExchangeCode trades the authorization code from the callback for tokens
+func (c *Client) ExchangeCode(ctx context.Context, code, verifier string) (*Token, error) {
+	return c.token(ctx, url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"code_verifier": {verifier},
+		"redirect_uri":  {c.cfg.RedirectURI},
+	})
+}
+
+// Refresh gets a new access token; MAL rotates the refresh token as well
+func (c *Client) Refresh(ctx context.Context, refreshToken string) (*Token, error) {
+	return c.token(ctx, url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {refreshToken},
+	})
+}
+
+func (c *Client) token(ctx context.Context, form url.Values) (*Token, error) {
+	form.Set("client_id", c.cfg.ClientID)
+	form.Set("client_secret", c.cfg.ClientSecret)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.cfg.AuthURL+"/token", strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var tok Token
+	if err := c.do(req, &tok); err != nil {
+		return nil, err
+	}
+	if tok.AccessToken == "" {
+		return nil, fmt.Errorf("MAL token response has no access token")
+	}
+	return &tok, nil
+}
+
+// Username returns the name of the account the token belongs to
+func (c *Client) Username(ctx context.Context, accessToken string) (string, error) {
+	var me struct {
+		Name string `json:"name"`
+	}
+	if err := c.api(ctx, accessToken, http.MethodGet, c.cfg.APIURL+"/users/@me", nil, &me); err != nil {
+		return "", err
+	}
+	return me.Name, nil
+}
+
+// ListStatuses fetches the user's whole manga list, following pagination
+func (c *Client) ListStatuses(ctx context.Context, accessToken string) ([]RemoteEntry, error) {
+	next := c.cfg.APIURL + "/users/@me/mangalist?fields=list_status&limit=1000&nsfw=true"
+	var entries []RemoteEntry
+	for next != "" {
+		var page struct {
+			Data []struct {
+				Node struct {
+					ID          int    `json:"id"`
+					Title       string `json:"title"`
+					MainPicture *struct {
+						Medium string `json:"medium"`
+						Large  string `json:"large"`
+					} `json:"main_picture"`
+				} `json:"node"`
+				ListStatus ListStatus `json:"list_status"`
+			} `json:"data"`
+			Paging struct {
+				Next string `json:"next"`
+			} `json:"paging"`
+		}
+		if err := c.api(ctx, accessToken, http.MethodGet, next, nil, &page); err != nil {
+			return nil, err
+		}
+		for _, d := range page.Data {
+			entry := RemoteEntry{
+				MangaID: strconv.Itoa(d.Node.ID),
+				Title:   d.Node.Title,
+				Status:  d.ListStatus,
+			}
+			if d.Node.MainPicture != nil {
+				entry.CoverURL = d.Node.MainPicture.Large
+			}
+			entries = append(entries, entry)
+		}
+		next = page.Paging.Next
+	}
+	return entries, nil
+}
+
+// UpdateStatus writes an entry to the user's MAL list and returns the
+// stored state, including MAL's new updated_at.
+func (c *Client) UpdateStatus(ctx context.Context, accessToken, mangaID string, status ListStatus) (*ListStatus, error) {
+	form := url.Values{}
+	form.Set("status", string(status.Status))
+	form.Set("num_chapters_read", strconv.Itoa(status.ChaptersRead))
+	form.Set("num_volumes_read", strconv.Itoa(status.VolumesRead))
+	if status.Score > 0 {
+		form.Set("score", strconv.Itoa(status.Score))
+	}
+	if status.StartDate != "" {
+		form.Set("start_date", status.StartDate)
+	}
+	if status.FinishDate != "" {
+		form.Set("finish_date", status.FinishDate)
+	}
+
+	var stored ListStatus
+	path := fmt.Sprintf("%s/manga/%s/my_list_status", c.cfg.APIURL, url.PathEscape(mangaID))
+	if err := c.api(ctx, accessToken, http.MethodPatch, path, form, &stored); err != nil {
+		return nil, err
+	}
+	return &stored, nil
+}
+
+func (c *Client) api(ctx context.Context, accessToken, method, endpoint string, form url.Values, out interface{}) error {
+	var body *strings.Reader
+	if form != nil {
+		body = strings.NewReader(form.Encode())
+	} else {
+		body = strings.NewReader("")
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	if form != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	return c.do(req, out)
+}
+
+func (c *Client) do(req *http.Request, out interface{}) error {
+	req.Header.Set("User-Agent", "MangaHub/1.0 (+github.com/binhbb2204/Manga-Hub-Group13)")
+	res, err := c.cfg.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Error   string `json:"error"`
+			Message string `json:"message"`
+		}
+		json.NewDecoder(res.Body).Decode(&apiErr)
+		if apiErr.Message != "" {
+			return fmt.Errorf("MAL API request failed: %s: %s", res.Status, apiErr.Message)
+		}
+		return fmt.Errorf("MAL API request failed: %s", res.Status)
+	}
+	return json.NewDecoder(res.Body).Decode(out)
+}
@@ -0,0 +1,174 @@
+package malsync
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+// Handler links MAL accounts and exposes on-demand syncs
+type Handler struct {
+	syncer *Syncer
+}
+
+// NewHandler creates a MAL handler. A nil syncer means MAL linking isn't
+// configured and every endpoint answers 503.
+func NewHandler(syncer *Syncer) *Handler {
+	return &Handler{syncer: syncer}
+}
+
+func (h *Handler) configured(c *gin.Context) bool {
+	if h.syncer == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "MyAnimeList linking is not configured"})
+		return false
+	}
+	return true
+}
+
+// Link starts the OAuth2 PKCE flow and returns the MAL authorization URL
+func (h *Handler) Link(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	if !h.configured(c) {
+		return
+	}
+
+	state, verifier, expiresAt, err := newLinkRequest(userID, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start MyAnimeList linking"})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.MALLinkResponse{
+		AuthorizationURL: h.syncer.client.AuthorizationURL(state, verifier),
+		ExpiresAt:        expiresAt,
+	})
+}
+
+// Callback is MAL's redirect target. It is unauthenticated; the state value
+// ties the authorization code back to the user who started the flow.
+func (h *Handler) Callback(c *gin.Context) {
+	if !h.configured(c) {
+		return
+	}
+	if denied := c.Query("error"); denied != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "MyAnimeList authorization was not granted: " + denied})
+		return
+	}
+	code, state := c.Query("code"), c.Query("state")
+	if code == "" || state == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "code and state are required"})
+		return
+	}
+
+	now := time.Now()
+	userID, verifier, err := takeLinkRequest(state, now)
+	if errors.Is(err, ErrUnknownState) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Link request expired, run `mangahub auth link-mal` again"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	tok, err := h.syncer.client.ExchangeCode(ctx, code, verifier)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+	username, err := h.syncer.client.Username(ctx, tok.AccessToken)
+	if err != nil {
+		log.Printf("Warning: failed to read MAL username: %v", err)
+	}
+	if err := saveAccount(userID, username, tok, now); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save MyAnimeList account"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "MyAnimeList account linked. You can close this window.",
+		"mal_username": username,
+	})
+}
+
+// Status reports whether the user has linked MAL and how the last sync went
+func (h *Handler) Status(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	a, err := loadAccount(userID)
+	if errors.Is(err, ErrNotLinked) {
+		c.JSON(http.StatusOK, models.MALAccountStatus{Linked: false})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	status := models.MALAccountStatus{
+		Linked:    true,
+		Username:  a.Username,
+		LinkedAt:  &a.LinkedAt,
+		LastError: a.LastError,
+	}
+	if a.LastSyncedAt.Valid {
+		status.LastSyncedAt = &a.LastSyncedAt.Time
+	}
+	c.JSON(http.StatusOK, status)
+}
+
+// SyncNow runs a sync immediately instead of waiting for the worker
+func (h *Handler) SyncNow(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	if !h.configured(c) {
+		return
+	}
+
+	result, err := h.syncer.Sync(c.Request.Context(), userID)
+	if errors.Is(err, ErrNotLinked) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No MyAnimeList account linked"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// Unlink forgets the user's MAL tokens. Their MAL list is left as it is.
+func (h *Handler) Unlink(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	removed, err := deleteAccount(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if !removed {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No MyAnimeList account linked"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "MyAnimeList account unlinked"})
+}
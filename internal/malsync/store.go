@@ -0,0 +1,150 @@
+package malsync
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/database"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/utils"
+)
+
+// linkRequestTTL is how long the user has to approve access on MAL
+const linkRequestTTL = 10 * time.Minute
+
+var (
+	ErrNotLinked    = errors.New("no MyAnimeList account linked")
+	ErrUnknownState = errors.New("unknown or expired link request")
+)
+
+type account struct {
+	UserID       string
+	Username     string
+	AccessToken  string
+	RefreshToken string
+	ExpiresAt    time.Time
+	LastSyncedAt sql.NullTime
+	LastError    string
+	LinkedAt     time.Time
+}
+
+// newLinkRequest stores a PKCE verifier under a fresh state value
+func newLinkRequest(userID string, now time.Time) (state, verifier string, expiresAt time.Time, err error) {
+	if state, err = utils.GenerateID(16); err != nil {
+		return "", "", time.Time{}, err
+	}
+	// 96 hex characters, within PKCE's 43-128 character limit
+	if verifier, err = utils.GenerateID(48); err != nil {
+		return "", "", time.Time{}, err
+	}
+	expiresAt = now.Add(linkRequestTTL)
+
+	database.DB.Exec(`DELETE FROM mal_link_requests WHERE expires_at < ?`, now)
+	_, err = database.DB.Exec(`INSERT INTO mal_link_requests (state, user_id, code_verifier, expires_at) VALUES (?, ?, ?, ?)`,
+		state, userID, verifier, expiresAt)
+	return state, verifier, expiresAt, err
+}
+
+// takeLinkRequest returns and deletes the request for state, so each
+// authorization code can only be redeemed once.
+func takeLinkRequest(state string, now time.Time) (userID, verifier string, err error) {
+	var expiresAt time.Time
+	err = database.DB.QueryRow(`SELECT user_id, code_verifier, expires_at FROM mal_link_requests WHERE state = ?`, state).
+		Scan(&userID, &verifier, &expiresAt)
+	if err == sql.ErrNoRows {
+		return "", "", ErrUnknownState
+	}
+	if err != nil {
+		return "", "", err
+	}
+	database.DB.Exec(`DELETE FROM mal_link_requests WHERE state = ?`, state)
+	if now.After(expiresAt) {
+		return "", "", ErrUnknownState
+	}
+	return userID, verifier, nil
+}
+
+func saveAccount(userID, username string, tok *Token, now time.Time) error {
+	_, err := database.DB.Exec(`INSERT INTO mal_accounts (user_id, mal_username, access_token, refresh_token, expires_at, linked_at)
+                                VALUES (?, ?, ?, ?, ?, ?)
+                                ON CONFLICT(user_id) DO UPDATE SET
+                                    mal_username = excluded.mal_username,
+                                    access_token = excluded.access_token,
+                                    refresh_token = excluded.refresh_token,
+                                    expires_at = excluded.expires_at,
+                                    last_error = '',
+                                    linked_at = excluded.linked_at`,
+		userID, username, tok.AccessToken, tok.RefreshToken, expiry(tok, now), now)
+	return err
+}
+
+func loadAccount(userID string) (*account, error) {
+	a := account{UserID: userID}
+	err := database.DB.QueryRow(`SELECT COALESCE(mal_username, ''), access_token, refresh_token, expires_at,
+                                        last_synced_at, COALESCE(last_error, ''), linked_at
+                                 FROM mal_accounts WHERE user_id = ?`, userID).
+		Scan(&a.Username, &a.AccessToken, &a.RefreshToken, &a.ExpiresAt, &a.LastSyncedAt, &a.LastError, &a.LinkedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotLinked
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+func deleteAccount(userID string) (bool, error) {
+	result, err := database.DB.Exec(`DELETE FROM mal_accounts WHERE user_id = ?`, userID)
+	if err != nil {
+		return false, err
+	}
+	n, _ := result.RowsAffected()
+	return n > 0, nil
+}
+
+func linkedUsers() ([]string, error) {
+	rows, err := database.DB.Query(`SELECT user_id FROM mal_accounts ORDER BY user_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []string
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		users = append(users, userID)
+	}
+	return users, rows.Err()
+}
+
+// accessToken returns a usable access token, refreshing and storing a new
+// pair when the current one expires within a minute.
+func (c *Client) accessToken(ctx context.Context, a *account, now time.Time) (string, error) {
+	if a.ExpiresAt.Sub(now) > time.Minute {
+		return a.AccessToken, nil
+	}
+
+	tok, err := c.Refresh(ctx, a.RefreshToken)
+	if err != nil {
+		return "", fmt.Errorf("refresh MAL token (relink with `mangahub auth link-mal`): %w", err)
+	}
+	if tok.RefreshToken == "" {
+		tok.RefreshToken = a.RefreshToken
+	}
+	a.AccessToken, a.RefreshToken, a.ExpiresAt = tok.AccessToken, tok.RefreshToken, expiry(tok, now)
+	_, err = database.DB.Exec(`UPDATE mal_accounts SET access_token = ?, refresh_token = ?, expires_at = ? WHERE user_id = ?`,
+		a.AccessToken, a.RefreshToken, a.ExpiresAt, a.UserID)
+	return a.AccessToken, err
+}
+
+func expiry(tok *Token, now time.Time) time.Time {
+	if tok.ExpiresIn <= 0 {
+		return now.Add(time.Hour)
+	}
+	return now.Add(time.Duration(tok.ExpiresIn) * time.Second)
+}
@@ -0,0 +1,260 @@
+package malsync
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/bridge"
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/manga"
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/review"
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/stats"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/database"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/models"
+)
+
+// Syncer reconciles MangaHub libraries with the linked MAL lists.
+//
+// An entry changed on one side since the last sync is copied to the other.
+// An entry changed on both sides is a conflict, and the most recent write
+// wins. Removing an entry on either side is not mirrored, and only catalog
+// entries with MAL's numeric IDs take part.
+type Syncer struct {
+	client *Client
+	bridge *bridge.Bridge
+
+	// mu keeps the worker and on-demand syncs from running over each other
+	mu sync.Mutex
+}
+
+func NewSyncer(client *Client, br *bridge.Bridge) *Syncer {
+	return &Syncer{client: client, bridge: br}
+}
+
+type localEntry struct {
+	MangaID    string
+	Status     models.ReadingStatus
+	Chapter    int
+	Volume     int
+	Score      int
+	StartedAt  string
+	FinishedAt string
+	UpdatedAt  time.Time
+}
+
+func (l localEntry) listStatus() ListStatus {
+	return ListStatus{
+		Status:       l.Status,
+		ChaptersRead: l.Chapter,
+		VolumesRead:  l.Volume,
+		Score:        l.Score,
+		StartDate:    l.StartedAt,
+		FinishDate:   l.FinishedAt,
+	}
+}
+
+func (l localEntry) matches(r ListStatus) bool {
+	return l.Status == r.Status && l.Chapter == r.ChaptersRead && l.Volume == r.VolumesRead && l.Score == r.Score
+}
+
+// Sync runs one two-way sync for the user and records its outcome on the
+// linked account.
+func (s *Syncer) Sync(ctx context.Context, userID string) (models.MALSyncResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a, err := loadAccount(userID)
+	if err != nil {
+		return models.MALSyncResult{}, err
+	}
+
+	now := time.Now()
+	result, err := s.sync(ctx, a, now)
+	if err != nil {
+		database.DB.Exec(`UPDATE mal_accounts SET last_error = ? WHERE user_id = ?`, err.Error(), userID)
+		return result, err
+	}
+	_, err = database.DB.Exec(`UPDATE mal_accounts SET last_synced_at = ?, last_error = '' WHERE user_id = ?`, now, userID)
+	return result, err
+}
+
+func (s *Syncer) sync(ctx context.Context, a *account, now time.Time) (models.MALSyncResult, error) {
+	result := models.MALSyncResult{}
+
+	token, err := s.client.accessToken(ctx, a, now)
+	if err != nil {
+		return result, err
+	}
+	remote, err := s.client.ListStatuses(ctx, token)
+	if err != nil {
+		return result, err
+	}
+	local, err := localEntries(a.UserID)
+	if err != nil {
+		return result, err
+	}
+
+	// A zero time makes everything count as changed on the first sync
+	var since time.Time
+	if a.LastSyncedAt.Valid {
+		since = a.LastSyncedAt.Time
+	}
+
+	remoteByID := make(map[string]RemoteEntry, len(remote))
+	for _, r := range remote {
+		remoteByID[r.MangaID] = r
+	}
+
+	fail := func(mangaID string, err error) {
+		result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", mangaID, err))
+	}
+
+	for _, l := range local {
+		r, onMAL := remoteByID[l.MangaID]
+		localChanged := l.UpdatedAt.After(since)
+		remoteChanged := onMAL && r.Status.UpdatedAt.After(since)
+		if onMAL && l.matches(r.Status) {
+			continue
+		}
+
+		push := localChanged
+		if localChanged && remoteChanged {
+			result.Conflicts++
+			push = !r.Status.UpdatedAt.After(l.UpdatedAt)
+		}
+		switch {
+		case push:
+			if _, err := s.client.UpdateStatus(ctx, token, l.MangaID, l.listStatus()); err != nil {
+				fail(l.MangaID, err)
+				continue
+			}
+			result.Pushed++
+		case remoteChanged:
+			if err := pullEntry(a.UserID, r); err != nil {
+				fail(l.MangaID, err)
+				continue
+			}
+			result.Pulled++
+		}
+	}
+
+	localIDs := make(map[string]bool, len(local))
+	for _, l := range local {
+		localIDs[l.MangaID] = true
+	}
+	for _, r := range remote {
+		if localIDs[r.MangaID] || !r.Status.UpdatedAt.After(since) {
+			continue
+		}
+		if err := pullEntry(a.UserID, r); err != nil {
+			fail(r.MangaID, err)
+			continue
+		}
+		result.Pulled++
+	}
+
+	if result.Pulled > 0 {
+		stats.Invalidate(a.UserID)
+		if s.bridge != nil {
+			s.bridge.NotifyLibraryUpdate(bridge.LibraryUpdateEvent{
+				UserID: a.UserID,
+				Action: "mal_sync",
+			})
+		}
+	}
+	return result, nil
+}
+
+// localEntries loads the library entries MAL can know about
+func localEntries(userID string) ([]localEntry, error) {
+	rows, err := database.DB.Query(`
+        SELECT up.manga_id, up.status, up.current_chapter, COALESCE(up.current_volume, 0), COALESCE(r.score, 0),
+               COALESCE(up.started_at, ''), COALESCE(up.finished_at, ''), up.updated_at
+        FROM user_progress up
+        LEFT JOIN ratings r ON r.user_id = up.user_id AND r.manga_id = up.manga_id
+        WHERE up.user_id = ?`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []localEntry
+	for rows.Next() {
+		var l localEntry
+		if err := rows.Scan(&l.MangaID, &l.Status, &l.Chapter, &l.Volume, &l.Score,
+			&l.StartedAt, &l.FinishedAt, &l.UpdatedAt); err != nil {
+			return nil, err
+		}
+		if _, err := strconv.Atoi(l.MangaID); err != nil {
+			continue
+		}
+		entries = append(entries, l)
+	}
+	return entries, rows.Err()
+}
+
+// pullEntry copies a MAL list entry into the library. The entry keeps MAL's
+// updated_at so the next sync doesn't see it as a local change.
+func pullEntry(userID string, r RemoteEntry) error {
+	if !r.Status.Status.Valid() {
+		return fmt.Errorf("unknown MAL status %q", r.Status.Status)
+	}
+
+	var exists bool
+	if err := database.DB.QueryRow(`SELECT EXISTS(SELECT 1 FROM manga WHERE id = ?)`, r.MangaID).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		m := models.Manga{ID: r.MangaID, Title: r.Title, CoverURL: r.CoverURL}
+		if err := manga.InsertManga(&m); err != nil {
+			return err
+		}
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`INSERT INTO user_progress (user_id, manga_id, current_chapter, current_volume, status,
+                          started_at, finished_at, updated_at)
+                      VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+                      ON CONFLICT(user_id, manga_id) DO UPDATE SET
+                          current_chapter = excluded.current_chapter,
+                          current_volume = excluded.current_volume,
+                          status = excluded.status,
+                          started_at = COALESCE(NULLIF(excluded.started_at, ''), started_at),
+                          finished_at = COALESCE(NULLIF(excluded.finished_at, ''), finished_at),
+                          updated_at = excluded.updated_at`,
+		userID, r.MangaID, r.Status.ChaptersRead, r.Status.VolumesRead, r.Status.Status,
+		fullDate(r.Status.StartDate), fullDate(r.Status.FinishDate), r.Status.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	// An unscored MAL entry leaves the rating alone; deleting it would also
+	// delete the user's written review.
+	if r.Status.Score > 0 {
+		_, err = tx.Exec(`INSERT INTO ratings (user_id, manga_id, score, created_at, updated_at) VALUES (?, ?, ?, ?, ?)
+                          ON CONFLICT(user_id, manga_id) DO UPDATE SET score = excluded.score, updated_at = excluded.updated_at`,
+			userID, r.MangaID, r.Status.Score, r.Status.UpdatedAt, r.Status.UpdatedAt)
+		if err != nil {
+			return err
+		}
+		if err := review.RefreshCommunityScore(tx, r.MangaID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// fullDate drops the partial dates ("2020" or "2020-05") MAL allows
+func fullDate(s string) string {
+	if _, err := time.Parse("2006-01-02", s); err != nil {
+		return ""
+	}
+	return s
+}
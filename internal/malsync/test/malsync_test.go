@@ -0,0 +1,372 @@
+package malsync_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/malsync"
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/manga"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/database"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+// fakeMAL stands in for MyAnimeList's OAuth2 and list endpoints
+type fakeMAL struct {
+	mu         sync.Mutex
+	challenges map[string]string // authorization code -> PKCE challenge
+	list       map[int]malsync.ListStatus
+	titles     map[int]string
+	refreshes  int
+	failTokens bool
+	patched    []string
+}
+
+func newFakeMAL(t *testing.T) (*fakeMAL, *httptest.Server) {
+	f := &fakeMAL{
+		challenges: map[string]string{},
+		list:       map[int]malsync.ListStatus{},
+		titles:     map[int]string{},
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/oauth2/token", f.token)
+	mux.HandleFunc("/v2/users/@me", func(w http.ResponseWriter, r *http.Request) {
+		if !f.authorized(w, r) {
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"name": "mal_reader"})
+	})
+	mux.HandleFunc("/v2/users/@me/mangalist", f.mangaList)
+	mux.HandleFunc("/v2/manga/", f.updateStatus)
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return f, srv
+}
+
+func (f *fakeMAL) token(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	r.ParseForm()
+	if r.Form.Get("client_id") != "cid" || r.Form.Get("client_secret") != "secret" || f.failTokens {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid_client", "message": "bad credentials"})
+		return
+	}
+	switch r.Form.Get("grant_type") {
+	case "authorization_code":
+		challenge, ok := f.challenges[r.Form.Get("code")]
+		if !ok || challenge != r.Form.Get("code_verifier") {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		delete(f.challenges, r.Form.Get("code"))
+	case "refresh_token":
+		if r.Form.Get("refresh_token") != "refresh-1" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.refreshes++
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"access_token":  "access-1",
+		"refresh_token": "refresh-1",
+		"expires_in":    3600,
+	})
+}
+
+func (f *fakeMAL) authorized(w http.ResponseWriter, r *http.Request) bool {
+	if r.Header.Get("Authorization") != "Bearer access-1" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return false
+	}
+	return true
+}
+
+// mangaList serves one entry per page to exercise pagination
+func (f *fakeMAL) mangaList(w http.ResponseWriter, r *http.Request) {
+	if !f.authorized(w, r) {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	ids := []int{}
+	for id := range f.list {
+		ids = append(ids, id)
+	}
+	for i := range ids {
+		for j := i + 1; j < len(ids); j++ {
+			if ids[j] < ids[i] {
+				ids[i], ids[j] = ids[j], ids[i]
+			}
+		}
+	}
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+
+	page := map[string]interface{}{"data": []interface{}{}, "paging": map[string]string{}}
+	if offset < len(ids) {
+		id := ids[offset]
+		page["data"] = []interface{}{map[string]interface{}{
+			"node":        map[string]interface{}{"id": id, "title": f.titles[id]},
+			"list_status": f.list[id],
+		}}
+		if offset+1 < len(ids) {
+			next := "http://" + r.Host + r.URL.Path + "?offset=" + strconv.Itoa(offset+1)
+			page["paging"] = map[string]string{"next": next}
+		}
+	}
+	json.NewEncoder(w).Encode(page)
+}
+
+func (f *fakeMAL) updateStatus(w http.ResponseWriter, r *http.Request) {
+	if !f.authorized(w, r) {
+		return
+	}
+	if r.Method != http.MethodPatch || !strings.HasSuffix(r.URL.Path, "/my_list_status") {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	id, _ := strconv.Atoi(strings.Split(strings.TrimPrefix(r.URL.Path, "/v2/manga/"), "/")[0])
+	r.ParseForm()
+	status := f.list[id]
+	status.Status = models.ReadingStatus(r.Form.Get("status"))
+	status.ChaptersRead, _ = strconv.Atoi(r.Form.Get("num_chapters_read"))
+	status.VolumesRead, _ = strconv.Atoi(r.Form.Get("num_volumes_read"))
+	if score := r.Form.Get("score"); score != "" {
+		status.Score, _ = strconv.Atoi(score)
+	}
+	status.UpdatedAt = time.Now().UTC()
+	f.list[id] = status
+	f.patched = append(f.patched, strconv.Itoa(id))
+	json.NewEncoder(w).Encode(status)
+}
+
+func setupMALTest(t *testing.T) (*fakeMAL, *malsync.Syncer, *gin.Engine) {
+	tmpDir := t.TempDir()
+	if err := database.InitDatabase(tmpDir + "/test.db"); err != nil {
+		t.Fatalf("init db: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	if _, err := database.DB.Exec(`INSERT INTO users (id, username, email, password_hash) VALUES ('u1', 'reader', 'reader@example.com', 'x')`); err != nil {
+		t.Fatalf("insert user: %v", err)
+	}
+
+	fake, srv := newFakeMAL(t)
+	client := malsync.NewClient(malsync.Config{
+		ClientID:     "cid",
+		ClientSecret: "secret",
+		RedirectURI:  "http://localhost:8080/auth/mal/callback",
+		AuthURL:      srv.URL + "/v1/oauth2",
+		APIURL:       srv.URL + "/v2",
+	})
+	syncer := malsync.NewSyncer(client, nil)
+	handler := malsync.NewHandler(syncer)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/auth/mal/callback", handler.Callback)
+	users := router.Group("/users")
+	// Stand-in for the auth middleware
+	users.Use(func(c *gin.Context) { c.Set("user_id", "u1") })
+	users.GET("/me/mal", handler.Status)
+	users.POST("/me/mal/link", handler.Link)
+	users.POST("/me/mal/sync", handler.SyncNow)
+	users.DELETE("/me/mal", handler.Unlink)
+	return fake, syncer, router
+}
+
+func serve(router *gin.Engine, method, target string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	return resp
+}
+
+// linkAccount runs the PKCE flow the way a browser would
+func linkAccount(t *testing.T, fake *fakeMAL, router *gin.Engine) {
+	t.Helper()
+	resp := serve(router, "POST", "/users/me/mal/link")
+	if resp.Code != 200 {
+		t.Fatalf("link: expected 200, got %d: %s", resp.Code, resp.Body.String())
+	}
+	var link models.MALLinkResponse
+	json.Unmarshal(resp.Body.Bytes(), &link)
+
+	authURL, err := url.Parse(link.AuthorizationURL)
+	if err != nil {
+		t.Fatalf("parse authorization URL: %v", err)
+	}
+	q := authURL.Query()
+	if !strings.HasSuffix(authURL.Path, "/v1/oauth2/authorize") || q.Get("client_id") != "cid" ||
+		q.Get("code_challenge_method") != "plain" || len(q.Get("code_challenge")) < 43 ||
+		q.Get("redirect_uri") != "http://localhost:8080/auth/mal/callback" {
+		t.Fatalf("unexpected authorization URL: %s", link.AuthorizationURL)
+	}
+
+	fake.mu.Lock()
+	fake.challenges["code-1"] = q.Get("code_challenge")
+	fake.mu.Unlock()
+
+	callback := "/auth/mal/callback?code=code-1&state=" + url.QueryEscape(q.Get("state"))
+	if resp := serve(router, "GET", callback); resp.Code != 200 {
+		t.Fatalf("callback: expected 200, got %d: %s", resp.Code, resp.Body.String())
+	}
+	if resp := serve(router, "GET", callback); resp.Code != 400 {
+		t.Fatalf("replayed callback: expected 400, got %d", resp.Code)
+	}
+}
+
+func TestLinkMAL(t *testing.T) {
+	fake, _, router := setupMALTest(t)
+
+	var status models.MALAccountStatus
+	json.Unmarshal(serve(router, "GET", "/users/me/mal").Body.Bytes(), &status)
+	if status.Linked {
+		t.Fatalf("expected no linked account yet")
+	}
+	if resp := serve(router, "POST", "/users/me/mal/sync"); resp.Code != 404 {
+		t.Fatalf("sync without account: expected 404, got %d", resp.Code)
+	}
+
+	linkAccount(t, fake, router)
+
+	json.Unmarshal(serve(router, "GET", "/users/me/mal").Body.Bytes(), &status)
+	if !status.Linked || status.Username != "mal_reader" || status.LastSyncedAt != nil {
+		t.Fatalf("unexpected status after linking: %+v", status)
+	}
+
+	if resp := serve(router, "GET", "/auth/mal/callback?code=x&state=unknown"); resp.Code != 400 {
+		t.Fatalf("unknown state: expected 400, got %d", resp.Code)
+	}
+	if resp := serve(router, "DELETE", "/users/me/mal"); resp.Code != 200 {
+		t.Fatalf("unlink: expected 200, got %d", resp.Code)
+	}
+	if resp := serve(router, "DELETE", "/users/me/mal"); resp.Code != 404 {
+		t.Fatalf("second unlink: expected 404, got %d", resp.Code)
+	}
+}
+
+func TestSyncPushesPullsAndResolvesConflicts(t *testing.T) {
+	fake, syncer, router := setupMALTest(t)
+	linkAccount(t, fake, router)
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	for _, m := range []models.Manga{{ID: "2", Title: "Berserk"}, {ID: "5", Title: "Monster"}, {ID: "local-1", Title: "Zine"}} {
+		m := m
+		if err := manga.InsertManga(&m); err != nil {
+			t.Fatalf("insert manga: %v", err)
+		}
+	}
+	database.DB.Exec(`INSERT INTO user_progress (user_id, manga_id, current_chapter, status, updated_at) VALUES
+                      ('u1', '2', 100, 'reading', ?), ('u1', '5', 0, 'plan_to_read', ?), ('u1', 'local-1', 3, 'reading', ?)`,
+		now.Add(-time.Hour), now.Add(-time.Hour), now.Add(-time.Hour))
+
+	fake.mu.Lock()
+	// Berserk changed on MAL before the local change, so the local one wins
+	fake.list[2] = malsync.ListStatus{Status: models.StatusReading, ChaptersRead: 90, UpdatedAt: now.Add(-2 * time.Hour)}
+	fake.list[13] = malsync.ListStatus{Status: models.StatusCompleted, ChaptersRead: 1000, Score: 9,
+		StartDate: "2015-01-02", FinishDate: "2020-05", UpdatedAt: now.Add(-3 * time.Hour)}
+	fake.titles[13] = "One Piece"
+	fake.mu.Unlock()
+
+	result, err := syncer.Sync(ctx, "u1")
+	if err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	if result.Pushed != 2 || result.Pulled != 1 || result.Conflicts != 1 || len(result.Errors) != 0 {
+		t.Fatalf("unexpected first sync: %+v", result)
+	}
+	if fake.list[2].ChaptersRead != 100 || fake.list[5].Status != models.StatusPlanToRead {
+		t.Fatalf("local entries were not pushed: %+v", fake.list)
+	}
+	for _, id := range fake.patched {
+		if id == "local-1" {
+			t.Fatalf("entries without a MAL ID must not be pushed")
+		}
+	}
+
+	var chapter, score int
+	var title, startedAt, finishedAt string
+	database.DB.QueryRow(`SELECT up.current_chapter, up.started_at, up.finished_at, m.title FROM user_progress up
+                          JOIN manga m ON m.id = up.manga_id WHERE up.user_id = 'u1' AND up.manga_id = '13'`).
+		Scan(&chapter, &startedAt, &finishedAt, &title)
+	database.DB.QueryRow(`SELECT score FROM ratings WHERE user_id = 'u1' AND manga_id = '13'`).Scan(&score)
+	if chapter != 1000 || title != "One Piece" || score != 9 || startedAt != "2015-01-02" || finishedAt != "" {
+		t.Fatalf("unexpected pulled entry: ch %d %q score %d %q %q", chapter, title, score, startedAt, finishedAt)
+	}
+
+	// Nothing changed since, including the pushes echoed back by MAL
+	if result, err := syncer.Sync(ctx, "u1"); err != nil || result.Pushed+result.Pulled+result.Conflicts != 0 {
+		t.Fatalf("expected an idle second sync, got %+v, %v", result, err)
+	}
+
+	// Both sides change Monster; MAL's change is newer and wins
+	time.Sleep(10 * time.Millisecond)
+	database.DB.Exec(`UPDATE user_progress SET current_chapter = 10, status = 'reading', updated_at = ? WHERE user_id = 'u1' AND manga_id = '5'`, time.Now())
+	time.Sleep(10 * time.Millisecond)
+	fake.mu.Lock()
+	fake.list[5] = malsync.ListStatus{Status: models.StatusReading, ChaptersRead: 25, UpdatedAt: time.Now().UTC()}
+	fake.mu.Unlock()
+
+	result, err = syncer.Sync(ctx, "u1")
+	if err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	if result.Pulled != 1 || result.Pushed != 0 || result.Conflicts != 1 {
+		t.Fatalf("unexpected conflict sync: %+v", result)
+	}
+	database.DB.QueryRow(`SELECT current_chapter FROM user_progress WHERE user_id = 'u1' AND manga_id = '5'`).Scan(&chapter)
+	if chapter != 25 {
+		t.Fatalf("expected MAL's newer chapter 25, got %d", chapter)
+	}
+
+	var status models.MALAccountStatus
+	json.Unmarshal(serve(router, "GET", "/users/me/mal").Body.Bytes(), &status)
+	if status.LastSyncedAt == nil || status.LastError != "" {
+		t.Fatalf("expected a recorded successful sync, got %+v", status)
+	}
+}
+
+func TestSyncRefreshesExpiredTokens(t *testing.T) {
+	fake, syncer, router := setupMALTest(t)
+	linkAccount(t, fake, router)
+	database.DB.Exec(`UPDATE mal_accounts SET access_token = 'stale', expires_at = ? WHERE user_id = 'u1'`, time.Now().Add(-time.Minute))
+
+	if _, err := syncer.Sync(context.Background(), "u1"); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	var accessToken string
+	var expiresAt time.Time
+	database.DB.QueryRow(`SELECT access_token, expires_at FROM mal_accounts WHERE user_id = 'u1'`).Scan(&accessToken, &expiresAt)
+	if fake.refreshes != 1 || accessToken != "access-1" || time.Until(expiresAt) < 50*time.Minute {
+		t.Fatalf("expected a stored refreshed token, got %d refreshes, %q, %v", fake.refreshes, accessToken, expiresAt)
+	}
+
+	// A refresh MAL rejects is reported and recorded on the account
+	database.DB.Exec(`UPDATE mal_accounts SET expires_at = ? WHERE user_id = 'u1'`, time.Now())
+	fake.mu.Lock()
+	fake.failTokens = true
+	fake.mu.Unlock()
+	resp := serve(router, "POST", "/users/me/mal/sync")
+	if resp.Code != 502 {
+		t.Fatalf("expected 502 when the refresh fails, got %d: %s", resp.Code, resp.Body.String())
+	}
+	var status models.MALAccountStatus
+	json.Unmarshal(serve(router, "GET", "/users/me/mal").Body.Bytes(), &status)
+	if !strings.Contains(status.LastError, "refresh MAL token") {
+		t.Fatalf("expected the refresh error to be recorded, got %q", status.LastError)
+	}
+}
@@ -0,0 +1,82 @@
+package malsync
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/logger"
+)
+
+const defaultSyncInterval = 30 * time.Minute
+
+// SyncIntervalFromEnv reads MAL_SYNC_INTERVAL (e.g. "15m"), defaulting to
+// 30 minutes.
+func SyncIntervalFromEnv() time.Duration {
+	if d, err := time.ParseDuration(os.Getenv("MAL_SYNC_INTERVAL")); err == nil && d > 0 {
+		return d
+	}
+	return defaultSyncInterval
+}
+
+// Worker syncs every linked account on a fixed schedule
+type Worker struct {
+	syncer   *Syncer
+	interval time.Duration
+	log      *logger.Logger
+	stop     chan struct{}
+}
+
+func NewWorker(syncer *Syncer, interval time.Duration) *Worker {
+	return &Worker{
+		syncer:   syncer,
+		interval: interval,
+		log:      logger.WithContext("component", "mal_sync"),
+		stop:     make(chan struct{}),
+	}
+}
+
+func (w *Worker) Start() {
+	w.log.Info("mal_sync_worker_started", "interval", w.interval.String())
+	go func() {
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				w.SyncAll(context.Background())
+			case <-w.stop:
+				w.log.Info("mal_sync_worker_stopped")
+				return
+			}
+		}
+	}()
+}
+
+func (w *Worker) Stop() {
+	close(w.stop)
+}
+
+// SyncAll syncs each linked account in turn. A failing account is logged
+// and recorded on the account without stopping the others.
+func (w *Worker) SyncAll(ctx context.Context) {
+	users, err := linkedUsers()
+	if err != nil {
+		w.log.Warn("mal_sync_list_accounts_failed", "error", err.Error())
+		return
+	}
+	for _, userID := range users {
+		result, err := w.syncer.Sync(ctx, userID)
+		if err != nil {
+			w.log.Warn("mal_sync_failed", "user_id", userID, "error", err.Error())
+			continue
+		}
+		w.log.Info("mal_sync_complete",
+			"user_id", userID,
+			"pushed", result.Pushed,
+			"pulled", result.Pulled,
+			"conflicts", result.Conflicts,
+			"errors", len(result.Errors),
+		)
+	}
+}
@@ -52,9 +52,12 @@ type malSearchRes struct {
 				En       string   `json:"en"`
 				Ja       string   `json:"ja"`
 			} `json:"alternative_titles"`
-			Synopsis    string `json:"synopsis"`
-			NumChapters int    `json:"num_chapters"`
-			Status      string `json:"status"`
+			Synopsis    string  `json:"synopsis"`
+			NumChapters int     `json:"num_chapters"`
+			NumVolumes  int     `json:"num_volumes"`
+			StartDate   string  `json:"start_date"`
+			Mean        float64 `json:"mean"`
+			Status      string  `json:"status"`
 			Genres      []struct {
 				ID   int    `json:"id"`
 				Name string `json:"name"`
@@ -137,7 +140,7 @@ func (m *MALSource) Search(ctx context.Context, q string, limit, offset int) ([]
 	if offset > 0 {
 		qs.Set("offset", fmt.Sprintf("%d", offset))
 	}
-	qs.Set("fields", "id,title,main_picture,alternative_titles,synopsis,num_chapters,status,genres,authors{first_name,last_name}")
+	qs.Set("fields", "id,title,main_picture,alternative_titles,synopsis,num_chapters,num_volumes,start_date,mean,status,genres,authors{first_name,last_name}")
 	u.RawQuery = qs.Encode()
 
 	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
@@ -162,6 +165,9 @@ func (m *MALSource) Search(ctx context.Context, q string, limit, offset int) ([]
 	for _, d := range r.Data {
 		manga := convertMALToManga(d.Node.ID, d.Node.Title, d.Node.MainPicture, d.Node.AlternativeTitles,
 			d.Node.Synopsis, d.Node.NumChapters, d.Node.Status, d.Node.Genres, d.Node.Authors)
+		manga.NumVolumes = d.Node.NumVolumes
+		manga.StartDate = d.Node.StartDate
+		manga.Mean = d.Node.Mean
 		out = append(out, manga)
 	}
 	return out, nil
@@ -330,20 +336,34 @@ func convertMALToManga(id int, title string, mainPicture interface{}, altTitles
 		}
 	}
 
+	var altTitlesMap map[string]interface{}
+	if alt, ok := altTitles.(*struct {
+		Synonyms []string `json:"synonyms"`
+		En       string   `json:"en"`
+		Ja       string   `json:"ja"`
+	}); ok && alt != nil {
+		altTitlesMap = map[string]interface{}{
+			"en":       alt.En,
+			"ja":       alt.Ja,
+			"synonyms": alt.Synonyms,
+		}
+	}
+
 	statusLower := strings.ToLower(status)
 	if statusLower == "finished" {
 		statusLower = "completed"
 	}
 
 	return models.Manga{
-		ID:            fmt.Sprintf("%d", id),
-		Title:         title,
-		Author:        authorName,
-		Genres:        genreList,
-		Status:        statusLower,
-		TotalChapters: numChapters,
-		Description:   synopsis,
-		CoverURL:      coverURL,
+		ID:                fmt.Sprintf("%d", id),
+		Title:             title,
+		Author:            authorName,
+		Genres:            genreList,
+		Status:            statusLower,
+		TotalChapters:     numChapters,
+		Description:       synopsis,
+		CoverURL:          coverURL,
+		AlternativeTitles: altTitlesMap,
 	}
 }
 
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"log"
 	"net/http"
 	"net/url"
 	"os"
@@ -14,6 +15,7 @@ import (
 
 	"github.com/binhbb2204/Manga-Hub-Group13/pkg/database"
 	"github.com/binhbb2204/Manga-Hub-Group13/pkg/models"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/utils"
 	"github.com/gin-gonic/gin"
 )
 
@@ -59,6 +61,11 @@ func NewHandler() *Handler {
 	}
 }
 
+// SetExternalSource replaces the source manga info is fetched from
+func (h *Handler) SetExternalSource(source ExternalSource) {
+	h.externalSource = source
+}
+
 // SearchManga searches for manga based on filters
 func (h *Handler) SearchManga(c *gin.Context) {
 	var req models.SearchMangaRequest
@@ -71,12 +78,24 @@ func (h *Handler) SearchManga(c *gin.Context) {
 		req.Limit = 100
 	}
 
-	query := `SELECT id, title, author, genres, status, total_chapters, description, cover_url FROM manga WHERE 1=1`
+	query := `SELECT ` + mangaColumns + ` FROM manga WHERE 1=1`
 	args := []interface{}{}
 
+	if req.Query != "" {
+		parsed := parseSearchQuery(c, req.Query)
+		if parsed == nil {
+			return
+		}
+		where, whereArgs := compileQuery(parsed)
+		query += where
+		args = append(args, whereArgs...)
+	}
+
 	if req.Title != "" {
-		query += ` AND title LIKE ?`
-		args = append(args, "%"+req.Title+"%")
+		normalized := "%" + utils.NormalizeTitle(req.Title) + "%"
+		query += ` AND (title LIKE ? OR normalized_title LIKE ?
+                OR id IN (SELECT manga_id FROM manga_alt_titles WHERE normalized_title LIKE ?))`
+		args = append(args, "%"+req.Title+"%", normalized, normalized)
 	}
 
 	if req.Author != "" {
@@ -106,30 +125,15 @@ func (h *Handler) SearchManga(c *gin.Context) {
 
 	var mangas []models.Manga
 	for rows.Next() {
-		var manga models.Manga
-		var genresJSON string
-
-		err := rows.Scan(
-			&manga.ID,
-			&manga.Title,
-			&manga.Author,
-			&genresJSON,
-			&manga.Status,
-			&manga.TotalChapters,
-			&manga.Description,
-			&manga.CoverURL,
-		)
+		manga, err := scanManga(rows)
 		if err != nil {
 			continue
 		}
-
-		if genresJSON != "" {
-			json.Unmarshal([]byte(genresJSON), &manga.Genres)
-		}
-
 		mangas = append(mangas, manga)
 	}
 
+	attachTitles(mangas, titleLanguageFor(c))
+
 	c.JSON(http.StatusOK, gin.H{
 		"mangas": mangas,
 		"count":  len(mangas),
@@ -143,13 +147,24 @@ func (h *Handler) SearchExternal(c *gin.Context) {
 		return
 	}
 
-	query := c.Query("q")
-	if query == "" {
+	rawQuery := c.Query("q")
+	if rawQuery == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Query parameter 'q' is required"})
 		return
 	}
 
+	parsed := parseSearchQuery(c, rawQuery)
+	if parsed == nil {
+		return
+	}
+
+	// MAL only supports keyword search; structured clauses are applied to the results
+	query := parsed.Text()
 	if len(strings.TrimSpace(query)) < 3 {
+		if parsed.HasFilters() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Search query must be at least 3 characters of title text; use GET /manga?q= to filter the local catalog only"})
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Search query must be at least 3 characters"})
 		return
 	}
@@ -180,6 +195,22 @@ func (h *Handler) SearchExternal(c *gin.Context) {
 		return
 	}
 
+	if parsed.HasFilters() {
+		filtered := make([]models.Manga, 0, len(mangas))
+		for i := range mangas {
+			if parsed.Match(&mangas[i]) {
+				filtered = append(filtered, mangas[i])
+			}
+		}
+		mangas = filtered
+	}
+
+	if lang := titleLanguageFor(c); lang != "" {
+		for i := range mangas {
+			mangas[i].DisplayTitle = mangas[i].DisplayTitleFor(lang)
+		}
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"mangas": mangas,
 		"count":  len(mangas),
@@ -219,27 +250,27 @@ func (h *Handler) GetMangaInfo(c *gin.Context) {
 		return
 	}
 
+	// Cache the entry locally so its alternative titles become searchable,
+	// leaving a recent copy alone so page views don't each rewrite it
+	if !cacheIsFresh(manga.ID) {
+		if err := UpsertManga(manga); err != nil {
+			log.Printf("Warning: failed to cache manga %s: %v", manga.ID, err)
+		}
+	}
+	database.DB.QueryRow(`SELECT COALESCE(community_score, 0), COALESCE(community_score_count, 0) FROM manga WHERE id = ?`, manga.ID).
+		Scan(&manga.CommunityScore, &manga.CommunityScoreCount)
+	if lang := titleLanguageFor(c); lang != "" {
+		manga.DisplayTitle = manga.DisplayTitleFor(lang)
+	}
+
 	c.JSON(http.StatusOK, manga)
 }
 
 func (h *Handler) GetMangaByID(c *gin.Context) {
 	mangaID := c.Param("id")
 
-	var manga models.Manga
-	var genresJSON string
-
-	query := `SELECT id, title, author, genres, status, total_chapters, description, cover_url FROM manga WHERE id = ?`
-	err := database.DB.QueryRow(query, mangaID).Scan(
-		&manga.ID,
-		&manga.Title,
-		&manga.Author,
-		&genresJSON,
-		&manga.Status,
-		&manga.TotalChapters,
-		&manga.Description,
-		&manga.CoverURL,
-	)
-
+	query := `SELECT ` + mangaColumns + ` FROM manga WHERE id = ?`
+	manga, err := scanManga(database.DB.QueryRow(query, mangaID))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Manga not found"})
@@ -249,9 +280,8 @@ func (h *Handler) GetMangaByID(c *gin.Context) {
 		return
 	}
 
-	// Parse genres JSON
-	if genresJSON != "" {
-		json.Unmarshal([]byte(genresJSON), &manga.Genres)
+	if err := AttachAlternativeTitles([]*models.Manga{&manga}, titleLanguageFor(c)); err != nil {
+		log.Printf("Warning: failed to load alternative titles: %v", err)
 	}
 
 	c.JSON(http.StatusOK, manga)
@@ -270,27 +300,7 @@ func (h *Handler) CreateManga(c *gin.Context) {
 		return
 	}
 
-	genresJSON, err := json.Marshal(manga.Genres)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to serialize genres"})
-		return
-	}
-
-	query := `INSERT INTO manga (id, title, author, genres, status, total_chapters, description, cover_url) 
-              VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
-	_, err = database.DB.Exec(
-		query,
-		manga.ID,
-		manga.Title,
-		manga.Author,
-		string(genresJSON),
-		manga.Status,
-		manga.TotalChapters,
-		manga.Description,
-		manga.CoverURL,
-	)
-
-	if err != nil {
+	if err := InsertManga(&manga); err != nil {
 		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
 			c.JSON(http.StatusConflict, gin.H{"error": "Manga with this ID already exists"})
 			return
@@ -304,7 +314,7 @@ func (h *Handler) CreateManga(c *gin.Context) {
 
 // GetAllManga retrieves all manga (for testing purposes)
 func (h *Handler) GetAllManga(c *gin.Context) {
-	query := `SELECT id, title, author, genres, status, total_chapters, description, cover_url FROM manga`
+	query := `SELECT ` + mangaColumns + ` FROM manga`
 	rows, err := database.DB.Query(query)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
@@ -314,36 +324,31 @@ func (h *Handler) GetAllManga(c *gin.Context) {
 
 	var mangas []models.Manga
 	for rows.Next() {
-		var manga models.Manga
-		var genresJSON string
-
-		err := rows.Scan(
-			&manga.ID,
-			&manga.Title,
-			&manga.Author,
-			&genresJSON,
-			&manga.Status,
-			&manga.TotalChapters,
-			&manga.Description,
-			&manga.CoverURL,
-		)
+		manga, err := scanManga(rows)
 		if err != nil {
 			continue
 		}
-
-		if genresJSON != "" {
-			json.Unmarshal([]byte(genresJSON), &manga.Genres)
-		}
-
 		mangas = append(mangas, manga)
 	}
 
+	attachTitles(mangas, titleLanguageFor(c))
+
 	c.JSON(http.StatusOK, gin.H{
 		"mangas": mangas,
 		"count":  len(mangas),
 	})
 }
 
+func attachTitles(mangas []models.Manga, lang string) {
+	ptrs := make([]*models.Manga, len(mangas))
+	for i := range mangas {
+		ptrs[i] = &mangas[i]
+	}
+	if err := AttachAlternativeTitles(ptrs, lang); err != nil {
+		log.Printf("Warning: failed to load alternative titles: %v", err)
+	}
+}
+
 func (h *Handler) fetchRanking(clientID, rankingType string, limit int) ([]RankingManga, error) {
 	apiURL := "https://api.myanimelist.net/v2/manga/ranking"
 	params := url.Values{}
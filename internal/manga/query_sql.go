@@ -0,0 +1,90 @@
+package manga
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/query"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// compileQuery turns a parsed search query into a parameterized WHERE
+// fragment for the local manga table. The fragment starts with " AND".
+func compileQuery(q *query.Query) (string, []interface{}) {
+	var sb strings.Builder
+	var args []interface{}
+
+	for _, c := range q.Clauses {
+		expr, exprArgs := compileClause(c)
+		if c.Negate {
+			expr = "NOT (" + expr + ")"
+		}
+		sb.WriteString(" AND ")
+		sb.WriteString(expr)
+		args = append(args, exprArgs...)
+	}
+	return sb.String(), args
+}
+
+func compileClause(c query.Clause) (string, []interface{}) {
+	switch c.Field {
+	case "title":
+		normalized := "%" + escapeLike(utils.NormalizeTitle(c.Value)) + "%"
+		return `(title LIKE ? ESCAPE '\' OR COALESCE(normalized_title, '') LIKE ? ESCAPE '\'
+                OR id IN (SELECT manga_id FROM manga_alt_titles WHERE normalized_title LIKE ? ESCAPE '\'))`,
+			[]interface{}{"%" + escapeLike(c.Value) + "%", normalized, normalized}
+	case "author":
+		return `COALESCE(author, '') LIKE ? ESCAPE '\'`, []interface{}{"%" + escapeLike(c.Value) + "%"}
+	case "genre":
+		// genres is stored as a JSON array, so match the quoted element
+		return `COALESCE(genres, '') LIKE ? ESCAPE '\'`, []interface{}{`%"` + escapeLike(c.Value) + `"%`}
+	case "status":
+		return `COALESCE(status, '') = ?`, []interface{}{c.Value}
+	case "chapters":
+		return compileNumeric(`COALESCE(total_chapters, 0)`, c)
+	case "volumes":
+		return compileNumeric(`COALESCE(num_volumes, 0)`, c)
+	case "year":
+		return compileNumeric(`CAST(substr(COALESCE(start_date, ''), 1, 4) AS INTEGER)`, c)
+	case "score":
+		return compileNumeric(`COALESCE(mean, 0)`, c)
+	}
+	// Parse rejects unknown fields, so this only guards against new fields
+	// being added to query.Fields without a SQL mapping.
+	return `0`, nil
+}
+
+// likeEscaper makes LIKE wildcards in user input match literally
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
+
+func compileNumeric(column string, c query.Clause) (string, []interface{}) {
+	if c.Op == query.OpRange {
+		return column + ` BETWEEN ? AND ?`, []interface{}{c.Min, c.Max}
+	}
+	return column + ` ` + string(c.Op) + ` ?`, []interface{}{c.Min}
+}
+
+// parseSearchQuery parses q and writes a 400 listing unsupported clauses when
+// it cannot be understood. It returns nil when a response has been written.
+func parseSearchQuery(c *gin.Context, raw string) *query.Query {
+	parsed, err := query.Parse(raw)
+	if err == nil {
+		return parsed
+	}
+
+	resp := gin.H{"error": err.Error()}
+	if unsupported, ok := err.(*query.UnsupportedClauseError); ok {
+		clauses := make([]gin.H, len(unsupported.Clauses))
+		for i := range unsupported.Clauses {
+			clauses[i] = gin.H{"clause": unsupported.Clauses[i], "reason": unsupported.Reasons[i]}
+		}
+		resp["unsupported"] = clauses
+	}
+	c.JSON(http.StatusBadRequest, resp)
+	return nil
+}
@@ -0,0 +1,253 @@
+package manga
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/database"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/models"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// InsertManga stores a new manga and its alternative titles. It fails with a
+// UNIQUE constraint error when the ID already exists.
+func InsertManga(m *models.Manga) error {
+	return writeManga(m, false)
+}
+
+// cacheTTL is how long an entry cached from the external source is served
+// before GetMangaInfo writes a fresh copy over it
+const cacheTTL = 24 * time.Hour
+
+// UpsertManga stores a manga, replacing the catalog entry and alternative
+// titles if the ID already exists. Used when caching external sources.
+func UpsertManga(m *models.Manga) error {
+	return writeManga(m, true)
+}
+
+func writeManga(m *models.Manga, upsert bool) error {
+	genresJSON, err := json.Marshal(m.Genres)
+	if err != nil {
+		return err
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Only entries written from the external source count as cached
+	var cachedAt interface{}
+	if upsert {
+		cachedAt = time.Now()
+	}
+	query := `INSERT INTO manga (id, title, author, genres, status, total_chapters, description, cover_url,
+                  normalized_title, num_volumes, start_date, mean, cached_at)
+              VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	if upsert {
+		query += ` ON CONFLICT(id) DO UPDATE SET
+              title = excluded.title,
+              author = excluded.author,
+              genres = excluded.genres,
+              status = excluded.status,
+              total_chapters = excluded.total_chapters,
+              description = excluded.description,
+              cover_url = excluded.cover_url,
+              normalized_title = excluded.normalized_title,
+              num_volumes = excluded.num_volumes,
+              start_date = excluded.start_date,
+              mean = excluded.mean,
+              cached_at = excluded.cached_at`
+	}
+	_, err = tx.Exec(query,
+		m.ID,
+		m.Title,
+		m.Author,
+		string(genresJSON),
+		m.Status,
+		m.TotalChapters,
+		m.Description,
+		m.CoverURL,
+		utils.NormalizeTitle(m.Title),
+		m.NumVolumes,
+		m.StartDate,
+		m.Mean,
+		cachedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	if m.AlternativeTitles != nil {
+		if err := replaceAltTitles(tx, m.ID, m.AlternativeTitles); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// cacheIsFresh reports whether id was cached from the external source
+// within cacheTTL
+func cacheIsFresh(id string) bool {
+	var cachedAt sql.NullTime
+	err := database.DB.QueryRow(`SELECT cached_at FROM manga WHERE id = ?`, id).Scan(&cachedAt)
+	return err == nil && cachedAt.Valid && time.Since(cachedAt.Time) < cacheTTL
+}
+
+// mangaColumns is the column list read by scanManga.
+const mangaColumns = `id, title, COALESCE(author, ''), COALESCE(genres, ''), COALESCE(status, ''),
+        COALESCE(total_chapters, 0), COALESCE(description, ''), COALESCE(cover_url, ''),
+        COALESCE(num_volumes, 0), COALESCE(start_date, ''), COALESCE(mean, 0),
+        COALESCE(community_score, 0), COALESCE(community_score_count, 0)`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanManga(row rowScanner) (models.Manga, error) {
+	var manga models.Manga
+	var genresJSON string
+	err := row.Scan(
+		&manga.ID,
+		&manga.Title,
+		&manga.Author,
+		&genresJSON,
+		&manga.Status,
+		&manga.TotalChapters,
+		&manga.Description,
+		&manga.CoverURL,
+		&manga.NumVolumes,
+		&manga.StartDate,
+		&manga.Mean,
+		&manga.CommunityScore,
+		&manga.CommunityScoreCount,
+	)
+	if err != nil {
+		return manga, err
+	}
+	if genresJSON != "" {
+		json.Unmarshal([]byte(genresJSON), &manga.Genres)
+	}
+	return manga, nil
+}
+
+func replaceAltTitles(tx *sql.Tx, mangaID string, alt map[string]interface{}) error {
+	if _, err := tx.Exec(`DELETE FROM manga_alt_titles WHERE manga_id = ?`, mangaID); err != nil {
+		return err
+	}
+
+	insert := `INSERT OR IGNORE INTO manga_alt_titles (manga_id, language, title, normalized_title) VALUES (?, ?, ?, ?)`
+	for lang, value := range alt {
+		for _, title := range altTitleValues(value) {
+			title = strings.TrimSpace(title)
+			if title == "" {
+				continue
+			}
+			if _, err := tx.Exec(insert, mangaID, lang, title, utils.NormalizeTitle(title)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// altTitleValues accepts both the typed values built by the MAL converter and
+// the generic ones produced by decoding a JSON request body.
+func altTitleValues(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []string:
+		return v
+	case []interface{}:
+		out := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+// AttachAlternativeTitles loads stored alternative titles for the given manga
+// and fills DisplayTitle according to lang. An empty lang leaves DisplayTitle unset.
+func AttachAlternativeTitles(mangas []*models.Manga, lang string) error {
+	if len(mangas) == 0 {
+		return nil
+	}
+
+	ids := make([]interface{}, 0, len(mangas))
+	placeholders := make([]string, 0, len(mangas))
+	for _, m := range mangas {
+		ids = append(ids, m.ID)
+		placeholders = append(placeholders, "?")
+	}
+
+	query := `SELECT manga_id, language, title FROM manga_alt_titles WHERE manga_id IN (` + strings.Join(placeholders, ",") + `) ORDER BY rowid`
+	rows, err := database.DB.Query(query, ids...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	byID := map[string]map[string]interface{}{}
+	for rows.Next() {
+		var mangaID, language, title string
+		if err := rows.Scan(&mangaID, &language, &title); err != nil {
+			return err
+		}
+		alt, ok := byID[mangaID]
+		if !ok {
+			alt = map[string]interface{}{}
+			byID[mangaID] = alt
+		}
+		if language == "synonyms" {
+			synonyms, _ := alt[language].([]string)
+			alt[language] = append(synonyms, title)
+		} else {
+			alt[language] = title
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, m := range mangas {
+		if alt, ok := byID[m.ID]; ok {
+			m.AlternativeTitles = alt
+		}
+		if lang != "" {
+			m.DisplayTitle = m.DisplayTitleFor(lang)
+		}
+	}
+	return nil
+}
+
+// UserTitleLanguage returns the user's preferred title language, or "" when
+// the user has not chosen one.
+func UserTitleLanguage(userID string) string {
+	if userID == "" {
+		return ""
+	}
+	var lang sql.NullString
+	if err := database.DB.QueryRow(`SELECT preferred_title_language FROM users WHERE id = ?`, userID).Scan(&lang); err != nil {
+		return ""
+	}
+	return lang.String
+}
+
+// titleLanguageFor resolves the display language for a request: an explicit
+// ?lang= wins, otherwise the authenticated user's preference is used.
+func titleLanguageFor(c *gin.Context) string {
+	if lang := c.Query("lang"); lang != "" {
+		return lang
+	}
+	return UserTitleLanguage(c.GetString("user_id"))
+}
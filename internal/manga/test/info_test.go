@@ -0,0 +1,68 @@
+package manga_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/manga"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/database"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+// infoSource stands in for MyAnimeList, serving whatever title it holds
+type infoSource struct {
+	title string
+}
+
+func (s *infoSource) Search(ctx context.Context, query string, limit, offset int) ([]models.Manga, error) {
+	return nil, nil
+}
+
+func (s *infoSource) GetMangaByID(ctx context.Context, id string) (*models.Manga, error) {
+	return &models.Manga{ID: id, Title: s.title}, nil
+}
+
+func TestGetMangaInfo_CachesOnlyMissingOrStaleEntries(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := database.InitDatabase(tmpDir + "/test.db"); err != nil {
+		t.Fatalf("init db: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	source := &infoSource{title: "Berserk"}
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handler := manga.NewHandler()
+	handler.SetExternalSource(source)
+	router.GET("/manga/info/:id", handler.GetMangaInfo)
+
+	cachedTitle := func() string {
+		t.Helper()
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, httptest.NewRequest("GET", "/manga/info/2", nil))
+		if resp.Code != 200 {
+			t.Fatalf("expected 200, got %d: %s", resp.Code, resp.Body.String())
+		}
+		var title string
+		database.DB.QueryRow(`SELECT title FROM manga WHERE id = '2'`).Scan(&title)
+		return title
+	}
+
+	if got := cachedTitle(); got != "Berserk" {
+		t.Fatalf("missing entry: expected it cached as Berserk, got %q", got)
+	}
+
+	source.title = "Berserk (Deluxe)"
+	if got := cachedTitle(); got != "Berserk" {
+		t.Fatalf("fresh entry: expected no rewrite, got %q", got)
+	}
+
+	if _, err := database.DB.Exec(`UPDATE manga SET cached_at = datetime('now', '-2 days') WHERE id = '2'`); err != nil {
+		t.Fatalf("age cache: %v", err)
+	}
+	if got := cachedTitle(); got != "Berserk (Deluxe)" {
+		t.Fatalf("stale entry: expected a refresh, got %q", got)
+	}
+}
@@ -0,0 +1,149 @@
+package manga_test
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/manga"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/database"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+func setupSearchTest(t *testing.T) *gin.Engine {
+	tmpDir := t.TempDir()
+	if err := database.InitDatabase(tmpDir + "/test.db"); err != nil {
+		t.Fatalf("init db: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	err := manga.InsertManga(&models.Manga{
+		ID:     "23390",
+		Title:  "Shingeki no Kyojin",
+		Author: "Hajime Isayama",
+		Genres: []string{"Action", "Drama"},
+		Status: "completed",
+		AlternativeTitles: map[string]interface{}{
+			"en":       "Attack on Titan",
+			"ja":       "進撃の巨人",
+			"synonyms": []string{"AoT"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("insert manga: %v", err)
+	}
+	if err := manga.InsertManga(&models.Manga{ID: "13", Title: "Pokémon Adventures"}); err != nil {
+		t.Fatalf("insert manga: %v", err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handler := manga.NewHandler()
+	router.GET("/manga", handler.SearchManga)
+	return router
+}
+
+func searchTitles(t *testing.T, router *gin.Engine, params url.Values) []models.Manga {
+	req := httptest.NewRequest("GET", "/manga?"+params.Encode(), nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	if resp.Code != 200 {
+		t.Fatalf("expected 200, got %d: %s", resp.Code, resp.Body.String())
+	}
+	var result struct {
+		Mangas []models.Manga `json:"mangas"`
+	}
+	if err := json.Unmarshal(resp.Body.Bytes(), &result); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	return result.Mangas
+}
+
+func TestSearchManga_MatchesAlternativeTitles(t *testing.T) {
+	router := setupSearchTest(t)
+
+	queries := []string{
+		"attack on titan",
+		"ＡＴＴＡＣＫ ＯＮ",
+		"進撃",
+		"aot",
+		"shingeki",
+	}
+	for _, q := range queries {
+		mangas := searchTitles(t, router, url.Values{"title": {q}})
+		if len(mangas) != 1 || mangas[0].ID != "23390" {
+			t.Errorf("query %q: expected manga 23390, got %+v", q, mangas)
+		}
+	}
+}
+
+func TestSearchManga_IgnoresDiacritics(t *testing.T) {
+	router := setupSearchTest(t)
+
+	mangas := searchTitles(t, router, url.Values{"title": {"pokemon"}})
+	if len(mangas) != 1 || mangas[0].ID != "13" {
+		t.Fatalf("expected manga 13, got %+v", mangas)
+	}
+}
+
+func TestSearchManga_DisplayTitleLanguage(t *testing.T) {
+	router := setupSearchTest(t)
+
+	mangas := searchTitles(t, router, url.Values{"title": {"shingeki"}, "lang": {"en"}})
+	if len(mangas) != 1 {
+		t.Fatalf("expected 1 result, got %d", len(mangas))
+	}
+	if mangas[0].DisplayTitle != "Attack on Titan" {
+		t.Errorf("expected English display title, got %q", mangas[0].DisplayTitle)
+	}
+	if mangas[0].Title != "Shingeki no Kyojin" {
+		t.Errorf("main title should be unchanged, got %q", mangas[0].Title)
+	}
+}
+
+func TestSearchManga_StructuredQuery(t *testing.T) {
+	router := setupSearchTest(t)
+
+	mangas := searchTitles(t, router, url.Values{"q": {"genre:action status:completed"}})
+	if len(mangas) != 1 || mangas[0].ID != "23390" {
+		t.Fatalf("expected manga 23390, got %+v", mangas)
+	}
+
+	mangas = searchTitles(t, router, url.Values{"q": {"-genre:drama"}})
+	if len(mangas) != 1 || mangas[0].ID != "13" {
+		t.Fatalf("expected manga 13, got %+v", mangas)
+	}
+}
+
+func TestSearchManga_WildcardsMatchLiterally(t *testing.T) {
+	router := setupSearchTest(t)
+
+	for _, q := range []string{"genre:%", "genre:Dram_", `author:\%`, "author:%isayama"} {
+		if mangas := searchTitles(t, router, url.Values{"q": {q}}); len(mangas) != 0 {
+			t.Errorf("query %q: expected no matches, got %+v", q, mangas)
+		}
+	}
+}
+
+func TestSearchManga_UnsupportedClause(t *testing.T) {
+	router := setupSearchTest(t)
+
+	req := httptest.NewRequest("GET", "/manga?"+url.Values{"q": {"publisher:kodansha"}}.Encode(), nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	if resp.Code != 400 {
+		t.Fatalf("expected 400, got %d", resp.Code)
+	}
+
+	var result struct {
+		Unsupported []struct {
+			Clause string `json:"clause"`
+		} `json:"unsupported"`
+	}
+	json.Unmarshal(resp.Body.Bytes(), &result)
+	if len(result.Unsupported) != 1 || result.Unsupported[0].Clause != "publisher:kodansha" {
+		t.Errorf("expected unsupported clause to be reported, got %s", resp.Body.String())
+	}
+}
@@ -0,0 +1,287 @@
+package review
+
+import (
+	"database/sql"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/database"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+const defaultPageSize = 20
+
+// Handler handles manga ratings and reviews
+type Handler struct{}
+
+// NewHandler creates a new review handler
+func NewHandler() *Handler {
+	return &Handler{}
+}
+
+// CreateReview rates a manga and optionally attaches written text
+func (h *Handler) CreateReview(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	mangaID := c.Param("id")
+	var req models.CreateReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var exists bool
+	err := database.DB.QueryRow(`SELECT EXISTS(SELECT 1 FROM manga WHERE id = ?)`, mangaID).Scan(&exists)
+	if err != nil || !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Manga not found"})
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	_, err = tx.Exec(`INSERT INTO ratings (user_id, manga_id, score, created_at, updated_at) VALUES (?, ?, ?, ?, ?)`,
+		userID, mangaID, req.Score, now, now)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			c.JSON(http.StatusConflict, gin.H{"error": "You have already reviewed this manga"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save review"})
+		return
+	}
+
+	if text := strings.TrimSpace(req.Text); text != "" {
+		_, err = tx.Exec(`INSERT INTO reviews (user_id, manga_id, body, spoiler, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)`,
+			userID, mangaID, text, req.Spoiler, now, now)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save review"})
+			return
+		}
+	}
+
+	if err := RefreshCommunityScore(tx, mangaID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save review"})
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save review"})
+		return
+	}
+
+	review, _ := getReview(userID, mangaID)
+	c.JSON(http.StatusCreated, review)
+}
+
+// UpdateReview changes the current user's score, text or spoiler flag
+func (h *Handler) UpdateReview(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	mangaID := c.Param("id")
+	var req models.UpdateReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	current, err := getReview(userID, mangaID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Review not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	if req.Score != nil {
+		current.Score = *req.Score
+	}
+	if req.Text != nil {
+		current.Text = strings.TrimSpace(*req.Text)
+	}
+	if req.Spoiler != nil {
+		current.Spoiler = *req.Spoiler
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	if _, err := tx.Exec(`UPDATE ratings SET score = ?, updated_at = ? WHERE user_id = ? AND manga_id = ?`,
+		current.Score, now, userID, mangaID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update review"})
+		return
+	}
+
+	// Clearing the text keeps the rating but removes the written review
+	if current.Text == "" {
+		_, err = tx.Exec(`DELETE FROM reviews WHERE user_id = ? AND manga_id = ?`, userID, mangaID)
+	} else {
+		_, err = tx.Exec(`INSERT INTO reviews (user_id, manga_id, body, spoiler, created_at, updated_at)
+                          VALUES (?, ?, ?, ?, ?, ?)
+                          ON CONFLICT(user_id, manga_id) DO UPDATE SET body = excluded.body, spoiler = excluded.spoiler, updated_at = excluded.updated_at`,
+			userID, mangaID, current.Text, current.Spoiler, now, now)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update review"})
+		return
+	}
+
+	if err := RefreshCommunityScore(tx, mangaID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update review"})
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update review"})
+		return
+	}
+
+	review, _ := getReview(userID, mangaID)
+	c.JSON(http.StatusOK, review)
+}
+
+// DeleteReview removes the current user's rating and review
+func (h *Handler) DeleteReview(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	mangaID := c.Param("id")
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`DELETE FROM ratings WHERE user_id = ? AND manga_id = ?`, userID, mangaID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete review"})
+		return
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Review not found"})
+		return
+	}
+
+	if err := RefreshCommunityScore(tx, mangaID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete review"})
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete review"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Review deleted successfully"})
+}
+
+// ListReviews returns a page of ratings and reviews for a manga, newest first
+func (h *Handler) ListReviews(c *gin.Context) {
+	mangaID := c.Param("id")
+
+	var req models.ListReviewsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Page == 0 {
+		req.Page = 1
+	}
+	if req.Limit == 0 {
+		req.Limit = defaultPageSize
+	}
+
+	var score float64
+	var total int
+	err := database.DB.QueryRow(`SELECT COALESCE(community_score, 0), COALESCE(community_score_count, 0) FROM manga WHERE id = ?`, mangaID).
+		Scan(&score, &total)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Manga not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	query := reviewSelect + ` WHERE r.manga_id = ? ORDER BY r.updated_at DESC, r.user_id LIMIT ? OFFSET ?`
+	rows, err := database.DB.Query(query, mangaID, req.Limit, (req.Page-1)*req.Limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	defer rows.Close()
+
+	reviews := []models.Review{}
+	for rows.Next() {
+		review, err := scanReview(rows)
+		if err != nil {
+			continue
+		}
+		reviews = append(reviews, review)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"reviews":               reviews,
+		"page":                  req.Page,
+		"limit":                 req.Limit,
+		"total":                 total,
+		"community_score":       score,
+		"community_score_count": total,
+	})
+}
+
+const reviewSelect = `SELECT r.user_id, COALESCE(u.username, ''), r.manga_id, r.score,
+        COALESCE(v.body, ''), COALESCE(v.spoiler, 0), r.created_at, r.updated_at
+    FROM ratings r
+    LEFT JOIN users u ON u.id = r.user_id
+    LEFT JOIN reviews v ON v.user_id = r.user_id AND v.manga_id = r.manga_id`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanReview(row rowScanner) (models.Review, error) {
+	var review models.Review
+	err := row.Scan(&review.UserID, &review.Username, &review.MangaID, &review.Score,
+		&review.Text, &review.Spoiler, &review.CreatedAt, &review.UpdatedAt)
+	return review, err
+}
+
+func getReview(userID, mangaID string) (models.Review, error) {
+	return scanReview(database.DB.QueryRow(reviewSelect+` WHERE r.user_id = ? AND r.manga_id = ?`, userID, mangaID))
+}
+
+// RefreshCommunityScore recomputes the cached aggregate on the manga row so
+// reads never need to aggregate ratings.
+func RefreshCommunityScore(tx *sql.Tx, mangaID string) error {
+	_, err := tx.Exec(`UPDATE manga SET
+            community_score = COALESCE((SELECT AVG(score) FROM ratings WHERE manga_id = ?), 0),
+            community_score_count = (SELECT COUNT(*) FROM ratings WHERE manga_id = ?)
+        WHERE id = ?`, mangaID, mangaID, mangaID)
+	return err
+}
@@ -0,0 +1,149 @@
+package review_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/manga"
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/review"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/database"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+func setupReviewTest(t *testing.T) *gin.Engine {
+	tmpDir := t.TempDir()
+	if err := database.InitDatabase(tmpDir + "/test.db"); err != nil {
+		t.Fatalf("init db: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	for _, u := range []string{"alice", "bob"} {
+		_, err := database.DB.Exec(`INSERT INTO users (id, username, email, password_hash) VALUES (?, ?, ?, 'x')`, u, u, u+"@example.com")
+		if err != nil {
+			t.Fatalf("insert user: %v", err)
+		}
+	}
+	if err := manga.InsertManga(&models.Manga{ID: "1", Title: "Monster"}); err != nil {
+		t.Fatalf("insert manga: %v", err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	// Stand-in for the auth middleware
+	router.Use(func(c *gin.Context) {
+		if id := c.GetHeader("X-User-ID"); id != "" {
+			c.Set("user_id", id)
+		}
+	})
+	handler := review.NewHandler()
+	router.GET("/manga/:id/reviews", handler.ListReviews)
+	router.POST("/manga/:id/reviews", handler.CreateReview)
+	router.PUT("/manga/:id/reviews", handler.UpdateReview)
+	router.DELETE("/manga/:id/reviews", handler.DeleteReview)
+	return router
+}
+
+func doRequest(router *gin.Engine, method, path, userID string, body interface{}) *httptest.ResponseRecorder {
+	var payload []byte
+	if body != nil {
+		payload, _ = json.Marshal(body)
+	}
+	req := httptest.NewRequest(method, path, bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	if userID != "" {
+		req.Header.Set("X-User-ID", userID)
+	}
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	return resp
+}
+
+type listResponse struct {
+	Reviews             []models.Review `json:"reviews"`
+	Total               int             `json:"total"`
+	CommunityScore      float64         `json:"community_score"`
+	CommunityScoreCount int             `json:"community_score_count"`
+}
+
+func listReviews(t *testing.T, router *gin.Engine, query string) listResponse {
+	resp := doRequest(router, "GET", "/manga/1/reviews"+query, "", nil)
+	if resp.Code != 200 {
+		t.Fatalf("list: expected 200, got %d: %s", resp.Code, resp.Body.String())
+	}
+	var result listResponse
+	json.Unmarshal(resp.Body.Bytes(), &result)
+	return result
+}
+
+func TestReviews_CommunityScore(t *testing.T) {
+	router := setupReviewTest(t)
+
+	resp := doRequest(router, "POST", "/manga/1/reviews", "alice", gin.H{"score": 9, "text": "Great ending", "spoiler": true})
+	if resp.Code != 201 {
+		t.Fatalf("create: expected 201, got %d: %s", resp.Code, resp.Body.String())
+	}
+	resp = doRequest(router, "POST", "/manga/1/reviews", "bob", gin.H{"score": 6})
+	if resp.Code != 201 {
+		t.Fatalf("create: expected 201, got %d: %s", resp.Code, resp.Body.String())
+	}
+
+	result := listReviews(t, router, "")
+	if result.CommunityScoreCount != 2 || result.CommunityScore != 7.5 {
+		t.Errorf("expected score 7.5 from 2 ratings, got %.2f from %d", result.CommunityScore, result.CommunityScoreCount)
+	}
+
+	resp = doRequest(router, "PUT", "/manga/1/reviews", "bob", gin.H{"score": 10})
+	if resp.Code != 200 {
+		t.Fatalf("update: expected 200, got %d: %s", resp.Code, resp.Body.String())
+	}
+	resp = doRequest(router, "DELETE", "/manga/1/reviews", "alice", nil)
+	if resp.Code != 200 {
+		t.Fatalf("delete: expected 200, got %d: %s", resp.Code, resp.Body.String())
+	}
+
+	result = listReviews(t, router, "")
+	if result.CommunityScoreCount != 1 || result.CommunityScore != 10 {
+		t.Errorf("expected score 10 from 1 rating, got %.2f from %d", result.CommunityScore, result.CommunityScoreCount)
+	}
+}
+
+func TestReviews_Validation(t *testing.T) {
+	router := setupReviewTest(t)
+
+	if resp := doRequest(router, "POST", "/manga/1/reviews", "alice", gin.H{"score": 11}); resp.Code != 400 {
+		t.Errorf("score 11: expected 400, got %d", resp.Code)
+	}
+	if resp := doRequest(router, "POST", "/manga/missing/reviews", "alice", gin.H{"score": 5}); resp.Code != 404 {
+		t.Errorf("unknown manga: expected 404, got %d", resp.Code)
+	}
+	if resp := doRequest(router, "PUT", "/manga/1/reviews", "alice", gin.H{"score": 5}); resp.Code != 404 {
+		t.Errorf("update without review: expected 404, got %d", resp.Code)
+	}
+
+	doRequest(router, "POST", "/manga/1/reviews", "alice", gin.H{"score": 5})
+	if resp := doRequest(router, "POST", "/manga/1/reviews", "alice", gin.H{"score": 7}); resp.Code != 409 {
+		t.Errorf("duplicate review: expected 409, got %d", resp.Code)
+	}
+}
+
+func TestReviews_Pagination(t *testing.T) {
+	router := setupReviewTest(t)
+
+	doRequest(router, "POST", "/manga/1/reviews", "alice", gin.H{"score": 8, "text": "Tense"})
+	doRequest(router, "POST", "/manga/1/reviews", "bob", gin.H{"score": 7, "text": "Slow start"})
+
+	first := listReviews(t, router, "?limit=1&page=1")
+	second := listReviews(t, router, "?limit=1&page=2")
+	if len(first.Reviews) != 1 || len(second.Reviews) != 1 {
+		t.Fatalf("expected one review per page, got %d and %d", len(first.Reviews), len(second.Reviews))
+	}
+	if first.Reviews[0].UserID == second.Reviews[0].UserID {
+		t.Errorf("pages returned the same review")
+	}
+	if first.Total != 2 {
+		t.Errorf("expected total 2, got %d", first.Total)
+	}
+}
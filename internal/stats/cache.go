@@ -0,0 +1,64 @@
+package stats
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/database"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/models"
+)
+
+// cacheTTL bounds how stale cached stats can get when nothing invalidates
+// them, e.g. a streak ending at midnight.
+const cacheTTL = 5 * time.Minute
+
+// bumpVersion invalidates the user's cached stats. The version lives in the
+// database so invalidations from the TCP server reach the API server's cache.
+const bumpVersion = `UPDATE users SET stats_version = stats_version + 1 WHERE id = ?`
+
+type cacheEntry struct {
+	stats   models.ReadingStats
+	version int64
+	expires time.Time
+}
+
+var (
+	cacheMu sync.Mutex
+	cache   = map[string]map[string]cacheEntry{} // user ID -> range -> stats
+)
+
+// currentVersion returns the user's stats version, read before computing so
+// an invalidation during the computation isn't lost
+func currentVersion(userID string) (int64, error) {
+	var version int64
+	err := database.DB.QueryRow(`SELECT stats_version FROM users WHERE id = ?`, userID).Scan(&version)
+	return version, err
+}
+
+func cached(userID, rng string, version int64) (models.ReadingStats, bool) {
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+	entry, ok := cache[userID][rng]
+	if !ok || entry.version != version || time.Now().After(entry.expires) {
+		return models.ReadingStats{}, false
+	}
+	return entry.stats, true
+}
+
+func store(userID, rng string, version int64, stats models.ReadingStats) {
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+	if cache[userID] == nil {
+		cache[userID] = map[string]cacheEntry{}
+	}
+	cache[userID][rng] = cacheEntry{stats: stats, version: version, expires: time.Now().Add(cacheTTL)}
+}
+
+// Invalidate drops the user's cached stats after their library changes, in
+// this process and any other sharing the database
+func Invalidate(userID string) {
+	if _, err := database.DB.Exec(bumpVersion, userID); err != nil {
+		log.Printf("Warning: failed to invalidate stats cache: %v", err)
+	}
+}
@@ -0,0 +1,275 @@
+package stats
+
+import (
+	"database/sql"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/database"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/models"
+)
+
+var rangeDays = map[string]int{
+	models.StatsRangeWeek:  7,
+	models.StatsRangeMonth: 30,
+	models.StatsRangeYear:  365,
+}
+
+// Compute builds the user's reading stats for the range as of now
+func Compute(userID, rng string, now time.Time) (models.ReadingStats, error) {
+	now = now.UTC()
+	today := now.Truncate(24 * time.Hour)
+	stats := models.ReadingStats{
+		Range:   rng,
+		To:      today.Format(dayFormat),
+		Daily:   []models.DailyChapters{},
+		Genres:  []models.StatsBreakdown{},
+		Authors: []models.StatsBreakdown{},
+	}
+
+	var since time.Time
+	if days, ok := rangeDays[rng]; ok {
+		since = today.AddDate(0, 0, -(days - 1))
+		stats.From = since.Format(dayFormat)
+	}
+
+	days, err := readingDays(userID)
+	if err != nil {
+		return stats, err
+	}
+	for _, d := range days {
+		if d.Day >= stats.From {
+			stats.Daily = append(stats.Daily, d)
+			stats.ChaptersRead.Total += d.Chapters
+		}
+	}
+	if stats.From == "" && len(days) > 0 {
+		stats.From = days[0].Day
+	}
+	averageChapters(&stats.ChaptersRead, stats.From, today)
+	stats.CurrentStreak, stats.LongestStreak = streaks(days, today)
+
+	if err := breakdowns(userID, since, &stats); err != nil {
+		return stats, err
+	}
+	if err := completionTimes(userID, since, &stats); err != nil {
+		return stats, err
+	}
+	if err := statusDistribution(userID, &stats); err != nil {
+		return stats, err
+	}
+	return stats, nil
+}
+
+func readingDays(userID string) ([]models.DailyChapters, error) {
+	rows, err := database.DB.Query(`SELECT day, chapters FROM reading_days WHERE user_id = ? AND chapters > 0 ORDER BY day`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var days []models.DailyChapters
+	for rows.Next() {
+		var d models.DailyChapters
+		if err := rows.Scan(&d.Day, &d.Chapters); err != nil {
+			return nil, err
+		}
+		days = append(days, d)
+	}
+	return days, rows.Err()
+}
+
+// averageChapters spreads the total over every day from `from` to today,
+// including days without reading.
+func averageChapters(c *models.ChaptersRead, from string, today time.Time) {
+	start, err := time.Parse(dayFormat, from)
+	if err != nil {
+		return
+	}
+	span := today.Sub(start).Hours()/24 + 1
+	if span < 1 {
+		span = 1
+	}
+	c.PerDay = float64(c.Total) / span
+	c.PerWeek = c.PerDay * 7
+	c.PerMonth = c.PerDay * 30
+}
+
+// streaks returns the run of consecutive days ending today (or yesterday, so
+// a streak isn't broken before the user has had a chance to read today) and
+// the longest run overall. days must be sorted.
+func streaks(days []models.DailyChapters, today time.Time) (current, longest int) {
+	run := 0
+	var prev time.Time
+	for _, d := range days {
+		day, err := time.Parse(dayFormat, d.Day)
+		if err != nil {
+			continue
+		}
+		if run > 0 && day.Sub(prev) == 24*time.Hour {
+			run++
+		} else {
+			run = 1
+		}
+		prev = day
+		if run > longest {
+			longest = run
+		}
+	}
+	if run > 0 && today.Sub(prev) <= 24*time.Hour {
+		current = run
+	}
+	return current, longest
+}
+
+// breakdowns groups the library by genre and author. With a bounded range only
+// series read during it count; all-time stats cover the whole library.
+func breakdowns(userID string, since time.Time, stats *models.ReadingStats) error {
+	chapters := map[string]int{}
+	rows, err := database.DB.Query(`SELECT manga_id, SUM(chapters_read) FROM progress_history
+                                    WHERE user_id = ? AND recorded_at >= ? GROUP BY manga_id`, userID, since)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var mangaID string
+		var n int
+		if err := rows.Scan(&mangaID, &n); err != nil {
+			rows.Close()
+			return err
+		}
+		chapters[mangaID] = n
+	}
+	rows.Close()
+
+	rows, err = database.DB.Query(`SELECT m.id, COALESCE(m.author, ''), COALESCE(m.genres, '')
+                                   FROM user_progress up JOIN manga m ON m.id = up.manga_id
+                                   WHERE up.user_id = ?`, userID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	genres := map[string]*models.StatsBreakdown{}
+	authors := map[string]*models.StatsBreakdown{}
+	add := func(groups map[string]*models.StatsBreakdown, name string, n int) {
+		if name == "" {
+			return
+		}
+		g, ok := groups[name]
+		if !ok {
+			g = &models.StatsBreakdown{Name: name}
+			groups[name] = g
+		}
+		g.Series++
+		g.Chapters += n
+	}
+
+	for rows.Next() {
+		var mangaID, author, genresJSON string
+		if err := rows.Scan(&mangaID, &author, &genresJSON); err != nil {
+			return err
+		}
+		n, read := chapters[mangaID]
+		if !since.IsZero() && !read {
+			continue
+		}
+		var mangaGenres []string
+		json.Unmarshal([]byte(genresJSON), &mangaGenres)
+		for _, genre := range mangaGenres {
+			add(genres, genre, n)
+		}
+		add(authors, author, n)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	stats.Genres = sortedBreakdown(genres)
+	stats.Authors = sortedBreakdown(authors)
+	return nil
+}
+
+func sortedBreakdown(groups map[string]*models.StatsBreakdown) []models.StatsBreakdown {
+	out := make([]models.StatsBreakdown, 0, len(groups))
+	for _, g := range groups {
+		out = append(out, *g)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Chapters != out[j].Chapters {
+			return out[i].Chapters > out[j].Chapters
+		}
+		if out[i].Series != out[j].Series {
+			return out[i].Series > out[j].Series
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+// completionTimes averages the time from a series' first history entry to
+// the first time it was marked completed, for series completed in the range.
+func completionTimes(userID string, since time.Time, stats *models.ReadingStats) error {
+	rows, err := database.DB.Query(`SELECT manga_id, status, recorded_at FROM progress_history
+                                    WHERE user_id = ? ORDER BY recorded_at, id`, userID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	started := map[string]time.Time{}
+	completed := map[string]time.Time{}
+	for rows.Next() {
+		var mangaID string
+		var status models.ReadingStatus
+		var at sql.NullTime
+		if err := rows.Scan(&mangaID, &status, &at); err != nil {
+			return err
+		}
+		if _, ok := started[mangaID]; !ok {
+			started[mangaID] = at.Time
+		}
+		if _, ok := completed[mangaID]; !ok && status == models.StatusCompleted {
+			completed[mangaID] = at.Time
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	var total time.Duration
+	for mangaID, done := range completed {
+		if done.Before(since) {
+			continue
+		}
+		total += done.Sub(started[mangaID])
+		stats.CompletedSeries++
+	}
+	if stats.CompletedSeries > 0 {
+		stats.AverageCompletionDays = total.Hours() / 24 / float64(stats.CompletedSeries)
+	}
+	return nil
+}
+
+func statusDistribution(userID string, stats *models.ReadingStats) error {
+	stats.StatusDistribution = make(map[string]int, len(models.ReadingStatuses))
+	for _, status := range models.ReadingStatuses {
+		stats.StatusDistribution[string(status)] = 0
+	}
+
+	rows, err := database.DB.Query(`SELECT status, COUNT(*) FROM user_progress WHERE user_id = ? GROUP BY status`, userID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var status string
+		var n int
+		if err := rows.Scan(&status, &n); err != nil {
+			return err
+		}
+		stats.StatusDistribution[status] = n
+	}
+	return rows.Err()
+}
@@ -0,0 +1,55 @@
+package stats
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+// Handler handles personal reading statistics
+type Handler struct{}
+
+// NewHandler creates a new stats handler
+func NewHandler() *Handler {
+	return &Handler{}
+}
+
+// GetStats returns the current user's reading statistics for ?range=7d, 30d,
+// 365d or all (the default)
+func (h *Handler) GetStats(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req models.StatsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Range == "" {
+		req.Range = models.StatsRangeAll
+	}
+
+	version, err := currentVersion(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute stats"})
+		return
+	}
+	if stats, ok := cached(userID, req.Range, version); ok {
+		c.JSON(http.StatusOK, stats)
+		return
+	}
+
+	stats, err := Compute(userID, req.Range, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute stats"})
+		return
+	}
+	store(userID, req.Range, version, stats)
+
+	c.JSON(http.StatusOK, stats)
+}
@@ -0,0 +1,58 @@
+package stats
+
+import (
+	"time"
+
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/database"
+)
+
+const dayFormat = "2006-01-02"
+
+// RecordProgress appends the entry's current chapter and status to the
+// user's progress history, counting the chapters read since previousChapter.
+// Call it after the user_progress row has been written.
+func RecordProgress(userID, mangaID string, previousChapter int, at time.Time) error {
+	return record(userID, mangaID, previousChapter, at)
+}
+
+// RecordStatusChange appends the entry's current state to the history without
+// counting any chapters as read, e.g. when a manga is added to the library.
+func RecordStatusChange(userID, mangaID string, at time.Time) error {
+	return record(userID, mangaID, nil, at)
+}
+
+func record(userID, mangaID string, previousChapter interface{}, at time.Time) error {
+	at = at.UTC()
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`INSERT INTO progress_history (user_id, manga_id, chapter, chapters_read, status, recorded_at)
+                            SELECT user_id, manga_id, current_chapter, MAX(current_chapter - COALESCE(?, current_chapter), 0), status, ?
+                            FROM user_progress WHERE user_id = ? AND manga_id = ?`,
+		previousChapter, at, userID, mangaID)
+	if err != nil {
+		return err
+	}
+	historyID, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	// reading_days is the per-day rollup the stats endpoint reads from
+	_, err = tx.Exec(`INSERT INTO reading_days (user_id, day, chapters)
+                      SELECT user_id, ?, chapters_read FROM progress_history WHERE id = ? AND chapters_read > 0
+                      ON CONFLICT(user_id, day) DO UPDATE SET chapters = chapters + excluded.chapters`,
+		at.Format(dayFormat), historyID)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(bumpVersion, userID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,164 @@
+package stats_test
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/manga"
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/stats"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/database"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+func setupStatsTest(t *testing.T) {
+	if err := database.InitDatabase(t.TempDir() + "/test.db"); err != nil {
+		t.Fatalf("init db: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	if _, err := database.DB.Exec(`INSERT INTO users (id, username, email, password_hash) VALUES ('u1', 'reader', 'reader@example.com', 'x')`); err != nil {
+		t.Fatalf("insert user: %v", err)
+	}
+	for _, m := range []models.Manga{
+		{ID: "1", Title: "Berserk", Author: "Kentaro Miura", Genres: []string{"Action", "Horror"}},
+		{ID: "2", Title: "Yotsuba&!", Author: "Kiyohiko Azuma", Genres: []string{"Comedy"}},
+	} {
+		m := m
+		if err := manga.InsertManga(&m); err != nil {
+			t.Fatalf("insert manga: %v", err)
+		}
+	}
+}
+
+// readTo moves the library entry to chapter and records it at the given time
+func readTo(t *testing.T, mangaID string, chapter int, status models.ReadingStatus, at time.Time) {
+	var previous int
+	database.DB.QueryRow(`SELECT current_chapter FROM user_progress WHERE user_id = 'u1' AND manga_id = ?`, mangaID).Scan(&previous)
+	_, err := database.DB.Exec(`INSERT INTO user_progress (user_id, manga_id, current_chapter, status) VALUES ('u1', ?, ?, ?)
+                                ON CONFLICT(user_id, manga_id) DO UPDATE SET current_chapter = excluded.current_chapter, status = excluded.status`,
+		mangaID, chapter, status)
+	if err != nil {
+		t.Fatalf("write progress: %v", err)
+	}
+	if err := stats.RecordProgress("u1", mangaID, previous, at); err != nil {
+		t.Fatalf("record progress: %v", err)
+	}
+}
+
+func TestCompute(t *testing.T) {
+	setupStatsTest(t)
+	now := time.Date(2026, 3, 20, 15, 0, 0, 0, time.UTC)
+	day := func(n int) time.Time { return now.AddDate(0, 0, -n) }
+
+	// Three days in a row ending today, after an older two-day run
+	readTo(t, "1", 10, models.StatusReading, day(40))
+	readTo(t, "1", 20, models.StatusReading, day(39))
+	readTo(t, "1", 30, models.StatusReading, day(2))
+	readTo(t, "2", 5, models.StatusReading, day(1))
+	readTo(t, "2", 15, models.StatusCompleted, day(0))
+
+	all, err := stats.Compute("u1", models.StatsRangeAll, now)
+	if err != nil {
+		t.Fatalf("compute: %v", err)
+	}
+	if all.ChaptersRead.Total != 45 {
+		t.Errorf("expected 45 chapters read, got %d", all.ChaptersRead.Total)
+	}
+	if all.CurrentStreak != 3 || all.LongestStreak != 3 {
+		t.Errorf("expected streaks 3/3, got %d/%d", all.CurrentStreak, all.LongestStreak)
+	}
+	if all.CompletedSeries != 1 || all.AverageCompletionDays != 1 {
+		t.Errorf("expected one series completed in 1 day, got %d in %.2f", all.CompletedSeries, all.AverageCompletionDays)
+	}
+	if all.StatusDistribution["reading"] != 1 || all.StatusDistribution["completed"] != 1 || all.StatusDistribution["dropped"] != 0 {
+		t.Errorf("unexpected status distribution %v", all.StatusDistribution)
+	}
+	if len(all.Genres) != 3 || all.Genres[0].Name != "Action" || all.Genres[0].Chapters != 30 {
+		t.Errorf("expected Action first with 30 chapters, got %+v", all.Genres)
+	}
+
+	week, err := stats.Compute("u1", models.StatsRangeWeek, now)
+	if err != nil {
+		t.Fatalf("compute: %v", err)
+	}
+	if week.ChaptersRead.Total != 25 || len(week.Daily) != 3 {
+		t.Errorf("expected 25 chapters over 3 days this week, got %d over %d", week.ChaptersRead.Total, len(week.Daily))
+	}
+	if week.ChaptersRead.PerDay != 25.0/7 {
+		t.Errorf("expected per-day average over the whole week, got %.2f", week.ChaptersRead.PerDay)
+	}
+	if len(week.Authors) != 2 || week.Authors[0].Name != "Kiyohiko Azuma" || week.Authors[0].Chapters != 15 {
+		t.Errorf("expected Azuma first this week, got %+v", week.Authors)
+	}
+}
+
+func TestGetStats_CachesUntilProgressChanges(t *testing.T) {
+	setupStatsTest(t)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	// Stand-in for the auth middleware
+	router.Use(func(c *gin.Context) { c.Set("user_id", "u1") })
+	router.GET("/users/stats", stats.NewHandler().GetStats)
+
+	get := func(query string) (int, models.ReadingStats) {
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, httptest.NewRequest("GET", "/users/stats"+query, nil))
+		var result models.ReadingStats
+		json.Unmarshal(resp.Body.Bytes(), &result)
+		return resp.Code, result
+	}
+
+	if code, _ := get("?range=2w"); code != 400 {
+		t.Errorf("expected 400 for an unknown range, got %d", code)
+	}
+
+	readTo(t, "1", 10, models.StatusReading, time.Now())
+	if _, s := get(""); s.Range != "all" || s.ChaptersRead.Total != 10 {
+		t.Fatalf("expected 10 chapters all time, got %+v", s)
+	}
+
+	// Writes that bypass RecordProgress are only seen once the cache expires
+	database.DB.Exec(`UPDATE reading_days SET chapters = 99`)
+	if _, s := get(""); s.ChaptersRead.Total != 10 {
+		t.Errorf("expected cached stats, got %d chapters", s.ChaptersRead.Total)
+	}
+
+	readTo(t, "1", 12, models.StatusReading, time.Now())
+	if _, s := get(""); s.ChaptersRead.Total != 101 {
+		t.Errorf("expected recording progress to invalidate the cache, got %d chapters", s.ChaptersRead.Total)
+	}
+}
+
+func TestGetStats_InvalidatedFromAnotherProcess(t *testing.T) {
+	setupStatsTest(t)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) { c.Set("user_id", "u1") })
+	router.GET("/users/stats", stats.NewHandler().GetStats)
+
+	get := func() models.ReadingStats {
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, httptest.NewRequest("GET", "/users/stats", nil))
+		var result models.ReadingStats
+		json.Unmarshal(resp.Body.Bytes(), &result)
+		return result
+	}
+
+	readTo(t, "1", 10, models.StatusReading, time.Now())
+	if s := get(); s.ChaptersRead.Total != 10 {
+		t.Fatalf("expected 10 chapters all time, got %d", s.ChaptersRead.Total)
+	}
+
+	// The TCP server runs in its own process with its own cache, so all this
+	// one sees of its invalidation is the version in the database
+	database.DB.Exec(`UPDATE reading_days SET chapters = 25`)
+	database.DB.Exec(`UPDATE users SET stats_version = stats_version + 1 WHERE id = 'u1'`)
+	if s := get(); s.ChaptersRead.Total != 25 {
+		t.Errorf("expected the other process's invalidation to drop the cache, got %d chapters", s.ChaptersRead.Total)
+	}
+}
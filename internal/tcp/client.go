@@ -3,6 +3,8 @@ package tcp
 import (
 	"net"
 	"sync"
+
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/models"
 )
 
 type Client struct {
@@ -10,7 +12,33 @@ type Client struct {
 	ID            string
 	UserID        string
 	Username      string
+	Role          models.Role
+	Scopes        []string // set when authenticated with a personal access token
 	Authenticated bool
+
+	// loginMu guards the login session the client authenticated with, which
+	// the server's session watcher reads from another goroutine
+	loginMu      sync.Mutex
+	sessionID    string
+	connectionID string
+}
+
+// setLoginSession records the login session and tracked connection of the
+// client's token and returns the connection it replaces, if any
+func (c *Client) setLoginSession(sessionID, connectionID string) string {
+	c.loginMu.Lock()
+	defer c.loginMu.Unlock()
+	previous := c.connectionID
+	c.sessionID, c.connectionID = sessionID, connectionID
+	return previous
+}
+
+// loginSession returns the login session the client authenticated with and
+// its tracked connection. Both are empty for personal access tokens.
+func (c *Client) loginSession() (sessionID, connectionID string) {
+	c.loginMu.Lock()
+	defer c.loginMu.Unlock()
+	return c.sessionID, c.connectionID
 }
 
 type ClientManager struct {
@@ -43,6 +71,32 @@ func (cm *ClientManager) Get(id string) (*Client, bool) {
 	return c, ok
 }
 
+// loginSessions lists the tracked connections of every client and the
+// login sessions they belong to
+func (cm *ClientManager) loginSessions() (connectionIDs, sessionIDs []string) {
+	for _, c := range cm.List() {
+		sessionID, connectionID := c.loginSession()
+		if connectionID != "" {
+			connectionIDs = append(connectionIDs, connectionID)
+		}
+		if sessionID != "" {
+			sessionIDs = append(sessionIDs, sessionID)
+		}
+	}
+	return connectionIDs, sessionIDs
+}
+
+// bySession returns the clients authenticated with sessionID
+func (cm *ClientManager) bySession(sessionID string) []*Client {
+	var clients []*Client
+	for _, c := range cm.List() {
+		if id, _ := c.loginSession(); id == sessionID {
+			clients = append(clients, c)
+		}
+	}
+	return clients
+}
+
 func (cm *ClientManager) List() []*Client {
 	cm.mu.RLock()
 	defer cm.mu.RUnlock()
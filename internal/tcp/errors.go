@@ -3,6 +3,8 @@ package tcp
 import (
 	"encoding/json"
 	"fmt"
+
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/models"
 )
 
 type ErrorCategory string
@@ -34,6 +36,7 @@ const (
 	ErrAuthTokenExpired     ErrorCode = "AUTH-003"
 	ErrAuthNotAuthenticated ErrorCode = "AUTH-004"
 	ErrAuthPermissionDenied ErrorCode = "AUTH-005"
+	ErrAuthSessionRevoked   ErrorCode = "AUTH-006"
 
 	ErrBizMangaNotFound    ErrorCode = "BIZ-001"
 	ErrBizInvalidChapter   ErrorCode = "BIZ-002"
@@ -41,6 +44,7 @@ const (
 	ErrBizAlreadyInLibrary ErrorCode = "BIZ-004"
 	ErrBizNotInLibrary     ErrorCode = "BIZ-005"
 	ErrBizInvalidMangaID   ErrorCode = "BIZ-006"
+	ErrBizInvalidVolume    ErrorCode = "BIZ-007"
 
 	ErrDatabaseQuery      ErrorCode = "DB-001"
 	ErrDatabaseConnection ErrorCode = "DB-002"
@@ -141,6 +145,15 @@ func NewAuthNotAuthenticatedError() *TCPError {
 	return NewTCPError(AuthenticationError, ErrAuthNotAuthenticated, "Authentication required", nil)
 }
 
+func NewAuthPermissionDeniedError(messageType string) *TCPError {
+	return NewTCPError(AuthenticationError, ErrAuthPermissionDenied,
+		fmt.Sprintf("Permission denied for %s", messageType), nil)
+}
+
+func NewAuthSessionRevokedError() *TCPError {
+	return NewTCPError(AuthenticationError, ErrAuthSessionRevoked, "Session was signed out", nil)
+}
+
 func NewBizMangaNotFoundError(mangaID string) *TCPError {
 	return NewTCPError(BusinessLogicError, ErrBizMangaNotFound,
 		fmt.Sprintf("Manga not found: %s", mangaID), nil)
@@ -151,9 +164,14 @@ func NewBizInvalidChapterError(chapter int) *TCPError {
 		fmt.Sprintf("Invalid chapter number: %d", chapter), nil)
 }
 
-func NewBizInvalidStatusError(status string) *TCPError {
+func NewBizInvalidVolumeError(volume, numVolumes int) *TCPError {
+	return NewTCPError(BusinessLogicError, ErrBizInvalidVolume,
+		fmt.Sprintf("Volume %d is out of range (manga has %d volumes)", volume, numVolumes), nil)
+}
+
+func NewBizInvalidStatusError(status models.ReadingStatus) *TCPError {
 	return NewTCPError(BusinessLogicError, ErrBizInvalidStatus,
-		fmt.Sprintf("Invalid status. Must be: reading, completed, or plan_to_read. Got: %s", status), nil)
+		fmt.Sprintf("Invalid status. Must be one of: %s. Got: %s", models.StatusList(), status), nil)
 }
 
 func NewBizNotInLibraryError(mangaID string) *TCPError {
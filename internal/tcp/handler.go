@@ -2,14 +2,19 @@ package tcp
 
 import (
 	"bufio"
+	"database/sql"
 	"encoding/json"
-	"os"
 	"strings"
 	"time"
 
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/auth"
 	"github.com/binhbb2204/Manga-Hub-Group13/internal/bridge"
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/goal"
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/library"
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/stats"
 	"github.com/binhbb2204/Manga-Hub-Group13/pkg/database"
 	"github.com/binhbb2204/Manga-Hub-Group13/pkg/logger"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/models"
 	"github.com/binhbb2204/Manga-Hub-Group13/pkg/utils"
 )
 
@@ -24,6 +29,11 @@ func HandleConnection(client *Client, manager *ClientManager, removeClient func(
 			br.UnregisterTCPClient(client.Conn, client.UserID)
 		}
 		sessionMgr.RemoveSessionByClientID(client.ID)
+		if _, connectionID := client.loginSession(); connectionID != "" {
+			if err := auth.UntrackConnection(connectionID); err != nil {
+				log.Warn("failed_to_untrack_connection", "error", err.Error())
+			}
+		}
 		log.Info("client_disconnected")
 		removeClient(client.ID)
 		client.Conn.Close()
@@ -70,9 +80,66 @@ func HandleConnection(client *Client, manager *ClientManager, removeClient func(
 	}
 }
 
+// messagePermissions lists the message types that need more than an
+// authenticated client. Anything not listed is open to every user.
+var messagePermissions = map[string]auth.Permission{
+	"list_sessions": auth.PermViewSessions,
+}
+
+// messageScopes lists what a personal access token needs for each message
+// type. Tokens can't send types missing here; ping and auth need no scope.
+var messageScopes = map[string]models.Scope{
+	"connect":             models.ScopeProgressRead,
+	"disconnect":          models.ScopeProgressRead,
+	"heartbeat":           models.ScopeProgressRead,
+	"status_request":      models.ScopeProgressRead,
+	"subscribe_updates":   models.ScopeProgressRead,
+	"unsubscribe_updates": models.ScopeProgressRead,
+	"get_progress":        models.ScopeProgressRead,
+	"sync_progress":       models.ScopeProgressWrite,
+	"get_library":         models.ScopeLibraryRead,
+	"add_to_library":      models.ScopeLibraryWrite,
+	"remove_from_library": models.ScopeLibraryWrite,
+	"set_favorite":        models.ScopeLibraryWrite,
+}
+
+// checkPermission sends AUTH-004 or AUTH-005 and returns the error when the
+// client may not send msgType
+func checkPermission(client *Client, msgType string) error {
+	if client.Authenticated && client.Scopes != nil && msgType != "ping" && msgType != "auth" {
+		scope, ok := messageScopes[msgType]
+		if !ok || !auth.HasScope(client.Scopes, scope) {
+			authErr := NewAuthPermissionDeniedError(msgType)
+			SendError(client, authErr)
+			return authErr
+		}
+	}
+
+	perm, ok := messagePermissions[msgType]
+	if !ok {
+		return nil
+	}
+	if !client.Authenticated {
+		authErr := NewAuthNotAuthenticatedError()
+		SendError(client, authErr)
+		return authErr
+	}
+	if !auth.HasPermission(client.Role, perm) {
+		authErr := NewAuthPermissionDeniedError(msgType)
+		SendError(client, authErr)
+		return authErr
+	}
+	return nil
+}
+
 func routeMessage(client *Client, msg *Message, log *logger.Logger, br *bridge.Bridge, sessionMgr *SessionManager, heartbeatMgr *HeartbeatManager) error {
 	log = log.WithContext("message_type", msg.Type)
 
+	if err := checkPermission(client, msg.Type); err != nil {
+		log.Warn("permission_denied", "user_id", client.UserID, "role", string(client.Role))
+		return err
+	}
+
 	switch msg.Type {
 	case "ping":
 		return handlePing(client, log)
@@ -100,6 +167,10 @@ func routeMessage(client *Client, msg *Message, log *logger.Logger, br *bridge.B
 		return handleAddToLibrary(client, msg.Payload, log, br)
 	case "remove_from_library":
 		return handleRemoveFromLibrary(client, msg.Payload, log, br)
+	case "set_favorite":
+		return handleSetFavorite(client, msg.Payload, log, br)
+	case "list_sessions":
+		return handleListSessions(client, log, sessionMgr)
 	default:
 		err := NewProtocolUnknownTypeError(msg.Type)
 		SendError(client, err)
@@ -130,12 +201,7 @@ func handleAuth(client *Client, payload json.RawMessage, log *logger.Logger, br
 		return authErr
 	}
 
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		jwtSecret = "your-secret-key-change-this-in-production"
-	}
-
-	claims, err := utils.ValidateJWT(authPayload.Token, jwtSecret)
+	claims, err := auth.ValidateToken(authPayload.Token, auth.DefaultKeys())
 	if err != nil {
 		authErr := NewAuthTokenInvalidError()
 		log.Warn("authentication_failed", "error", err.Error())
@@ -145,8 +211,29 @@ func handleAuth(client *Client, payload json.RawMessage, log *logger.Logger, br
 
 	client.UserID = claims.UserID
 	client.Username = claims.Username
+	client.Role = models.Role(claims.Role)
+	client.Scopes = claims.Scopes
 	client.Authenticated = true
 
+	// Connections made with a login session's token are listed with the
+	// session, and closed when it is signed out
+	var connectionID string
+	if claims.SessionID != "" {
+		conn := models.LiveConnection{
+			SessionID:  claims.SessionID,
+			UserID:     claims.UserID,
+			Transport:  models.TransportTCP,
+			RemoteAddr: client.ID,
+		}
+		if err := auth.TrackConnection(&conn); err != nil {
+			log.Warn("failed_to_track_connection", "error", err.Error())
+		}
+		connectionID = conn.ID
+	}
+	if previous := client.setLoginSession(claims.SessionID, connectionID); previous != "" {
+		auth.UntrackConnection(previous)
+	}
+
 	if br != nil {
 		br.RegisterTCPClient(client.Conn, client.UserID)
 	}
@@ -186,49 +273,65 @@ func handleSyncProgress(client *Client, payload json.RawMessage, log *logger.Log
 		return bizErr
 	}
 
-	validStatuses := map[string]bool{
-		"reading":      true,
-		"completed":    true,
-		"plan_to_read": true,
-	}
-	if syncPayload.Status != "" && !validStatuses[syncPayload.Status] {
+	if syncPayload.Status != "" && !syncPayload.Status.Valid() {
 		bizErr := NewBizInvalidStatusError(syncPayload.Status)
 		SendError(client, bizErr)
 		return bizErr
 	}
 
-	var exists bool
-	var mangaTitle string
-	checkQuery := `SELECT EXISTS(SELECT 1 FROM manga WHERE id = ?), COALESCE((SELECT title FROM manga WHERE id = ?), '')`
-	err := database.DB.QueryRow(checkQuery, syncPayload.MangaID, syncPayload.MangaID).Scan(&exists, &mangaTitle)
+	var manga models.Manga
+	var previousChapter, volume int
+	checkQuery := `SELECT m.title, COALESCE(m.num_volumes, 0), COALESCE(up.current_chapter, 0), COALESCE(up.current_volume, 0)
+                   FROM manga m
+                   LEFT JOIN user_progress up ON up.manga_id = m.id AND up.user_id = ?
+                   WHERE m.id = ?`
+	err := database.DB.QueryRow(checkQuery, client.UserID, syncPayload.MangaID).
+		Scan(&manga.Title, &manga.NumVolumes, &previousChapter, &volume)
+	if err == sql.ErrNoRows {
+		bizErr := NewBizMangaNotFoundError(syncPayload.MangaID)
+		SendError(client, bizErr)
+		return bizErr
+	}
 	if err != nil {
 		dbErr := NewDatabaseQueryError(err)
 		log.Error("database_error_checking_manga", "error", err.Error(), "manga_id", syncPayload.MangaID)
 		SendError(client, dbErr)
 		return dbErr
 	}
-	if !exists {
-		bizErr := NewBizMangaNotFoundError(syncPayload.MangaID)
-		SendError(client, bizErr)
-		return bizErr
+	mangaTitle := manga.Title
+
+	// A missing volume keeps whatever the entry already has
+	if syncPayload.CurrentVolume != nil {
+		if !manga.HasVolume(*syncPayload.CurrentVolume) {
+			bizErr := NewBizInvalidVolumeError(*syncPayload.CurrentVolume, manga.NumVolumes)
+			SendError(client, bizErr)
+			return bizErr
+		}
+		volume = *syncPayload.CurrentVolume
 	}
 
 	now := time.Now()
-	query := `INSERT INTO user_progress (user_id, manga_id, current_chapter, status, updated_at)
-              VALUES (?, ?, ?, ?, ?)
+	query := `INSERT INTO user_progress (user_id, manga_id, current_chapter, current_volume, status, updated_at)
+              VALUES (?, ?, ?, ?, ?, ?)
               ON CONFLICT(user_id, manga_id) DO UPDATE SET 
               current_chapter = ?, 
+              current_volume = ?,
               status = COALESCE(?, status),
               updated_at = ?`
 
 	status := syncPayload.Status
 	if status == "" {
-		status = "reading"
+		status = models.StatusReading
+	}
+	// An empty status keeps whatever the entry already has
+	var newStatus interface{}
+	if syncPayload.Status != "" {
+		newStatus = syncPayload.Status
 	}
 
 	_, err = database.DB.Exec(query,
-		client.UserID, syncPayload.MangaID, syncPayload.CurrentChapter, status, now,
-		syncPayload.CurrentChapter, syncPayload.Status, now)
+		client.UserID, syncPayload.MangaID, syncPayload.CurrentChapter, volume, status, now,
+		syncPayload.CurrentChapter, volume, newStatus, now)
 
 	if err != nil {
 		dbErr := NewDatabaseQueryError(err)
@@ -237,9 +340,17 @@ func handleSyncProgress(client *Client, payload json.RawMessage, log *logger.Log
 		return dbErr
 	}
 
+	if err := stats.RecordProgress(client.UserID, syncPayload.MangaID, previousChapter, now); err != nil {
+		log.Warn("failed_to_record_progress_history", "error", err.Error())
+	}
+	if err := goal.Evaluate(br, client.UserID); err != nil {
+		log.Warn("failed_to_evaluate_goals", "error", err.Error())
+	}
+
 	log.Info("progress_synced",
 		"manga_id", syncPayload.MangaID,
 		"chapter", syncPayload.CurrentChapter,
+		"volume", volume,
 		"status", status)
 
 	if session, ok := sessionMgr.GetSessionByClientID(client.ID); ok {
@@ -252,6 +363,7 @@ func handleSyncProgress(client *Client, payload json.RawMessage, log *logger.Log
 			MangaID:      syncPayload.MangaID,
 			MangaTitle:   mangaTitle,
 			ChapterID:    syncPayload.CurrentChapter,
+			Volume:       volume,
 			Status:       status,
 			LastReadDate: now,
 		})
@@ -273,16 +385,40 @@ func handleGetLibrary(client *Client, payload json.RawMessage, log *logger.Logge
 		"username": client.Username,
 	})
 
+	var req GetLibraryPayload
+	if len(payload) > 0 && string(payload) != "null" {
+		if err := json.Unmarshal(payload, &req); err != nil {
+			protoErr := NewProtocolInvalidPayloadError("Invalid get_library payload")
+			SendError(client, protoErr)
+			return protoErr
+		}
+	}
+
 	query := `
         SELECT m.id, m.title, m.author, m.genres, m.status, m.total_chapters, m.description, m.cover_url,
-               up.current_chapter, up.status, up.updated_at
+               up.current_chapter, COALESCE(up.current_volume, 0), up.status, COALESCE(up.is_favorite, 0), up.updated_at
         FROM user_progress up
         JOIN manga m ON up.manga_id = m.id
-        WHERE up.user_id = ?
-        ORDER BY up.updated_at DESC
-    `
+        WHERE up.user_id = ?`
+	args := []interface{}{client.UserID}
 
-	rows, err := database.DB.Query(query, client.UserID)
+	if req.Tag != "" {
+		query += ` AND EXISTS (SELECT 1 FROM library_tags lt
+                     WHERE lt.user_id = up.user_id AND lt.manga_id = up.manga_id AND lt.tag = ?)`
+		args = append(args, utils.NormalizeTag(req.Tag))
+	}
+
+	query += ` ORDER BY up.updated_at DESC`
+
+	tags, err := library.LoadTags(client.UserID)
+	if err != nil {
+		dbErr := NewDatabaseQueryError(err)
+		log.Error("database_error_fetching_library", "error", err.Error())
+		SendError(client, dbErr)
+		return dbErr
+	}
+
+	rows, err := database.DB.Query(query, args...)
 	if err != nil {
 		dbErr := NewDatabaseQueryError(err)
 		log.Error("database_error_fetching_library", "error", err.Error())
@@ -292,17 +428,20 @@ func handleGetLibrary(client *Client, payload json.RawMessage, log *logger.Logge
 	defer rows.Close()
 
 	type MangaProgress struct {
-		MangaID        string `json:"manga_id"`
-		Title          string `json:"title"`
-		Author         string `json:"author"`
-		Genres         string `json:"genres"`
-		Status         string `json:"manga_status"`
-		TotalChapters  int    `json:"total_chapters"`
-		Description    string `json:"description"`
-		CoverURL       string `json:"cover_url"`
-		CurrentChapter int    `json:"current_chapter"`
-		ReadStatus     string `json:"read_status"`
-		UpdatedAt      string `json:"updated_at"`
+		MangaID        string               `json:"manga_id"`
+		Title          string               `json:"title"`
+		Author         string               `json:"author"`
+		Genres         string               `json:"genres"`
+		Status         string               `json:"manga_status"`
+		TotalChapters  int                  `json:"total_chapters"`
+		Description    string               `json:"description"`
+		CoverURL       string               `json:"cover_url"`
+		CurrentChapter int                  `json:"current_chapter"`
+		CurrentVolume  int                  `json:"current_volume"`
+		ReadStatus     models.ReadingStatus `json:"read_status"`
+		IsFavorite     bool                 `json:"is_favorite"`
+		Tags           []string             `json:"tags"`
+		UpdatedAt      string               `json:"updated_at"`
 	}
 
 	library := []MangaProgress{}
@@ -321,7 +460,9 @@ func handleGetLibrary(client *Client, payload json.RawMessage, log *logger.Logge
 			&description,
 			&coverURL,
 			&mp.CurrentChapter,
+			&mp.CurrentVolume,
 			&mp.ReadStatus,
+			&mp.IsFavorite,
 			&mp.UpdatedAt,
 		)
 		if err != nil {
@@ -337,6 +478,10 @@ func handleGetLibrary(client *Client, payload json.RawMessage, log *logger.Logge
 		if coverURL != nil {
 			mp.CoverURL = *coverURL
 		}
+		mp.Tags = tags[mp.MangaID]
+		if mp.Tags == nil {
+			mp.Tags = []string{}
+		}
 		library = append(library, mp)
 	}
 
@@ -373,13 +518,15 @@ func handleGetProgress(client *Client, payload json.RawMessage, log *logger.Logg
 	}
 
 	var progress struct {
-		CurrentChapter int    `json:"current_chapter"`
-		Status         string `json:"status"`
-		UpdatedAt      string `json:"updated_at"`
+		CurrentChapter int                  `json:"current_chapter"`
+		CurrentVolume  int                  `json:"current_volume"`
+		Status         models.ReadingStatus `json:"status"`
+		UpdatedAt      string               `json:"updated_at"`
 	}
 
-	query := `SELECT current_chapter, status, updated_at FROM user_progress WHERE user_id = ? AND manga_id = ?`
-	err := database.DB.QueryRow(query, client.UserID, req.MangaID).Scan(&progress.CurrentChapter, &progress.Status, &progress.UpdatedAt)
+	query := `SELECT current_chapter, COALESCE(current_volume, 0), status, updated_at FROM user_progress WHERE user_id = ? AND manga_id = ?`
+	err := database.DB.QueryRow(query, client.UserID, req.MangaID).
+		Scan(&progress.CurrentChapter, &progress.CurrentVolume, &progress.Status, &progress.UpdatedAt)
 	if err != nil {
 		dbErr := NewDatabaseNotFoundError()
 		log.Info("progress_not_found", "manga_id", req.MangaID)
@@ -417,16 +564,11 @@ func handleAddToLibrary(client *Client, payload json.RawMessage, log *logger.Log
 		return bizErr
 	}
 
-	validStatuses := map[string]bool{
-		"reading":      true,
-		"completed":    true,
-		"plan_to_read": true,
-	}
 	status := req.Status
 	if status == "" {
-		status = "plan_to_read"
+		status = models.StatusPlanToRead
 	}
-	if !validStatuses[status] {
+	if !status.Valid() {
 		bizErr := NewBizInvalidStatusError(status)
 		SendError(client, bizErr)
 		return bizErr
@@ -442,11 +584,11 @@ func handleAddToLibrary(client *Client, payload json.RawMessage, log *logger.Log
 	}
 
 	now := time.Now()
-	query := `INSERT INTO user_progress (user_id, manga_id, current_chapter, status, updated_at)
-              VALUES (?, ?, 0, ?, ?)
-              ON CONFLICT(user_id, manga_id) DO UPDATE SET status = ?, updated_at = ?`
+	query := `INSERT INTO user_progress (user_id, manga_id, current_chapter, status, is_favorite, updated_at)
+              VALUES (?, ?, 0, ?, ?, ?)
+              ON CONFLICT(user_id, manga_id) DO UPDATE SET status = ?, is_favorite = MAX(is_favorite, excluded.is_favorite), updated_at = ?`
 
-	_, err = database.DB.Exec(query, client.UserID, req.MangaID, status, now, status, now)
+	_, err = database.DB.Exec(query, client.UserID, req.MangaID, status, req.IsFavorite, now, status, now)
 	if err != nil {
 		dbErr := NewDatabaseQueryError(err)
 		log.Error("database_error_adding_to_library", "error", err.Error(), "manga_id", req.MangaID)
@@ -454,6 +596,13 @@ func handleAddToLibrary(client *Client, payload json.RawMessage, log *logger.Log
 		return dbErr
 	}
 
+	if err := stats.RecordStatusChange(client.UserID, req.MangaID, now); err != nil {
+		log.Warn("failed_to_record_progress_history", "error", err.Error())
+	}
+	if err := goal.Evaluate(br, client.UserID); err != nil {
+		log.Warn("failed_to_evaluate_goals", "error", err.Error())
+	}
+
 	log.Info("manga_added_to_library", "manga_id", req.MangaID, "status", status)
 
 	if br != nil {
@@ -509,6 +658,8 @@ func handleRemoveFromLibrary(client *Client, payload json.RawMessage, log *logge
 		return bizErr
 	}
 
+	stats.Invalidate(client.UserID)
+
 	log.Info("manga_removed_from_library", "manga_id", req.MangaID)
 
 	if br != nil {
@@ -523,6 +674,65 @@ func handleRemoveFromLibrary(client *Client, payload json.RawMessage, log *logge
 	return nil
 }
 
+func handleSetFavorite(client *Client, payload json.RawMessage, log *logger.Logger, br *bridge.Bridge) error {
+	if !client.Authenticated {
+		authErr := NewAuthNotAuthenticatedError()
+		SendError(client, authErr)
+		return authErr
+	}
+
+	log = log.WithFields(map[string]interface{}{
+		"user_id":  client.UserID,
+		"username": client.Username,
+	})
+
+	var req SetFavoritePayload
+	if err := json.Unmarshal(payload, &req); err != nil {
+		protoErr := NewProtocolInvalidPayloadError("Invalid set_favorite payload")
+		SendError(client, protoErr)
+		return protoErr
+	}
+
+	if req.MangaID == "" {
+		bizErr := NewBizInvalidMangaIDError()
+		SendError(client, bizErr)
+		return bizErr
+	}
+
+	query := `UPDATE user_progress SET is_favorite = ?, updated_at = ? WHERE user_id = ? AND manga_id = ?`
+	result, err := database.DB.Exec(query, req.IsFavorite, time.Now(), client.UserID, req.MangaID)
+	if err != nil {
+		dbErr := NewDatabaseQueryError(err)
+		log.Error("database_error_setting_favorite", "error", err.Error(), "manga_id", req.MangaID)
+		SendError(client, dbErr)
+		return dbErr
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		bizErr := NewBizNotInLibraryError(req.MangaID)
+		SendError(client, bizErr)
+		return bizErr
+	}
+
+	log.Info("favorite_updated", "manga_id", req.MangaID, "is_favorite", req.IsFavorite)
+
+	action := "favorited"
+	if !req.IsFavorite {
+		action = "unfavorited"
+	}
+	if br != nil {
+		br.NotifyLibraryUpdate(bridge.LibraryUpdateEvent{
+			UserID:  client.UserID,
+			MangaID: req.MangaID,
+			Action:  action,
+		})
+	}
+
+	client.Conn.Write(CreateSuccessMessage("Favorite updated successfully"))
+	return nil
+}
+
 func handleConnect(client *Client, payload json.RawMessage, log *logger.Logger, sessionMgr *SessionManager, heartbeatMgr *HeartbeatManager) error {
 	if !client.Authenticated {
 		authErr := NewAuthNotAuthenticatedError()
@@ -539,6 +749,11 @@ func handleConnect(client *Client, payload json.RawMessage, log *logger.Logger,
 
 	session := sessionMgr.CreateSession(client.ID, client.UserID, connectPayload.DeviceType, connectPayload.DeviceName)
 	heartbeatMgr.RecordHeartbeat(client.ID, 0)
+	if _, connectionID := client.loginSession(); connectionID != "" {
+		if err := auth.SetConnectionDevice(connectionID, connectPayload.DeviceType, connectPayload.DeviceName); err != nil {
+			log.Warn("failed_to_record_device", "error", err.Error())
+		}
+	}
 
 	log.Info("client_connected_sync",
 		"session_id", session.SessionID,
@@ -598,6 +813,19 @@ func handleHeartbeat(client *Client, payload json.RawMessage, log *logger.Logger
 	return nil
 }
 
+// handleListSessions lists every live sync session on this server. The
+// permission check in routeMessage limits it to moderators and admins.
+func handleListSessions(client *Client, log *logger.Logger, sessionMgr *SessionManager) error {
+	sessions := sessionMgr.ListSessions()
+	list := SessionListPayload{Sessions: sessions, Total: len(sessions)}
+
+	log.Info("sessions_listed", "user_id", client.UserID, "total", list.Total)
+	if _, err := client.Conn.Write(CreateSessionListMessage(list)); err != nil {
+		return NewNetworkWriteError(err)
+	}
+	return nil
+}
+
 func handleStatusRequest(client *Client, log *logger.Logger, sessionMgr *SessionManager, heartbeatMgr *HeartbeatManager) error {
 	if !client.Authenticated {
 		authErr := NewAuthNotAuthenticatedError()
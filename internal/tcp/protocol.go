@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"errors"
 	"time"
+
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/models"
 )
 
 type Message struct {
@@ -16,10 +18,11 @@ type AuthPayload struct {
 }
 
 type SyncProgressPayload struct {
-	UserID         string `json:"user_id"`
-	MangaID        string `json:"manga_id"`
-	CurrentChapter int    `json:"current_chapter"`
-	Status         string `json:"status"`
+	UserID         string               `json:"user_id"`
+	MangaID        string               `json:"manga_id"`
+	CurrentChapter int                  `json:"current_chapter"`
+	CurrentVolume  *int                 `json:"current_volume,omitempty"`
+	Status         models.ReadingStatus `json:"status"`
 }
 
 type ErrorPayload struct {
@@ -31,6 +34,7 @@ type SuccessPayload struct {
 }
 
 type GetLibraryPayload struct {
+	Tag string `json:"tag,omitempty"` // Only return entries carrying this tag
 }
 
 type GetProgressPayload struct {
@@ -38,8 +42,14 @@ type GetProgressPayload struct {
 }
 
 type AddToLibraryPayload struct {
-	MangaID string `json:"manga_id"`
-	Status  string `json:"status"`
+	MangaID    string               `json:"manga_id"`
+	Status     models.ReadingStatus `json:"status"`
+	IsFavorite bool                 `json:"is_favorite,omitempty"`
+}
+
+type SetFavoritePayload struct {
+	MangaID    string `json:"manga_id"`
+	IsFavorite bool   `json:"is_favorite"`
 }
 
 type RemoveFromLibraryPayload struct {
@@ -84,6 +94,22 @@ type LastSyncInfo struct {
 	Timestamp  string `json:"timestamp"` // ISO timestamp
 }
 
+// SessionInfo describes one live sync session in a list_sessions response
+type SessionInfo struct {
+	SessionID    string `json:"session_id"`
+	UserID       string `json:"user_id"`
+	DeviceType   string `json:"device_type"`
+	DeviceName   string `json:"device_name"`
+	ConnectedAt  string `json:"connected_at"`  // ISO timestamp
+	LastActivity string `json:"last_activity"` // ISO timestamp of the last heartbeat
+}
+
+// SessionListPayload answers list_sessions
+type SessionListPayload struct {
+	Sessions []SessionInfo `json:"sessions"`
+	Total    int           `json:"total"`
+}
+
 // SubscribeUpdatesPayload subscribes to real-time updates
 type SubscribeUpdatesPayload struct {
 	EventTypes []string `json:"event_types,omitempty"` // Optional filter: ["progress", "library"]
@@ -183,6 +209,11 @@ func CreateStatusResponseMessage(status StatusResponsePayload) []byte {
 	return CreateDataMessage("status", status)
 }
 
+// CreateSessionListMessage creates a list_sessions response
+func CreateSessionListMessage(list SessionListPayload) []byte {
+	return CreateDataMessage("session_list", list)
+}
+
 // CreateUpdateEventMessage creates a real-time update event message
 func CreateUpdateEventMessage(event UpdateEventPayload) []byte {
 	return CreateDataMessage("update_event", event)
@@ -4,6 +4,7 @@ import (
 	"net"
 	"sync/atomic"
 
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/auth"
 	"github.com/binhbb2204/Manga-Hub-Group13/internal/bridge"
 	"github.com/binhbb2204/Manga-Hub-Group13/pkg/logger"
 )
@@ -17,6 +18,7 @@ type Server struct {
 	bridge           *bridge.Bridge
 	sessionManager   *SessionManager
 	heartbeatManager *HeartbeatManager
+	stopWatch        chan struct{}
 }
 
 func NewServer(port string, br *bridge.Bridge) *Server {
@@ -44,6 +46,8 @@ func (s *Server) Start() error {
 	}
 	s.running.Store(true)
 	s.heartbeatManager.Start()
+	s.stopWatch = make(chan struct{})
+	go auth.WatchSessions(s.stopWatch, s.clientManager.loginSessions, s.closeSession)
 	s.log.Info("tcp_server_started", "port", s.Port)
 	go s.acceptConnections()
 	return nil
@@ -70,6 +74,10 @@ func (s *Server) acceptConnections() {
 func (s *Server) Stop() error {
 	s.running.Store(false)
 	s.heartbeatManager.Stop()
+	if s.stopWatch != nil {
+		close(s.stopWatch)
+		s.stopWatch = nil
+	}
 	s.log.Info("tcp_server_stopping", "active_clients", len(s.clientManager.List()))
 
 	for _, client := range s.clientManager.List() {
@@ -89,6 +97,16 @@ func (s *Server) Stop() error {
 	return nil
 }
 
+// closeSession drops the clients of a login session that was signed out.
+// HandleConnection cleans up after them once their reads fail.
+func (s *Server) closeSession(sessionID string) {
+	for _, client := range s.clientManager.bySession(sessionID) {
+		s.log.Info("session_revoked_closing_client", "client_id", client.ID, "session_id", sessionID)
+		SendError(client, NewAuthSessionRevokedError())
+		client.Conn.Close()
+	}
+}
+
 func (s *Server) removeClient(userID string) {
 	s.clientManager.Remove(userID)
 	s.log.Debug("client_removed", "client_id", userID)
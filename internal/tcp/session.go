@@ -3,6 +3,7 @@ package tcp
 import (
 	"crypto/rand"
 	"encoding/hex"
+	"sort"
 	"sync"
 	"time"
 )
@@ -24,6 +25,10 @@ type ClientSession struct {
 	EventTypes         []string
 }
 
+func (cs *ClientSession) GetSessionID() string {
+	return cs.SessionID
+}
+
 func (cs *ClientSession) GetUserID() string {
 	return cs.UserID
 }
@@ -36,6 +41,14 @@ func (cs *ClientSession) GetDeviceName() string {
 	return cs.DeviceName
 }
 
+func (cs *ClientSession) GetConnectedAt() time.Time {
+	return cs.ConnectedAt
+}
+
+func (cs *ClientSession) GetLastHeartbeat() time.Time {
+	return cs.LastHeartbeat
+}
+
 type SessionManager struct {
 	sessions        map[string]*ClientSession
 	clientToSession map[string]string
@@ -98,8 +111,17 @@ func (sma *sessionManagerAdapter) IsSubscribed(clientID string) bool {
 	return sma.sm.IsSubscribed(clientID)
 }
 
+// GetSessionByClientID hands the bridge a copy, since the manager keeps
+// updating the session under its lock
 func (sma *sessionManagerAdapter) GetSessionByClientID(clientID string) (any, bool) {
-	return sma.sm.GetSessionByClientID(clientID)
+	session, ok := sma.sm.GetSessionByClientID(clientID)
+	if !ok {
+		return nil, false
+	}
+	sma.sm.mu.RLock()
+	snapshot := *session
+	sma.sm.mu.RUnlock()
+	return &snapshot, true
 }
 
 func (sm *SessionManager) AsInterface() interface {
@@ -206,6 +228,28 @@ func (sm *SessionManager) GetAllSessions() []*ClientSession {
 	return sessions
 }
 
+// ListSessions describes every session, oldest first
+func (sm *SessionManager) ListSessions() []SessionInfo {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	list := make([]SessionInfo, 0, len(sm.sessions))
+	for _, session := range sm.sessions {
+		info := SessionInfo{
+			SessionID:   session.SessionID,
+			UserID:      session.UserID,
+			DeviceType:  session.DeviceType,
+			DeviceName:  session.DeviceName,
+			ConnectedAt: session.ConnectedAt.Format(time.RFC3339),
+		}
+		if !session.LastHeartbeat.IsZero() {
+			info.LastActivity = session.LastHeartbeat.Format(time.RFC3339)
+		}
+		list = append(list, info)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ConnectedAt < list[j].ConnectedAt })
+	return list
+}
+
 func (sm *SessionManager) CleanupStale(timeout time.Duration) []string {
 	sm.mu.Lock()
 	defer sm.mu.Unlock()
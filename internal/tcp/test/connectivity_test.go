@@ -3,13 +3,11 @@ package tcp_test
 import (
 	"encoding/json"
 	"net"
-	"os"
 	"testing"
 	"time"
 
 	"github.com/binhbb2204/Manga-Hub-Group13/internal/tcp"
 	"github.com/binhbb2204/Manga-Hub-Group13/pkg/database"
-	"github.com/binhbb2204/Manga-Hub-Group13/pkg/utils"
 )
 
 func TestConnectivityGracefulDisconnection(t *testing.T) {
@@ -151,11 +149,7 @@ func TestConnectivityAbruptDisconnection(t *testing.T) {
 		t.Fatalf("Failed to connect: %v", err)
 	}
 
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		jwtSecret = "your-secret-key-change-this-in-production"
-	}
-	token, _ := utils.GenerateJWT("test-user-1", "testuser", jwtSecret)
+	token, _ := signToken("test-user-1", "testuser", "")
 
 	authMsg := map[string]interface{}{
 		"type":    "auth",
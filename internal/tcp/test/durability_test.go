@@ -3,13 +3,11 @@ package tcp_test
 import (
 	"encoding/json"
 	"net"
-	"os"
 	"testing"
 	"time"
 
 	"github.com/binhbb2204/Manga-Hub-Group13/internal/tcp"
 	"github.com/binhbb2204/Manga-Hub-Group13/pkg/database"
-	"github.com/binhbb2204/Manga-Hub-Group13/pkg/utils"
 )
 
 func TestDurabilityDatabasePersistence(t *testing.T) {
@@ -29,11 +27,7 @@ func TestDurabilityDatabasePersistence(t *testing.T) {
 	}
 	defer conn.Close()
 
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		jwtSecret = "your-secret-key-change-this-in-production"
-	}
-	token, _ := utils.GenerateJWT("test-user-1", "testuser", jwtSecret)
+	token, _ := signToken("test-user-1", "testuser", "")
 
 	authMsg := map[string]interface{}{
 		"type":    "auth",
@@ -96,11 +90,7 @@ func TestDurabilityDataConsistency(t *testing.T) {
 	}
 	defer conn.Close()
 
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		jwtSecret = "your-secret-key-change-this-in-production"
-	}
-	token, _ := utils.GenerateJWT("test-user-1", "testuser", jwtSecret)
+	token, _ := signToken("test-user-1", "testuser", "")
 
 	authMsg := map[string]interface{}{
 		"type":    "auth",
@@ -157,11 +147,7 @@ func TestDurabilityServerRestart(t *testing.T) {
 		t.Fatalf("Failed to connect: %v", err)
 	}
 
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		jwtSecret = "your-secret-key-change-this-in-production"
-	}
-	token, _ := utils.GenerateJWT("test-user-1", "testuser", jwtSecret)
+	token, _ := signToken("test-user-1", "testuser", "")
 
 	authMsg := map[string]interface{}{
 		"type":    "auth",
@@ -202,7 +188,7 @@ func TestDurabilityServerRestart(t *testing.T) {
 	}
 	defer conn2.Close()
 
-	token2, _ := utils.GenerateJWT("test-user-1", "testuser", jwtSecret)
+	token2, _ := signToken("test-user-1", "testuser", "")
 	authMsg2 := map[string]interface{}{
 		"type":    "auth",
 		"payload": map[string]string{"token": token2},
@@ -247,11 +233,7 @@ func TestDurabilityTransactionRollback(t *testing.T) {
 	}
 	defer conn.Close()
 
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		jwtSecret = "your-secret-key-change-this-in-production"
-	}
-	token, _ := utils.GenerateJWT("test-user-1", "testuser", jwtSecret)
+	token, _ := signToken("test-user-1", "testuser", "")
 
 	authMsg := map[string]interface{}{
 		"type":    "auth",
@@ -3,13 +3,11 @@ package tcp_test
 import (
 	"encoding/json"
 	"net"
-	"os"
 	"testing"
 	"time"
 
 	"github.com/binhbb2204/Manga-Hub-Group13/internal/tcp"
 	"github.com/binhbb2204/Manga-Hub-Group13/pkg/database"
-	"github.com/binhbb2204/Manga-Hub-Group13/pkg/utils"
 )
 
 func setupTestDB(t *testing.T) string {
@@ -55,11 +53,7 @@ func TestSyncProgressWithDatabase(t *testing.T) {
 	}
 	defer conn.Close()
 
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		jwtSecret = "your-secret-key-change-this-in-production"
-	}
-	token, err := utils.GenerateJWT("test-user-1", "testuser", jwtSecret)
+	token, err := signToken("test-user-1", "testuser", "")
 	if err != nil {
 		t.Fatalf("Failed to generate JWT: %v", err)
 	}
@@ -132,11 +126,7 @@ func TestSyncProgressMangaNotFound(t *testing.T) {
 	}
 	defer conn.Close()
 
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		jwtSecret = "your-secret-key-change-this-in-production"
-	}
-	token, err := utils.GenerateJWT("test-user-1", "testuser", jwtSecret)
+	token, err := signToken("test-user-1", "testuser", "")
 	if err != nil {
 		t.Fatalf("Failed to generate JWT: %v", err)
 	}
@@ -188,11 +178,7 @@ func TestSyncProgressInvalidStatus(t *testing.T) {
 	}
 	defer conn.Close()
 
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		jwtSecret = "your-secret-key-change-this-in-production"
-	}
-	token, err := utils.GenerateJWT("test-user-1", "testuser", jwtSecret)
+	token, err := signToken("test-user-1", "testuser", "")
 	if err != nil {
 		t.Fatalf("Failed to generate JWT: %v", err)
 	}
@@ -4,14 +4,12 @@ import (
 	"bufio"
 	"encoding/json"
 	"net"
-	"os"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/binhbb2204/Manga-Hub-Group13/internal/tcp"
 	"github.com/binhbb2204/Manga-Hub-Group13/pkg/database"
-	"github.com/binhbb2204/Manga-Hub-Group13/pkg/utils"
 )
 
 func TestInteroperabilityJSONProtocol(t *testing.T) {
@@ -182,11 +180,7 @@ func TestInteroperabilityUTF8Encoding(t *testing.T) {
 	}
 	defer conn.Close()
 
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		jwtSecret = "your-secret-key-change-this-in-production"
-	}
-	token, _ := utils.GenerateJWT("test-user-1", "testuser", jwtSecret)
+	token, _ := signToken("test-user-1", "testuser", "")
 
 	authMsg := map[string]interface{}{
 		"type":    "auth",
@@ -243,11 +237,7 @@ func TestInteroperabilityMultipleMessageTypes(t *testing.T) {
 	}
 	defer conn.Close()
 
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		jwtSecret = "your-secret-key-change-this-in-production"
-	}
-	token, _ := utils.GenerateJWT("test-user-1", "testuser", jwtSecret)
+	token, _ := signToken("test-user-1", "testuser", "")
 
 	messages := []map[string]interface{}{
 		{"type": "ping", "payload": map[string]interface{}{}},
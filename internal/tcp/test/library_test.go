@@ -3,13 +3,11 @@ package tcp_test
 import (
 	"encoding/json"
 	"net"
-	"os"
 	"testing"
 	"time"
 
 	"github.com/binhbb2204/Manga-Hub-Group13/internal/tcp"
 	"github.com/binhbb2204/Manga-Hub-Group13/pkg/database"
-	"github.com/binhbb2204/Manga-Hub-Group13/pkg/utils"
 )
 
 func setupLibraryTestDB(t *testing.T) {
@@ -38,11 +36,7 @@ func setupLibraryTestDB(t *testing.T) {
 }
 
 func authenticateClient(t *testing.T, conn net.Conn) {
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		jwtSecret = "your-secret-key-change-this-in-production"
-	}
-	token, err := utils.GenerateJWT("test-user-1", "testuser", jwtSecret)
+	token, err := signToken("test-user-1", "testuser", "")
 	if err != nil {
 		t.Fatalf("Failed to generate JWT: %v", err)
 	}
@@ -300,3 +294,118 @@ func TestAddToLibraryMangaNotFound(t *testing.T) {
 		t.Errorf("Expected 'Manga not found' error, got: %s", responseStr)
 	}
 }
+
+func TestSetFavorite(t *testing.T) {
+	setupLibraryTestDB(t)
+	defer database.Close()
+
+	_, err := database.DB.Exec(`
+		INSERT INTO user_progress (user_id, manga_id, current_chapter, status, updated_at)
+		VALUES ('test-user-1', 'manga-1', 10, 'reading', datetime('now'))
+	`)
+	if err != nil {
+		t.Fatalf("Failed to insert test progress: %v", err)
+	}
+
+	server := tcp.NewServer("9210", nil)
+	if err := server.Start(); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	defer server.Stop()
+	time.Sleep(100 * time.Millisecond)
+
+	conn, err := net.Dial("tcp", "localhost:9210")
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer conn.Close()
+
+	authenticateClient(t, conn)
+
+	favMsg := map[string]interface{}{
+		"type": "set_favorite",
+		"payload": map[string]interface{}{
+			"manga_id":    "manga-1",
+			"is_favorite": true,
+		},
+	}
+	favJSON, _ := json.Marshal(favMsg)
+	conn.Write(append(favJSON, '\n'))
+
+	response := make([]byte, 1024)
+	n, _ := conn.Read(response)
+	if !contains(string(response[:n]), "success") {
+		t.Fatalf("Expected success response, got: %s", string(response[:n]))
+	}
+
+	var isFavorite bool
+	err = database.DB.QueryRow(`SELECT is_favorite FROM user_progress WHERE user_id = ? AND manga_id = ?`,
+		"test-user-1", "manga-1").Scan(&isFavorite)
+	if err != nil {
+		t.Fatalf("Failed to query: %v", err)
+	}
+	if !isFavorite {
+		t.Error("Expected manga-1 to be marked as favorite")
+	}
+
+	favMsg["payload"] = map[string]interface{}{"manga_id": "manga-2", "is_favorite": true}
+	favJSON, _ = json.Marshal(favMsg)
+	conn.Write(append(favJSON, '\n'))
+
+	n, _ = conn.Read(response)
+	if !contains(string(response[:n]), "error") {
+		t.Errorf("Expected error for manga not in library, got: %s", string(response[:n]))
+	}
+}
+
+func TestSyncProgressVolume(t *testing.T) {
+	setupLibraryTestDB(t)
+	defer database.Close()
+
+	if _, err := database.DB.Exec(`UPDATE manga SET num_volumes = 10 WHERE id = 'manga-1'`); err != nil {
+		t.Fatalf("Failed to set volume count: %v", err)
+	}
+
+	server := tcp.NewServer("9211", nil)
+	if err := server.Start(); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	defer server.Stop()
+	time.Sleep(100 * time.Millisecond)
+
+	conn, err := net.Dial("tcp", "localhost:9211")
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer conn.Close()
+
+	authenticateClient(t, conn)
+
+	syncProgress := func(payload map[string]interface{}) string {
+		msg, _ := json.Marshal(map[string]interface{}{"type": "sync_progress", "payload": payload})
+		conn.Write(append(msg, '\n'))
+		response := make([]byte, 1024)
+		n, _ := conn.Read(response)
+		return string(response[:n])
+	}
+
+	if resp := syncProgress(map[string]interface{}{"manga_id": "manga-1", "current_chapter": 30, "current_volume": 4}); !contains(resp, "success") {
+		t.Fatalf("Expected success response, got: %s", resp)
+	}
+	if resp := syncProgress(map[string]interface{}{"manga_id": "manga-1", "current_chapter": 90, "current_volume": 11}); !contains(resp, "BIZ-007") {
+		t.Errorf("Expected invalid volume error, got: %s", resp)
+	}
+	if resp := syncProgress(map[string]interface{}{"manga_id": "manga-1", "current_chapter": 35}); !contains(resp, "success") {
+		t.Fatalf("Expected success response, got: %s", resp)
+	}
+
+	var chapter, volume int
+	err = database.DB.QueryRow(`SELECT current_chapter, current_volume FROM user_progress WHERE user_id = ? AND manga_id = ?`,
+		"test-user-1", "manga-1").Scan(&chapter, &volume)
+	if err != nil {
+		t.Fatalf("Failed to query: %v", err)
+	}
+	if chapter != 35 || volume != 4 {
+		t.Errorf("Expected Vol. 4, Ch. 35, got Vol. %d, Ch. %d", volume, chapter)
+	}
+}
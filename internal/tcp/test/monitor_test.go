@@ -4,7 +4,6 @@ import (
 	"bufio"
 	"encoding/json"
 	"net"
-	"os"
 	"strings"
 	"sync"
 	"testing"
@@ -14,7 +13,6 @@ import (
 	"github.com/binhbb2204/Manga-Hub-Group13/internal/tcp"
 	"github.com/binhbb2204/Manga-Hub-Group13/pkg/database"
 	"github.com/binhbb2204/Manga-Hub-Group13/pkg/logger"
-	"github.com/binhbb2204/Manga-Hub-Group13/pkg/utils"
 )
 
 // TestSubscribeUpdatesBasic tests basic subscription functionality
@@ -698,11 +696,7 @@ func connectAndAuthenticateClient(t *testing.T, port, userID, username string) n
 		t.Fatalf("Failed to connect: %v", err)
 	}
 
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		jwtSecret = "your-secret-key-change-this-in-production"
-	}
-	token, err := utils.GenerateJWT(userID, username, jwtSecret)
+	token, err := signToken(userID, username, "")
 	if err != nil {
 		t.Fatalf("Failed to generate JWT: %v", err)
 	}
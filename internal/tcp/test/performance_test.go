@@ -3,13 +3,11 @@ package tcp_test
 import (
 	"encoding/json"
 	"net"
-	"os"
 	"testing"
 	"time"
 
 	"github.com/binhbb2204/Manga-Hub-Group13/internal/tcp"
 	"github.com/binhbb2204/Manga-Hub-Group13/pkg/database"
-	"github.com/binhbb2204/Manga-Hub-Group13/pkg/utils"
 )
 
 func TestPerformanceMessageLatency(t *testing.T) {
@@ -86,11 +84,7 @@ func TestPerformanceDatabaseQuerySpeed(t *testing.T) {
 	}
 	defer conn.Close()
 
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		jwtSecret = "your-secret-key-change-this-in-production"
-	}
-	token, _ := utils.GenerateJWT("test-user-1", "testuser", jwtSecret)
+	token, _ := signToken("test-user-1", "testuser", "")
 
 	authMsg := map[string]interface{}{
 		"type":    "auth",
@@ -194,18 +188,13 @@ func TestPerformanceAuthenticationSpeed(t *testing.T) {
 	numAuths := 20
 	var totalAuthTime time.Duration
 
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		jwtSecret = "your-secret-key-change-this-in-production"
-	}
-
 	for i := 0; i < numAuths; i++ {
 		conn, err := net.Dial("tcp", "localhost:9703")
 		if err != nil {
 			t.Fatalf("Failed to connect: %v", err)
 		}
 
-		token, _ := utils.GenerateJWT("test-user-1", "testuser", jwtSecret)
+		token, _ := signToken("test-user-1", "testuser", "")
 		authMsg := map[string]interface{}{
 			"type":    "auth",
 			"payload": map[string]string{"token": token},
@@ -262,11 +251,7 @@ func TestPerformanceLargeLibraryRetrieval(t *testing.T) {
 	}
 	defer conn.Close()
 
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		jwtSecret = "your-secret-key-change-this-in-production"
-	}
-	token, _ := utils.GenerateJWT("test-user-1", "testuser", jwtSecret)
+	token, _ := signToken("test-user-1", "testuser", "")
 
 	authMsg := map[string]interface{}{
 		"type":    "auth",
@@ -0,0 +1,163 @@
+package tcp_test
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/auth"
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/tcp"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/database"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/utils"
+)
+
+// testKeys sign the tokens used in these tests
+var testKeys = auth.KeysFromSecret("test-secret")
+
+// signToken issues an access token and makes testKeys the keys the servers
+// under test verify with
+func signToken(userID, username, role string) (string, error) {
+	auth.SetDefaultKeys(testKeys)
+	claims, err := utils.NewAccessClaims(userID, username, role)
+	if err != nil {
+		return "", err
+	}
+	return testKeys.Sign(claims)
+}
+
+// dialAs connects to the server and authenticates with a token carrying role
+func dialAs(t *testing.T, addr, role string) (net.Conn, *bufio.Reader) {
+	token, err := signToken("user-"+role, role, role)
+	if err != nil {
+		t.Fatalf("Failed to generate JWT: %v", err)
+	}
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	reader := bufio.NewReader(conn)
+
+	authJSON, _ := json.Marshal(map[string]interface{}{
+		"type":    "auth",
+		"payload": map[string]string{"token": token},
+	})
+	conn.Write(append(authJSON, '\n'))
+	readMessage(t, conn, reader)
+	return conn, reader
+}
+
+func readMessage(t *testing.T, conn net.Conn, reader *bufio.Reader) map[string]interface{} {
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	line, err := reader.ReadBytes('\n')
+	if err != nil {
+		t.Fatalf("Failed to read response: %v", err)
+	}
+	var msg map[string]interface{}
+	if err := json.Unmarshal(line, &msg); err != nil {
+		t.Fatalf("Invalid response %q: %v", line, err)
+	}
+	return msg
+}
+
+func TestListSessionsRequiresModerator(t *testing.T) {
+	server := tcp.NewServer("9900", nil)
+	if err := server.Start(); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	defer server.Stop()
+	time.Sleep(100 * time.Millisecond)
+
+	listJSON, _ := json.Marshal(map[string]interface{}{"type": "list_sessions", "payload": map[string]string{}})
+
+	conn, reader := dialAs(t, "localhost:9900", "user")
+	conn.Write(append(listJSON, '\n'))
+	msg := readMessage(t, conn, reader)
+	payload, _ := msg["payload"].(map[string]interface{})
+	if msg["type"] != "error" || payload["code"] != string(tcp.ErrAuthPermissionDenied) {
+		t.Fatalf("user list_sessions: got %v, want %s error", msg, tcp.ErrAuthPermissionDenied)
+	}
+
+	conn, reader = dialAs(t, "localhost:9900", "moderator")
+	conn.Write(append(listJSON, '\n'))
+	msg = readMessage(t, conn, reader)
+	if msg["type"] != "session_list" {
+		t.Fatalf("moderator list_sessions: got %v, want session_list", msg)
+	}
+}
+
+func TestListSessionsRequiresAuthentication(t *testing.T) {
+	server := tcp.NewServer("9901", nil)
+	if err := server.Start(); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	defer server.Stop()
+	time.Sleep(100 * time.Millisecond)
+
+	conn, err := net.Dial("tcp", "localhost:9901")
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer conn.Close()
+
+	listJSON, _ := json.Marshal(map[string]interface{}{"type": "list_sessions", "payload": map[string]string{}})
+	conn.Write(append(listJSON, '\n'))
+	msg := readMessage(t, conn, bufio.NewReader(conn))
+	payload, _ := msg["payload"].(map[string]interface{})
+	if payload["code"] != string(tcp.ErrAuthNotAuthenticated) {
+		t.Fatalf("anonymous list_sessions: got %v, want %s error", msg, tcp.ErrAuthNotAuthenticated)
+	}
+}
+
+func TestPersonalTokenScopesOverTCP(t *testing.T) {
+	if err := database.InitDatabase(t.TempDir() + "/test.db"); err != nil {
+		t.Fatalf("Failed to init test database: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	token := "mhp_tcpscopetest"
+	sum := sha256.Sum256([]byte(token))
+	database.DB.Exec(`INSERT INTO users (id, username, email, password_hash) VALUES ('pat-user', 'patuser', 'pat@example.com', 'x')`)
+	_, err := database.DB.Exec(`INSERT INTO personal_access_tokens (id, user_id, name, token_hash, scopes) VALUES ('t1', 'pat-user', 'script', ?, 'library:read')`,
+		hex.EncodeToString(sum[:]))
+	if err != nil {
+		t.Fatalf("Failed to insert token: %v", err)
+	}
+
+	server := tcp.NewServer("9902", nil)
+	if err := server.Start(); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	defer server.Stop()
+	time.Sleep(100 * time.Millisecond)
+
+	conn, err := net.Dial("tcp", "localhost:9902")
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+
+	send := func(msgType string, payload interface{}) map[string]interface{} {
+		data, _ := json.Marshal(map[string]interface{}{"type": msgType, "payload": payload})
+		conn.Write(append(data, '\n'))
+		return readMessage(t, conn, reader)
+	}
+
+	if msg := send("auth", map[string]string{"token": token}); msg["type"] != "success" {
+		t.Fatalf("auth with personal access token: got %v", msg)
+	}
+	if msg := send("get_library", map[string]string{}); msg["type"] == "error" {
+		t.Fatalf("get_library with library:read: got %v", msg)
+	}
+	msg := send("sync_progress", map[string]interface{}{"manga_id": "m1", "current_chapter": 3})
+	payload, _ := msg["payload"].(map[string]interface{})
+	if msg["type"] != "error" || payload["code"] != string(tcp.ErrAuthPermissionDenied) {
+		t.Fatalf("sync_progress without progress:write: got %v", msg)
+	}
+}
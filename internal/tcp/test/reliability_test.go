@@ -3,7 +3,6 @@ package tcp_test
 import (
 	"encoding/json"
 	"net"
-	"os"
 	"strings"
 	"sync"
 	"testing"
@@ -11,7 +10,6 @@ import (
 
 	"github.com/binhbb2204/Manga-Hub-Group13/internal/tcp"
 	"github.com/binhbb2204/Manga-Hub-Group13/pkg/database"
-	"github.com/binhbb2204/Manga-Hub-Group13/pkg/utils"
 )
 
 func TestReliabilityInvalidAuthToken(t *testing.T) {
@@ -205,11 +203,7 @@ func TestReliabilityInvalidDatabaseOperations(t *testing.T) {
 	}
 	defer conn.Close()
 
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		jwtSecret = "your-secret-key-change-this-in-production"
-	}
-	token, _ := utils.GenerateJWT("test-user-1", "testuser", jwtSecret)
+	token, _ := signToken("test-user-1", "testuser", "")
 
 	authMsg := map[string]interface{}{
 		"type":    "auth",
@@ -286,11 +280,6 @@ func TestReliabilityConcurrentUserUpdates(t *testing.T) {
 	var wg sync.WaitGroup
 	wg.Add(numConcurrent)
 
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		jwtSecret = "your-secret-key-change-this-in-production"
-	}
-
 	for i := 0; i < numConcurrent; i++ {
 		go func(chapter int) {
 			defer wg.Done()
@@ -301,7 +290,7 @@ func TestReliabilityConcurrentUserUpdates(t *testing.T) {
 			}
 			defer conn.Close()
 
-			token, _ := utils.GenerateJWT("test-user-1", "testuser", jwtSecret)
+			token, _ := signToken("test-user-1", "testuser", "")
 			authMsg := map[string]interface{}{
 				"type":    "auth",
 				"payload": map[string]string{"token": token},
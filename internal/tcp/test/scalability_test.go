@@ -3,7 +3,6 @@ package tcp_test
 import (
 	"encoding/json"
 	"net"
-	"os"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -11,7 +10,6 @@ import (
 
 	"github.com/binhbb2204/Manga-Hub-Group13/internal/tcp"
 	"github.com/binhbb2204/Manga-Hub-Group13/pkg/database"
-	"github.com/binhbb2204/Manga-Hub-Group13/pkg/utils"
 )
 
 func TestScalabilityConcurrentConnections(t *testing.T) {
@@ -42,11 +40,7 @@ func TestScalabilityConcurrentConnections(t *testing.T) {
 			}
 			defer conn.Close()
 
-			jwtSecret := os.Getenv("JWT_SECRET")
-			if jwtSecret == "" {
-				jwtSecret = "your-secret-key-change-this-in-production"
-			}
-			token, _ := utils.GenerateJWT("test-user-1", "testuser", jwtSecret)
+			token, _ := signToken("test-user-1", "testuser", "")
 
 			authMsg := map[string]interface{}{
 				"type":    "auth",
@@ -104,11 +98,7 @@ func TestScalabilityHighThroughput(t *testing.T) {
 	}
 	defer conn.Close()
 
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		jwtSecret = "your-secret-key-change-this-in-production"
-	}
-	token, _ := utils.GenerateJWT("test-user-1", "testuser", jwtSecret)
+	token, _ := signToken("test-user-1", "testuser", "")
 
 	authMsg := map[string]interface{}{
 		"type":    "auth",
@@ -174,11 +164,7 @@ func TestScalabilityConcurrentDatabaseWrites(t *testing.T) {
 			}
 			defer conn.Close()
 
-			jwtSecret := os.Getenv("JWT_SECRET")
-			if jwtSecret == "" {
-				jwtSecret = "your-secret-key-change-this-in-production"
-			}
-			token, _ := utils.GenerateJWT("test-user-1", "testuser", jwtSecret)
+			token, _ := signToken("test-user-1", "testuser", "")
 
 			authMsg := map[string]interface{}{
 				"type":    "auth",
@@ -237,11 +223,7 @@ func BenchmarkMessageProcessing(b *testing.B) {
 	conn, _ := net.Dial("tcp", "localhost:9303")
 	defer conn.Close()
 
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		jwtSecret = "your-secret-key-change-this-in-production"
-	}
-	token, _ := utils.GenerateJWT("test-user-1", "testuser", jwtSecret)
+	token, _ := signToken("test-user-1", "testuser", "")
 
 	authMsg := map[string]interface{}{
 		"type":    "auth",
@@ -0,0 +1,77 @@
+package tcp_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/auth"
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/tcp"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/database"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/utils"
+)
+
+func TestRevokedSessionClosesConnection(t *testing.T) {
+	if err := database.InitDatabase(t.TempDir() + "/test.db"); err != nil {
+		t.Fatalf("Failed to init test database: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+	database.DB.Exec(`INSERT INTO users (id, username, email, password_hash) VALUES ('phone-user', 'phoneuser', 'phone@example.com', 'x')`)
+	database.DB.Exec(`INSERT INTO login_sessions (id, user_id, last_seen_at) VALUES ('lost-phone', 'phone-user', ?)`, time.Now())
+
+	auth.SetDefaultKeys(testKeys)
+	claims, _ := utils.NewAccessClaims("phone-user", "phoneuser", "")
+	claims.SessionID = "lost-phone"
+	token, err := testKeys.Sign(claims)
+	if err != nil {
+		t.Fatalf("Failed to sign token: %v", err)
+	}
+
+	interval := auth.SessionCheckInterval
+	auth.SessionCheckInterval = 50 * time.Millisecond
+	t.Cleanup(func() { auth.SessionCheckInterval = interval })
+
+	server := tcp.NewServer("9903", nil)
+	if err := server.Start(); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	defer server.Stop()
+	time.Sleep(100 * time.Millisecond)
+
+	conn, err := net.Dial("tcp", "localhost:9903")
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+
+	send := func(msgType string, payload interface{}) map[string]interface{} {
+		data, _ := json.Marshal(map[string]interface{}{"type": msgType, "payload": payload})
+		conn.Write(append(data, '\n'))
+		return readMessage(t, conn, reader)
+	}
+	if msg := send("auth", map[string]string{"token": token}); msg["type"] != "success" {
+		t.Fatalf("auth: got %v", msg)
+	}
+	send("connect", map[string]string{"device_type": "mobile", "device_name": "phone"})
+
+	var deviceName string
+	database.DB.QueryRow(`SELECT device_name FROM live_connections WHERE session_id = 'lost-phone' AND transport = 'tcp'`).Scan(&deviceName)
+	if deviceName != "phone" {
+		t.Fatalf("tracked connection device = %q, want phone", deviceName)
+	}
+
+	database.DB.Exec(`UPDATE login_sessions SET revoked_at = ? WHERE id = 'lost-phone'`, time.Now())
+
+	msg := readMessage(t, conn, reader)
+	payload, _ := msg["payload"].(map[string]interface{})
+	if msg["type"] != "error" || payload["code"] != string(tcp.ErrAuthSessionRevoked) {
+		t.Fatalf("after revoking the session: got %v, want %s error", msg, tcp.ErrAuthSessionRevoked)
+	}
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if _, err := reader.ReadByte(); err == nil {
+		t.Fatalf("connection still open after its session was revoked")
+	}
+}
@@ -58,5 +58,26 @@ func (b *Broadcaster) BroadcastToUser(userID string, event bridge.BroadcastEvent
 }
 
 func (b *Broadcaster) BroadcastToAll(event bridge.BroadcastEvent) {
-	b.log.Info("broadcasting_to_all", "event_type", event.EventType)
+	subscribers := b.subMgr.GetAllSubscribers(event.EventType)
+	messageBytes := CreateNotificationMessage("", event.EventType, event.Data)
+
+	successCount := 0
+	failCount := 0
+
+	for _, sub := range subscribers {
+		if _, err := b.conn.WriteToUDP(messageBytes, sub.Addr); err != nil {
+			failCount++
+			b.log.Warn("broadcast_failed",
+				"user_id", sub.UserID,
+				"addr", sub.Addr.String(),
+				"error", err.Error())
+		} else {
+			successCount++
+		}
+	}
+
+	b.log.Info("udp_broadcast_all_complete",
+		"event_type", event.EventType,
+		"success_count", successCount,
+		"fail_count", failCount)
 }
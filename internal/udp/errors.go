@@ -18,6 +18,7 @@ const (
 	ErrUDPInvalidEventType   ErrorCode = "UDP-008"
 	ErrUDPWriteFailed        ErrorCode = "UDP-009"
 	ErrUDPReadFailed         ErrorCode = "UDP-010"
+	ErrUDPPermissionDenied   ErrorCode = "UDP-011"
 )
 
 type UDPError struct {
@@ -78,3 +79,7 @@ func NewWriteError(cause error) *UDPError {
 func NewReadError(cause error) *UDPError {
 	return NewUDPError(ErrUDPReadFailed, "Failed to read UDP packet", cause)
 }
+
+func NewPermissionDeniedError(operation string) *UDPError {
+	return NewUDPError(ErrUDPPermissionDenied, fmt.Sprintf("Permission denied for %s", operation), nil)
+}
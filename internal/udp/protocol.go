@@ -3,6 +3,8 @@ package udp
 import (
 	"encoding/json"
 	"time"
+
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/models"
 )
 
 type Message struct {
@@ -21,15 +23,29 @@ type SubscribePayload struct {
 	EventTypes []string `json:"event_types"`
 }
 
+// AnnouncePayload is an admin's announcement to every subscriber. UDP has no
+// connection to hang a role on, so the token travels with each request.
+type AnnouncePayload struct {
+	Token   string `json:"token"`
+	Message string `json:"message"`
+}
+
+// AnnouncementData is the notification body subscribers receive
+type AnnouncementData struct {
+	Message string `json:"message"`
+	From    string `json:"from"`
+}
+
 type HeartbeatPayload struct {
 	ClientID string `json:"client_id"`
 }
 
 type NotificationData struct {
-	MangaID   string `json:"manga_id,omitempty"`
-	ChapterID int    `json:"chapter_id,omitempty"`
-	Status    string `json:"status,omitempty"`
-	Action    string `json:"action,omitempty"`
+	MangaID   string               `json:"manga_id,omitempty"`
+	ChapterID int                  `json:"chapter_id,omitempty"`
+	Volume    int                  `json:"volume,omitempty"`
+	Status    models.ReadingStatus `json:"status,omitempty"`
+	Action    string               `json:"action,omitempty"`
 }
 
 type SuccessPayload struct {
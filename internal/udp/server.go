@@ -3,12 +3,12 @@ package udp
 import (
 	"encoding/json"
 	"net"
-	"os"
 	"sync/atomic"
 
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/auth"
 	"github.com/binhbb2204/Manga-Hub-Group13/internal/bridge"
 	"github.com/binhbb2204/Manga-Hub-Group13/pkg/logger"
-	"github.com/binhbb2204/Manga-Hub-Group13/pkg/utils"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/models"
 )
 
 type Server struct {
@@ -19,6 +19,7 @@ type Server struct {
 	broadcaster       *Broadcaster
 	log               *logger.Logger
 	bridge            *bridge.Bridge
+	stopWatch         chan struct{}
 }
 
 func NewServer(port string, br *bridge.Bridge) *Server {
@@ -45,6 +46,8 @@ func (s *Server) Start() error {
 	s.broadcaster = NewBroadcaster(s.conn, s.subscriberManager, s.log)
 	s.running.Store(true)
 	s.subscriberManager.StartCleanup()
+	s.stopWatch = make(chan struct{})
+	go auth.WatchSessions(s.stopWatch, s.subscriberManager.LoginSessions, s.closeSession)
 
 	if s.bridge != nil {
 		s.bridge.SetUDPBroadcaster(s.broadcaster)
@@ -58,6 +61,10 @@ func (s *Server) Start() error {
 func (s *Server) Stop() error {
 	s.running.Store(false)
 	s.subscriberManager.Stop()
+	if s.stopWatch != nil {
+		close(s.stopWatch)
+		s.stopWatch = nil
+	}
 
 	if s.conn != nil {
 		if err := s.conn.Close(); err != nil {
@@ -118,6 +125,8 @@ func (s *Server) processPacket(data []byte, addr *net.UDPAddr) {
 		s.handleSubscribe(addr, msg.Data)
 	case "heartbeat":
 		s.handleHeartbeat(addr)
+	case "announce":
+		s.handleAnnounce(addr, msg.Data)
 	default:
 		s.log.Warn("unknown_message_type",
 			"type", msg.Type,
@@ -133,12 +142,7 @@ func (s *Server) handleRegister(addr *net.UDPAddr, payload json.RawMessage) {
 		return
 	}
 
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		jwtSecret = "your-secret-key-change-this-in-production"
-	}
-
-	claims, err := utils.ValidateJWT(regPayload.Token, jwtSecret)
+	claims, err := auth.ValidateToken(regPayload.Token, auth.DefaultKeys())
 	if err != nil {
 		s.log.Warn("authentication_failed",
 			"addr", addr.String(),
@@ -146,8 +150,31 @@ func (s *Server) handleRegister(addr *net.UDPAddr, payload json.RawMessage) {
 		s.sendError(addr, string(ErrUDPAuthFailed), "Authentication failed")
 		return
 	}
+	if !auth.HasScope(claims.Scopes, models.ScopeNotificationsRead) {
+		permErr := NewPermissionDeniedError("register")
+		s.sendError(addr, string(permErr.Code), permErr.Message)
+		return
+	}
 
-	s.subscriberManager.Subscribe(claims.UserID, addr, []string{"all"})
+	// Registering again from the same address replaces the old registration
+	s.dropSubscriber(addr)
+
+	// Registrations made with a login session's token are listed with the
+	// session, and dropped when it is signed out
+	var connectionID string
+	if claims.SessionID != "" {
+		conn := models.LiveConnection{
+			SessionID:  claims.SessionID,
+			UserID:     claims.UserID,
+			Transport:  models.TransportUDP,
+			RemoteAddr: addr.String(),
+		}
+		if err := auth.TrackConnection(&conn); err != nil {
+			s.log.Warn("failed_to_track_connection", "addr", addr.String(), "error", err.Error())
+		}
+		connectionID = conn.ID
+	}
+	s.subscriberManager.SubscribeSession(claims.UserID, claims.SessionID, connectionID, addr, []string{"all"})
 
 	s.log.Info("client_registered",
 		"user_id", claims.UserID,
@@ -164,7 +191,7 @@ func (s *Server) handleUnregister(addr *net.UDPAddr) {
 		return
 	}
 
-	s.subscriberManager.Unsubscribe(addr)
+	s.dropSubscriber(addr)
 
 	s.log.Info("client_unregistered",
 		"user_id", userID,
@@ -173,6 +200,33 @@ func (s *Server) handleUnregister(addr *net.UDPAddr) {
 	s.sendSuccess(addr, "Unregistered successfully")
 }
 
+// dropSubscriber unsubscribes addr and forgets its tracked connection
+func (s *Server) dropSubscriber(addr *net.UDPAddr) {
+	sub, ok := s.subscriberManager.GetSubscriber(addr)
+	if !ok {
+		return
+	}
+	s.subscriberManager.Unsubscribe(addr)
+	if err := auth.UntrackConnection(sub.ConnectionID); err != nil {
+		s.log.Warn("failed_to_untrack_connection", "addr", addr.String(), "error", err.Error())
+	}
+}
+
+// closeSession drops the subscribers of a login session that was signed out
+// and tells them why
+func (s *Server) closeSession(sessionID string) {
+	for _, sub := range s.subscriberManager.UnsubscribeSession(sessionID) {
+		s.log.Info("session_revoked_unregistered",
+			"user_id", sub.UserID,
+			"addr", sub.Addr.String(),
+			"session_id", sessionID)
+		s.sendError(sub.Addr, string(ErrUDPAuthFailed), "Session was signed out")
+		if err := auth.UntrackConnection(sub.ConnectionID); err != nil {
+			s.log.Warn("failed_to_untrack_connection", "addr", sub.Addr.String(), "error", err.Error())
+		}
+	}
+}
+
 func (s *Server) handleSubscribe(addr *net.UDPAddr, payload json.RawMessage) {
 	var subPayload SubscribePayload
 	if err := json.Unmarshal(payload, &subPayload); err != nil {
@@ -181,9 +235,13 @@ func (s *Server) handleSubscribe(addr *net.UDPAddr, payload json.RawMessage) {
 	}
 
 	validEvents := map[string]bool{
-		"all":             true,
-		"progress_update": true,
-		"library_update":  true,
+		"all":                  true,
+		"progress_update":      true,
+		"library_update":       true,
+		"goal_update":          true,
+		"comment_reply":        true,
+		"achievement_unlocked": true,
+		"announcement":         true,
 	}
 
 	for _, eventType := range subPayload.EventTypes {
@@ -207,6 +265,40 @@ func (s *Server) handleSubscribe(addr *net.UDPAddr, payload json.RawMessage) {
 	s.sendSuccess(addr, "Subscription updated successfully")
 }
 
+// handleAnnounce sends an admin's message to every subscriber that listens
+// for announcements
+func (s *Server) handleAnnounce(addr *net.UDPAddr, payload json.RawMessage) {
+	var announce AnnouncePayload
+	if err := json.Unmarshal(payload, &announce); err != nil || announce.Message == "" {
+		s.sendError(addr, string(ErrUDPInvalidPacket), "Invalid announce payload")
+		return
+	}
+
+	claims, err := auth.ValidateToken(announce.Token, auth.DefaultKeys())
+	if err != nil {
+		s.sendError(addr, string(ErrUDPAuthFailed), "Authentication failed")
+		return
+	}
+	// Personal access tokens have no scope for announcements
+	if auth.IsPersonalToken(claims) || !auth.HasPermission(models.Role(claims.Role), auth.PermBroadcast) {
+		s.log.Warn("permission_denied",
+			"user_id", claims.UserID,
+			"role", claims.Role,
+			"operation", "announce")
+		permErr := NewPermissionDeniedError("announce")
+		s.sendError(addr, string(permErr.Code), permErr.Message)
+		return
+	}
+
+	s.broadcaster.BroadcastToAll(bridge.BroadcastEvent{
+		EventType: "announcement",
+		Data:      AnnouncementData{Message: announce.Message, From: claims.Username},
+	})
+
+	s.log.Info("announcement_sent", "user_id", claims.UserID)
+	s.sendSuccess(addr, "Announcement sent")
+}
+
 func (s *Server) handleHeartbeat(addr *net.UDPAddr) {
 	if !s.subscriberManager.Heartbeat(addr) {
 		s.sendError(addr, string(ErrUDPHeartbeatFailed), "Not registered")
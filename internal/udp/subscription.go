@@ -14,6 +14,11 @@ type Subscriber struct {
 	EventTypes   []string
 	RegisteredAt time.Time
 	LastSeen     time.Time
+	// SessionID is the login session the subscriber registered with and
+	// ConnectionID its tracked connection. Both are empty for personal
+	// access tokens.
+	SessionID    string
+	ConnectionID string
 }
 
 type SubscriberManager struct {
@@ -35,6 +40,12 @@ func NewSubscriberManager(log *logger.Logger) *SubscriberManager {
 }
 
 func (sm *SubscriberManager) Subscribe(userID string, addr *net.UDPAddr, eventTypes []string) {
+	sm.SubscribeSession(userID, "", "", addr, eventTypes)
+}
+
+// SubscribeSession registers addr like Subscribe, remembering the login
+// session and tracked connection it belongs to
+func (sm *SubscriberManager) SubscribeSession(userID, sessionID, connectionID string, addr *net.UDPAddr, eventTypes []string) {
 	sm.mu.Lock()
 	defer sm.mu.Unlock()
 
@@ -47,6 +58,8 @@ func (sm *SubscriberManager) Subscribe(userID string, addr *net.UDPAddr, eventTy
 		EventTypes:   eventTypes,
 		RegisteredAt: now,
 		LastSeen:     now,
+		SessionID:    sessionID,
+		ConnectionID: connectionID,
 	}
 
 	sm.subscribers[userID] = append(sm.subscribers[userID], sub)
@@ -89,6 +102,51 @@ func (sm *SubscriberManager) Unsubscribe(addr *net.UDPAddr) {
 		"addr", addrKey)
 }
 
+// UnsubscribeSession removes every subscriber registered with sessionID and
+// returns them
+func (sm *SubscriberManager) UnsubscribeSession(sessionID string) []*Subscriber {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	var removed []*Subscriber
+	for userID, subs := range sm.subscribers {
+		filtered := []*Subscriber{}
+		for _, sub := range subs {
+			if sub.SessionID == sessionID {
+				removed = append(removed, sub)
+				delete(sm.addrToUser, sub.Addr.String())
+			} else {
+				filtered = append(filtered, sub)
+			}
+		}
+		if len(filtered) > 0 {
+			sm.subscribers[userID] = filtered
+		} else {
+			delete(sm.subscribers, userID)
+		}
+	}
+	return removed
+}
+
+// LoginSessions lists the tracked connections of every subscriber and the
+// login sessions they belong to
+func (sm *SubscriberManager) LoginSessions() (connectionIDs, sessionIDs []string) {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	for _, subs := range sm.subscribers {
+		for _, sub := range subs {
+			if sub.ConnectionID != "" {
+				connectionIDs = append(connectionIDs, sub.ConnectionID)
+			}
+			if sub.SessionID != "" {
+				sessionIDs = append(sessionIDs, sub.SessionID)
+			}
+		}
+	}
+	return connectionIDs, sessionIDs
+}
+
 func (sm *SubscriberManager) UpdateSubscription(addr *net.UDPAddr, eventTypes []string) bool {
 	sm.mu.Lock()
 	defer sm.mu.Unlock()
@@ -150,6 +208,37 @@ func (sm *SubscriberManager) GetSubscribers(userID, eventType string) []*Subscri
 	return filtered
 }
 
+// GetAllSubscribers returns every subscriber, across users, that wants
+// eventType
+func (sm *SubscriberManager) GetAllSubscribers(eventType string) []*Subscriber {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	filtered := []*Subscriber{}
+	for _, subs := range sm.subscribers {
+		for _, sub := range subs {
+			if sm.matchesEventType(sub, eventType) {
+				filtered = append(filtered, sub)
+			}
+		}
+	}
+	return filtered
+}
+
+// GetSubscriber returns the subscriber registered at addr
+func (sm *SubscriberManager) GetSubscriber(addr *net.UDPAddr) (*Subscriber, bool) {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	addrKey := addr.String()
+	for _, sub := range sm.subscribers[sm.addrToUser[addrKey]] {
+		if sub.Addr.String() == addrKey {
+			return sub, true
+		}
+	}
+	return nil, false
+}
+
 func (sm *SubscriberManager) GetUserByAddr(addr *net.UDPAddr) (string, bool) {
 	sm.mu.RLock()
 	defer sm.mu.RUnlock()
@@ -2,13 +2,11 @@ package udp_test
 
 import (
 	"net"
-	"os"
 	"testing"
 	"time"
 
 	"github.com/binhbb2204/Manga-Hub-Group13/internal/udp"
 	"github.com/binhbb2204/Manga-Hub-Group13/pkg/logger"
-	"github.com/binhbb2204/Manga-Hub-Group13/pkg/utils"
 )
 
 func TestEndToEndConnectivity(t *testing.T) {
@@ -31,11 +29,7 @@ func TestEndToEndConnectivity(t *testing.T) {
 	}
 	defer conn.Close()
 
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		jwtSecret = "your-secret-key-change-this-in-production"
-	}
-	token, _ := utils.GenerateJWT("user1", "testuser", jwtSecret)
+	token, _ := signToken("user1", "testuser", "")
 
 	registerMsg := udp.CreateRegisterMessage(token)
 	_, err = conn.Write(registerMsg)
@@ -80,11 +74,7 @@ func TestIPv4Connectivity(t *testing.T) {
 	}
 	defer conn.Close()
 
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		jwtSecret = "your-secret-key-change-this-in-production"
-	}
-	token, _ := utils.GenerateJWT("user1", "testuser", jwtSecret)
+	token, _ := signToken("user1", "testuser", "")
 
 	registerMsg := udp.CreateRegisterMessage(token)
 	conn.Write(registerMsg)
@@ -131,11 +121,7 @@ func TestLocalhostConnectivity(t *testing.T) {
 			}
 			defer conn.Close()
 
-			jwtSecret := os.Getenv("JWT_SECRET")
-			if jwtSecret == "" {
-				jwtSecret = "your-secret-key-change-this-in-production"
-			}
-			token, _ := utils.GenerateJWT("user1", "testuser", jwtSecret)
+			token, _ := signToken("user1", "testuser", "")
 
 			registerMsg := udp.CreateRegisterMessage(token)
 			conn.Write(registerMsg)
@@ -166,10 +152,6 @@ func TestMultipleClientsConnectivity(t *testing.T) {
 	time.Sleep(100 * time.Millisecond)
 
 	numClients := 10
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		jwtSecret = "your-secret-key-change-this-in-production"
-	}
 
 	for i := 0; i < numClients; i++ {
 		conn, err := net.DialUDP("udp", nil, &net.UDPAddr{
@@ -180,7 +162,7 @@ func TestMultipleClientsConnectivity(t *testing.T) {
 			t.Fatalf("Client %d failed to connect: %v", i, err)
 		}
 
-		token, _ := utils.GenerateJWT("user"+string(rune(i)), "testuser", jwtSecret)
+		token, _ := signToken("user"+string(rune(i)), "testuser", "")
 		registerMsg := udp.CreateRegisterMessage(token)
 		conn.Write(registerMsg)
 
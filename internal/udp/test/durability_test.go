@@ -2,13 +2,11 @@ package udp_test
 
 import (
 	"net"
-	"os"
 	"testing"
 	"time"
 
 	"github.com/binhbb2204/Manga-Hub-Group13/internal/udp"
 	"github.com/binhbb2204/Manga-Hub-Group13/pkg/logger"
-	"github.com/binhbb2204/Manga-Hub-Group13/pkg/utils"
 )
 
 func TestServerRestart(t *testing.T) {
@@ -43,11 +41,7 @@ func TestServerRestart(t *testing.T) {
 	}
 	defer conn.Close()
 
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		jwtSecret = "your-secret-key-change-this-in-production"
-	}
-	token, _ := utils.GenerateJWT("user1", "testuser", jwtSecret)
+	token, _ := signToken("user1", "testuser", "")
 
 	registerMsg := udp.CreateRegisterMessage(token)
 	conn.Write(registerMsg)
@@ -82,11 +76,7 @@ func TestGracefulShutdown(t *testing.T) {
 	}
 	defer conn.Close()
 
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		jwtSecret = "your-secret-key-change-this-in-production"
-	}
-	token, _ := utils.GenerateJWT("user1", "testuser", jwtSecret)
+	token, _ := signToken("user1", "testuser", "")
 
 	registerMsg := udp.CreateRegisterMessage(token)
 	conn.Write(registerMsg)
@@ -125,11 +115,7 @@ func TestMalformedPacketRecovery(t *testing.T) {
 
 	time.Sleep(100 * time.Millisecond)
 
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		jwtSecret = "your-secret-key-change-this-in-production"
-	}
-	token, _ := utils.GenerateJWT("user1", "testuser", jwtSecret)
+	token, _ := signToken("user1", "testuser", "")
 
 	registerMsg := udp.CreateRegisterMessage(token)
 	conn.Write(registerMsg)
@@ -160,11 +146,7 @@ func TestMultipleRestartCycles(t *testing.T) {
 			Port: 19303,
 		})
 		if err == nil {
-			jwtSecret := os.Getenv("JWT_SECRET")
-			if jwtSecret == "" {
-				jwtSecret = "your-secret-key-change-this-in-production"
-			}
-			token, _ := utils.GenerateJWT("user1", "testuser", jwtSecret)
+			token, _ := signToken("user1", "testuser", "")
 			registerMsg := udp.CreateRegisterMessage(token)
 			conn.Write(registerMsg)
 			conn.Close()
@@ -210,11 +192,6 @@ func TestLongRunningStability(t *testing.T) {
 	}
 	defer server.Stop()
 
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		jwtSecret = "your-secret-key-change-this-in-production"
-	}
-
 	duration := 30 * time.Second
 	ticker := time.NewTicker(1 * time.Second)
 	defer ticker.Stop()
@@ -234,7 +211,7 @@ func TestLongRunningStability(t *testing.T) {
 				continue
 			}
 
-			token, _ := utils.GenerateJWT("user1", "testuser", jwtSecret)
+			token, _ := signToken("user1", "testuser", "")
 			registerMsg := udp.CreateRegisterMessage(token)
 			conn.Write(registerMsg)
 
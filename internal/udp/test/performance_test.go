@@ -2,14 +2,12 @@ package udp_test
 
 import (
 	"net"
-	"os"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/binhbb2204/Manga-Hub-Group13/internal/udp"
 	"github.com/binhbb2204/Manga-Hub-Group13/pkg/logger"
-	"github.com/binhbb2204/Manga-Hub-Group13/pkg/utils"
 )
 
 func BenchmarkRegister(b *testing.B) {
@@ -23,11 +21,7 @@ func BenchmarkRegister(b *testing.B) {
 
 	time.Sleep(100 * time.Millisecond)
 
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		jwtSecret = "your-secret-key-change-this-in-production"
-	}
-	token, _ := utils.GenerateJWT("user1", "testuser", jwtSecret)
+	token, _ := signToken("user1", "testuser", "")
 	registerMsg := udp.CreateRegisterMessage(token)
 
 	b.ResetTimer()
@@ -70,11 +64,7 @@ func BenchmarkHeartbeat(b *testing.B) {
 	}
 	defer conn.Close()
 
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		jwtSecret = "your-secret-key-change-this-in-production"
-	}
-	token, _ := utils.GenerateJWT("user1", "testuser", jwtSecret)
+	token, _ := signToken("user1", "testuser", "")
 	registerMsg := udp.CreateRegisterMessage(token)
 	conn.Write(registerMsg)
 
@@ -113,11 +103,7 @@ func TestThroughput(t *testing.T) {
 	}
 	defer conn.Close()
 
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		jwtSecret = "your-secret-key-change-this-in-production"
-	}
-	token, _ := utils.GenerateJWT("user1", "testuser", jwtSecret)
+	token, _ := signToken("user1", "testuser", "")
 	registerMsg := udp.CreateRegisterMessage(token)
 	conn.Write(registerMsg)
 
@@ -171,11 +157,7 @@ func TestLatency(t *testing.T) {
 	}
 	defer conn.Close()
 
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		jwtSecret = "your-secret-key-change-this-in-production"
-	}
-	token, _ := utils.GenerateJWT("user1", "testuser", jwtSecret)
+	token, _ := signToken("user1", "testuser", "")
 	registerMsg := udp.CreateRegisterMessage(token)
 	conn.Write(registerMsg)
 
@@ -231,11 +213,6 @@ func TestConcurrentThroughput(t *testing.T) {
 	numClients := 10
 	messagesPerClient := 100
 
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		jwtSecret = "your-secret-key-change-this-in-production"
-	}
-
 	var wg sync.WaitGroup
 	totalSuccess := 0
 	var mu sync.Mutex
@@ -256,7 +233,7 @@ func TestConcurrentThroughput(t *testing.T) {
 			}
 			defer conn.Close()
 
-			token, _ := utils.GenerateJWT("user"+string(rune(id)), "testuser", jwtSecret)
+			token, _ := signToken("user"+string(rune(id)), "testuser", "")
 			registerMsg := udp.CreateRegisterMessage(token)
 			conn.Write(registerMsg)
 
@@ -2,14 +2,12 @@ package udp_test
 
 import (
 	"net"
-	"os"
 	"testing"
 	"time"
 
 	"github.com/binhbb2204/Manga-Hub-Group13/internal/bridge"
 	"github.com/binhbb2204/Manga-Hub-Group13/internal/udp"
 	"github.com/binhbb2204/Manga-Hub-Group13/pkg/logger"
-	"github.com/binhbb2204/Manga-Hub-Group13/pkg/utils"
 )
 
 func TestMessageDelivery(t *testing.T) {
@@ -32,11 +30,7 @@ func TestMessageDelivery(t *testing.T) {
 	}
 	defer conn.Close()
 
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		jwtSecret = "your-secret-key-change-this-in-production"
-	}
-	token, _ := utils.GenerateJWT("user1", "testuser", jwtSecret)
+	token, _ := signToken("user1", "testuser", "")
 
 	numMessages := 100
 	successCount := 0
@@ -82,11 +76,7 @@ func TestSessionPersistence(t *testing.T) {
 	}
 	defer conn.Close()
 
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		jwtSecret = "your-secret-key-change-this-in-production"
-	}
-	token, _ := utils.GenerateJWT("user1", "testuser", jwtSecret)
+	token, _ := signToken("user1", "testuser", "")
 
 	registerMsg := udp.CreateRegisterMessage(token)
 	conn.Write(registerMsg)
@@ -126,11 +116,7 @@ func TestReconnectionBehavior(t *testing.T) {
 
 	time.Sleep(100 * time.Millisecond)
 
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		jwtSecret = "your-secret-key-change-this-in-production"
-	}
-	token, _ := utils.GenerateJWT("user1", "testuser", jwtSecret)
+	token, _ := signToken("user1", "testuser", "")
 
 	for i := 0; i < 5; i++ {
 		conn, err := net.DialUDP("udp", nil, &net.UDPAddr{
@@ -184,11 +170,7 @@ func TestNotificationBroadcast(t *testing.T) {
 	}
 	defer conn.Close()
 
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		jwtSecret = "your-secret-key-change-this-in-production"
-	}
-	token, _ := utils.GenerateJWT("user1", "testuser", jwtSecret)
+	token, _ := signToken("user1", "testuser", "")
 
 	registerMsg := udp.CreateRegisterMessage(token)
 	conn.Write(registerMsg)
@@ -255,11 +237,7 @@ func TestMultiDeviceNotification(t *testing.T) {
 	numDevices := 3
 	connections := make([]*net.UDPConn, numDevices)
 
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		jwtSecret = "your-secret-key-change-this-in-production"
-	}
-	token, _ := utils.GenerateJWT("user1", "testuser", jwtSecret)
+	token, _ := signToken("user1", "testuser", "")
 
 	for i := 0; i < numDevices; i++ {
 		conn, err := net.DialUDP("udp", nil, &net.UDPAddr{
@@ -2,14 +2,12 @@ package udp_test
 
 import (
 	"net"
-	"os"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/binhbb2204/Manga-Hub-Group13/internal/udp"
 	"github.com/binhbb2204/Manga-Hub-Group13/pkg/logger"
-	"github.com/binhbb2204/Manga-Hub-Group13/pkg/utils"
 )
 
 func TestConcurrentConnections(t *testing.T) {
@@ -25,10 +23,6 @@ func TestConcurrentConnections(t *testing.T) {
 
 	numClients := 100
 	var wg sync.WaitGroup
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		jwtSecret = "your-secret-key-change-this-in-production"
-	}
 
 	successCount := 0
 	var mu sync.Mutex
@@ -47,7 +41,7 @@ func TestConcurrentConnections(t *testing.T) {
 			}
 			defer conn.Close()
 
-			token, _ := utils.GenerateJWT("user"+string(rune(id)), "testuser", jwtSecret)
+			token, _ := signToken("user"+string(rune(id)), "testuser", "")
 			registerMsg := udp.CreateRegisterMessage(token)
 			conn.Write(registerMsg)
 
@@ -91,11 +85,7 @@ func TestHighFrequencyHeartbeats(t *testing.T) {
 	}
 	defer conn.Close()
 
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		jwtSecret = "your-secret-key-change-this-in-production"
-	}
-	token, _ := utils.GenerateJWT("user1", "testuser", jwtSecret)
+	token, _ := signToken("user1", "testuser", "")
 
 	registerMsg := udp.CreateRegisterMessage(token)
 	conn.Write(registerMsg)
@@ -138,10 +128,6 @@ func TestSessionLoad(t *testing.T) {
 	time.Sleep(100 * time.Millisecond)
 
 	numSessions := 500
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		jwtSecret = "your-secret-key-change-this-in-production"
-	}
 
 	connections := make([]*net.UDPConn, 0, numSessions)
 
@@ -154,7 +140,7 @@ func TestSessionLoad(t *testing.T) {
 			continue
 		}
 
-		token, _ := utils.GenerateJWT("user"+string(rune(i)), "testuser", jwtSecret)
+		token, _ := signToken("user"+string(rune(i)), "testuser", "")
 		registerMsg := udp.CreateRegisterMessage(token)
 		conn.Write(registerMsg)
 
@@ -187,11 +173,6 @@ func TestConnectionChurn(t *testing.T) {
 
 	time.Sleep(100 * time.Millisecond)
 
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		jwtSecret = "your-secret-key-change-this-in-production"
-	}
-
 	numCycles := 50
 	for i := 0; i < numCycles; i++ {
 		conn, err := net.DialUDP("udp", nil, &net.UDPAddr{
@@ -202,7 +183,7 @@ func TestConnectionChurn(t *testing.T) {
 			continue
 		}
 
-		token, _ := utils.GenerateJWT("user1", "testuser", jwtSecret)
+		token, _ := signToken("user1", "testuser", "")
 		registerMsg := udp.CreateRegisterMessage(token)
 		conn.Write(registerMsg)
 
@@ -232,10 +213,6 @@ func TestScalabilityMetrics(t *testing.T) {
 	time.Sleep(100 * time.Millisecond)
 
 	testCases := []int{10, 50, 100, 200}
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		jwtSecret = "your-secret-key-change-this-in-production"
-	}
 
 	for _, numClients := range testCases {
 		t.Run("clients_"+string(rune(numClients)), func(t *testing.T) {
@@ -256,7 +233,7 @@ func TestScalabilityMetrics(t *testing.T) {
 					}
 					defer conn.Close()
 
-					token, _ := utils.GenerateJWT("user"+string(rune(id)), "testuser", jwtSecret)
+					token, _ := signToken("user"+string(rune(id)), "testuser", "")
 					registerMsg := udp.CreateRegisterMessage(token)
 					conn.Write(registerMsg)
 
@@ -2,15 +2,29 @@ package udp_test
 
 import (
 	"net"
-	"os"
 	"testing"
 	"time"
 
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/auth"
 	"github.com/binhbb2204/Manga-Hub-Group13/internal/udp"
 	"github.com/binhbb2204/Manga-Hub-Group13/pkg/logger"
 	"github.com/binhbb2204/Manga-Hub-Group13/pkg/utils"
 )
 
+// testKeys sign the tokens used in these tests
+var testKeys = auth.KeysFromSecret("test-secret")
+
+// signToken issues an access token and makes testKeys the keys the servers
+// under test verify with
+func signToken(userID, username, role string) (string, error) {
+	auth.SetDefaultKeys(testKeys)
+	claims, err := utils.NewAccessClaims(userID, username, role)
+	if err != nil {
+		return "", err
+	}
+	return testKeys.Sign(claims)
+}
+
 func TestServerStartStop(t *testing.T) {
 	logger.Init(logger.ERROR, false, nil)
 
@@ -57,11 +71,7 @@ func TestServerRegister(t *testing.T) {
 	}
 	defer conn.Close()
 
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		jwtSecret = "your-secret-key-change-this-in-production"
-	}
-	token, err := utils.GenerateJWT("user1", "testuser", jwtSecret)
+	token, err := signToken("user1", "testuser", "")
 	if err != nil {
 		t.Fatalf("Failed to generate JWT: %v", err)
 	}
@@ -153,11 +163,7 @@ func TestServerUnregister(t *testing.T) {
 	}
 	defer conn.Close()
 
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		jwtSecret = "your-secret-key-change-this-in-production"
-	}
-	token, _ := utils.GenerateJWT("user1", "testuser", jwtSecret)
+	token, _ := signToken("user1", "testuser", "")
 
 	registerMsg := udp.CreateRegisterMessage(token)
 	conn.Write(registerMsg)
@@ -205,11 +211,7 @@ func TestServerSubscribe(t *testing.T) {
 	}
 	defer conn.Close()
 
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		jwtSecret = "your-secret-key-change-this-in-production"
-	}
-	token, _ := utils.GenerateJWT("user1", "testuser", jwtSecret)
+	token, _ := signToken("user1", "testuser", "")
 
 	registerMsg := udp.CreateRegisterMessage(token)
 	conn.Write(registerMsg)
@@ -257,11 +259,7 @@ func TestServerHeartbeat(t *testing.T) {
 	}
 	defer conn.Close()
 
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		jwtSecret = "your-secret-key-change-this-in-production"
-	}
-	token, _ := utils.GenerateJWT("user1", "testuser", jwtSecret)
+	token, _ := signToken("user1", "testuser", "")
 
 	registerMsg := udp.CreateRegisterMessage(token)
 	conn.Write(registerMsg)
@@ -288,3 +286,51 @@ func TestServerHeartbeat(t *testing.T) {
 		t.Errorf("Expected success response for heartbeat, got '%s'", msg.Type)
 	}
 }
+
+func TestServerSubscribeCommentReplies(t *testing.T) {
+	logger.Init(logger.ERROR, false, nil)
+
+	server := udp.NewServer("19097", nil)
+	if err := server.Start(); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	defer server.Stop()
+
+	time.Sleep(100 * time.Millisecond)
+
+	conn, err := net.DialUDP("udp", nil, &net.UDPAddr{
+		IP:   net.ParseIP("127.0.0.1"),
+		Port: 19097,
+	})
+	if err != nil {
+		t.Fatalf("Failed to dial UDP: %v", err)
+	}
+	defer conn.Close()
+
+	token, err := signToken("user1", "testuser", "")
+	if err != nil {
+		t.Fatalf("Failed to generate JWT: %v", err)
+	}
+
+	buffer := make([]byte, 1024)
+	send := func(data []byte) *udp.Message {
+		conn.Write(data)
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		n, err := conn.Read(buffer)
+		if err != nil {
+			t.Fatalf("Failed to read response: %v", err)
+		}
+		msg, err := udp.ParseMessage(buffer[:n])
+		if err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		return msg
+	}
+
+	if msg := send(udp.CreateRegisterMessage(token)); msg.Type != "success" {
+		t.Fatalf("Expected register to succeed, got '%s'", msg.Type)
+	}
+	if msg := send(udp.CreateSubscribeMessage([]string{"comment_reply"})); msg.Type != "success" {
+		t.Errorf("Expected subscribing to comment_reply to succeed, got '%s'", msg.Type)
+	}
+}
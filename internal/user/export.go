@@ -0,0 +1,249 @@
+package user
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/database"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+// exportCSVColumns is the CSV header, also sent in the X-Export-Columns
+// response header. New columns are only ever appended so scripts reading
+// older exports by position keep working.
+var exportCSVColumns = []string{
+	"manga_id", "title", "status", "current_chapter", "current_volume", "score",
+	"started_at", "finished_at", "is_favorite", "tags", "updated_at",
+}
+
+type exportFormat struct {
+	contentType string
+	extension   string
+	write       func(w io.Writer, rows *sql.Rows) error
+}
+
+var exportFormats = map[string]exportFormat{
+	models.ExportFormatMALXML: {"application/xml; charset=utf-8", "xml", writeMALExport},
+	models.ExportFormatCSV:    {"text/csv; charset=utf-8", "csv", writeCSVExport},
+	models.ExportFormatJSON:   {"application/json; charset=utf-8", "json", writeJSONExport},
+}
+
+// ExportLibrary streams the user's library as MAL XML, CSV or JSON straight
+// from the query, so large libraries are never held in memory.
+func (h *Handler) ExportLibrary(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req models.ExportLibraryRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Format == "" {
+		req.Format = models.ExportFormatJSON
+	}
+	format := exportFormats[req.Format]
+
+	rows, err := database.DB.Query(`
+        SELECT up.manga_id, m.title, up.status, up.current_chapter, COALESCE(up.current_volume, 0),
+               COALESCE(r.score, 0), COALESCE(up.started_at, ''), COALESCE(up.finished_at, ''),
+               COALESCE(up.is_favorite, 0),
+               COALESCE((SELECT GROUP_CONCAT(tag, char(31)) FROM library_tags lt
+                         WHERE lt.user_id = up.user_id AND lt.manga_id = up.manga_id), ''),
+               up.updated_at, COALESCE(m.total_chapters, 0), COALESCE(m.num_volumes, 0)
+        FROM user_progress up
+        JOIN manga m ON m.id = up.manga_id
+        LEFT JOIN ratings r ON r.user_id = up.user_id AND r.manga_id = up.manga_id
+        WHERE up.user_id = ?
+        ORDER BY m.title`, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	defer rows.Close()
+
+	c.Header("Content-Type", format.contentType)
+	c.Header("Content-Disposition", `attachment; filename="mangahub-library.`+format.extension+`"`)
+	if req.Format == models.ExportFormatCSV {
+		c.Header("X-Export-Columns", strings.Join(exportCSVColumns, ","))
+	}
+	c.Status(http.StatusOK)
+
+	// Headers are already sent, so a failure can only cut the file short
+	if err := format.write(c.Writer, rows); err != nil {
+		log.Printf("Warning: library export for %s stopped early: %v", userID, err)
+	}
+}
+
+// exportRow is a library entry plus the catalog totals MAL XML includes
+type exportRow struct {
+	models.LibraryExportEntry
+	TotalChapters int
+	NumVolumes    int
+}
+
+func scanExportRow(rows *sql.Rows) (exportRow, error) {
+	var row exportRow
+	var tags string
+	err := rows.Scan(&row.MangaID, &row.Title, &row.Status, &row.CurrentChapter, &row.CurrentVolume,
+		&row.Score, &row.StartedAt, &row.FinishedAt, &row.IsFavorite, &tags,
+		&row.UpdatedAt, &row.TotalChapters, &row.NumVolumes)
+	row.Tags = []string{}
+	if tags != "" {
+		row.Tags = strings.Split(tags, "\x1f")
+	}
+	return row, err
+}
+
+func writeCSVExport(w io.Writer, rows *sql.Rows) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(exportCSVColumns); err != nil {
+		return err
+	}
+	for rows.Next() {
+		row, err := scanExportRow(rows)
+		if err != nil {
+			return err
+		}
+		record := []string{
+			row.MangaID,
+			row.Title,
+			string(row.Status),
+			strconv.Itoa(row.CurrentChapter),
+			strconv.Itoa(row.CurrentVolume),
+			strconv.Itoa(row.Score),
+			row.StartedAt,
+			row.FinishedAt,
+			strconv.FormatBool(row.IsFavorite),
+			strings.Join(row.Tags, ";"),
+			row.UpdatedAt.UTC().Format(time.RFC3339),
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return err
+	}
+	return rows.Err()
+}
+
+func writeJSONExport(w io.Writer, rows *sql.Rows) error {
+	if _, err := io.WriteString(w, `{"exported_at":"`+time.Now().UTC().Format(time.RFC3339)+`","entries":[`); err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	for first := true; rows.Next(); first = false {
+		row, err := scanExportRow(rows)
+		if err != nil {
+			return err
+		}
+		if !first {
+			if _, err := io.WriteString(w, ","); err != nil {
+				return err
+			}
+		}
+		if err := enc.Encode(row.LibraryExportEntry); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "]}\n")
+	return err
+}
+
+// malStatuses are the my_status values MyAnimeList's importer accepts
+var malStatuses = map[models.ReadingStatus]string{
+	models.StatusReading:    "Reading",
+	models.StatusCompleted:  "Completed",
+	models.StatusOnHold:     "On-Hold",
+	models.StatusDropped:    "Dropped",
+	models.StatusPlanToRead: "Plan to Read",
+}
+
+type cdata struct {
+	Value string `xml:",cdata"`
+}
+
+type malExportManga struct {
+	XMLName        xml.Name `xml:"manga"`
+	ID             string   `xml:"manga_mangadb_id"`
+	Title          cdata    `xml:"manga_title"`
+	Volumes        int      `xml:"manga_volumes"`
+	Chapters       int      `xml:"manga_chapters"`
+	MyID           int      `xml:"my_id"`
+	ReadVolumes    int      `xml:"my_read_volumes"`
+	ReadChapters   int      `xml:"my_read_chapters"`
+	StartDate      string   `xml:"my_start_date"`
+	FinishDate     string   `xml:"my_finish_date"`
+	Score          int      `xml:"my_score"`
+	Status         string   `xml:"my_status"`
+	Tags           cdata    `xml:"my_tags"`
+	UpdateOnImport int      `xml:"update_on_import"`
+}
+
+// writeMALExport writes the format of MyAnimeList's own manga list export so
+// the file can be imported there. MAL only knows its own numeric IDs, so
+// entries added to the catalog by hand are left out.
+func writeMALExport(w io.Writer, rows *sql.Rows) error {
+	header := xml.Header + "<myanimelist>\n\t<myinfo>\n\t\t<user_export_type>2</user_export_type>\n\t</myinfo>\n"
+	if _, err := io.WriteString(w, header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("\t", "\t")
+	for rows.Next() {
+		row, err := scanExportRow(rows)
+		if err != nil {
+			return err
+		}
+		if _, err := strconv.Atoi(row.MangaID); err != nil {
+			continue
+		}
+		err = enc.Encode(malExportManga{
+			ID:             row.MangaID,
+			Title:          cdata{row.Title},
+			Volumes:        row.NumVolumes,
+			Chapters:       row.TotalChapters,
+			ReadVolumes:    row.CurrentVolume,
+			ReadChapters:   row.CurrentChapter,
+			StartDate:      malDate(row.StartedAt),
+			FinishDate:     malDate(row.FinishedAt),
+			Score:          row.Score,
+			Status:         malStatuses[row.Status],
+			Tags:           cdata{strings.Join(row.Tags, ", ")},
+			UpdateOnImport: 1,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n</myanimelist>\n")
+	return err
+}
+
+func malDate(day string) string {
+	if day == "" {
+		return "0000-00-00"
+	}
+	return day
+}
@@ -1,26 +1,46 @@
 package user
 
 import (
+	"database/sql"
 	"encoding/json"
+	"fmt"
+	"log"
 	"net/http"
 	"time"
 
 	"github.com/binhbb2204/Manga-Hub-Group13/internal/bridge"
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/goal"
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/library"
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/manga"
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/stats"
 	"github.com/binhbb2204/Manga-Hub-Group13/pkg/database"
 	"github.com/binhbb2204/Manga-Hub-Group13/pkg/models"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/utils"
 	"github.com/gin-gonic/gin"
 )
 
 // Handler handles user-related operations
 type Handler struct {
-	bridge *bridge.Bridge
+	bridge         *bridge.Bridge
+	externalSource manga.ExternalSource
 }
 
-// NewHandler creates a new user handler
+// NewHandler creates a new user handler. Library imports look up unknown
+// titles through the external source when MAL_CLIENT_ID is configured.
 func NewHandler(br *bridge.Bridge) *Handler {
-	return &Handler{
+	h := &Handler{
 		bridge: br,
 	}
+	if source, err := manga.NewExternalSourceFromEnv(); err == nil {
+		h.externalSource = source
+	}
+	return h
+}
+
+// SetExternalSource replaces the source used to add unknown titles to the
+// catalog during imports.
+func (h *Handler) SetExternalSource(source manga.ExternalSource) {
+	h.externalSource = source
 }
 
 // GetProfile gets the current user's profile
@@ -32,8 +52,9 @@ func (h *Handler) GetProfile(c *gin.Context) {
 	}
 
 	var user models.User
-	query := `SELECT id, username, email, created_at FROM users WHERE id = ?`
-	err := database.DB.QueryRow(query, userID).Scan(&user.ID, &user.Username, &user.Email, &user.CreatedAt)
+	query := `SELECT id, username, email, email_verified, totp_enabled, role, created_at, COALESCE(preferred_title_language, '') FROM users WHERE id = ?`
+	err := database.DB.QueryRow(query, userID).Scan(&user.ID, &user.Username, &user.Email, &user.EmailVerified, &user.TwoFactor,
+		&user.Role, &user.CreatedAt, &user.PreferredTitleLanguage)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 		return
@@ -42,6 +63,32 @@ func (h *Handler) GetProfile(c *gin.Context) {
 	c.JSON(http.StatusOK, user)
 }
 
+// UpdatePreferences updates the current user's display preferences
+func (h *Handler) UpdatePreferences(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req models.UpdatePreferencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	_, err := database.DB.Exec(`UPDATE users SET preferred_title_language = ? WHERE id = ?`, req.TitleLanguage, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update preferences"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":                  "Preferences updated successfully",
+		"preferred_title_language": req.TitleLanguage,
+	})
+}
+
 // AddToLibrary adds a manga to user's library
 func (h *Handler) AddToLibrary(c *gin.Context) {
 	userID := c.GetString("user_id")
@@ -66,16 +113,23 @@ func (h *Handler) AddToLibrary(c *gin.Context) {
 	}
 
 	// Insert or update user progress
-	query := `INSERT INTO user_progress (user_id, manga_id, current_chapter, status, updated_at)
-              VALUES (?, ?, 0, ?, ?)
-              ON CONFLICT(user_id, manga_id) DO UPDATE SET status = ?, updated_at = ?`
+	// Re-adding without --favorite keeps an existing favorite flag
+	query := `INSERT INTO user_progress (user_id, manga_id, current_chapter, status, is_favorite, updated_at)
+              VALUES (?, ?, 0, ?, ?, ?)
+              ON CONFLICT(user_id, manga_id) DO UPDATE SET status = ?, is_favorite = MAX(is_favorite, excluded.is_favorite), updated_at = ?`
 
 	now := time.Now()
-	_, err = database.DB.Exec(query, userID, req.MangaID, req.Status, now, req.Status, now)
+	_, err = database.DB.Exec(query, userID, req.MangaID, req.Status, req.IsFavorite, now, req.Status, now)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add manga to library"})
 		return
 	}
+	if err := stats.RecordStatusChange(userID, req.MangaID, now); err != nil {
+		log.Printf("Warning: failed to record progress history: %v", err)
+	}
+	if err := goal.Evaluate(h.bridge, userID); err != nil {
+		log.Printf("Warning: failed to evaluate goals: %v", err)
+	}
 
 	h.bridge.NotifyLibraryUpdate(bridge.LibraryUpdateEvent{
 		UserID:  userID,
@@ -94,16 +148,37 @@ func (h *Handler) GetLibrary(c *gin.Context) {
 		return
 	}
 
+	var filter models.LibraryFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	query := `
         SELECT m.id, m.title, m.author, m.genres, m.status, m.total_chapters, m.description, m.cover_url,
-               up.current_chapter, up.status, up.updated_at
+               up.current_chapter, COALESCE(up.current_volume, 0), up.status, COALESCE(up.is_favorite, 0),
+               COALESCE(r.score, 0), COALESCE(up.started_at, ''), COALESCE(up.finished_at, ''), up.updated_at
         FROM user_progress up
         JOIN manga m ON up.manga_id = m.id
-        WHERE up.user_id = ?
-        ORDER BY up.updated_at DESC
-    `
+        LEFT JOIN ratings r ON r.user_id = up.user_id AND r.manga_id = up.manga_id
+        WHERE up.user_id = ?`
+	args := []interface{}{userID}
+
+	if filter.Tag != "" {
+		query += ` AND EXISTS (SELECT 1 FROM library_tags lt
+                     WHERE lt.user_id = up.user_id AND lt.manga_id = up.manga_id AND lt.tag = ?)`
+		args = append(args, utils.NormalizeTag(filter.Tag))
+	}
+
+	query += ` ORDER BY up.updated_at DESC`
+
+	tags, err := library.LoadTags(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
 
-	rows, err := database.DB.Query(query, userID)
+	rows, err := database.DB.Query(query, args...)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
 		return
@@ -113,9 +188,13 @@ func (h *Handler) GetLibrary(c *gin.Context) {
 	library := models.UserLibrary{
 		Reading:    []models.MangaProgress{},
 		Completed:  []models.MangaProgress{},
+		OnHold:     []models.MangaProgress{},
+		Dropped:    []models.MangaProgress{},
 		PlanToRead: []models.MangaProgress{},
+		Favorites:  []models.MangaProgress{},
 	}
 
+	var entries []models.MangaProgress
 	for rows.Next() {
 		var mp models.MangaProgress
 		var genresJSON string
@@ -130,7 +209,12 @@ func (h *Handler) GetLibrary(c *gin.Context) {
 			&mp.Manga.Description,
 			&mp.Manga.CoverURL,
 			&mp.CurrentChapter,
+			&mp.CurrentVolume,
 			&mp.Status,
+			&mp.IsFavorite,
+			&mp.UserScore,
+			&mp.StartedAt,
+			&mp.FinishedAt,
 			&mp.UpdatedAt,
 		)
 		if err != nil {
@@ -142,15 +226,39 @@ func (h *Handler) GetLibrary(c *gin.Context) {
 			json.Unmarshal([]byte(genresJSON), &mp.Manga.Genres)
 		}
 
+		mp.Tags = tags[mp.Manga.ID]
+		if mp.Tags == nil {
+			mp.Tags = []string{}
+		}
+
+		entries = append(entries, mp)
+	}
+
+	titles := make([]*models.Manga, len(entries))
+	for i := range entries {
+		titles[i] = &entries[i].Manga
+	}
+	if err := manga.AttachAlternativeTitles(titles, manga.UserTitleLanguage(userID)); err != nil {
+		log.Printf("Warning: failed to load alternative titles: %v", err)
+	}
+
+	for _, mp := range entries {
 		// Categorize by status
 		switch mp.Status {
-		case "reading":
+		case models.StatusReading:
 			library.Reading = append(library.Reading, mp)
-		case "completed":
+		case models.StatusCompleted:
 			library.Completed = append(library.Completed, mp)
-		case "plan_to_read":
+		case models.StatusOnHold:
+			library.OnHold = append(library.OnHold, mp)
+		case models.StatusDropped:
+			library.Dropped = append(library.Dropped, mp)
+		case models.StatusPlanToRead:
 			library.PlanToRead = append(library.PlanToRead, mp)
 		}
+		if mp.IsFavorite {
+			library.Favorites = append(library.Favorites, mp)
+		}
 	}
 
 	c.JSON(http.StatusOK, library)
@@ -171,17 +279,30 @@ func (h *Handler) UpdateProgress(c *gin.Context) {
 	}
 
 	// Check if manga exists in user's library
-	var exists bool
-	checkQuery := `SELECT EXISTS(SELECT 1 FROM user_progress WHERE user_id = ? AND manga_id = ?)`
-	err := database.DB.QueryRow(checkQuery, userID, req.MangaID).Scan(&exists)
-	if err != nil || !exists {
+	var previousChapter, volume int
+	var m models.Manga
+	checkQuery := `SELECT up.current_chapter, COALESCE(up.current_volume, 0), COALESCE(m.num_volumes, 0)
+                   FROM user_progress up
+                   JOIN manga m ON m.id = up.manga_id
+                   WHERE up.user_id = ? AND up.manga_id = ?`
+	err := database.DB.QueryRow(checkQuery, userID, req.MangaID).Scan(&previousChapter, &volume, &m.NumVolumes)
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Manga not in library"})
 		return
 	}
 
+	if req.CurrentVolume != nil {
+		if !m.HasVolume(*req.CurrentVolume) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Volume %d is out of range (manga has %d volumes)", *req.CurrentVolume, m.NumVolumes)})
+			return
+		}
+		volume = *req.CurrentVolume
+	}
+
 	// Build update query
-	query := `UPDATE user_progress SET current_chapter = ?, updated_at = ?`
-	args := []interface{}{req.CurrentChapter, time.Now()}
+	now := time.Now()
+	query := `UPDATE user_progress SET current_chapter = ?, current_volume = ?, updated_at = ?`
+	args := []interface{}{req.CurrentChapter, volume, now}
 
 	if req.Status != "" {
 		query += `, status = ?`
@@ -196,18 +317,97 @@ func (h *Handler) UpdateProgress(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update progress"})
 		return
 	}
+	if err := stats.RecordProgress(userID, req.MangaID, previousChapter, now); err != nil {
+		log.Printf("Warning: failed to record progress history: %v", err)
+	}
+	if err := goal.Evaluate(h.bridge, userID); err != nil {
+		log.Printf("Warning: failed to evaluate goals: %v", err)
+	}
 
 	h.bridge.NotifyProgressUpdate(bridge.ProgressUpdateEvent{
 		UserID:       userID,
 		MangaID:      req.MangaID,
 		ChapterID:    req.CurrentChapter,
+		Volume:       volume,
 		Status:       req.Status,
-		LastReadDate: time.Now(),
+		LastReadDate: now,
 	})
 
 	c.JSON(http.StatusOK, gin.H{"message": "Progress updated successfully"})
 }
 
+// GetProgress returns the user's progress on a single library entry
+func (h *Handler) GetProgress(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	progress := models.UserProgress{UserID: userID, MangaID: c.Param("manga_id")}
+	query := `SELECT current_chapter, COALESCE(current_volume, 0), status,
+                     COALESCE(started_at, ''), COALESCE(finished_at, ''), updated_at
+              FROM user_progress WHERE user_id = ? AND manga_id = ?`
+	err := database.DB.QueryRow(query, userID, progress.MangaID).
+		Scan(&progress.CurrentChapter, &progress.CurrentVolume, &progress.Status,
+			&progress.StartedAt, &progress.FinishedAt, &progress.UpdatedAt)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Manga not in library"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, progress)
+}
+
+// SetFavorite marks or unmarks a library entry as a favorite
+func (h *Handler) SetFavorite(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	mangaID := c.Param("manga_id")
+	var req models.SetFavoriteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	query := `UPDATE user_progress SET is_favorite = ?, updated_at = ? WHERE user_id = ? AND manga_id = ?`
+	result, err := database.DB.Exec(query, *req.IsFavorite, time.Now(), userID, mangaID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update favorite"})
+		return
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Manga not in library"})
+		return
+	}
+	stats.Invalidate(userID)
+
+	action := "favorited"
+	if !*req.IsFavorite {
+		action = "unfavorited"
+	}
+	h.bridge.NotifyLibraryUpdate(bridge.LibraryUpdateEvent{
+		UserID:  userID,
+		MangaID: mangaID,
+		Action:  action,
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"manga_id":    mangaID,
+		"is_favorite": *req.IsFavorite,
+	})
+}
+
 // RemoveFromLibrary removes manga from user's library
 func (h *Handler) RemoveFromLibrary(c *gin.Context) {
 	userID := c.GetString("user_id")
@@ -234,6 +434,7 @@ func (h *Handler) RemoveFromLibrary(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Manga not in library"})
 		return
 	}
+	stats.Invalidate(userID)
 
 	h.bridge.NotifyLibraryUpdate(bridge.LibraryUpdateEvent{
 		UserID:  userID,
@@ -0,0 +1,328 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/bridge"
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/goal"
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/manga"
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/review"
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/stats"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/database"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/models"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// maxImportBytes and maxImportRecords bound a single import request;
+	// larger libraries are imported in several parts
+	maxImportBytes   = 10 << 20
+	maxImportRecords = 2000
+	// maxImportLookups and importLookupTimeout bound how much of one request
+	// is spent fetching titles the catalog doesn't have yet
+	maxImportLookups    = 25
+	importLookupTimeout = 30 * time.Second
+)
+
+// errImportRetry marks entries left unresolved because the request ran out
+// of external lookups; importing the same file again picks them up
+var errImportRetry = errors.New("unknown, retry: too many new titles in one import, import the file again to add the rest")
+
+// ImportLibrary reads a MyAnimeList, AniList or CSV export and upserts its
+// entries into the user's library. With dry_run nothing is written and the
+// response shows what would happen.
+func (h *Handler) ImportLibrary(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportBytes)
+	var req models.ImportLibraryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("Import is larger than %d MB", maxImportBytes>>20)})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Conflict == "" {
+		req.Conflict = models.ImportKeepHigher
+	}
+
+	records, err := parseImport(req.Format, req.Data)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(records) > maxImportRecords {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("Import has %d entries; at most %d are allowed per request", len(records), maxImportRecords)})
+		return
+	}
+
+	result := models.ImportResult{
+		Format:   req.Format,
+		Conflict: req.Conflict,
+		DryRun:   req.DryRun,
+		Total:    len(records),
+		Entries:  make([]models.ImportEntry, 0, len(records)),
+	}
+
+	// Catalog lookups may write through UpsertManga, so they all happen
+	// before the library transaction is opened.
+	ctx, cancel := context.WithTimeout(c.Request.Context(), importLookupTimeout)
+	defer cancel()
+	lookups := maxImportLookups
+	for _, rec := range records {
+		entry := models.ImportEntry{
+			Title:          rec.Title,
+			Status:         rec.Status,
+			CurrentChapter: rec.Chapter,
+			CurrentVolume:  rec.Volume,
+			Score:          rec.Score,
+			StartedAt:      rec.StartedAt,
+			FinishedAt:     rec.FinishedAt,
+		}
+		if entry.Status == "" {
+			entry.Status = models.StatusPlanToRead
+			if rec.Chapter > 0 {
+				entry.Status = models.StatusReading
+			}
+		}
+		if err := h.resolveImport(ctx, rec, &entry, req.DryRun, &lookups); err != nil {
+			entry.Action = models.ImportFailed
+			entry.Reason = err.Error()
+		}
+		result.Entries = append(result.Entries, entry)
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	for i := range result.Entries {
+		entry := &result.Entries[i]
+		if entry.Action == "" {
+			if err := applyImport(tx, userID, req.Conflict, req.DryRun, entry, now); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import library"})
+				return
+			}
+		}
+		switch entry.Action {
+		case models.ImportCreated:
+			result.Created++
+		case models.ImportUpdated:
+			result.Updated++
+		case models.ImportSkipped:
+			result.Skipped++
+		case models.ImportFailed:
+			result.Failed++
+		}
+	}
+
+	if req.DryRun || result.Created+result.Updated == 0 {
+		c.JSON(http.StatusOK, result)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import library"})
+		return
+	}
+
+	// Imported entries carry past progress, so they are not added to the
+	// reading history that stats and goals count chapters from.
+	stats.Invalidate(userID)
+	if err := goal.Evaluate(h.bridge, userID); err != nil {
+		log.Printf("Warning: failed to evaluate goals: %v", err)
+	}
+	h.bridge.NotifyLibraryUpdate(bridge.LibraryUpdateEvent{
+		UserID: userID,
+		Action: "imported",
+	})
+
+	c.JSON(http.StatusOK, result)
+}
+
+// resolveImport finds the catalog entry for rec, fetching it from the
+// external source when the catalog doesn't have it yet. Each fetch uses up
+// one of lookups.
+func (h *Handler) resolveImport(ctx context.Context, rec importRecord, entry *models.ImportEntry, dryRun bool, lookups *int) error {
+	var m models.Manga
+	var err error
+	if rec.MALID != "" {
+		err = database.DB.QueryRow(`SELECT id, title, COALESCE(num_volumes, 0) FROM manga WHERE id = ?`, rec.MALID).
+			Scan(&m.ID, &m.Title, &m.NumVolumes)
+	} else if rec.Title != "" {
+		normalized := utils.NormalizeTitle(rec.Title)
+		err = database.DB.QueryRow(`SELECT id, title, COALESCE(num_volumes, 0) FROM manga
+                                    WHERE normalized_title = ?
+                                       OR id IN (SELECT manga_id FROM manga_alt_titles WHERE normalized_title = ?)
+                                    ORDER BY normalized_title = ? DESC LIMIT 1`, normalized, normalized, normalized).
+			Scan(&m.ID, &m.Title, &m.NumVolumes)
+	} else {
+		return fmt.Errorf("entry has neither an ID nor a title")
+	}
+	if err == sql.ErrNoRows {
+		if h.externalSource != nil {
+			if *lookups <= 0 || ctx.Err() != nil {
+				return errImportRetry
+			}
+			*lookups--
+		}
+		found, fetchErr := h.fetchImportManga(ctx, rec)
+		if ctx.Err() != nil {
+			return errImportRetry
+		}
+		if fetchErr != nil {
+			return fetchErr
+		}
+		if !dryRun {
+			if err := manga.UpsertManga(found); err != nil {
+				return fmt.Errorf("failed to add to catalog: %v", err)
+			}
+		}
+		m = *found
+		entry.AddedToCatalog = true
+	} else if err != nil {
+		return fmt.Errorf("catalog lookup failed: %v", err)
+	}
+
+	entry.MangaID = m.ID
+	if entry.Title == "" {
+		entry.Title = m.Title
+	}
+	if !m.HasVolume(rec.Volume) {
+		return fmt.Errorf("volume %d is out of range (manga has %d volumes)", rec.Volume, m.NumVolumes)
+	}
+	return nil
+}
+
+func (h *Handler) fetchImportManga(ctx context.Context, rec importRecord) (*models.Manga, error) {
+	if h.externalSource == nil {
+		return nil, fmt.Errorf("not in the catalog and no external source is configured")
+	}
+	if rec.MALID != "" {
+		found, err := h.externalSource.GetMangaByID(ctx, rec.MALID)
+		if err != nil {
+			return nil, fmt.Errorf("not found on external source: %v", err)
+		}
+		return found, nil
+	}
+
+	results, err := h.externalSource.Search(ctx, rec.Title, 10, 0)
+	if err != nil {
+		return nil, fmt.Errorf("external search failed: %v", err)
+	}
+	normalized := utils.NormalizeTitle(rec.Title)
+	for i := range results {
+		if titleMatches(&results[i], normalized) {
+			return &results[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no exact title match on external source")
+}
+
+// titleMatches compares the normalized title against the main and alternative
+// titles of a search result, so only exact matches are imported.
+func titleMatches(m *models.Manga, normalized string) bool {
+	if utils.NormalizeTitle(m.Title) == normalized {
+		return true
+	}
+	for _, key := range []string{"en", "ja", "synonyms"} {
+		switch v := m.AlternativeTitles[key].(type) {
+		case string:
+			if utils.NormalizeTitle(v) == normalized {
+				return true
+			}
+		case []string:
+			for _, title := range v {
+				if utils.NormalizeTitle(title) == normalized {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// applyImport writes one resolved entry according to the conflict policy and
+// records the outcome on it. In a dry run only the outcome is computed.
+func applyImport(tx *sql.Tx, userID, conflict string, dryRun bool, entry *models.ImportEntry, now time.Time) error {
+	var chapter, volume int
+	err := tx.QueryRow(`SELECT current_chapter, COALESCE(current_volume, 0) FROM user_progress
+                        WHERE user_id = ? AND manga_id = ?`, userID, entry.MangaID).Scan(&chapter, &volume)
+	exists := err == nil
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+
+	if exists {
+		switch conflict {
+		case models.ImportSkip:
+			entry.Action = models.ImportSkipped
+			entry.Reason = "already in library"
+			return nil
+		case models.ImportKeepHigher:
+			if entry.CurrentChapter < chapter || (entry.CurrentChapter == chapter && entry.CurrentVolume <= volume) {
+				entry.Action = models.ImportSkipped
+				entry.Reason = fmt.Sprintf("library is already at %s", progressLabel(volume, chapter))
+				return nil
+			}
+		}
+		entry.Action = models.ImportUpdated
+	} else {
+		entry.Action = models.ImportCreated
+	}
+	if dryRun {
+		return nil
+	}
+
+	// Dates missing from the export keep whatever the library already has
+	_, err = tx.Exec(`INSERT INTO user_progress (user_id, manga_id, current_chapter, current_volume, status,
+                          started_at, finished_at, updated_at)
+                      VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+                      ON CONFLICT(user_id, manga_id) DO UPDATE SET
+                          current_chapter = excluded.current_chapter,
+                          current_volume = excluded.current_volume,
+                          status = excluded.status,
+                          started_at = COALESCE(NULLIF(excluded.started_at, ''), started_at),
+                          finished_at = COALESCE(NULLIF(excluded.finished_at, ''), finished_at),
+                          updated_at = excluded.updated_at`,
+		userID, entry.MangaID, entry.CurrentChapter, entry.CurrentVolume, entry.Status,
+		entry.StartedAt, entry.FinishedAt, now)
+	if err != nil {
+		return err
+	}
+
+	if entry.Score < 1 || entry.Score > 10 {
+		return nil
+	}
+	_, err = tx.Exec(`INSERT INTO ratings (user_id, manga_id, score, created_at, updated_at) VALUES (?, ?, ?, ?, ?)
+                      ON CONFLICT(user_id, manga_id) DO UPDATE SET score = excluded.score, updated_at = excluded.updated_at`,
+		userID, entry.MangaID, entry.Score, now, now)
+	if err != nil {
+		return err
+	}
+	return review.RefreshCommunityScore(tx, entry.MangaID)
+}
+
+func progressLabel(volume, chapter int) string {
+	if volume > 0 {
+		return fmt.Sprintf("volume %d, chapter %d", volume, chapter)
+	}
+	return fmt.Sprintf("chapter %d", chapter)
+}
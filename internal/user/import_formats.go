@@ -0,0 +1,269 @@
+package user
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/models"
+)
+
+// importRecord is one library entry read from an export file. MALID is the
+// MyAnimeList ID, which is also the ID of catalog entries cached from MAL.
+type importRecord struct {
+	MALID      string
+	Title      string
+	Status     models.ReadingStatus
+	Chapter    int
+	Volume     int
+	Score      int // 1-10, 0 when unrated
+	StartedAt  string
+	FinishedAt string
+}
+
+const importDateFormat = "2006-01-02"
+
+func parseImport(format, data string) ([]importRecord, error) {
+	switch format {
+	case models.ImportFormatMALXML:
+		return parseMALXML(data)
+	case models.ImportFormatAniListJSON:
+		return parseAniListJSON(data)
+	case models.ImportFormatCSV:
+		return parseImportCSV(data)
+	}
+	return nil, fmt.Errorf("unsupported format %q", format)
+}
+
+// parseMALXML reads the manga list export from myanimelist.net/panel.php?go=export
+func parseMALXML(data string) ([]importRecord, error) {
+	var export struct {
+		Manga []struct {
+			ID         string `xml:"manga_mangadb_id"`
+			Title      string `xml:"manga_title"`
+			Volumes    int    `xml:"my_read_volumes"`
+			Chapters   int    `xml:"my_read_chapters"`
+			StartDate  string `xml:"my_start_date"`
+			FinishDate string `xml:"my_finish_date"`
+			Score      int    `xml:"my_score"`
+			Status     string `xml:"my_status"`
+		} `xml:"manga"`
+	}
+	if err := xml.Unmarshal([]byte(data), &export); err != nil {
+		return nil, fmt.Errorf("invalid MAL XML: %v", err)
+	}
+
+	records := make([]importRecord, 0, len(export.Manga))
+	for _, m := range export.Manga {
+		status, _ := models.ParseReadingStatus(m.Status)
+		records = append(records, importRecord{
+			MALID:      strings.TrimSpace(m.ID),
+			Title:      strings.TrimSpace(m.Title),
+			Status:     status,
+			Chapter:    m.Chapters,
+			Volume:     m.Volumes,
+			Score:      m.Score,
+			StartedAt:  importDate(m.StartDate),
+			FinishedAt: importDate(m.FinishDate),
+		})
+	}
+	return records, nil
+}
+
+type aniListDate struct {
+	Year  int `json:"year"`
+	Month int `json:"month"`
+	Day   int `json:"day"`
+}
+
+func (d aniListDate) String() string {
+	if d.Year == 0 || d.Month == 0 || d.Day == 0 {
+		return ""
+	}
+	return importDate(fmt.Sprintf("%04d-%02d-%02d", d.Year, d.Month, d.Day))
+}
+
+type aniListCollection struct {
+	Lists []struct {
+		Entries []struct {
+			Status          string      `json:"status"`
+			Progress        int         `json:"progress"`
+			ProgressVolumes int         `json:"progressVolumes"`
+			Score           float64     `json:"score"`
+			StartedAt       aniListDate `json:"startedAt"`
+			CompletedAt     aniListDate `json:"completedAt"`
+			Media           struct {
+				IDMal int `json:"idMal"`
+				Title struct {
+					Romaji  string `json:"romaji"`
+					English string `json:"english"`
+				} `json:"title"`
+			} `json:"media"`
+		} `json:"entries"`
+	} `json:"lists"`
+}
+
+// aniListStatuses maps AniList's MediaListStatus values
+var aniListStatuses = map[string]models.ReadingStatus{
+	"CURRENT":   models.StatusReading,
+	"REPEATING": models.StatusReading,
+	"COMPLETED": models.StatusCompleted,
+	"PAUSED":    models.StatusOnHold,
+	"DROPPED":   models.StatusDropped,
+	"PLANNING":  models.StatusPlanToRead,
+}
+
+// parseAniListJSON reads a MediaListCollection query result for type MANGA,
+// either the full GraphQL response or just the collection object.
+func parseAniListJSON(data string) ([]importRecord, error) {
+	var export struct {
+		Data struct {
+			MediaListCollection *aniListCollection `json:"MediaListCollection"`
+		} `json:"data"`
+		aniListCollection
+	}
+	if err := json.Unmarshal([]byte(data), &export); err != nil {
+		return nil, fmt.Errorf("invalid AniList JSON: %v", err)
+	}
+	collection := export.aniListCollection
+	if export.Data.MediaListCollection != nil {
+		collection = *export.Data.MediaListCollection
+	}
+
+	var records []importRecord
+	for _, list := range collection.Lists {
+		for _, e := range list.Entries {
+			title := e.Media.Title.English
+			if title == "" {
+				title = e.Media.Title.Romaji
+			}
+			rec := importRecord{
+				Title:      strings.TrimSpace(title),
+				Status:     aniListStatuses[strings.ToUpper(e.Status)],
+				Chapter:    e.Progress,
+				Volume:     e.ProgressVolumes,
+				Score:      tenPointScore(e.Score),
+				StartedAt:  e.StartedAt.String(),
+				FinishedAt: e.CompletedAt.String(),
+			}
+			if e.Media.IDMal > 0 {
+				rec.MALID = strconv.Itoa(e.Media.IDMal)
+			}
+			records = append(records, rec)
+		}
+	}
+	return records, nil
+}
+
+// tenPointScore converts a score to the 1-10 rating scale. Scores above 10
+// are taken to be on AniList's 100-point format and scaled down.
+func tenPointScore(score float64) int {
+	if score > 10 {
+		score /= 10
+	}
+	n := int(math.Round(score))
+	if n < 0 || n > 10 {
+		return 0
+	}
+	if n == 0 && score > 0 {
+		return 1
+	}
+	return n
+}
+
+// parseImportCSV reads a CSV with a header row. Columns are matched by name;
+// either mal_id (or manga_id) or title is required, the rest are optional.
+func parseImportCSV(data string) ([]importRecord, error) {
+	r := csv.NewReader(strings.NewReader(data))
+	r.TrimLeadingSpace = true
+	r.FieldsPerRecord = -1
+
+	header, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV: missing header row")
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	idColumn := "mal_id"
+	if _, ok := columns[idColumn]; !ok {
+		idColumn = "manga_id"
+	}
+	_, hasID := columns[idColumn]
+	if _, hasTitle := columns["title"]; !hasID && !hasTitle {
+		return nil, fmt.Errorf("invalid CSV: header needs a mal_id, manga_id or title column")
+	}
+
+	var records []importRecord
+	for line := 2; ; line++ {
+		row, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV: %v", err)
+		}
+		field := func(names ...string) string {
+			for _, name := range names {
+				if i, ok := columns[name]; ok && i < len(row) {
+					return strings.TrimSpace(row[i])
+				}
+			}
+			return ""
+		}
+		number := func(names ...string) (int, error) {
+			v := field(names...)
+			if v == "" {
+				return 0, nil
+			}
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return 0, fmt.Errorf("invalid CSV: line %d: %s is not a number", line, v)
+			}
+			return n, nil
+		}
+
+		rec := importRecord{
+			MALID:      field(idColumn),
+			Title:      field("title"),
+			StartedAt:  importDate(field("started_at", "start_date")),
+			FinishedAt: importDate(field("finished_at", "finish_date")),
+		}
+		if rec.MALID == "" && rec.Title == "" {
+			continue
+		}
+		rec.Status, _ = models.ParseReadingStatus(field("status"))
+		if rec.Chapter, err = number("current_chapter", "chapter", "chapters"); err != nil {
+			return nil, err
+		}
+		if rec.Volume, err = number("current_volume", "volume", "volumes"); err != nil {
+			return nil, err
+		}
+		if v := field("score"); v != "" {
+			score, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid CSV: line %d: %s is not a score", line, v)
+			}
+			rec.Score = tenPointScore(score)
+		}
+		records = append(records, rec)
+	}
+	return records, nil
+}
+
+// importDate keeps YYYY-MM-DD dates and drops MAL's 0000-00-00 placeholders
+// and partial dates.
+func importDate(s string) string {
+	d, err := time.Parse(importDateFormat, strings.TrimSpace(s))
+	if err != nil {
+		return ""
+	}
+	return d.Format(importDateFormat)
+}
@@ -0,0 +1,114 @@
+package user
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/bridge"
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/library"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/database"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/models"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// AddTags attaches user-defined tags to a library entry
+func (h *Handler) AddTags(c *gin.Context) {
+	h.changeTags(c, true)
+}
+
+// RemoveTags detaches tags from a library entry
+func (h *Handler) RemoveTags(c *gin.Context) {
+	h.changeTags(c, false)
+}
+
+func (h *Handler) changeTags(c *gin.Context, add bool) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	mangaID := c.Param("manga_id")
+	var req models.LibraryTagsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tags := make([]string, 0, len(req.Tags))
+	for _, tag := range req.Tags {
+		if tag = utils.NormalizeTag(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	if len(tags) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "At least one non-empty tag is required"})
+		return
+	}
+
+	var exists bool
+	checkQuery := `SELECT EXISTS(SELECT 1 FROM user_progress WHERE user_id = ? AND manga_id = ?)`
+	err := database.DB.QueryRow(checkQuery, userID, mangaID).Scan(&exists)
+	if err != nil || !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Manga not in library"})
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	for _, tag := range tags {
+		if add {
+			_, err = tx.Exec(`INSERT OR IGNORE INTO library_tags (user_id, manga_id, tag, created_at) VALUES (?, ?, ?, ?)`,
+				userID, mangaID, tag, now)
+		} else {
+			_, err = tx.Exec(`DELETE FROM library_tags WHERE user_id = ? AND manga_id = ? AND tag = ?`,
+				userID, mangaID, tag)
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update tags"})
+			return
+		}
+	}
+	// Tag changes count as a library change so other devices re-sync the entry
+	if _, err := tx.Exec(`UPDATE user_progress SET updated_at = ? WHERE user_id = ? AND manga_id = ?`, now, userID, mangaID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update tags"})
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update tags"})
+		return
+	}
+
+	current, err := library.LoadTags(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	entryTags := current[mangaID]
+	if entryTags == nil {
+		entryTags = []string{}
+	}
+
+	action := "tags_added"
+	if !add {
+		action = "tags_removed"
+	}
+	h.bridge.NotifyLibraryUpdate(bridge.LibraryUpdateEvent{
+		UserID:  userID,
+		MangaID: mangaID,
+		Action:  action,
+		Tags:    entryTags,
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"manga_id": mangaID,
+		"tags":     entryTags,
+	})
+}
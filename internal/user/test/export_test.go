@@ -0,0 +1,133 @@
+package user_test
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/manga"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/database"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+// setupExportTest imports the sample MAL list, adds a hand-made catalog entry
+// that MAL can't know about, and tags Berserk.
+func setupExportTest(t *testing.T) *gin.Engine {
+	router, _ := setupImportTest(t)
+	importLibrary(t, router, gin.H{"format": "mal-xml", "data": malExport})
+
+	local := models.Manga{ID: "local-1", Title: "Zine"}
+	if err := manga.InsertManga(&local); err != nil {
+		t.Fatalf("insert manga: %v", err)
+	}
+	if _, err := database.DB.Exec(`INSERT INTO user_progress (user_id, manga_id, current_chapter, status, is_favorite)
+                                   VALUES ('u1', 'local-1', 3, 'completed', 1)`); err != nil {
+		t.Fatalf("insert progress: %v", err)
+	}
+	if _, err := database.DB.Exec(`INSERT INTO library_tags (user_id, manga_id, tag) VALUES ('u1', '2', 'dark'), ('u1', '2', 're-read')`); err != nil {
+		t.Fatalf("insert tags: %v", err)
+	}
+	return router
+}
+
+func exportLibrary(t *testing.T, router *gin.Engine, format string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest("GET", "/users/library/export?format="+format, nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	if resp.Code != 200 {
+		t.Fatalf("export %s: expected 200, got %d: %s", format, resp.Code, resp.Body.String())
+	}
+	return resp
+}
+
+func TestExportLibrary_CSVColumnsAndRoundTrip(t *testing.T) {
+	router := setupExportTest(t)
+	resp := exportLibrary(t, router, "csv")
+
+	columns := resp.Header().Get("X-Export-Columns")
+	if columns != "manga_id,title,status,current_chapter,current_volume,score,started_at,finished_at,is_favorite,tags,updated_at" {
+		t.Fatalf("unexpected X-Export-Columns: %q", columns)
+	}
+	if !strings.Contains(resp.Header().Get("Content-Disposition"), "mangahub-library.csv") {
+		t.Fatalf("expected an attachment filename, got %q", resp.Header().Get("Content-Disposition"))
+	}
+
+	records, err := csv.NewReader(strings.NewReader(resp.Body.String())).ReadAll()
+	if err != nil {
+		t.Fatalf("parse csv: %v", err)
+	}
+	if len(records) != 4 || strings.Join(records[0], ",") != columns {
+		t.Fatalf("expected header plus 3 rows, got %v", records)
+	}
+	berserk := records[1]
+	if berserk[0] != "2" || berserk[2] != "reading" || berserk[3] != "120" || berserk[4] != "12" ||
+		berserk[5] != "9" || berserk[6] != "2019-03-01" || berserk[8] != "false" {
+		t.Fatalf("unexpected Berserk row: %v", berserk)
+	}
+	if tags := strings.Split(berserk[9], ";"); len(tags) != 2 {
+		t.Fatalf("expected two tags, got %q", berserk[9])
+	}
+
+	// The export is accepted by the CSV importer as-is
+	database.DB.Exec(`DELETE FROM user_progress WHERE user_id = 'u1'`)
+	result := importLibrary(t, router, gin.H{"format": "csv", "data": resp.Body.String()})
+	if result.Created != 3 || result.Failed != 0 {
+		t.Fatalf("unexpected re-import result: %+v", result)
+	}
+}
+
+func TestExportLibrary_MALXMLSkipsLocalEntries(t *testing.T) {
+	router := setupExportTest(t)
+	body := exportLibrary(t, router, "mal-xml").Body.String()
+
+	for _, want := range []string{
+		"<user_export_type>2</user_export_type>",
+		"<manga_title><![CDATA[Berserk]]></manga_title>",
+		"<my_status>Plan to Read</my_status>",
+		"<my_finish_date>0000-00-00</my_finish_date>",
+	} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("expected %s in export:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, "Zine") {
+		t.Fatalf("entries without a MAL ID must be left out:\n%s", body)
+	}
+
+	database.DB.Exec(`DELETE FROM user_progress WHERE user_id = 'u1'`)
+	result := importLibrary(t, router, gin.H{"format": "mal-xml", "data": body})
+	if result.Created != 2 || result.Entries[0].CurrentChapter != 120 || result.Entries[0].Score != 9 {
+		t.Fatalf("unexpected re-import result: %+v", result)
+	}
+}
+
+func TestExportLibrary_JSON(t *testing.T) {
+	router := setupExportTest(t)
+	resp := exportLibrary(t, router, "")
+
+	var export struct {
+		ExportedAt string                      `json:"exported_at"`
+		Entries    []models.LibraryExportEntry `json:"entries"`
+	}
+	if err := json.Unmarshal(resp.Body.Bytes(), &export); err != nil {
+		t.Fatalf("decode json: %v\n%s", err, resp.Body.String())
+	}
+	if export.ExportedAt == "" || len(export.Entries) != 3 {
+		t.Fatalf("unexpected export: %+v", export)
+	}
+	zine := export.Entries[2]
+	if zine.MangaID != "local-1" || !zine.IsFavorite || zine.Status != models.StatusCompleted || zine.Tags == nil {
+		t.Fatalf("unexpected entry: %+v", zine)
+	}
+
+	req := httptest.NewRequest("GET", "/users/library/export?format=pdf", nil)
+	bad := httptest.NewRecorder()
+	router.ServeHTTP(bad, req)
+	if bad.Code != 400 {
+		t.Fatalf("expected 400 for unknown format, got %d", bad.Code)
+	}
+}
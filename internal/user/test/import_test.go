@@ -0,0 +1,299 @@
+package user_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/bridge"
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/manga"
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/user"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/database"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/logger"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+// fakeSource stands in for MyAnimeList during imports
+type fakeSource struct {
+	manga   map[string]models.Manga
+	fetched []string
+}
+
+func (f *fakeSource) Search(ctx context.Context, query string, limit, offset int) ([]models.Manga, error) {
+	var out []models.Manga
+	for _, m := range f.manga {
+		out = append(out, m)
+	}
+	return out, nil
+}
+
+func (f *fakeSource) GetMangaByID(ctx context.Context, id string) (*models.Manga, error) {
+	f.fetched = append(f.fetched, id)
+	m, ok := f.manga[id]
+	if !ok {
+		return nil, fmt.Errorf("MAL API request failed: 404 Not Found")
+	}
+	return &m, nil
+}
+
+func setupImportTest(t *testing.T) (*gin.Engine, *fakeSource) {
+	tmpDir := t.TempDir()
+	if err := database.InitDatabase(tmpDir + "/test.db"); err != nil {
+		t.Fatalf("init db: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	if _, err := database.DB.Exec(`INSERT INTO users (id, username, email, password_hash) VALUES ('u1', 'reader', 'reader@example.com', 'x')`); err != nil {
+		t.Fatalf("insert user: %v", err)
+	}
+	berserk := models.Manga{ID: "2", Title: "Berserk", NumVolumes: 42}
+	if err := manga.InsertManga(&berserk); err != nil {
+		t.Fatalf("insert manga: %v", err)
+	}
+
+	logger.Init(logger.INFO, false, nil)
+	br := bridge.NewBridge(logger.GetLogger())
+	br.Start()
+	t.Cleanup(br.Stop)
+
+	source := &fakeSource{manga: map[string]models.Manga{
+		"13": {ID: "13", Title: "One Piece", AlternativeTitles: map[string]interface{}{"en": "One Piece"}},
+		"11": {ID: "11", Title: "Naruto", AlternativeTitles: map[string]interface{}{"synonyms": []string{"NARUTO -ナルト-"}}},
+	}}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	// Stand-in for the auth middleware
+	router.Use(func(c *gin.Context) { c.Set("user_id", "u1") })
+	handler := user.NewHandler(br)
+	handler.SetExternalSource(source)
+	router.POST("/users/library/import", handler.ImportLibrary)
+	router.GET("/users/library/export", handler.ExportLibrary)
+	return router, source
+}
+
+func importLibrary(t *testing.T, router *gin.Engine, payload gin.H) models.ImportResult {
+	t.Helper()
+	body, _ := json.Marshal(payload)
+	req := httptest.NewRequest("POST", "/users/library/import", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	if resp.Code != 200 {
+		t.Fatalf("import: expected 200, got %d: %s", resp.Code, resp.Body.String())
+	}
+	var result models.ImportResult
+	if err := json.Unmarshal(resp.Body.Bytes(), &result); err != nil {
+		t.Fatalf("decode result: %v", err)
+	}
+	return result
+}
+
+func libraryCount(t *testing.T) int {
+	t.Helper()
+	var n int
+	database.DB.QueryRow(`SELECT COUNT(*) FROM user_progress WHERE user_id = 'u1'`).Scan(&n)
+	return n
+}
+
+const malExport = `<?xml version="1.0" encoding="UTF-8" ?>
+<myanimelist>
+  <myinfo><user_export_type>2</user_export_type></myinfo>
+  <manga>
+    <manga_mangadb_id>2</manga_mangadb_id>
+    <manga_title><![CDATA[Berserk]]></manga_title>
+    <my_read_volumes>12</my_read_volumes>
+    <my_read_chapters>120</my_read_chapters>
+    <my_start_date>2019-03-01</my_start_date>
+    <my_finish_date>0000-00-00</my_finish_date>
+    <my_score>9</my_score>
+    <my_status>Reading</my_status>
+  </manga>
+  <manga>
+    <manga_mangadb_id>13</manga_mangadb_id>
+    <manga_title><![CDATA[One Piece]]></manga_title>
+    <my_read_volumes>0</my_read_volumes>
+    <my_read_chapters>0</my_read_chapters>
+    <my_start_date>0000-00-00</my_start_date>
+    <my_finish_date>0000-00-00</my_finish_date>
+    <my_score>0</my_score>
+    <my_status>Plan to Read</my_status>
+  </manga>
+  <manga>
+    <manga_mangadb_id>404</manga_mangadb_id>
+    <manga_title><![CDATA[Missing]]></manga_title>
+    <my_status>Dropped</my_status>
+  </manga>
+</myanimelist>`
+
+func TestImportLibrary_MALDryRunThenImport(t *testing.T) {
+	router, source := setupImportTest(t)
+
+	dry := importLibrary(t, router, gin.H{"format": "mal-xml", "data": malExport, "dry_run": true})
+	if !dry.DryRun || dry.Total != 3 || dry.Created != 2 || dry.Failed != 1 {
+		t.Fatalf("unexpected dry run result: %+v", dry)
+	}
+	if libraryCount(t) != 0 {
+		t.Fatalf("dry run wrote to the library")
+	}
+	var cached int
+	database.DB.QueryRow(`SELECT COUNT(*) FROM manga WHERE id = '13'`).Scan(&cached)
+	if cached != 0 {
+		t.Fatalf("dry run added a title to the catalog")
+	}
+
+	result := importLibrary(t, router, gin.H{"format": "mal-xml", "data": malExport})
+	if result.Created != 2 || result.Failed != 1 {
+		t.Fatalf("unexpected import result: %+v", result)
+	}
+	if e := result.Entries[1]; !e.AddedToCatalog || e.MangaID != "13" || e.Status != models.StatusPlanToRead {
+		t.Fatalf("expected One Piece to be added from the external source, got %+v", e)
+	}
+	if e := result.Entries[2]; e.Action != models.ImportFailed || e.Reason == "" {
+		t.Fatalf("expected unknown ID to fail with a reason, got %+v", e)
+	}
+	if len(source.fetched) != 4 {
+		t.Fatalf("expected unknown IDs to be fetched on each run, got %v", source.fetched)
+	}
+
+	var chapter, volume int
+	var status, startedAt, finishedAt string
+	err := database.DB.QueryRow(`SELECT current_chapter, current_volume, status, started_at, finished_at
+                                 FROM user_progress WHERE user_id = 'u1' AND manga_id = '2'`).
+		Scan(&chapter, &volume, &status, &startedAt, &finishedAt)
+	if err != nil {
+		t.Fatalf("read progress: %v", err)
+	}
+	if chapter != 120 || volume != 12 || status != "reading" || startedAt != "2019-03-01" || finishedAt != "" {
+		t.Fatalf("unexpected progress: ch %d vol %d %s %q %q", chapter, volume, status, startedAt, finishedAt)
+	}
+
+	var score int
+	var communityScore float64
+	database.DB.QueryRow(`SELECT score FROM ratings WHERE user_id = 'u1' AND manga_id = '2'`).Scan(&score)
+	database.DB.QueryRow(`SELECT community_score FROM manga WHERE id = '2'`).Scan(&communityScore)
+	if score != 9 || communityScore != 9 {
+		t.Fatalf("expected score 9 and community score 9, got %d and %v", score, communityScore)
+	}
+}
+
+func TestImportLibrary_ConflictPolicies(t *testing.T) {
+	router, _ := setupImportTest(t)
+	if _, err := database.DB.Exec(`INSERT INTO user_progress (user_id, manga_id, current_chapter, status)
+                                   VALUES ('u1', '2', 200, 'reading')`); err != nil {
+		t.Fatalf("insert progress: %v", err)
+	}
+	csvExport := "mal_id,title,status,chapter,volume,score\n2,Berserk,completed,150,40,10\n"
+
+	for _, tc := range []struct {
+		conflict string
+		action   string
+		chapter  int
+	}{
+		{"", models.ImportSkipped, 200},
+		{"skip", models.ImportSkipped, 200},
+		{"overwrite", models.ImportUpdated, 150},
+	} {
+		result := importLibrary(t, router, gin.H{"format": "csv", "data": csvExport, "conflict": tc.conflict})
+		if got := result.Entries[0].Action; got != tc.action {
+			t.Fatalf("conflict %q: expected %s, got %s", tc.conflict, tc.action, got)
+		}
+		var chapter int
+		database.DB.QueryRow(`SELECT current_chapter FROM user_progress WHERE user_id = 'u1' AND manga_id = '2'`).Scan(&chapter)
+		if chapter != tc.chapter {
+			t.Fatalf("conflict %q: expected chapter %d, got %d", tc.conflict, tc.chapter, chapter)
+		}
+	}
+
+	higher := importLibrary(t, router, gin.H{"format": "csv", "data": "mal_id,chapter\n2,151\n"})
+	if higher.Updated != 1 {
+		t.Fatalf("keep_higher: expected further progress to update, got %+v", higher)
+	}
+}
+
+func TestImportLibrary_AniListMatchesTitles(t *testing.T) {
+	router, _ := setupImportTest(t)
+	export := `{"data":{"MediaListCollection":{"lists":[{"entries":[
+        {"status":"COMPLETED","progress":700,"progressVolumes":72,"score":85,
+         "startedAt":{"year":2015,"month":6,"day":1},"completedAt":{"year":2016,"month":1,"day":20},
+         "media":{"title":{"romaji":"NARUTO -ナルト-"}}},
+        {"status":"PAUSED","progress":10,"score":0,"media":{"title":{"romaji":"Berserk"}}}
+    ]}]}}}`
+
+	result := importLibrary(t, router, gin.H{"format": "anilist-json", "data": export})
+	if result.Created != 2 {
+		t.Fatalf("unexpected import result: %+v", result)
+	}
+	naruto := result.Entries[0]
+	if naruto.MangaID != "11" || naruto.Status != models.StatusCompleted || naruto.Score != 9 || naruto.FinishedAt != "2016-01-20" {
+		t.Fatalf("unexpected AniList entry: %+v", naruto)
+	}
+	if berserk := result.Entries[1]; berserk.MangaID != "2" || berserk.AddedToCatalog || berserk.Status != models.StatusOnHold {
+		t.Fatalf("expected Berserk to match the local catalog, got %+v", berserk)
+	}
+}
+
+func TestImportLibrary_RejectsMalformedFiles(t *testing.T) {
+	router, _ := setupImportTest(t)
+	for _, payload := range []gin.H{
+		{"format": "mal-xml", "data": "<myanimelist><manga>"},
+		{"format": "anilist-json", "data": "{"},
+		{"format": "csv", "data": "status,chapter\nreading,1\n"},
+		{"format": "csv", "data": "mal_id,chapter\n2,many\n"},
+		{"format": "kitsu", "data": "x"},
+	} {
+		body, _ := json.Marshal(payload)
+		req := httptest.NewRequest("POST", "/users/library/import", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		if resp.Code != 400 {
+			t.Fatalf("%v: expected 400, got %d: %s", payload["format"], resp.Code, resp.Body.String())
+		}
+	}
+	if libraryCount(t) != 0 {
+		t.Fatalf("malformed imports wrote to the library")
+	}
+}
+
+func TestImportLibrary_Limits(t *testing.T) {
+	router, source := setupImportTest(t)
+	post := func(body []byte) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/users/library/import", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	huge, _ := json.Marshal(gin.H{"format": "csv", "data": "mal_id\n" + strings.Repeat("2\n", 6<<20)})
+	if resp := post(huge); resp.Code != 413 {
+		t.Fatalf("oversized body: expected 413, got %d", resp.Code)
+	}
+	many, _ := json.Marshal(gin.H{"format": "csv", "data": "mal_id\n" + strings.Repeat("2\n", 2001)})
+	if resp := post(many); resp.Code != 413 {
+		t.Fatalf("too many entries: expected 413, got %d", resp.Code)
+	}
+
+	// Only a bounded number of unknown titles are fetched per request; the
+	// rest are reported for a later retry
+	var csvExport strings.Builder
+	csvExport.WriteString("mal_id\n")
+	for i := 0; i < 30; i++ {
+		fmt.Fprintf(&csvExport, "%d\n", 1000+i)
+	}
+	result := importLibrary(t, router, gin.H{"format": "csv", "data": csvExport.String()})
+	if len(source.fetched) != 25 || result.Failed != 30 {
+		t.Fatalf("expected 25 lookups and 30 failures, got %d lookups: %+v", len(source.fetched), result)
+	}
+	for _, entry := range result.Entries[25:] {
+		if !strings.HasPrefix(entry.Reason, "unknown, retry") {
+			t.Fatalf("expected a retry reason past the lookup limit, got %+v", entry)
+		}
+	}
+}
@@ -0,0 +1,320 @@
+package user_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/bridge"
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/manga"
+	"github.com/binhbb2204/Manga-Hub-Group13/internal/user"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/database"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/logger"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+type recordingBroadcaster struct {
+	mu     sync.Mutex
+	events []bridge.BroadcastEvent
+}
+
+func (r *recordingBroadcaster) BroadcastToUser(userID string, event bridge.BroadcastEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+func setupLibraryTest(t *testing.T) (*gin.Engine, *recordingBroadcaster) {
+	tmpDir := t.TempDir()
+	if err := database.InitDatabase(tmpDir + "/test.db"); err != nil {
+		t.Fatalf("init db: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	if _, err := database.DB.Exec(`INSERT INTO users (id, username, email, password_hash) VALUES ('u1', 'reader', 'reader@example.com', 'x')`); err != nil {
+		t.Fatalf("insert user: %v", err)
+	}
+	for _, m := range []models.Manga{{ID: "1", Title: "Berserk"}, {ID: "2", Title: "Solo Leveling"}} {
+		m := m
+		if err := manga.InsertManga(&m); err != nil {
+			t.Fatalf("insert manga: %v", err)
+		}
+		if _, err := database.DB.Exec(`INSERT INTO user_progress (user_id, manga_id, status) VALUES ('u1', ?, 'reading')`, m.ID); err != nil {
+			t.Fatalf("insert progress: %v", err)
+		}
+	}
+
+	logger.Init(logger.INFO, false, nil)
+	br := bridge.NewBridge(logger.GetLogger())
+	br.Start()
+	t.Cleanup(br.Stop)
+	recorder := &recordingBroadcaster{}
+	br.SetUDPBroadcaster(recorder)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	// Stand-in for the auth middleware
+	router.Use(func(c *gin.Context) { c.Set("user_id", "u1") })
+	handler := user.NewHandler(br)
+	router.GET("/users/library", handler.GetLibrary)
+	router.POST("/users/library/:manga_id/tags", handler.AddTags)
+	router.DELETE("/users/library/:manga_id/tags", handler.RemoveTags)
+	router.POST("/users/library", handler.AddToLibrary)
+	router.PUT("/users/library/:manga_id/favorite", handler.SetFavorite)
+	router.PUT("/users/progress", handler.UpdateProgress)
+	router.GET("/users/progress/:manga_id", handler.GetProgress)
+	return router, recorder
+}
+
+func changeTags(t *testing.T, router *gin.Engine, method, mangaID string, tags ...string) []string {
+	payload, _ := json.Marshal(gin.H{"tags": tags})
+	req := httptest.NewRequest(method, "/users/library/"+mangaID+"/tags", bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	if resp.Code != 200 {
+		t.Fatalf("%s tags: expected 200, got %d: %s", method, resp.Code, resp.Body.String())
+	}
+	var result struct {
+		Tags []string `json:"tags"`
+	}
+	json.Unmarshal(resp.Body.Bytes(), &result)
+	return result.Tags
+}
+
+func getLibrary(t *testing.T, router *gin.Engine, query string) models.UserLibrary {
+	req := httptest.NewRequest("GET", "/users/library"+query, nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	if resp.Code != 200 {
+		t.Fatalf("library: expected 200, got %d: %s", resp.Code, resp.Body.String())
+	}
+	var library models.UserLibrary
+	json.Unmarshal(resp.Body.Bytes(), &library)
+	return library
+}
+
+func TestLibraryTags_AddRemoveAndFilter(t *testing.T) {
+	router, recorder := setupLibraryTest(t)
+
+	tags := changeTags(t, router, "POST", "1", "Re read", "gift-from-anna")
+	if len(tags) != 2 || tags[0] != "gift-from-anna" || tags[1] != "re-read" {
+		t.Fatalf("expected normalized tags, got %v", tags)
+	}
+	changeTags(t, router, "POST", "2", "webtoon", "re-read")
+
+	library := getLibrary(t, router, "?tag=webtoon")
+	if len(library.Reading) != 1 || library.Reading[0].Manga.ID != "2" {
+		t.Fatalf("expected only manga 2 for tag webtoon, got %+v", library.Reading)
+	}
+
+	library = getLibrary(t, router, "?tag=RE-READ")
+	if len(library.Reading) != 2 {
+		t.Errorf("expected tag filter to be case-insensitive, got %d entries", len(library.Reading))
+	}
+
+	tags = changeTags(t, router, "DELETE", "1", "re-read")
+	if len(tags) != 1 || tags[0] != "gift-from-anna" {
+		t.Errorf("expected one tag left, got %v", tags)
+	}
+
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	if len(recorder.events) != 3 {
+		t.Fatalf("expected a library_update event per tag change, got %d", len(recorder.events))
+	}
+	data, _ := recorder.events[2].Data.(map[string]interface{})
+	if data["action"] != "tags_removed" {
+		t.Errorf("expected tags_removed action, got %v", data["action"])
+	}
+}
+
+func TestLibraryTags_RequiresLibraryEntry(t *testing.T) {
+	router, _ := setupLibraryTest(t)
+
+	payload, _ := json.Marshal(gin.H{"tags": []string{"webtoon"}})
+	req := httptest.NewRequest("POST", "/users/library/999/tags", bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	if resp.Code != 404 {
+		t.Errorf("expected 404, got %d", resp.Code)
+	}
+}
+
+func setFavorite(t *testing.T, router *gin.Engine, mangaID string, favorite bool, want int) {
+	payload, _ := json.Marshal(gin.H{"is_favorite": favorite})
+	req := httptest.NewRequest("PUT", "/users/library/"+mangaID+"/favorite", bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	if resp.Code != want {
+		t.Fatalf("favorite: expected %d, got %d: %s", want, resp.Code, resp.Body.String())
+	}
+}
+
+func TestLibraryFavorites(t *testing.T) {
+	router, recorder := setupLibraryTest(t)
+
+	setFavorite(t, router, "1", true, 200)
+	setFavorite(t, router, "999", true, 404)
+
+	library := getLibrary(t, router, "")
+	if len(library.Favorites) != 1 || library.Favorites[0].Manga.ID != "1" {
+		t.Fatalf("expected manga 1 in favorites, got %+v", library.Favorites)
+	}
+	if len(library.Reading) != 2 {
+		t.Errorf("favorites should still appear in their status bucket, got %d reading", len(library.Reading))
+	}
+
+	// Re-adding without the flag keeps the favorite
+	payload, _ := json.Marshal(gin.H{"manga_id": "1", "status": "completed"})
+	req := httptest.NewRequest("POST", "/users/library", bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	library = getLibrary(t, router, "")
+	if len(library.Favorites) != 1 || library.Favorites[0].Status != "completed" {
+		t.Errorf("expected favorite to survive a status change, got %+v", library.Favorites)
+	}
+
+	setFavorite(t, router, "1", false, 200)
+	if library = getLibrary(t, router, ""); len(library.Favorites) != 0 {
+		t.Errorf("expected no favorites, got %+v", library.Favorites)
+	}
+
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	actions := []string{}
+	for _, e := range recorder.events {
+		data, _ := e.Data.(map[string]interface{})
+		actions = append(actions, data["action"].(string))
+	}
+	if len(actions) != 3 || actions[0] != "favorited" || actions[2] != "unfavorited" {
+		t.Errorf("expected favorited/added/unfavorited broadcasts, got %v", actions)
+	}
+}
+
+func TestLibraryStatuses_OnHoldAndDropped(t *testing.T) {
+	router, _ := setupLibraryTest(t)
+
+	for mangaID, status := range map[string]string{"1": "on_hold", "2": "dropped"} {
+		payload, _ := json.Marshal(gin.H{"manga_id": mangaID, "status": status})
+		req := httptest.NewRequest("POST", "/users/library", bytes.NewReader(payload))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		if resp.Code != 201 && resp.Code != 200 {
+			t.Fatalf("add %s: expected success, got %d: %s", status, resp.Code, resp.Body.String())
+		}
+	}
+
+	library := getLibrary(t, router, "")
+	if len(library.OnHold) != 1 || library.OnHold[0].Manga.ID != "1" {
+		t.Errorf("expected manga 1 on hold, got %+v", library.OnHold)
+	}
+	if len(library.Dropped) != 1 || library.Dropped[0].Manga.ID != "2" {
+		t.Errorf("expected manga 2 dropped, got %+v", library.Dropped)
+	}
+	if len(library.Reading) != 0 {
+		t.Errorf("expected no reading entries, got %+v", library.Reading)
+	}
+}
+
+func TestLibraryStatuses_MigratesLegacyValues(t *testing.T) {
+	dbPath := t.TempDir() + "/test.db"
+	if err := database.InitDatabase(dbPath); err != nil {
+		t.Fatalf("init db: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	database.DB.Exec(`INSERT INTO users (id, username, email, password_hash) VALUES ('u1', 'reader', 'reader@example.com', 'x')`)
+	legacy := map[string]struct {
+		status  string
+		chapter int
+	}{
+		"1": {"on-hold", 3},
+		"2": {"", 12},
+		"3": {"", 0},
+		"4": {"Plan to Read", 0},
+	}
+	for id, row := range legacy {
+		m := models.Manga{ID: id, Title: "Manga " + id}
+		if err := manga.InsertManga(&m); err != nil {
+			t.Fatalf("insert manga: %v", err)
+		}
+		database.DB.Exec(`INSERT INTO user_progress (user_id, manga_id, current_chapter, status) VALUES ('u1', ?, ?, ?)`,
+			id, row.chapter, row.status)
+	}
+
+	database.Close()
+	if err := database.InitDatabase(dbPath); err != nil {
+		t.Fatalf("reopen db: %v", err)
+	}
+
+	want := map[string]models.ReadingStatus{
+		"1": models.StatusOnHold,
+		"2": models.StatusReading,
+		"3": models.StatusPlanToRead,
+		"4": models.StatusPlanToRead,
+	}
+	for id, status := range want {
+		var got models.ReadingStatus
+		database.DB.QueryRow(`SELECT status FROM user_progress WHERE manga_id = ?`, id).Scan(&got)
+		if got != status {
+			t.Errorf("manga %s: expected %q, got %q", id, status, got)
+		}
+	}
+}
+
+func updateProgress(t *testing.T, router *gin.Engine, body gin.H, want int) {
+	payload, _ := json.Marshal(body)
+	req := httptest.NewRequest("PUT", "/users/progress", bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	if resp.Code != want {
+		t.Fatalf("update progress: expected %d, got %d: %s", want, resp.Code, resp.Body.String())
+	}
+}
+
+func TestProgressVolume(t *testing.T) {
+	router, recorder := setupLibraryTest(t)
+	database.DB.Exec(`UPDATE manga SET num_volumes = 41 WHERE id = '1'`)
+
+	updateProgress(t, router, gin.H{"manga_id": "1", "current_chapter": 120, "current_volume": 14}, 200)
+	updateProgress(t, router, gin.H{"manga_id": "1", "current_chapter": 130, "current_volume": 42}, 400)
+	// Omitting the volume keeps the stored one
+	updateProgress(t, router, gin.H{"manga_id": "1", "current_chapter": 125}, 200)
+	// Unknown volume counts accept any volume
+	updateProgress(t, router, gin.H{"manga_id": "2", "current_chapter": 200, "current_volume": 99}, 200)
+
+	req := httptest.NewRequest("GET", "/users/progress/1", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	var progress models.UserProgress
+	json.Unmarshal(resp.Body.Bytes(), &progress)
+	if resp.Code != 200 || progress.CurrentVolume != 14 || progress.CurrentChapter != 125 {
+		t.Fatalf("expected Vol. 14, Ch. 125, got %d %+v", resp.Code, progress)
+	}
+
+	library := getLibrary(t, router, "")
+	for _, mp := range library.Reading {
+		if mp.Manga.ID == "2" && mp.CurrentVolume != 99 {
+			t.Errorf("expected volume 99 in library, got %d", mp.CurrentVolume)
+		}
+	}
+
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	if len(recorder.events) == 0 {
+		t.Fatal("expected progress broadcasts")
+	}
+	data, _ := recorder.events[0].Data.(map[string]interface{})
+	if data["volume"] != 14 {
+		t.Errorf("expected volume 14 in progress event, got %v", data["volume"])
+	}
+}
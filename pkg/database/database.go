@@ -8,6 +8,8 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/models"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/utils"
 	_ "github.com/mattn/go-sqlite3"
 )
 
@@ -22,8 +24,15 @@ func InitDatabase(dbPath string) error {
 		}
 	}
 
+	// _foreign_keys makes the driver enable them on every pooled
+	// connection, so ON DELETE CASCADE always runs
+	dsn := dbPath + "?_foreign_keys=on"
+	if strings.Contains(dbPath, "?") {
+		dsn = dbPath + "&_foreign_keys=on"
+	}
+
 	var err error
-	DB, err = sql.Open("sqlite3", dbPath)
+	DB, err = sql.Open("sqlite3", dsn)
 	if err != nil {
 		return fmt.Errorf("failed to open database: %w", err)
 	}
@@ -33,11 +42,6 @@ func InitDatabase(dbPath string) error {
 	}
 	log.Println("Database connection established")
 
-	// Enable foreign key support for SQLite (useful for cascading deletes, etc.)
-	if _, err := DB.Exec("PRAGMA foreign_keys = ON;"); err != nil {
-		log.Printf("Warning: failed to enable foreign keys: %v", err)
-	}
-
 	if err = createTables(); err != nil {
 		return fmt.Errorf("failed to create tables: %w", err)
 	}
@@ -78,9 +82,299 @@ func createTables() error {
         FOREIGN KEY (manga_id) REFERENCES manga(id) ON DELETE CASCADE
     );
 
+    CREATE TABLE IF NOT EXISTS library_tags (
+        user_id TEXT NOT NULL,
+        manga_id TEXT NOT NULL,
+        tag TEXT NOT NULL,
+        created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+        PRIMARY KEY (user_id, manga_id, tag),
+        FOREIGN KEY (user_id, manga_id) REFERENCES user_progress(user_id, manga_id) ON DELETE CASCADE
+    );
+
+    CREATE TABLE IF NOT EXISTS lists (
+        id TEXT PRIMARY KEY,
+        user_id TEXT NOT NULL,
+        name TEXT NOT NULL,
+        description TEXT DEFAULT '',
+        is_public INTEGER DEFAULT 0,
+        share_id TEXT UNIQUE,
+        created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+        updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+        FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+    );
+
+    CREATE TABLE IF NOT EXISTS list_items (
+        list_id TEXT NOT NULL,
+        manga_id TEXT NOT NULL,
+        position INTEGER NOT NULL,
+        added_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+        PRIMARY KEY (list_id, manga_id),
+        FOREIGN KEY (list_id) REFERENCES lists(id) ON DELETE CASCADE,
+        FOREIGN KEY (manga_id) REFERENCES manga(id) ON DELETE CASCADE
+    );
+
+    CREATE TABLE IF NOT EXISTS progress_history (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        user_id TEXT NOT NULL,
+        manga_id TEXT NOT NULL,
+        chapter INTEGER NOT NULL,
+        chapters_read INTEGER NOT NULL DEFAULT 0,
+        status TEXT NOT NULL,
+        recorded_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+        FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+        FOREIGN KEY (manga_id) REFERENCES manga(id) ON DELETE CASCADE
+    );
+
+    CREATE TABLE IF NOT EXISTS reading_days (
+        user_id TEXT NOT NULL,
+        day TEXT NOT NULL,
+        chapters INTEGER NOT NULL DEFAULT 0,
+        PRIMARY KEY (user_id, day),
+        FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+    );
+
+    CREATE TABLE IF NOT EXISTS goals (
+        id TEXT PRIMARY KEY,
+        user_id TEXT NOT NULL,
+        type TEXT NOT NULL,
+        target INTEGER NOT NULL CHECK (target > 0),
+        genre TEXT DEFAULT '',
+        start_date TEXT NOT NULL,
+        end_date TEXT NOT NULL,
+        pace TEXT DEFAULT '',
+        achieved_at TIMESTAMP,
+        created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+        FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+    );
+
+    CREATE TABLE IF NOT EXISTS achievements (
+        user_id TEXT NOT NULL,
+        achievement_id TEXT NOT NULL,
+        unlocked_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+        PRIMARY KEY (user_id, achievement_id),
+        FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+    );
+
+    CREATE TABLE IF NOT EXISTS mal_accounts (
+        user_id TEXT PRIMARY KEY,
+        mal_username TEXT DEFAULT '',
+        access_token TEXT NOT NULL,
+        refresh_token TEXT NOT NULL,
+        expires_at TIMESTAMP NOT NULL,
+        last_synced_at TIMESTAMP,
+        last_error TEXT DEFAULT '',
+        linked_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+        FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+    );
+
+    CREATE TABLE IF NOT EXISTS mal_link_requests (
+        state TEXT PRIMARY KEY,
+        user_id TEXT NOT NULL,
+        code_verifier TEXT NOT NULL,
+        expires_at TIMESTAMP NOT NULL,
+        FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+    );
+
+    CREATE TABLE IF NOT EXISTS refresh_tokens (
+        token_hash TEXT PRIMARY KEY,
+        user_id TEXT NOT NULL,
+        family_id TEXT NOT NULL,
+        expires_at TIMESTAMP NOT NULL,
+        created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+        revoked_at TIMESTAMP,
+        FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+    );
+
+    CREATE TABLE IF NOT EXISTS revoked_tokens (
+        jti TEXT PRIMARY KEY,
+        user_id TEXT NOT NULL,
+        expires_at TIMESTAMP NOT NULL,
+        revoked_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+    );
+
+    CREATE TABLE IF NOT EXISTS password_resets (
+        token_hash TEXT PRIMARY KEY,
+        user_id TEXT NOT NULL,
+        expires_at TIMESTAMP NOT NULL,
+        used_at TIMESTAMP,
+        created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+        FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+    );
+
+    CREATE TABLE IF NOT EXISTS email_verifications (
+        token_hash TEXT PRIMARY KEY,
+        user_id TEXT NOT NULL,
+        email TEXT NOT NULL,
+        expires_at TIMESTAMP NOT NULL,
+        created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+        FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+    );
+
+    CREATE TABLE IF NOT EXISTS login_attempts (
+        attempt_key TEXT PRIMARY KEY,
+        failures INTEGER NOT NULL DEFAULT 0,
+        locked_until TIMESTAMP,
+        last_failure_at TIMESTAMP NOT NULL
+    );
+
+    CREATE TABLE IF NOT EXISTS auth_events (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        event TEXT NOT NULL,
+        user_id TEXT,
+        ip TEXT,
+        detail TEXT,
+        created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+    );
+
+    CREATE TABLE IF NOT EXISTS personal_access_tokens (
+        id TEXT PRIMARY KEY,
+        user_id TEXT NOT NULL,
+        name TEXT NOT NULL,
+        token_hash TEXT NOT NULL UNIQUE,
+        scopes TEXT NOT NULL,
+        created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+        expires_at TIMESTAMP,
+        last_used_at TIMESTAMP,
+        revoked_at TIMESTAMP,
+        FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+    );
+
+    CREATE TABLE IF NOT EXISTS recovery_codes (
+        code_hash TEXT PRIMARY KEY,
+        user_id TEXT NOT NULL,
+        used_at TIMESTAMP,
+        created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+        FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+    );
+
+    CREATE TABLE IF NOT EXISTS mfa_challenges (
+        token_hash TEXT PRIMARY KEY,
+        user_id TEXT NOT NULL,
+        attempts INTEGER NOT NULL DEFAULT 0,
+        expires_at TIMESTAMP NOT NULL,
+        created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+        FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+    );
+
+    CREATE TABLE IF NOT EXISTS user_identities (
+        issuer TEXT NOT NULL,
+        subject TEXT NOT NULL,
+        user_id TEXT NOT NULL,
+        email TEXT,
+        created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+        last_login_at TIMESTAMP,
+        PRIMARY KEY (issuer, subject),
+        FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+    );
+
+    CREATE TABLE IF NOT EXISTS oidc_logins (
+        state TEXT PRIMARY KEY,
+        nonce TEXT NOT NULL,
+        code_verifier TEXT NOT NULL,
+        user_code TEXT,
+        expires_at TIMESTAMP NOT NULL,
+        created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+    );
+
+    CREATE TABLE IF NOT EXISTS oidc_device_codes (
+        device_code_hash TEXT PRIMARY KEY,
+        user_code TEXT NOT NULL UNIQUE,
+        user_id TEXT,
+        ip TEXT,
+        user_agent TEXT,
+        device_name TEXT,
+        expires_at TIMESTAMP NOT NULL,
+        created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+        FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+    );
+
+    CREATE TABLE IF NOT EXISTS login_sessions (
+        id TEXT PRIMARY KEY,
+        user_id TEXT NOT NULL,
+        ip TEXT,
+        user_agent TEXT,
+        device_name TEXT,
+        created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+        last_seen_at TIMESTAMP,
+        revoked_at TIMESTAMP,
+        FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+    );
+
+    CREATE TABLE IF NOT EXISTS live_connections (
+        id TEXT PRIMARY KEY,
+        session_id TEXT NOT NULL,
+        user_id TEXT NOT NULL,
+        transport TEXT NOT NULL,
+        remote_addr TEXT NOT NULL,
+        device_type TEXT,
+        device_name TEXT,
+        connected_at TIMESTAMP NOT NULL,
+        last_seen_at TIMESTAMP NOT NULL,
+        FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+    );
+
+    CREATE TABLE IF NOT EXISTS manga_alt_titles (
+        manga_id TEXT NOT NULL,
+        language TEXT NOT NULL,
+        title TEXT NOT NULL,
+        normalized_title TEXT NOT NULL,
+        PRIMARY KEY (manga_id, language, title),
+        FOREIGN KEY (manga_id) REFERENCES manga(id) ON DELETE CASCADE
+    );
+
+    CREATE TABLE IF NOT EXISTS ratings (
+        user_id TEXT NOT NULL,
+        manga_id TEXT NOT NULL,
+        score INTEGER NOT NULL CHECK (score BETWEEN 1 AND 10),
+        created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+        updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+        PRIMARY KEY (user_id, manga_id),
+        FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+        FOREIGN KEY (manga_id) REFERENCES manga(id) ON DELETE CASCADE
+    );
+
+    CREATE TABLE IF NOT EXISTS reviews (
+        user_id TEXT NOT NULL,
+        manga_id TEXT NOT NULL,
+        body TEXT NOT NULL,
+        spoiler INTEGER DEFAULT 0,
+        created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+        updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+        PRIMARY KEY (user_id, manga_id),
+        FOREIGN KEY (user_id, manga_id) REFERENCES ratings(user_id, manga_id) ON DELETE CASCADE
+    );
+
+    CREATE TABLE IF NOT EXISTS chapter_comments (
+        id TEXT PRIMARY KEY,
+        manga_id TEXT NOT NULL,
+        chapter INTEGER NOT NULL,
+        user_id TEXT NOT NULL,
+        parent_id TEXT,
+        body TEXT NOT NULL,
+        created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+        FOREIGN KEY (manga_id) REFERENCES manga(id) ON DELETE CASCADE,
+        FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+        FOREIGN KEY (parent_id) REFERENCES chapter_comments(id) ON DELETE CASCADE
+    );
+
     CREATE INDEX IF NOT EXISTS idx_manga_title ON manga(title);
     CREATE INDEX IF NOT EXISTS idx_manga_author ON manga(author);
     CREATE INDEX IF NOT EXISTS idx_user_progress_user ON user_progress(user_id);
+    CREATE INDEX IF NOT EXISTS idx_library_tags_tag ON library_tags(user_id, tag);
+    CREATE INDEX IF NOT EXISTS idx_lists_user ON lists(user_id);
+    CREATE INDEX IF NOT EXISTS idx_list_items_position ON list_items(list_id, position);
+    CREATE INDEX IF NOT EXISTS idx_progress_history_user ON progress_history(user_id, recorded_at);
+    CREATE INDEX IF NOT EXISTS idx_goals_user ON goals(user_id, end_date);
+    CREATE INDEX IF NOT EXISTS idx_manga_alt_titles_normalized ON manga_alt_titles(normalized_title);
+    CREATE INDEX IF NOT EXISTS idx_ratings_manga ON ratings(manga_id, updated_at);
+    CREATE INDEX IF NOT EXISTS idx_chapter_comments_thread ON chapter_comments(manga_id, chapter, created_at);
+    CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family ON refresh_tokens(family_id);
+    CREATE INDEX IF NOT EXISTS idx_auth_events_user ON auth_events(user_id);
+    CREATE INDEX IF NOT EXISTS idx_personal_access_tokens_user ON personal_access_tokens(user_id);
+    CREATE INDEX IF NOT EXISTS idx_recovery_codes_user ON recovery_codes(user_id);
+    CREATE INDEX IF NOT EXISTS idx_user_identities_user ON user_identities(user_id);
+    CREATE INDEX IF NOT EXISTS idx_login_sessions_user ON login_sessions(user_id);
+    CREATE INDEX IF NOT EXISTS idx_live_connections_session ON live_connections(session_id);
     `
 
 	_, err := DB.Exec(schema)
@@ -91,32 +385,177 @@ func createTables() error {
 	if err := ensureUserEmailColumn(); err != nil {
 		return err
 	}
-	return nil
+	if err := ensureColumn("users", "preferred_title_language", "TEXT DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := ensureColumn("manga", "normalized_title", "TEXT"); err != nil {
+		return err
+	}
+	if err := ensureColumn("manga", "num_volumes", "INTEGER DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := ensureColumn("manga", "start_date", "TEXT DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := ensureColumn("manga", "mean", "REAL DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := ensureColumn("user_progress", "is_favorite", "INTEGER DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := ensureColumn("user_progress", "current_volume", "INTEGER DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := ensureColumn("user_progress", "started_at", "TEXT DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := ensureColumn("user_progress", "finished_at", "TEXT DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := ensureColumn("users", "role", "TEXT NOT NULL DEFAULT 'user'"); err != nil {
+		return err
+	}
+	if err := ensureColumn("users", "email_verified", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := ensureColumn("users", "totp_secret", "TEXT"); err != nil {
+		return err
+	}
+	if err := ensureColumn("users", "totp_enabled", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := ensureColumn("users", "totp_last_step", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := ensureColumn("users", "stats_version", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := ensureColumn("manga", "community_score", "REAL DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := ensureColumn("manga", "community_score_count", "INTEGER DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := ensureColumn("manga", "cached_at", "TIMESTAMP"); err != nil {
+		return err
+	}
+	if err := backfillNormalizedTitles(); err != nil {
+		return err
+	}
+	return normalizeReadingStatuses()
 }
 
 func ensureUserEmailColumn() error {
-	rows, err := DB.Query(`PRAGMA table_info(users);`)
+	hasEmail, err := hasColumn("users", "email")
+	if err != nil {
+		return err
+	}
+	if !hasEmail {
+		if _, err := DB.Exec(`ALTER TABLE users ADD COLUMN email TEXT UNIQUE;`); err != nil {
+			log.Printf("Warning: adding email column failed: %v", err)
+		}
+	}
+	return nil
+}
+
+// ensureColumn adds a column to an existing table when an older database
+// predates it.
+func ensureColumn(table, column, definition string) error {
+	exists, err := hasColumn(table, column)
 	if err != nil {
 		return err
 	}
+	if exists {
+		return nil
+	}
+	if _, err := DB.Exec(fmt.Sprintf(`ALTER TABLE %s ADD COLUMN %s %s;`, table, column, definition)); err != nil {
+		return fmt.Errorf("failed to add %s.%s: %w", table, column, err)
+	}
+	return nil
+}
+
+func hasColumn(table, column string) (bool, error) {
+	rows, err := DB.Query(fmt.Sprintf(`PRAGMA table_info(%s);`, table))
+	if err != nil {
+		return false, err
+	}
 	defer rows.Close()
-	hasEmail := false
 	for rows.Next() {
 		var cid int
 		var name, ctype string
 		var notnull, pk int
 		var dflt interface{}
 		if err := rows.Scan(&cid, &name, &ctype, &notnull, &dflt, &pk); err != nil {
+			return false, err
+		}
+		if strings.EqualFold(name, column) {
+			return true, nil
+		}
+	}
+	return false, rows.Err()
+}
+
+// backfillNormalizedTitles fills manga.normalized_title for rows written
+// before title normalization existed.
+func backfillNormalizedTitles() error {
+	rows, err := DB.Query(`SELECT id, title FROM manga WHERE normalized_title IS NULL`)
+	if err != nil {
+		return err
+	}
+	pending := map[string]string{}
+	for rows.Next() {
+		var id, title string
+		if err := rows.Scan(&id, &title); err != nil {
+			rows.Close()
 			return err
 		}
-		if strings.EqualFold(name, "email") {
-			hasEmail = true
-			break
+		pending[id] = title
+	}
+	rows.Close()
+
+	for id, title := range pending {
+		if _, err := DB.Exec(`UPDATE manga SET normalized_title = ? WHERE id = ?`, utils.NormalizeTitle(title), id); err != nil {
+			return err
 		}
 	}
-	if !hasEmail {
-		if _, err := DB.Exec(`ALTER TABLE users ADD COLUMN email TEXT UNIQUE;`); err != nil {
-			log.Printf("Warning: adding email column failed: %v", err)
+	return nil
+}
+
+// normalizeReadingStatuses rewrites user_progress.status values that are not
+// one of models.ReadingStatuses, such as "on-hold" or the empty status older
+// sync_progress calls could store. Anything unrecognized becomes "reading"
+// when the user has started the manga and "plan_to_read" otherwise.
+func normalizeReadingStatuses() error {
+	valid := make([]interface{}, len(models.ReadingStatuses))
+	for i, status := range models.ReadingStatuses {
+		valid[i] = string(status)
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(valid)), ", ")
+
+	rows, err := DB.Query(`SELECT DISTINCT COALESCE(status, '') FROM user_progress WHERE status IS NULL OR status NOT IN (`+placeholders+`)`, valid...)
+	if err != nil {
+		return err
+	}
+	var stale []string
+	for rows.Next() {
+		var status string
+		if err := rows.Scan(&status); err != nil {
+			rows.Close()
+			return err
+		}
+		stale = append(stale, status)
+	}
+	rows.Close()
+
+	for _, old := range stale {
+		if status, ok := models.ParseReadingStatus(old); ok {
+			_, err = DB.Exec(`UPDATE user_progress SET status = ? WHERE COALESCE(status, '') = ?`, status, old)
+		} else {
+			_, err = DB.Exec(`UPDATE user_progress SET status = CASE WHEN current_chapter > 0 THEN ? ELSE ? END
+                              WHERE COALESCE(status, '') = ?`, models.StatusReading, models.StatusPlanToRead, old)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to migrate reading status %q: %w", old, err)
 		}
 	}
 	return nil
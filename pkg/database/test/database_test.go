@@ -0,0 +1,46 @@
+package database_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/database"
+)
+
+func TestForeignKeysOnEveryConnection(t *testing.T) {
+	if err := database.InitDatabase(t.TempDir() + "/test.db"); err != nil {
+		t.Fatalf("init db: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	// Holding one connection makes the pool open another
+	ctx := context.Background()
+	first, err := database.DB.Conn(ctx)
+	if err != nil {
+		t.Fatalf("first conn: %v", err)
+	}
+	defer first.Close()
+	second, err := database.DB.Conn(ctx)
+	if err != nil {
+		t.Fatalf("second conn: %v", err)
+	}
+	defer second.Close()
+
+	for _, stmt := range []string{
+		`INSERT INTO users (id, username, email, password_hash) VALUES ('u1', 'reader', 'reader@example.com', 'x')`,
+		`INSERT INTO manga (id, title) VALUES ('1', 'Berserk')`,
+		`INSERT INTO user_progress (user_id, manga_id) VALUES ('u1', '1')`,
+		`INSERT INTO library_tags (user_id, manga_id, tag) VALUES ('u1', '1', 'webtoon')`,
+		`DELETE FROM user_progress WHERE user_id = 'u1' AND manga_id = '1'`,
+	} {
+		if _, err := second.ExecContext(ctx, stmt); err != nil {
+			t.Fatalf("%s: %v", stmt, err)
+		}
+	}
+
+	var left int
+	second.QueryRowContext(ctx, `SELECT COUNT(*) FROM library_tags`).Scan(&left)
+	if left != 0 {
+		t.Fatalf("delete did not cascade on a second pooled connection")
+	}
+}
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// ChapterComment is a comment in the discussion thread of a single chapter.
+// Hidden comments have their body removed because the caller has not read
+// that far yet.
+type ChapterComment struct {
+	ID        string           `json:"id" db:"id"`
+	MangaID   string           `json:"manga_id" db:"manga_id"`
+	Chapter   int              `json:"chapter" db:"chapter"`
+	UserID    string           `json:"user_id" db:"user_id"`
+	Username  string           `json:"username"`
+	ParentID  string           `json:"parent_id,omitempty" db:"parent_id"`
+	Body      string           `json:"body,omitempty" db:"body"`
+	Hidden    bool             `json:"hidden,omitempty"`
+	CreatedAt time.Time        `json:"created_at" db:"created_at"`
+	Replies   []ChapterComment `json:"replies"`
+}
+
+type CreateCommentRequest struct {
+	Body     string `json:"body" binding:"required,max=5000"`
+	ParentID string `json:"parent_id"`
+}
@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// Goal types. Either can be narrowed to a single genre.
+const (
+	GoalChapters        = "chapters"
+	GoalSeriesCompleted = "series_completed"
+)
+
+// Goal pace, from how far along the period is versus how far along the
+// target is.
+const (
+	GoalNotStarted = "not_started"
+	GoalOnTrack    = "on_track"
+	GoalBehind     = "behind"
+	GoalAchieved   = "achieved"
+	GoalMissed     = "missed"
+)
+
+type Goal struct {
+	ID         string     `json:"id"`
+	Type       string     `json:"type"`
+	Target     int        `json:"target"`
+	Genre      string     `json:"genre,omitempty"`
+	StartDate  string     `json:"start_date"` // YYYY-MM-DD, inclusive
+	EndDate    string     `json:"end_date"`   // YYYY-MM-DD, inclusive
+	Progress   int        `json:"progress"`
+	Expected   int        `json:"expected"` // where progress should be by now to finish on time
+	Pace       string     `json:"pace"`
+	AchievedAt *time.Time `json:"achieved_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// CreateGoalRequest sets a goal for a calendar year (the current one by
+// default) or for an explicit date range.
+type CreateGoalRequest struct {
+	Type      string `json:"type" binding:"required,oneof=chapters series_completed"`
+	Target    int    `json:"target" binding:"required,min=1"`
+	Genre     string `json:"genre"`
+	Year      int    `json:"year" binding:"omitempty,min=2000,max=2100"`
+	StartDate string `json:"start_date" binding:"omitempty,datetime=2006-01-02"`
+	EndDate   string `json:"end_date" binding:"omitempty,datetime=2006-01-02"`
+}
@@ -0,0 +1,19 @@
+package models
+
+// JSONWebKey is the public half of a token signing key, as published at
+// /.well-known/jwks.json (RFC 7517). RSA keys fill N and E, Ed25519 keys
+// Crv and X.
+type JSONWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
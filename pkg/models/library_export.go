@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// Formats served by GET /users/library/export
+const (
+	ExportFormatMALXML = "mal-xml"
+	ExportFormatCSV    = "csv"
+	ExportFormatJSON   = "json"
+)
+
+type ExportLibraryRequest struct {
+	Format string `form:"format" binding:"omitempty,oneof=mal-xml csv json"`
+}
+
+// LibraryExportEntry is one library row in a CSV or JSON export. The CSV
+// header uses the JSON names in field order.
+type LibraryExportEntry struct {
+	MangaID        string        `json:"manga_id"`
+	Title          string        `json:"title"`
+	Status         ReadingStatus `json:"status"`
+	CurrentChapter int           `json:"current_chapter"`
+	CurrentVolume  int           `json:"current_volume"`
+	Score          int           `json:"score"`
+	StartedAt      string        `json:"started_at"`
+	FinishedAt     string        `json:"finished_at"`
+	IsFavorite     bool          `json:"is_favorite"`
+	Tags           []string      `json:"tags"`
+	UpdatedAt      time.Time     `json:"updated_at"`
+}
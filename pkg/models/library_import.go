@@ -0,0 +1,59 @@
+package models
+
+// Export formats accepted by POST /users/library/import
+const (
+	ImportFormatMALXML      = "mal-xml"
+	ImportFormatAniListJSON = "anilist-json"
+	ImportFormatCSV         = "csv"
+)
+
+// Conflict policies for imported entries that are already in the library
+const (
+	ImportKeepHigher = "keep_higher" // update only when the import is further along
+	ImportOverwrite  = "overwrite"
+	ImportSkip       = "skip"
+)
+
+// Per-entry outcomes reported by an import
+const (
+	ImportCreated = "created"
+	ImportUpdated = "updated"
+	ImportSkipped = "skipped"
+	ImportFailed  = "failed"
+)
+
+type ImportLibraryRequest struct {
+	Format   string `json:"format" binding:"required,oneof=mal-xml anilist-json csv"`
+	Data     string `json:"data" binding:"required"`
+	Conflict string `json:"conflict" binding:"omitempty,oneof=keep_higher overwrite skip"`
+	DryRun   bool   `json:"dry_run"`
+}
+
+type ImportEntry struct {
+	Title          string        `json:"title"`
+	MangaID        string        `json:"manga_id,omitempty"`
+	Status         ReadingStatus `json:"status,omitempty"`
+	CurrentChapter int           `json:"current_chapter"`
+	CurrentVolume  int           `json:"current_volume"`
+	Score          int           `json:"score,omitempty"`
+	StartedAt      string        `json:"started_at,omitempty"`
+	FinishedAt     string        `json:"finished_at,omitempty"`
+	Action         string        `json:"action"`
+	Reason         string        `json:"reason,omitempty"`
+
+	// AddedToCatalog is set when the title had to be fetched from the
+	// external source first
+	AddedToCatalog bool `json:"added_to_catalog,omitempty"`
+}
+
+type ImportResult struct {
+	Format   string        `json:"format"`
+	Conflict string        `json:"conflict"`
+	DryRun   bool          `json:"dry_run"`
+	Total    int           `json:"total"`
+	Created  int           `json:"created"`
+	Updated  int           `json:"updated"`
+	Skipped  int           `json:"skipped"`
+	Failed   int           `json:"failed"`
+	Entries  []ImportEntry `json:"entries"`
+}
@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// ReadingList is an ordered, named collection of manga. Public lists can be
+// viewed by anyone through their ShareID.
+type ReadingList struct {
+	ID          string     `json:"id" db:"id"`
+	UserID      string     `json:"user_id" db:"user_id"`
+	Owner       string     `json:"owner,omitempty"`
+	Name        string     `json:"name" db:"name"`
+	Description string     `json:"description" db:"description"`
+	IsPublic    bool       `json:"is_public" db:"is_public"`
+	ShareID     string     `json:"share_id,omitempty" db:"share_id"`
+	ItemCount   int        `json:"item_count"`
+	Items       []ListItem `json:"items,omitempty"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// ListItem is a manga at a 1-based position within a list.
+type ListItem struct {
+	Position int       `json:"position" db:"position"`
+	Manga    Manga     `json:"manga"`
+	AddedAt  time.Time `json:"added_at" db:"added_at"`
+}
+
+type CreateListRequest struct {
+	Name        string `json:"name" binding:"required,max=100"`
+	Description string `json:"description" binding:"max=1000"`
+	IsPublic    bool   `json:"is_public"`
+}
+
+// UpdateListRequest only changes the fields that are present.
+type UpdateListRequest struct {
+	Name        *string `json:"name" binding:"omitempty,min=1,max=100"`
+	Description *string `json:"description" binding:"omitempty,max=1000"`
+	IsPublic    *bool   `json:"is_public"`
+}
+
+type AddListItemRequest struct {
+	MangaID  string `json:"manga_id" binding:"required"`
+	Position int    `json:"position" binding:"omitempty,min=1"` // Appended when omitted
+}
+
+type MoveListItemRequest struct {
+	Position int `json:"position" binding:"required,min=1"`
+}
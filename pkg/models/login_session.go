@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// Transports a live connection can use
+const (
+	TransportTCP = "tcp"
+	TransportUDP = "udp"
+)
+
+// LoginSession is one place a user is logged in. It starts at login and
+// lasts as long as its refresh tokens keep being rotated.
+type LoginSession struct {
+	ID          string           `json:"id"`
+	IP          string           `json:"ip,omitempty"`
+	UserAgent   string           `json:"user_agent,omitempty"`
+	DeviceName  string           `json:"device_name,omitempty"`
+	CreatedAt   time.Time        `json:"created_at"`
+	LastSeenAt  time.Time        `json:"last_seen_at"`
+	Current     bool             `json:"current"`
+	Connections []LiveConnection `json:"connections"`
+}
+
+// LiveConnection is a TCP sync connection or UDP notification registration
+// made with a login session's token
+type LiveConnection struct {
+	ID          string    `json:"id"`
+	SessionID   string    `json:"-"`
+	UserID      string    `json:"-"`
+	Transport   string    `json:"transport"`
+	RemoteAddr  string    `json:"remote_addr"`
+	DeviceType  string    `json:"device_type,omitempty"`
+	DeviceName  string    `json:"device_name,omitempty"`
+	ConnectedAt time.Time `json:"connected_at"`
+	LastSeenAt  time.Time `json:"last_seen_at"`
+}
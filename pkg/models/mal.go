@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+type MALLinkResponse struct {
+	AuthorizationURL string    `json:"authorization_url"`
+	ExpiresAt        time.Time `json:"expires_at"`
+}
+
+type MALAccountStatus struct {
+	Linked       bool       `json:"linked"`
+	Username     string     `json:"mal_username,omitempty"`
+	LinkedAt     *time.Time `json:"linked_at,omitempty"`
+	LastSyncedAt *time.Time `json:"last_synced_at,omitempty"`
+	LastError    string     `json:"last_error,omitempty"`
+}
+
+// MALSyncResult counts what one sync run did. Conflicts are entries changed
+// on both sides since the previous sync; they are also counted as pushed or
+// pulled depending on which side won.
+type MALSyncResult struct {
+	Pushed    int      `json:"pushed"`
+	Pulled    int      `json:"pulled"`
+	Conflicts int      `json:"conflicts"`
+	Errors    []string `json:"errors,omitempty"`
+}
@@ -22,14 +22,49 @@ type Manga struct {
 	Authors           []map[string]interface{} `json:"authors,omitempty"`
 	Serialization     []map[string]interface{} `json:"serialization,omitempty"`
 	Background        string                   `json:"background,omitempty"`
+	DisplayTitle      string                   `json:"display_title,omitempty"`
+
+	// CommunityScore is the average of MangaHub user ratings, kept alongside
+	// the MyAnimeList Mean.
+	CommunityScore      float64 `json:"community_score,omitempty"`
+	CommunityScoreCount int     `json:"community_score_count,omitempty"`
 }
 
 type SearchMangaRequest struct {
+	Query  string   `form:"q"` // Structured query, e.g. "genre:action status:completed chapters:>100"
 	Title  string   `form:"title"`
 	Author string   `form:"author"`
 	Genre  string   `form:"genre"`  // Single genre for filtering
 	Genres []string `form:"genres"` // Multiple genres (for future use)
 	Status string   `form:"status"`
-	Limit  int      `form:"limit" binding:"min=1,max=100"`
+	Limit  int      `form:"limit" binding:"omitempty,min=1,max=100"`
 	Offset int      `form:"offset" binding:"min=0"`
 }
+
+// Title languages a user can prefer when manga titles are displayed.
+const (
+	TitleLanguageDefault  = "default"
+	TitleLanguageEnglish  = "en"
+	TitleLanguageJapanese = "ja"
+)
+
+// DisplayTitleFor picks the title to show for the given language preference,
+// falling back to the main title when no matching alternative exists.
+func (m *Manga) DisplayTitleFor(lang string) string {
+	if m.AlternativeTitles != nil && lang != "" && lang != TitleLanguageDefault {
+		if t, ok := m.AlternativeTitles[lang].(string); ok && t != "" {
+			return t
+		}
+	}
+	return m.Title
+}
+
+// HasVolume reports whether volume is within the manga's volume count.
+// Volume 0 means "not tracked", and manga whose volume count is unknown
+// (ongoing series, local entries) accept any positive volume.
+func (m *Manga) HasVolume(volume int) bool {
+	if volume < 0 {
+		return false
+	}
+	return m.NumVolumes == 0 || volume <= m.NumVolumes
+}
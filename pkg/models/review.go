@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// Review is a user's rating of a manga with optional written text.
+type Review struct {
+	UserID    string    `json:"user_id" db:"user_id"`
+	Username  string    `json:"username"`
+	MangaID   string    `json:"manga_id" db:"manga_id"`
+	Score     int       `json:"score" db:"score"`
+	Text      string    `json:"text,omitempty" db:"body"`
+	Spoiler   bool      `json:"spoiler" db:"spoiler"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+type CreateReviewRequest struct {
+	Score   int    `json:"score" binding:"required,min=1,max=10"`
+	Text    string `json:"text" binding:"max=10000"`
+	Spoiler bool   `json:"spoiler"`
+}
+
+// UpdateReviewRequest only changes the fields that are present.
+type UpdateReviewRequest struct {
+	Score   *int    `json:"score" binding:"omitempty,min=1,max=10"`
+	Text    *string `json:"text" binding:"omitempty,max=10000"`
+	Spoiler *bool   `json:"spoiler"`
+}
+
+type ListReviewsRequest struct {
+	Page  int `form:"page" binding:"omitempty,min=1"`
+	Limit int `form:"limit" binding:"omitempty,min=1,max=100"`
+}
@@ -0,0 +1,29 @@
+package models
+
+// Role decides what a user is allowed to do beyond managing their own data
+type Role string
+
+const (
+	RoleUser      Role = "user"
+	RoleModerator Role = "moderator"
+	RoleAdmin     Role = "admin"
+)
+
+// Roles lists every role from least to most privileged. The oneof binding
+// tag on SetRoleRequest must list the same values.
+var Roles = []Role{RoleUser, RoleModerator, RoleAdmin}
+
+// Valid reports whether r is one of Roles
+func (r Role) Valid() bool {
+	for _, role := range Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// SetRoleRequest changes a user's role
+type SetRoleRequest struct {
+	Role Role `json:"role" binding:"required,oneof=user moderator admin"`
+}
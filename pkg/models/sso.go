@@ -0,0 +1,17 @@
+package models
+
+// DeviceAuthorization starts a device login: the CLI shows UserCode and
+// VerificationURI, then polls with DeviceCode until the user has signed in
+// through the browser.
+type DeviceAuthorization struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete"`
+	ExpiresIn               int    `json:"expires_in"`
+	Interval                int    `json:"interval"`
+}
+
+type DeviceTokenRequest struct {
+	DeviceCode string `json:"device_code" binding:"required"`
+}
@@ -0,0 +1,53 @@
+package models
+
+// Time ranges accepted by GET /users/stats
+const (
+	StatsRangeWeek  = "7d"
+	StatsRangeMonth = "30d"
+	StatsRangeYear  = "365d"
+	StatsRangeAll   = "all"
+)
+
+type StatsRequest struct {
+	Range string `form:"range" binding:"omitempty,oneof=7d 30d 365d all"`
+}
+
+type ChaptersRead struct {
+	Total    int     `json:"total"`
+	PerDay   float64 `json:"per_day"`
+	PerWeek  float64 `json:"per_week"`
+	PerMonth float64 `json:"per_month"`
+}
+
+type DailyChapters struct {
+	Day      string `json:"day"` // YYYY-MM-DD, UTC
+	Chapters int    `json:"chapters"`
+}
+
+type StatsBreakdown struct {
+	Name     string `json:"name"`
+	Series   int    `json:"series"`
+	Chapters int    `json:"chapters"`
+}
+
+type ReadingStats struct {
+	Range        string          `json:"range"`
+	From         string          `json:"from,omitempty"`
+	To           string          `json:"to"`
+	ChaptersRead ChaptersRead    `json:"chapters_read"`
+	Daily        []DailyChapters `json:"daily"`
+
+	// Streaks count consecutive UTC days with at least one chapter read and
+	// are always computed over the whole history.
+	CurrentStreak int `json:"current_streak"`
+	LongestStreak int `json:"longest_streak"`
+
+	Genres  []StatsBreakdown `json:"genres"`
+	Authors []StatsBreakdown `json:"authors"`
+
+	// AverageCompletionDays is the mean time from the first recorded
+	// progress on a series to it being marked completed.
+	AverageCompletionDays float64        `json:"average_completion_days"`
+	CompletedSeries       int            `json:"completed_series"`
+	StatusDistribution    map[string]int `json:"status_distribution"`
+}
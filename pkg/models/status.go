@@ -0,0 +1,69 @@
+package models
+
+import "strings"
+
+// ReadingStatus is where a manga sits in a user's library
+type ReadingStatus string
+
+const (
+	StatusReading    ReadingStatus = "reading"
+	StatusCompleted  ReadingStatus = "completed"
+	StatusOnHold     ReadingStatus = "on_hold"
+	StatusDropped    ReadingStatus = "dropped"
+	StatusPlanToRead ReadingStatus = "plan_to_read"
+)
+
+// ReadingStatuses lists every status in display order. The oneof binding
+// tags on the request models must list the same values.
+var ReadingStatuses = []ReadingStatus{
+	StatusReading,
+	StatusCompleted,
+	StatusOnHold,
+	StatusDropped,
+	StatusPlanToRead,
+}
+
+// statusAliases maps spellings found in older rows and imports to a status
+var statusAliases = map[string]ReadingStatus{
+	"on-hold":      StatusOnHold,
+	"onhold":       StatusOnHold,
+	"paused":       StatusOnHold,
+	"drop":         StatusDropped,
+	"finished":     StatusCompleted,
+	"plan-to-read": StatusPlanToRead,
+	"plantoread":   StatusPlanToRead,
+	"planned":      StatusPlanToRead,
+}
+
+// Valid reports whether s is one of ReadingStatuses
+func (s ReadingStatus) Valid() bool {
+	for _, status := range ReadingStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseReadingStatus accepts a status in any case, with spaces or dashes
+// instead of underscores, or one of the known aliases.
+func ParseReadingStatus(s string) (ReadingStatus, bool) {
+	key := strings.ToLower(strings.TrimSpace(s))
+	status := ReadingStatus(strings.Join(strings.Fields(key), "_"))
+	if status.Valid() {
+		return status, true
+	}
+	if alias, ok := statusAliases[strings.Join(strings.Fields(key), "-")]; ok {
+		return alias, true
+	}
+	return "", false
+}
+
+// StatusList joins ReadingStatuses for error messages
+func StatusList() string {
+	names := make([]string, len(ReadingStatuses))
+	for i, status := range ReadingStatuses {
+		names[i] = string(status)
+	}
+	return strings.Join(names, ", ")
+}
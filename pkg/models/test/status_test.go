@@ -0,0 +1,56 @@
+package models_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/models"
+)
+
+func TestParseReadingStatus(t *testing.T) {
+	cases := map[string]models.ReadingStatus{
+		"reading":      models.StatusReading,
+		"Completed":    models.StatusCompleted,
+		"on hold":      models.StatusOnHold,
+		"On-Hold":      models.StatusOnHold,
+		"paused":       models.StatusOnHold,
+		"dropped":      models.StatusDropped,
+		"plan to read": models.StatusPlanToRead,
+		"plan-to-read": models.StatusPlanToRead,
+	}
+	for input, want := range cases {
+		got, ok := models.ParseReadingStatus(input)
+		if !ok || got != want {
+			t.Errorf("ParseReadingStatus(%q) = %q, %v; want %q", input, got, ok, want)
+		}
+	}
+
+	for _, input := range []string{"", "rereading", "hold"} {
+		if _, ok := models.ParseReadingStatus(input); ok {
+			t.Errorf("ParseReadingStatus(%q) should fail", input)
+		}
+	}
+}
+
+// The binding tags can't reference ReadingStatuses directly, so make sure
+// they don't drift from it.
+func TestRequestBindingsListEveryStatus(t *testing.T) {
+	var want []string
+	for _, status := range models.ReadingStatuses {
+		want = append(want, string(status))
+	}
+
+	for _, req := range []interface{}{models.AddToLibraryRequest{}, models.UpdateProgressRequest{}} {
+		field, _ := reflect.TypeOf(req).FieldByName("Status")
+		var oneof []string
+		for _, rule := range strings.Split(field.Tag.Get("binding"), ",") {
+			if strings.HasPrefix(rule, "oneof=") {
+				oneof = strings.Fields(strings.TrimPrefix(rule, "oneof="))
+			}
+		}
+		if !reflect.DeepEqual(oneof, want) {
+			t.Errorf("%T status binding lists %v, want %v", req, oneof, want)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package models
+
+import "time"
+
+// Scope limits what a personal access token may do. Write scopes include
+// reading the same data.
+type Scope string
+
+const (
+	ScopeLibraryRead       Scope = "library:read"
+	ScopeLibraryWrite      Scope = "library:write"
+	ScopeProgressRead      Scope = "progress:read"
+	ScopeProgressWrite     Scope = "progress:write"
+	ScopeProfileRead       Scope = "profile:read"
+	ScopeNotificationsRead Scope = "notifications:read"
+)
+
+// Scopes lists every scope a token can be given
+var Scopes = []Scope{
+	ScopeLibraryRead, ScopeLibraryWrite,
+	ScopeProgressRead, ScopeProgressWrite,
+	ScopeProfileRead, ScopeNotificationsRead,
+}
+
+// Valid reports whether s is one of Scopes
+func (s Scope) Valid() bool {
+	for _, scope := range Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// PersonalAccessToken describes a token without the secret itself, which is
+// only shown once when the token is created
+type PersonalAccessToken struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Scopes     []Scope    `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+// CreateTokenRequest creates a personal access token. With ExpiresInDays
+// left at zero the token lasts until it is revoked.
+type CreateTokenRequest struct {
+	Name          string  `json:"name" binding:"required,max=100"`
+	Scopes        []Scope `json:"scopes" binding:"required,min=1"`
+	ExpiresInDays int     `json:"expires_in_days" binding:"min=0,max=3650"`
+}
+
+// CreateTokenResponse carries the new token. It can't be retrieved again.
+type CreateTokenResponse struct {
+	PersonalAccessToken
+	Token string `json:"token"`
+}
@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+// TwoFactorChallenge is what Login returns instead of tokens when the
+// account has two-factor authentication on. The MFAToken is exchanged along
+// with a code at /auth/login/2fa.
+type TwoFactorChallenge struct {
+	TwoFactorRequired bool      `json:"two_factor_required"`
+	MFAToken          string    `json:"mfa_token"`
+	ExpiresAt         time.Time `json:"expires_at"`
+}
+
+// TwoFactorLoginRequest finishes a two-step login. Code is either the
+// current authenticator code or an unused recovery code.
+type TwoFactorLoginRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+// TwoFactorSetupRequest starts enrollment. The password is asked again so a
+// borrowed session can't lock the owner out.
+type TwoFactorSetupRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
+// TwoFactorSetupResponse carries the new secret for the authenticator app
+type TwoFactorSetupResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+// TwoFactorCodeRequest carries an authenticator code, for example to
+// confirm enrollment
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// TwoFactorEnableResponse lists the recovery codes. They are only shown
+// once.
+type TwoFactorEnableResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// TwoFactorDisableRequest turns two-factor authentication off. It needs both
+// the password and a code, which may be a recovery code.
+type TwoFactorDisableRequest struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
@@ -3,11 +3,16 @@ package models
 import "time"
 
 type User struct {
-	ID           string    `json:"id" db:"id"`
-	Username     string    `json:"username" db:"username"`
-	Email        string    `json:"email" db:"email"`
-	PasswordHash string    `json:"-" db:"password_hash"`
-	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+	ID            string    `json:"id" db:"id"`
+	Username      string    `json:"username" db:"username"`
+	Email         string    `json:"email" db:"email"`
+	EmailVerified bool      `json:"email_verified" db:"email_verified"`
+	TwoFactor     bool      `json:"two_factor_enabled" db:"totp_enabled"`
+	PasswordHash  string    `json:"-" db:"password_hash"`
+	Role          Role      `json:"role" db:"role"`
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+
+	PreferredTitleLanguage string `json:"preferred_title_language" db:"preferred_title_language"`
 }
 
 type RegisterRequest struct {
@@ -23,10 +28,45 @@ type LoginRequest struct {
 }
 
 type AuthResponse struct {
-	Token     string    `json:"token"`
-	UserID    string    `json:"user_id"`
-	Username  string    `json:"username"`
-	Email     string    `json:"email"`
-	ExpiresAt time.Time `json:"expires_at"`
-	CreatedAt time.Time `json:"created_at"`
+	Token            string    `json:"token"`
+	RefreshToken     string    `json:"refresh_token"`
+	SessionID        string    `json:"session_id"`
+	UserID           string    `json:"user_id"`
+	Username         string    `json:"username"`
+	Email            string    `json:"email"`
+	EmailVerified    bool      `json:"email_verified"`
+	Role             Role      `json:"role"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required"`
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required"`
+}
+
+// ChangeEmailRequest asks to move the account to a new address. The current
+// password is required so a borrowed session can't take over the account.
+type ChangeEmailRequest struct {
+	Email    string `json:"email" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// LogoutRequest optionally names the refresh token to revoke along with the
+// access token used to call /auth/logout
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type UpdatePreferencesRequest struct {
+	TitleLanguage string `json:"title_language" binding:"required,oneof=default en ja"`
 }
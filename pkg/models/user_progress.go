@@ -3,33 +3,61 @@ package models
 import "time"
 
 type UserProgress struct {
-	UserID         string    `json:"user_id" db:"user_id"`
-	MangaID        string    `json:"manga_id" db:"manga_id"`
-	CurrentChapter int       `json:"current_chapter" db:"current_chapter"`
-	Status         string    `json:"status" db:"status"`
-	UpdatedAt      time.Time `json:"updated_at" db:"updated_at"`
+	UserID         string        `json:"user_id" db:"user_id"`
+	MangaID        string        `json:"manga_id" db:"manga_id"`
+	CurrentChapter int           `json:"current_chapter" db:"current_chapter"`
+	CurrentVolume  int           `json:"current_volume" db:"current_volume"`
+	Status         ReadingStatus `json:"status" db:"status"`
+	StartedAt      string        `json:"started_at,omitempty" db:"started_at"`   // YYYY-MM-DD
+	FinishedAt     string        `json:"finished_at,omitempty" db:"finished_at"` // YYYY-MM-DD
+	UpdatedAt      time.Time     `json:"updated_at" db:"updated_at"`
 }
 
 type AddToLibraryRequest struct {
-	MangaID string `json:"manga_id" binding:"required"`
-	Status  string `json:"status" binding:"required,oneof=reading completed plan_to_read"`
+	MangaID    string        `json:"manga_id" binding:"required"`
+	Status     ReadingStatus `json:"status" binding:"required,oneof=reading completed on_hold dropped plan_to_read"`
+	IsFavorite bool          `json:"is_favorite"`
+}
+
+type SetFavoriteRequest struct {
+	IsFavorite *bool `json:"is_favorite" binding:"required"`
 }
 
 type UpdateProgressRequest struct {
-	MangaID        string `json:"manga_id" binding:"required"`
-	CurrentChapter int    `json:"current_chapter" binding:"required,min=0"`
-	Status         string `json:"status" binding:"omitempty,oneof=reading completed plan_to_read"`
+	MangaID        string        `json:"manga_id" binding:"required"`
+	CurrentChapter int           `json:"current_chapter" binding:"required,min=0"`
+	CurrentVolume  *int          `json:"current_volume" binding:"omitempty,min=0"` // nil keeps the stored volume
+	Status         ReadingStatus `json:"status" binding:"omitempty,oneof=reading completed on_hold dropped plan_to_read"`
 }
 
 type MangaProgress struct {
-	Manga          Manga     `json:"manga"`
-	CurrentChapter int       `json:"current_chapter"`
-	Status         string    `json:"status"`
-	UpdatedAt      time.Time `json:"updated_at"`
+	Manga          Manga         `json:"manga"`
+	CurrentChapter int           `json:"current_chapter"`
+	CurrentVolume  int           `json:"current_volume"`
+	Status         ReadingStatus `json:"status"`
+	IsFavorite     bool          `json:"is_favorite"`
+	UserScore      int           `json:"user_score,omitempty"`
+	Tags           []string      `json:"tags"`
+	StartedAt      string        `json:"started_at,omitempty"`
+	FinishedAt     string        `json:"finished_at,omitempty"`
+	UpdatedAt      time.Time     `json:"updated_at"`
+}
+
+type LibraryTagsRequest struct {
+	Tags []string `json:"tags" binding:"required,min=1,dive,required,max=32"`
+}
+
+type LibraryFilter struct {
+	Tag string `form:"tag"`
 }
 
 type UserLibrary struct {
 	Reading    []MangaProgress `json:"reading"`
 	Completed  []MangaProgress `json:"completed"`
+	OnHold     []MangaProgress `json:"on_hold"`
+	Dropped    []MangaProgress `json:"dropped"`
 	PlanToRead []MangaProgress `json:"plan_to_read"`
+
+	// Favorites repeats favorite entries regardless of their status
+	Favorites []MangaProgress `json:"favorites"`
 }
@@ -0,0 +1,93 @@
+package query
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/models"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/utils"
+)
+
+// Match evaluates the query against a manga in memory. It is used for
+// sources, such as MyAnimeList search, that only accept a keyword.
+func (q *Query) Match(m *models.Manga) bool {
+	for _, c := range q.Clauses {
+		if c.matches(m) == c.Negate {
+			return false
+		}
+	}
+	return true
+}
+
+func (c Clause) matches(m *models.Manga) bool {
+	switch c.Field {
+	case "title":
+		needle := utils.NormalizeTitle(c.Value)
+		for _, title := range allTitles(m) {
+			if strings.Contains(utils.NormalizeTitle(title), needle) {
+				return true
+			}
+		}
+		return false
+	case "author":
+		return strings.Contains(strings.ToLower(m.Author), strings.ToLower(c.Value))
+	case "genre":
+		for _, g := range m.Genres {
+			if strings.EqualFold(g, c.Value) {
+				return true
+			}
+		}
+		return false
+	case "status":
+		return normalizeStatus(m.Status) == c.Value
+	case "chapters":
+		return c.compare(float64(m.TotalChapters))
+	case "volumes":
+		return c.compare(float64(m.NumVolumes))
+	case "year":
+		if len(m.StartDate) < 4 {
+			return false
+		}
+		year, err := strconv.Atoi(m.StartDate[:4])
+		return err == nil && c.compare(float64(year))
+	case "score":
+		return c.compare(m.Mean)
+	}
+	return false
+}
+
+func (c Clause) compare(n float64) bool {
+	switch c.Op {
+	case OpGt:
+		return n > c.Min
+	case OpGte:
+		return n >= c.Min
+	case OpLt:
+		return n < c.Min
+	case OpLte:
+		return n <= c.Min
+	case OpRange:
+		return n >= c.Min && n <= c.Max
+	default:
+		return n == c.Min
+	}
+}
+
+func allTitles(m *models.Manga) []string {
+	titles := []string{m.Title}
+	for _, value := range m.AlternativeTitles {
+		switch v := value.(type) {
+		case string:
+			titles = append(titles, v)
+		case []string:
+			titles = append(titles, v...)
+		case []interface{}:
+			for _, item := range v {
+				if s, ok := item.(string); ok {
+					titles = append(titles, s)
+				}
+			}
+		}
+	}
+	return titles
+}
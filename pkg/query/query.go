@@ -0,0 +1,230 @@
+// Package query parses the structured manga search syntax, e.g.
+//
+//	one piece genre:action -genre:horror status:completed chapters:>100 year:2010..2020 score:>=8
+//
+// into a list of clauses that can be compiled to SQL or evaluated in memory.
+package query
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type FieldKind int
+
+const (
+	TextField FieldKind = iota
+	ListField
+	EnumField
+	NumericField
+)
+
+// Fields lists every searchable field and how its values are compared.
+var Fields = map[string]FieldKind{
+	"title":    TextField,
+	"author":   TextField,
+	"genre":    ListField,
+	"status":   EnumField,
+	"chapters": NumericField,
+	"volumes":  NumericField,
+	"year":     NumericField,
+	"score":    NumericField,
+}
+
+type Operator string
+
+const (
+	OpContains Operator = "contains"
+	OpEq       Operator = "="
+	OpGt       Operator = ">"
+	OpGte      Operator = ">="
+	OpLt       Operator = "<"
+	OpLte      Operator = "<="
+	OpRange    Operator = ".."
+)
+
+// Clause is a single term of a query. Free text terms have Field "title".
+type Clause struct {
+	Field  string   `json:"field"`
+	Op     Operator `json:"op"`
+	Value  string   `json:"value,omitempty"`
+	Min    float64  `json:"min,omitempty"`
+	Max    float64  `json:"max,omitempty"`
+	Negate bool     `json:"negate,omitempty"`
+	Raw    string   `json:"raw"`
+}
+
+type Query struct {
+	Clauses []Clause `json:"clauses"`
+}
+
+// UnsupportedClauseError reports every clause that could not be understood.
+type UnsupportedClauseError struct {
+	Clauses []string
+	Reasons []string
+}
+
+func (e *UnsupportedClauseError) Error() string {
+	parts := make([]string, len(e.Clauses))
+	for i := range e.Clauses {
+		parts[i] = fmt.Sprintf("%q (%s)", e.Clauses[i], e.Reasons[i])
+	}
+	return "unsupported search clauses: " + strings.Join(parts, ", ")
+}
+
+func (e *UnsupportedClauseError) add(raw, reason string) {
+	e.Clauses = append(e.Clauses, raw)
+	e.Reasons = append(e.Reasons, reason)
+}
+
+// Parse turns the raw query string into a Query. All problems are collected
+// into a single UnsupportedClauseError instead of stopping at the first one.
+func Parse(input string) (*Query, error) {
+	q := &Query{}
+	unsupported := &UnsupportedClauseError{}
+
+	for _, token := range tokenize(input) {
+		raw := token
+		negate := false
+		if strings.HasPrefix(token, "-") && len(token) > 1 {
+			negate = true
+			token = token[1:]
+		}
+
+		field, value, hasField := strings.Cut(token, ":")
+		if !hasField || strings.HasPrefix(token, "\"") {
+			q.Clauses = append(q.Clauses, Clause{
+				Field:  "title",
+				Op:     OpContains,
+				Value:  unquote(token),
+				Negate: negate,
+				Raw:    raw,
+			})
+			continue
+		}
+
+		field = strings.ToLower(field)
+		value = unquote(value)
+		kind, ok := Fields[field]
+		if !ok {
+			unsupported.add(raw, fmt.Sprintf("unknown field %q; quote the term to search it as text", field))
+			continue
+		}
+		if value == "" {
+			unsupported.add(raw, "missing value")
+			continue
+		}
+
+		clause := Clause{Field: field, Negate: negate, Raw: raw}
+		switch kind {
+		case TextField, ListField:
+			clause.Op = OpContains
+			clause.Value = value
+		case EnumField:
+			clause.Op = OpEq
+			clause.Value = normalizeStatus(value)
+		case NumericField:
+			if err := parseNumeric(&clause, value); err != nil {
+				unsupported.add(raw, err.Error())
+				continue
+			}
+		}
+		q.Clauses = append(q.Clauses, clause)
+	}
+
+	if len(unsupported.Clauses) > 0 {
+		return q, unsupported
+	}
+	return q, nil
+}
+
+// Text returns the positive free text terms joined together, which is what
+// sources that only support keyword search can use.
+func (q *Query) Text() string {
+	var terms []string
+	for _, c := range q.Clauses {
+		if c.Field == "title" && !c.Negate {
+			terms = append(terms, c.Value)
+		}
+	}
+	return strings.Join(terms, " ")
+}
+
+// HasFilters reports whether the query contains anything beyond free text.
+func (q *Query) HasFilters() bool {
+	for _, c := range q.Clauses {
+		if c.Field != "title" || c.Negate {
+			return true
+		}
+	}
+	return false
+}
+
+func parseNumeric(c *Clause, value string) error {
+	if lo, hi, isRange := strings.Cut(value, ".."); isRange {
+		min, err1 := strconv.ParseFloat(lo, 64)
+		max, err2 := strconv.ParseFloat(hi, 64)
+		if err1 != nil || err2 != nil {
+			return fmt.Errorf("range must be numeric, e.g. %s:2010..2020", c.Field)
+		}
+		if min > max {
+			min, max = max, min
+		}
+		c.Op, c.Min, c.Max = OpRange, min, max
+		return nil
+	}
+
+	c.Op = OpEq
+	for _, op := range []Operator{OpGte, OpLte, OpGt, OpLt, OpEq} {
+		if strings.HasPrefix(value, string(op)) {
+			c.Op = op
+			value = value[len(op):]
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return fmt.Errorf("expected a number, e.g. %s:>=10", c.Field)
+	}
+	c.Min = n
+	return nil
+}
+
+func normalizeStatus(status string) string {
+	status = strings.ToLower(status)
+	if status == "finished" {
+		return "completed"
+	}
+	return status
+}
+
+// tokenize splits on whitespace while keeping double-quoted sections together.
+func tokenize(input string) []string {
+	var tokens []string
+	var current strings.Builder
+	inQuotes := false
+	for _, r := range input {
+		switch {
+		case r == '"':
+			inQuotes = !inQuotes
+			current.WriteRune(r)
+		case unicode.IsSpace(r) && !inQuotes:
+			if current.Len() > 0 {
+				tokens = append(tokens, current.String())
+				current.Reset()
+			}
+		default:
+			current.WriteRune(r)
+		}
+	}
+	if current.Len() > 0 {
+		tokens = append(tokens, current.String())
+	}
+	return tokens
+}
+
+func unquote(s string) string {
+	return strings.Trim(s, "\"")
+}
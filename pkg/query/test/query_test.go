@@ -0,0 +1,101 @@
+package query_test
+
+import (
+	"testing"
+
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/models"
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/query"
+)
+
+func TestParse_Clauses(t *testing.T) {
+	q, err := query.Parse(`one piece genre:action -genre:horror status:finished chapters:>100 year:2010..2020 score:>=8`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if q.Text() != "one piece" {
+		t.Errorf("expected free text %q, got %q", "one piece", q.Text())
+	}
+	if len(q.Clauses) != 8 {
+		t.Fatalf("expected 8 clauses, got %d: %+v", len(q.Clauses), q.Clauses)
+	}
+
+	horror := q.Clauses[3]
+	if horror.Field != "genre" || horror.Value != "horror" || !horror.Negate {
+		t.Errorf("expected negated genre clause, got %+v", horror)
+	}
+	status := q.Clauses[4]
+	if status.Value != "completed" {
+		t.Errorf("expected finished to normalize to completed, got %q", status.Value)
+	}
+	chapters := q.Clauses[5]
+	if chapters.Op != query.OpGt || chapters.Min != 100 {
+		t.Errorf("expected chapters > 100, got %+v", chapters)
+	}
+	year := q.Clauses[6]
+	if year.Op != query.OpRange || year.Min != 2010 || year.Max != 2020 {
+		t.Errorf("expected year range 2010..2020, got %+v", year)
+	}
+}
+
+func TestParse_QuotedColonIsText(t *testing.T) {
+	q, err := query.Parse(`"re:zero" genre:"slice of life"`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if q.Text() != "re:zero" {
+		t.Errorf("expected quoted text to be kept, got %q", q.Text())
+	}
+	if q.Clauses[1].Value != "slice of life" {
+		t.Errorf("expected quoted genre value, got %q", q.Clauses[1].Value)
+	}
+}
+
+func TestParse_ReportsAllUnsupportedClauses(t *testing.T) {
+	_, err := query.Parse(`naruto publisher:shueisha chapters:lots year:`)
+	unsupported, ok := err.(*query.UnsupportedClauseError)
+	if !ok {
+		t.Fatalf("expected UnsupportedClauseError, got %v", err)
+	}
+
+	expected := []string{"publisher:shueisha", "chapters:lots", "year:"}
+	if len(unsupported.Clauses) != len(expected) {
+		t.Fatalf("expected %d unsupported clauses, got %v", len(expected), unsupported.Clauses)
+	}
+	for i, clause := range expected {
+		if unsupported.Clauses[i] != clause {
+			t.Errorf("clause %d: expected %q, got %q", i, clause, unsupported.Clauses[i])
+		}
+	}
+}
+
+func TestQuery_Match(t *testing.T) {
+	manga := &models.Manga{
+		Title:         "One Piece",
+		Author:        "Eiichiro Oda",
+		Genres:        []string{"Action", "Adventure"},
+		Status:        "ongoing",
+		TotalChapters: 1100,
+		StartDate:     "1997-07-22",
+		Mean:          9.2,
+	}
+
+	cases := map[string]bool{
+		"piece genre:action":         true,
+		"genre:action -genre:horror": true,
+		"-genre:adventure":           false,
+		"status:completed":           false,
+		"chapters:>1000 score:>=9":   true,
+		"year:2000..2010":            false,
+		"year:1990..2000 author:oda": true,
+	}
+	for input, want := range cases {
+		q, err := query.Parse(input)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", input, err)
+		}
+		if got := q.Match(manga); got != want {
+			t.Errorf("%q: expected match=%v, got %v", input, want, got)
+		}
+	}
+}
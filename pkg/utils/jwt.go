@@ -1,45 +1,45 @@
 package utils
 
 import (
-	"errors"
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
 )
 
+// AccessTokenTTL is how long an access token is accepted. Clients keep a
+// session going by exchanging their refresh token at /auth/refresh.
+const AccessTokenTTL = 15 * time.Minute
+
 type JWTClaims struct {
 	UserID   string `json:"user_id"`
 	Username string `json:"username"`
+	Role     string `json:"role,omitempty"`
+	// Scopes is only set for personal access tokens. Nil means the full
+	// access of a login session.
+	Scopes []string `json:"scopes,omitempty"`
+	// SessionID names the login session the token was issued to, so
+	// signing the session out also stops its access tokens
+	SessionID string `json:"sid,omitempty"`
 	jwt.RegisteredClaims
 }
 
-func GenerateJWT(userID, username, secret string) (string, error) {
-	claims := JWTClaims{
+// NewAccessClaims fills in the claims of an access token that expires after
+// AccessTokenTTL
+func NewAccessClaims(userID, username, role string) (*JWTClaims, error) {
+	// The jti lets a single token be revoked before it expires
+	jti, err := GenerateID(16)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	return &JWTClaims{
 		UserID:   userID,
 		Username: username,
+		Role:     role,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ID:        jti,
+			ExpiresAt: jwt.NewNumericDate(now.Add(AccessTokenTTL)),
+			IssuedAt:  jwt.NewNumericDate(now),
 		},
-	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(secret))
-}
-
-func ValidateJWT(tokenString, secret string) (*JWTClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("invalid signing method")
-		}
-		return []byte(secret), nil
-	})
-
-	if err != nil {
-		return nil, err
-	}
-
-	if claims, ok := token.Claims.(*JWTClaims); ok && token.Valid {
-		return claims, nil
-	}
-	return nil, errors.New("invalid token")
+	}, nil
 }
@@ -0,0 +1,61 @@
+package utils_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/binhbb2204/Manga-Hub-Group13/pkg/utils"
+)
+
+// Test vectors from RFC 6238 appendix B, truncated to six digits. The
+// secret is the ASCII string "12345678901234567890".
+func TestTOTPCodeMatchesRFC(t *testing.T) {
+	const secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	cases := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+	for _, tc := range cases {
+		got, err := utils.TOTPCode(secret, utils.TOTPStep(time.Unix(tc.unix, 0)))
+		if err != nil || got != tc.want {
+			t.Errorf("TOTPCode at %d = %q, %v; want %q", tc.unix, got, err, tc.want)
+		}
+	}
+}
+
+func TestValidateTOTPAllowsOneStepOfDrift(t *testing.T) {
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		t.Fatalf("GenerateTOTPSecret: %v", err)
+	}
+	now := time.Now()
+	step := utils.TOTPStep(now)
+
+	for _, offset := range []int64{-1, 0, 1} {
+		code, _ := utils.TOTPCode(secret, step+offset)
+		if got, ok := utils.ValidateTOTP(secret, code, now); !ok || got != step+offset {
+			t.Errorf("code from step %+d: got %d, %v", offset, got, ok)
+		}
+	}
+	old, _ := utils.TOTPCode(secret, step-3)
+	if _, ok := utils.ValidateTOTP(secret, old, now); ok {
+		t.Errorf("code from three steps ago should be rejected")
+	}
+	if _, ok := utils.ValidateTOTP(secret, "12345", now); ok {
+		t.Errorf("short code should be rejected")
+	}
+}
+
+func TestTOTPURI(t *testing.T) {
+	uri := utils.TOTPURI("MangaHub", "reader", "ABC")
+	if !strings.HasPrefix(uri, "otpauth://totp/MangaHub:reader?") ||
+		!strings.Contains(uri, "secret=ABC") || !strings.Contains(uri, "issuer=MangaHub") {
+		t.Fatalf("TOTPURI = %q", uri)
+	}
+}
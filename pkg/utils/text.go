@@ -0,0 +1,32 @@
+package utils
+
+import (
+	"strings"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// NormalizeTitle folds a title into a comparable form: full-width characters
+// become their ASCII equivalents, diacritics are stripped and case is ignored.
+func NormalizeTitle(title string) string {
+	t := transform.Chain(norm.NFKD, runes.Remove(runes.Predicate(isCombiningDiacritic)), norm.NFC)
+	result, _, err := transform.String(t, title)
+	if err != nil {
+		result = title
+	}
+	return strings.Join(strings.Fields(strings.ToLower(result)), " ")
+}
+
+// isCombiningDiacritic only matches the Latin combining marks block so that
+// kana voicing marks survive normalization.
+func isCombiningDiacritic(r rune) bool {
+	return r >= 0x0300 && r <= 0x036F
+}
+
+// NormalizeTag trims and lowercases a library tag and joins inner whitespace
+// with dashes, so "Re read" and "re-read " end up as the same tag.
+func NormalizeTag(tag string) string {
+	return strings.Join(strings.Fields(strings.ToLower(tag)), "-")
+}